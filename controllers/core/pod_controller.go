@@ -31,6 +31,7 @@ import (
 
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;update;patch
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;patch;watch
+// +kubebuilder:rbac:groups="",resources=pods/status,verbs=patch
 
 type PodReconciler struct {
 	Log logr.Logger
@@ -16,9 +16,12 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	crdv1alpha1 "github.com/aws/amazon-vpc-cni-k8s/pkg/apis/crd/v1alpha1"
@@ -26,13 +29,18 @@ import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/controllers/apps"
 	corecontroller "github.com/aws/amazon-vpc-resource-controller-k8s/controllers/core"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
 	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api/fault"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/endpoint"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s/pod"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node/manager"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/resource"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/subnet"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/version"
 	asyncWorkers "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
@@ -92,6 +100,32 @@ func main() {
 	var leaderLeaseRetryPeriod int
 	var outputPath string
 	var introspectBindAddr string
+	var deadLetterBindAddr string
+	var enablePodENIReadinessGate bool
+	var fallbackSubnets string
+	var trunkNetworkCardPolicy string
+	var eniNetworkCardPolicy string
+	var enableSubnetMonitor bool
+	var subnetMonitorInterval time.Duration
+	var subnetIPThreshold int
+	var enableSubnetCapacityLimit bool
+	var coolDownMode string
+	var ipv4AssignmentStrategy string
+	var linuxIPv4Namespaces string
+	var ipv4MinimumIPTarget int
+	var ipv4WarmENITarget int
+	var ipv4IdleTTL time.Duration
+	var ipv4IdleDesiredSize int
+	var ipv4ReservedSize int
+	var enableWindowsIPv6 bool
+	var ec2FaultInjectionConfig string
+	var userClientMinQPS float64
+	var userClientMaxQPS float64
+	var instanceClientMinQPS float64
+	var instanceClientMaxQPS float64
+	var ec2PriorityShares string
+	var workerMaxNodeConcurrency int
+	var enableWindowsIPv6Prefixes bool
 
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080",
 		"The address the metric endpoint binds to.")
@@ -121,6 +155,84 @@ func main() {
 	flag.StringVar(&outputPath, "log-file", "stderr", "The path to redirect controller logs")
 	flag.StringVar(&introspectBindAddr, "introspect-bind-addr", ":22775",
 		"Port for serving the introspection API")
+	flag.StringVar(&deadLetterBindAddr, "dead-letter-bind-addr", "",
+		"Localhost address for serving the unauthenticated API to retry or forget the resources in the dead "+
+			"letter queues, for example 127.0.0.1:22776. Disabled by default")
+	flag.BoolVar(&enablePodENIReadinessGate, "enable-pod-eni-readiness-gate", false,
+		"Inject a readiness gate to pods using pod-eni, the pod is marked Ready only after the "+
+			"branch ENI is associated with the trunk ENI")
+	flag.StringVar(&fallbackSubnets, "fallback-subnets", "",
+		"Comma separated, ordered list of subnet IDs used for creating network interfaces when the node's "+
+			"subnet runs out of IP addresses. Only the subnets in the node's availability zone are used")
+	flag.StringVar(&trunkNetworkCardPolicy, "trunk-network-card-policy", config.NetworkCardPolicyDefault,
+		"The network card the trunk ENI is attached to on instances with multiple network cards - default (the "+
+			"network card of the primary ENI) or spread (the network card with the most free device indexes)")
+	flag.StringVar(&eniNetworkCardPolicy, "eni-network-card-policy", config.NetworkCardPolicyDefault,
+		"The network card the secondary ENIs are attached to on instances with multiple network cards - default "+
+			"(the network card of the primary ENI) or spread (the network card with the most free device indexes)")
+	flag.BoolVar(&enableSubnetMonitor, "enable-subnet-monitor", false,
+		"Periodically describe the subnets of the managed nodes and export the available IP address count")
+	flag.DurationVar(&subnetMonitorInterval, "subnet-monitor-interval", config.SubnetMonitorInterval,
+		"The time interval between each refresh of the subnet available IP address count")
+	flag.IntVar(&subnetIPThreshold, "subnet-ip-threshold", config.SubnetIPThreshold,
+		"Broadcast a warning event on the nodes when their subnet has fewer available IP addresses")
+	flag.BoolVar(&enableSubnetCapacityLimit, "enable-subnet-capacity-limit", false,
+		"Limit the advertised pod-eni and IPv4 capacity of the nodes to their share of the available IP "+
+			"addresses in their subnets, shared evenly between the nodes using a subnet. Requires the subnet "+
+			"monitor to be enabled")
+	flag.StringVar(&coolDownMode, "cool-down-mode", config.CoolDownModeTimer,
+		"How long the resources of deleted pods are held before reuse - timer (the fixed cool down period) or "+
+			"endpoint (until their addresses are removed from all the EndpointSlices, at most the cool down period)")
+	flag.StringVar(&ipv4AssignmentStrategy, "ipv4-assignment-strategy", config.IPv4DefaultAssignmentStrategy,
+		"The order in which warm IPv4 addresses are assigned to Windows pods - fifo, lru (never used or "+
+			"earliest freed first), packing (lowest index ENI first, so sparsely used ENIs can be released) or "+
+			"spreading (each ENI in turn)")
+	flag.IntVar(&ipv4MinimumIPTarget, "ipv4-minimum-ip-target", config.IPv4DefaultMinimumIPTarget,
+		"The total number of IPv4 addresses, used and warm, to keep allocated to each node. Disabled if 0")
+	flag.IntVar(&ipv4WarmENITarget, "ipv4-warm-eni-target", config.IPv4DefaultWarmENITarget,
+		"The number of ENIs without any assigned IPv4 address to keep attached to each node, on top of the "+
+			"free IPv4 addresses of the ENIs with assigned IPv4 addresses. Disabled if 0")
+	flag.DurationVar(&ipv4IdleTTL, "ipv4-idle-ttl", config.IPv4DefaultIdleTTL,
+		"The duration after which a node with no IPv4 address assigned or freed is considered idle and its "+
+			"warm IPv4 addresses are reclaimed down to the idle warm pool size. Disabled if 0")
+	flag.IntVar(&ipv4IdleDesiredSize, "ipv4-idle-warm-pool-size", config.IPv4DefaultIdleDesiredSize,
+		"The number of warm IPv4 addresses to keep on an idle node, the full warm pool is restored on the "+
+			"next assignment")
+	flag.IntVar(&ipv4ReservedSize, "ipv4-reserved-warm-pool-size", config.IPv4DefaultResSize,
+		"The number of warm IPv4 addresses held back for critical pods, with a system critical priority class "+
+			"or the "+config.CriticalPodAnnotation+" annotation set to true")
+	flag.StringVar(&linuxIPv4Namespaces, "linux-ipv4-namespaces", "",
+		"Comma separated list of namespaces in which Linux pods are allocated secondary IPv4 addresses by the "+
+			"controller, for clusters running a CNI other than the VPC CNI. Enables IPv4 management on Linux nodes, "+
+			"the controller only uses the ENIs it creates and leaves the primary ENI to the VPC CNI if present")
+	flag.BoolVar(&enableWindowsIPv6, "enable-windows-ipv6", false,
+		"Allocate IPv6 addresses instead of secondary IPv4 addresses to Windows pods, for IPv6 clusters. "+
+			"The addresses are assigned to the primary ENI of the Windows nodes")
+	flag.BoolVar(&enableWindowsIPv6Prefixes, "enable-windows-ipv6-prefixes", false,
+		"Allocate a /80 IPv6 prefix instead of an IPv6 address to each Windows pod. Requires IPv6 to be "+
+			"enabled for Windows")
+	flag.Float64Var(&userClientMinQPS, "ec2-user-client-min-qps", config.UserServiceClientMinQPS,
+		"The minimum QPS of the EC2 API calls made with the user service client, the QPS is decreased down to "+
+			"the minimum when the calls are throttled")
+	flag.Float64Var(&userClientMaxQPS, "ec2-user-client-max-qps", config.UserServiceClientMaxQPS,
+		"The maximum QPS of the EC2 API calls made with the user service client, the QPS is increased up to the "+
+			"maximum while the calls succeed. Defaults to the fixed QPS of previous releases, set a higher maximum "+
+			"to let the QPS grow. Set the minimum and the maximum to the same value for a fixed QPS")
+	flag.Float64Var(&instanceClientMinQPS, "ec2-instance-client-min-qps", config.InstanceServiceClientMinQPS,
+		"The minimum QPS of the EC2 API calls made with the instance service client when a role ARN is set")
+	flag.Float64Var(&instanceClientMaxQPS, "ec2-instance-client-max-qps", config.InstanceServiceClientMaxQPS,
+		"The maximum QPS of the EC2 API calls made with the instance service client when a role ARN is set")
+	flag.IntVar(&workerMaxNodeConcurrency, "worker-max-node-concurrency", 0,
+		"Process the jobs of the nodes in round-robin with at most this number of jobs of a node processed at "+
+			"the same time, so a node with many pending pods doesn't delay the pods of the other nodes. "+
+			"Disabled by default, the jobs are processed in the order they are submitted")
+	flag.StringVar(&ec2PriorityShares, "ec2-priority-shares", config.DefaultEC2PriorityShares,
+		"Comma separated shares of the EC2 API QPS reserved to the pod critical, node initialization and "+
+			"background calls, in this order. A class borrows the unused share of the other classes")
+	flag.StringVar(&ec2FaultInjectionConfig, "ec2-fault-injection-config", "",
+		"Path to a JSON file with the latency, errors and visibility delays to inject into the EC2 API calls. "+
+			"For testing the controller's retry and clean up paths only, must not be set in production. The faults "+
+			"are injected above the rate limited EC2 clients, injected throttling errors don't lower their rate")
 
 	flag.Parse()
 
@@ -163,6 +275,36 @@ func main() {
 		os.Exit(1)
 	}
 
+	if coolDownMode != config.CoolDownModeTimer && coolDownMode != config.CoolDownModeEndpoint {
+		setupLog.Error(fmt.Errorf("unsupported cool down mode %s", coolDownMode), "unable to start the controller")
+		os.Exit(1)
+	}
+
+	for _, policy := range []string{trunkNetworkCardPolicy, eniNetworkCardPolicy} {
+		if policy != config.NetworkCardPolicyDefault && policy != config.NetworkCardPolicySpread {
+			setupLog.Error(fmt.Errorf("unsupported network card policy %s", policy), "unable to start the controller")
+			os.Exit(1)
+		}
+	}
+
+	if enableSubnetCapacityLimit && !enableSubnetMonitor {
+		setupLog.Error(fmt.Errorf("enable-subnet-capacity-limit requires enable-subnet-monitor"),
+			"unable to start the controller")
+		os.Exit(1)
+	}
+
+	if deadLetterBindAddr != "" && !isLoopbackAddress(deadLetterBindAddr) {
+		setupLog.Error(fmt.Errorf("dead letter bind address %s must be a localhost address", deadLetterBindAddr),
+			"unable to start the controller")
+		os.Exit(1)
+	}
+
+	priorityShares, err := parsePriorityShares(ec2PriorityShares)
+	if err != nil {
+		setupLog.Error(err, "unable to start the controller")
+		os.Exit(1)
+	}
+
 	// Profiler disabled by default, to enable set the enableProfiling argument
 	if enableProfiling {
 		// To use the profiler - https://golang.org/pkg/net/http/pprof/
@@ -241,10 +383,47 @@ func main() {
 
 	ctx := ctrl.SetupSignalHandler()
 
-	ec2Wrapper, err := ec2API.NewEC2Wrapper(roleARN, setupLog)
+	ec2Wrapper, err := ec2API.NewEC2Wrapper(roleARN, ec2API.ClientRateLimits{
+		User: utils.AdaptiveRateLimiterConfig{
+			InitialQPS:       config.UserServiceClientQPS,
+			MinQPS:           userClientMinQPS,
+			MaxQPS:           userClientMaxQPS,
+			Burst:            config.UserServiceClientQPSBurst,
+			IncreaseStep:     config.ClientQPSIncreaseStep,
+			DecreaseFactor:   config.ClientQPSDecreaseFactor,
+			DecreaseInterval: config.ClientQPSDecreaseInterval,
+			PriorityShares:   priorityShares,
+		},
+		Instance: utils.AdaptiveRateLimiterConfig{
+			InitialQPS:       config.InstanceServiceClientQPS,
+			MinQPS:           instanceClientMinQPS,
+			MaxQPS:           instanceClientMaxQPS,
+			Burst:            config.InstanceServiceClientBurst,
+			IncreaseStep:     config.ClientQPSIncreaseStep,
+			DecreaseFactor:   config.ClientQPSDecreaseFactor,
+			DecreaseInterval: config.ClientQPSDecreaseInterval,
+			PriorityShares:   priorityShares,
+		},
+	}, setupLog)
 	if err != nil {
 		setupLog.Error(err, "unable to create ec2 wrapper")
 	}
+	// The faults are injected above the rate limited clients of the EC2 wrapper, so the injected errors bypass the
+	// SDK retries and the adaptive rate limiter
+	if ec2FaultInjectionConfig != "" {
+		faultConfig, err := fault.LoadConfig(ec2FaultInjectionConfig)
+		if err != nil {
+			setupLog.Error(err, "unable to load the ec2 fault injection config")
+			os.Exit(1)
+		}
+		ec2Wrapper, err = fault.NewInjector(ec2Wrapper, faultConfig, ctrl.Log.WithName("ec2 fault injector"))
+		if err != nil {
+			setupLog.Error(err, "unable to create the ec2 fault injector")
+			os.Exit(1)
+		}
+		setupLog.Info("injecting faults into the ec2 api calls", "config", ec2FaultInjectionConfig,
+			"rules", len(faultConfig.Rules), "visibility delay", faultConfig.VisibilityDelay.Duration)
+	}
 	ec2APIHelper := ec2API.NewEC2APIHelper(ec2Wrapper, clusterName)
 
 	sgpAPI := utils.NewSecurityGroupForPodsAPI(
@@ -265,8 +444,57 @@ func main() {
 		SGPAPI: sgpAPI,
 	}
 
+	if enableSubnetMonitor {
+		subnetMonitor := &subnet.Monitor{
+			EC2API:              ec2APIHelper,
+			K8sAPI:              k8sApi,
+			Log:                 ctrl.Log.WithName("subnet monitor"),
+			Interval:            subnetMonitorInterval,
+			Threshold:           subnetIPThreshold,
+			EnableCapacityLimit: enableSubnetCapacityLimit,
+		}
+		if err = subnetMonitor.SetupWithManager(ctx, mgr); err != nil {
+			setupLog.Error(err, "unable to start subnet monitor")
+			os.Exit(1)
+		}
+		apiWrapper.SubnetAPI = subnetMonitor
+	}
+
+	if coolDownMode == config.CoolDownModeEndpoint {
+		endpointTracker := &endpoint.Tracker{
+			Log: ctrl.Log.WithName("endpoint tracker"),
+		}
+		if err = endpointTracker.SetupWithManager(ctx, mgr); err != nil {
+			setupLog.Error(err, "unable to start endpoint tracker")
+			os.Exit(1)
+		}
+		apiWrapper.EndpointAPI = endpointTracker
+	}
+
 	supportedResources := []string{config.ResourceNamePodENI, config.ResourceNameIPAddress}
-	resourceManager, err := resource.NewResourceManager(ctx, supportedResources, apiWrapper)
+	resourceConfig := config.LoadResourceConfig()
+	resourceConfig[config.ResourceNameIPAddress].WarmPoolConfig.AssignmentStrategy = ipv4AssignmentStrategy
+	resourceConfig[config.ResourceNameIPAddress].WarmPoolConfig.MinimumIPTarget = ipv4MinimumIPTarget
+	resourceConfig[config.ResourceNameIPAddress].WarmPoolConfig.WarmENITarget = ipv4WarmENITarget
+	resourceConfig[config.ResourceNameIPAddress].WarmPoolConfig.IdleTTL = ipv4IdleTTL
+	resourceConfig[config.ResourceNameIPAddress].WarmPoolConfig.IdleDesiredSize = ipv4IdleDesiredSize
+	resourceConfig[config.ResourceNameIPAddress].WarmPoolConfig.ReservedSize = ipv4ReservedSize
+	for name, resConfig := range resourceConfig {
+		resConfig.MaxNodeConcurrency = workerMaxNodeConcurrency
+		resourceConfig[name] = resConfig
+	}
+	linuxIPv4NamespaceList := splitAndTrim(linuxIPv4Namespaces)
+	enableLinuxIPv4 := len(linuxIPv4NamespaceList) > 0
+	resourceConfig[config.ResourceNameIPAddress].SupportedOS[config.OSLinux] = enableLinuxIPv4
+	if enableWindowsIPv6 {
+		// Windows pods are allocated IPv6 addresses instead of IPv4 addresses
+		supportedResources = append(supportedResources, config.ResourceNameIPv6Address)
+		resourceConfig[config.ResourceNameIPAddress].SupportedOS[config.OSWindows] = false
+		ipv6Config := resourceConfig[config.ResourceNameIPv6Address]
+		ipv6Config.UsePrefixes = enableWindowsIPv6Prefixes
+		resourceConfig[config.ResourceNameIPv6Address] = ipv6Config
+	}
+	resourceManager, err := resource.NewResourceManager(ctx, supportedResources, resourceConfig, apiWrapper)
 	if err != nil {
 		ctrl.Log.Error(err, "failed to init resources", "resources", supportedResources)
 		os.Exit(1)
@@ -277,10 +505,14 @@ func main() {
 	controllerConditions := condition.NewControllerConditions(hasPodDataStoreSynced,
 		ctrl.Log.WithName("controller conditions"), k8sApi)
 
-	nodeManagerWorkers := asyncWorkers.NewDefaultWorkerPool("node async workers",
-		3, 1, ctrl.Log.WithName("node async workers"), ctx)
+	nodeManagerWorkers := asyncWorkers.NewWorkerPool("node async workers", asyncWorkers.PoolConfig{
+		MinWorkerCount: 3,
+		MaxWorkerCount: 10,
+		MaxRequeue:     1,
+	}, ctrl.Log.WithName("node async workers"), ctx)
 	nodeManager, err := manager.NewNodeManager(ctrl.Log.WithName("node manager"), resourceManager,
-		apiWrapper, nodeManagerWorkers, controllerConditions)
+		apiWrapper, nodeManagerWorkers, controllerConditions, splitAndTrim(fallbackSubnets),
+		ec2.NetworkCardPolicy{Trunk: trunkNetworkCardPolicy, ENI: eniNetworkCardPolicy}, enableLinuxIPv4)
 	if err != nil {
 		ctrl.Log.Error(err, "failed to init node manager")
 		os.Exit(1)
@@ -299,8 +531,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&vpc.LimitsOverrideLoader{
+		Reader:    mgr.GetAPIReader(),
+		Namespace: config.KubeSystemNamespace,
+		Name:      config.LimitsOverridesConfigMapName,
+		Log:       ctrl.Log.WithName("limits override loader"),
+		Interval:  config.LimitsOverridesRefreshInterval,
+	}).SetupWithManager(ctx, mgr); err != nil {
+		setupLog.Error(err, "unable to start limits override loader")
+		os.Exit(1)
+	}
+
 	if err = (&ec2API.ENICleaner{
-		EC2Wrapper:  ec2Wrapper,
+		EC2Wrapper:  ec2API.WithPriority(ec2Wrapper, ec2API.PriorityBackground),
 		ClusterName: clusterName,
 		Log:         ctrl.Log.WithName("eni cleaner"),
 	}).SetupWithManager(ctx, mgr); err != nil {
@@ -342,9 +585,10 @@ func main() {
 	}
 
 	if err = (&resource.IntrospectHandler{
-		Log:             ctrl.Log.WithName("introspect"),
-		BindAddress:     introspectBindAddr,
-		ResourceManager: resourceManager,
+		Log:                   ctrl.Log.WithName("introspect"),
+		BindAddress:           introspectBindAddr,
+		DeadLetterBindAddress: deadLetterBindAddr,
+		ResourceManager:       resourceManager,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create introspect API")
 		os.Exit(1)
@@ -357,9 +601,12 @@ func main() {
 	setupLog.Info("registering webhooks to the webhook server")
 	webhookServer.Register("/mutate-v1-pod", &webhook.Admission{
 		Handler: &webhookcore.PodMutationWebHook{
-			SGPAPI:    sgpAPI,
-			Log:       ctrl.Log.WithName("resource mutation webhook"),
-			Condition: controllerConditions,
+			SGPAPI:                    sgpAPI,
+			Log:                       ctrl.Log.WithName("resource mutation webhook"),
+			Condition:                 controllerConditions,
+			EnablePodENIReadinessGate: enablePodENIReadinessGate,
+			LinuxIPv4Namespaces:       linuxIPv4NamespaceList,
+			EnableWindowsIPv6:         enableWindowsIPv6,
 		}})
 
 	webhookServer.Register("/validate-v1-node", &webhook.Admission{
@@ -371,8 +618,10 @@ func main() {
 	// Validating webhook for pod.
 	webhookServer.Register("/validate-v1-pod", &webhook.Admission{
 		Handler: &webhookcore.AnnotationValidator{
-			Log:       ctrl.Log.WithName("annotation validation webhook"),
-			Condition: controllerConditions,
+			Log:               ctrl.Log.WithName("annotation validation webhook"),
+			Condition:         controllerConditions,
+			EnableLinuxIPv4:   enableLinuxIPv4,
+			EnableWindowsIPv6: enableWindowsIPv6,
 		}})
 
 	setupLog.Info("starting manager")
@@ -381,3 +630,44 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// splitAndTrim splits the comma separated list and removes the empty values
+func splitAndTrim(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// isLoopbackAddress returns true if the host of the address is localhost or a loopback IP
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// parsePriorityShares returns the share of each EC2 API priority class from the comma separated list
+func parsePriorityShares(list string) ([]float64, error) {
+	values := splitAndTrim(list)
+	if len(values) != ec2API.Priorities {
+		return nil, fmt.Errorf("expected %d ec2 priority shares, got %q", ec2API.Priorities, list)
+	}
+	var shares []float64
+	for _, value := range values {
+		share, err := strconv.ParseFloat(value, 64)
+		if err != nil || share <= 0 {
+			return nil, fmt.Errorf("invalid ec2 priority share %q, expected a positive number", value)
+		}
+		shares = append(shares, share)
+	}
+	return shares, nil
+}
@@ -20,6 +20,7 @@ package mock_api
 import (
 	reflect "reflect"
 
+	vpc "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	ec2 "github.com/aws/aws-sdk-go/service/ec2"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -62,6 +63,21 @@ func (mr *MockEC2APIHelperMockRecorder) AssignIPv4AddressesAndWaitTillReady(arg0
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignIPv4AddressesAndWaitTillReady", reflect.TypeOf((*MockEC2APIHelper)(nil).AssignIPv4AddressesAndWaitTillReady), arg0, arg1)
 }
 
+// AssignIPv6AddressesAndWaitTillReady mocks base method.
+func (m *MockEC2APIHelper) AssignIPv6AddressesAndWaitTillReady(arg0 string, arg1 int, arg2 bool) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignIPv6AddressesAndWaitTillReady", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignIPv6AddressesAndWaitTillReady indicates an expected call of AssignIPv6AddressesAndWaitTillReady.
+func (mr *MockEC2APIHelperMockRecorder) AssignIPv6AddressesAndWaitTillReady(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignIPv6AddressesAndWaitTillReady", reflect.TypeOf((*MockEC2APIHelper)(nil).AssignIPv6AddressesAndWaitTillReady), arg0, arg1, arg2)
+}
+
 // AssociateBranchToTrunk mocks base method.
 func (m *MockEC2APIHelper) AssociateBranchToTrunk(arg0, arg1 *string, arg2 int) (*ec2.AssociateTrunkInterfaceOutput, error) {
 	m.ctrl.T.Helper()
@@ -78,33 +94,33 @@ func (mr *MockEC2APIHelperMockRecorder) AssociateBranchToTrunk(arg0, arg1, arg2
 }
 
 // AttachNetworkInterfaceToInstance mocks base method.
-func (m *MockEC2APIHelper) AttachNetworkInterfaceToInstance(arg0, arg1 *string, arg2 *int64) (*string, error) {
+func (m *MockEC2APIHelper) AttachNetworkInterfaceToInstance(arg0, arg1 *string, arg2, arg3 *int64) (*string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AttachNetworkInterfaceToInstance", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "AttachNetworkInterfaceToInstance", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(*string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AttachNetworkInterfaceToInstance indicates an expected call of AttachNetworkInterfaceToInstance.
-func (mr *MockEC2APIHelperMockRecorder) AttachNetworkInterfaceToInstance(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockEC2APIHelperMockRecorder) AttachNetworkInterfaceToInstance(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachNetworkInterfaceToInstance", reflect.TypeOf((*MockEC2APIHelper)(nil).AttachNetworkInterfaceToInstance), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachNetworkInterfaceToInstance", reflect.TypeOf((*MockEC2APIHelper)(nil).AttachNetworkInterfaceToInstance), arg0, arg1, arg2, arg3)
 }
 
 // CreateAndAttachNetworkInterface mocks base method.
-func (m *MockEC2APIHelper) CreateAndAttachNetworkInterface(arg0, arg1 *string, arg2 []string, arg3 []*ec2.Tag, arg4 *int64, arg5, arg6 *string, arg7 int) (*ec2.NetworkInterface, error) {
+func (m *MockEC2APIHelper) CreateAndAttachNetworkInterface(arg0, arg1 *string, arg2 []string, arg3 []*ec2.Tag, arg4, arg5 *int64, arg6, arg7 *string, arg8 int) (*ec2.NetworkInterface, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAndAttachNetworkInterface", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	ret := m.ctrl.Call(m, "CreateAndAttachNetworkInterface", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
 	ret0, _ := ret[0].(*ec2.NetworkInterface)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateAndAttachNetworkInterface indicates an expected call of CreateAndAttachNetworkInterface.
-func (mr *MockEC2APIHelperMockRecorder) CreateAndAttachNetworkInterface(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7 interface{}) *gomock.Call {
+func (mr *MockEC2APIHelperMockRecorder) CreateAndAttachNetworkInterface(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAndAttachNetworkInterface", reflect.TypeOf((*MockEC2APIHelper)(nil).CreateAndAttachNetworkInterface), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAndAttachNetworkInterface", reflect.TypeOf((*MockEC2APIHelper)(nil).CreateAndAttachNetworkInterface), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
 }
 
 // CreateNetworkInterface mocks base method.
@@ -239,6 +255,22 @@ func (mr *MockEC2APIHelperMockRecorder) GetInstanceNetworkInterface(arg0 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceNetworkInterface", reflect.TypeOf((*MockEC2APIHelper)(nil).GetInstanceNetworkInterface), arg0)
 }
 
+// GetInstanceTypeLimits mocks base method.
+func (m *MockEC2APIHelper) GetInstanceTypeLimits(arg0 string) (*vpc.VPCLimits, []vpc.NetworkCard, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstanceTypeLimits", arg0)
+	ret0, _ := ret[0].(*vpc.VPCLimits)
+	ret1, _ := ret[1].([]vpc.NetworkCard)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetInstanceTypeLimits indicates an expected call of GetInstanceTypeLimits.
+func (mr *MockEC2APIHelperMockRecorder) GetInstanceTypeLimits(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceTypeLimits", reflect.TypeOf((*MockEC2APIHelper)(nil).GetInstanceTypeLimits), arg0)
+}
+
 // GetSubnet mocks base method.
 func (m *MockEC2APIHelper) GetSubnet(arg0 *string) (*ec2.Subnet, error) {
 	m.ctrl.T.Helper()
@@ -254,6 +286,21 @@ func (mr *MockEC2APIHelperMockRecorder) GetSubnet(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubnet", reflect.TypeOf((*MockEC2APIHelper)(nil).GetSubnet), arg0)
 }
 
+// GetSubnets mocks base method.
+func (m *MockEC2APIHelper) GetSubnets(arg0 []*string) ([]*ec2.Subnet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubnets", arg0)
+	ret0, _ := ret[0].([]*ec2.Subnet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubnets indicates an expected call of GetSubnets.
+func (mr *MockEC2APIHelperMockRecorder) GetSubnets(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubnets", reflect.TypeOf((*MockEC2APIHelper)(nil).GetSubnets), arg0)
+}
+
 // SetDeleteOnTermination mocks base method.
 func (m *MockEC2APIHelper) SetDeleteOnTermination(arg0, arg1 *string) error {
 	m.ctrl.T.Helper()
@@ -268,6 +315,20 @@ func (mr *MockEC2APIHelperMockRecorder) SetDeleteOnTermination(arg0, arg1 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeleteOnTermination", reflect.TypeOf((*MockEC2APIHelper)(nil).SetDeleteOnTermination), arg0, arg1)
 }
 
+// UnassignIPv6Addresses mocks base method.
+func (m *MockEC2APIHelper) UnassignIPv6Addresses(arg0 string, arg1 []string, arg2 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnassignIPv6Addresses", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnassignIPv6Addresses indicates an expected call of UnassignIPv6Addresses.
+func (mr *MockEC2APIHelperMockRecorder) UnassignIPv6Addresses(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnassignIPv6Addresses", reflect.TypeOf((*MockEC2APIHelper)(nil).UnassignIPv6Addresses), arg0, arg1, arg2)
+}
+
 // UnassignPrivateIpAddresses mocks base method.
 func (m *MockEC2APIHelper) UnassignPrivateIpAddresses(arg0 string, arg1 []string) error {
 	m.ctrl.T.Helper()
@@ -47,6 +47,21 @@ func (m *MockEC2Wrapper) EXPECT() *MockEC2WrapperMockRecorder {
 	return m.recorder
 }
 
+// AssignIPv6Addresses mocks base method.
+func (m *MockEC2Wrapper) AssignIPv6Addresses(arg0 *ec2.AssignIpv6AddressesInput) (*ec2.AssignIpv6AddressesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignIPv6Addresses", arg0)
+	ret0, _ := ret[0].(*ec2.AssignIpv6AddressesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignIPv6Addresses indicates an expected call of AssignIPv6Addresses.
+func (mr *MockEC2WrapperMockRecorder) AssignIPv6Addresses(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignIPv6Addresses", reflect.TypeOf((*MockEC2Wrapper)(nil).AssignIPv6Addresses), arg0)
+}
+
 // AssignPrivateIPAddresses mocks base method.
 func (m *MockEC2Wrapper) AssignPrivateIPAddresses(arg0 *ec2.AssignPrivateIpAddressesInput) (*ec2.AssignPrivateIpAddressesOutput, error) {
 	m.ctrl.T.Helper()
@@ -152,6 +167,21 @@ func (mr *MockEC2WrapperMockRecorder) DeleteNetworkInterface(arg0 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNetworkInterface", reflect.TypeOf((*MockEC2Wrapper)(nil).DeleteNetworkInterface), arg0)
 }
 
+// DescribeInstanceTypes mocks base method.
+func (m *MockEC2Wrapper) DescribeInstanceTypes(arg0 *ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeInstanceTypes", arg0)
+	ret0, _ := ret[0].(*ec2.DescribeInstanceTypesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeInstanceTypes indicates an expected call of DescribeInstanceTypes.
+func (mr *MockEC2WrapperMockRecorder) DescribeInstanceTypes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeInstanceTypes", reflect.TypeOf((*MockEC2Wrapper)(nil).DescribeInstanceTypes), arg0)
+}
+
 // DescribeInstances mocks base method.
 func (m *MockEC2Wrapper) DescribeInstances(arg0 *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
 	m.ctrl.T.Helper()
@@ -242,6 +272,21 @@ func (mr *MockEC2WrapperMockRecorder) ModifyNetworkInterfaceAttribute(arg0 inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModifyNetworkInterfaceAttribute", reflect.TypeOf((*MockEC2Wrapper)(nil).ModifyNetworkInterfaceAttribute), arg0)
 }
 
+// UnassignIPv6Addresses mocks base method.
+func (m *MockEC2Wrapper) UnassignIPv6Addresses(arg0 *ec2.UnassignIpv6AddressesInput) (*ec2.UnassignIpv6AddressesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnassignIPv6Addresses", arg0)
+	ret0, _ := ret[0].(*ec2.UnassignIpv6AddressesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnassignIPv6Addresses indicates an expected call of UnassignIPv6Addresses.
+func (mr *MockEC2WrapperMockRecorder) UnassignIPv6Addresses(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnassignIPv6Addresses", reflect.TypeOf((*MockEC2Wrapper)(nil).UnassignIPv6Addresses), arg0)
+}
+
 // UnassignPrivateIPAddresses mocks base method.
 func (m *MockEC2Wrapper) UnassignPrivateIPAddresses(arg0 *ec2.UnassignPrivateIpAddressesInput) (*ec2.UnassignPrivateIpAddressesOutput, error) {
 	m.ctrl.T.Helper()
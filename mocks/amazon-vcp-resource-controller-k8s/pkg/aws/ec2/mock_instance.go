@@ -20,7 +20,9 @@ package mock_ec2
 import (
 	reflect "reflect"
 
+	ec2 "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
 	api "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	vpc "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -47,31 +49,59 @@ func (m *MockEC2Instance) EXPECT() *MockEC2InstanceMockRecorder {
 	return m.recorder
 }
 
+// CandidateSubnets mocks base method.
+func (m *MockEC2Instance) CandidateSubnets() []ec2.Subnet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CandidateSubnets")
+	ret0, _ := ret[0].([]ec2.Subnet)
+	return ret0
+}
+
+// CandidateSubnets indicates an expected call of CandidateSubnets.
+func (mr *MockEC2InstanceMockRecorder) CandidateSubnets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CandidateSubnets", reflect.TypeOf((*MockEC2Instance)(nil).CandidateSubnets))
+}
+
+// ENINetworkCardIndex mocks base method.
+func (m *MockEC2Instance) ENINetworkCardIndex() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ENINetworkCardIndex")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// ENINetworkCardIndex indicates an expected call of ENINetworkCardIndex.
+func (mr *MockEC2InstanceMockRecorder) ENINetworkCardIndex() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ENINetworkCardIndex", reflect.TypeOf((*MockEC2Instance)(nil).ENINetworkCardIndex))
+}
+
 // FreeDeviceIndex mocks base method.
-func (m *MockEC2Instance) FreeDeviceIndex(arg0 int64) {
+func (m *MockEC2Instance) FreeDeviceIndex(arg0, arg1 int64) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "FreeDeviceIndex", arg0)
+	m.ctrl.Call(m, "FreeDeviceIndex", arg0, arg1)
 }
 
 // FreeDeviceIndex indicates an expected call of FreeDeviceIndex.
-func (mr *MockEC2InstanceMockRecorder) FreeDeviceIndex(arg0 interface{}) *gomock.Call {
+func (mr *MockEC2InstanceMockRecorder) FreeDeviceIndex(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreeDeviceIndex", reflect.TypeOf((*MockEC2Instance)(nil).FreeDeviceIndex), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreeDeviceIndex", reflect.TypeOf((*MockEC2Instance)(nil).FreeDeviceIndex), arg0, arg1)
 }
 
 // GetHighestUnusedDeviceIndex mocks base method.
-func (m *MockEC2Instance) GetHighestUnusedDeviceIndex() (int64, error) {
+func (m *MockEC2Instance) GetHighestUnusedDeviceIndex(arg0 int64) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetHighestUnusedDeviceIndex")
+	ret := m.ctrl.Call(m, "GetHighestUnusedDeviceIndex", arg0)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetHighestUnusedDeviceIndex indicates an expected call of GetHighestUnusedDeviceIndex.
-func (mr *MockEC2InstanceMockRecorder) GetHighestUnusedDeviceIndex() *gomock.Call {
+func (mr *MockEC2InstanceMockRecorder) GetHighestUnusedDeviceIndex(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHighestUnusedDeviceIndex", reflect.TypeOf((*MockEC2Instance)(nil).GetHighestUnusedDeviceIndex))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHighestUnusedDeviceIndex", reflect.TypeOf((*MockEC2Instance)(nil).GetHighestUnusedDeviceIndex), arg0)
 }
 
 // InstanceID mocks base method.
@@ -102,6 +132,35 @@ func (mr *MockEC2InstanceMockRecorder) InstanceSecurityGroup() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstanceSecurityGroup", reflect.TypeOf((*MockEC2Instance)(nil).InstanceSecurityGroup))
 }
 
+// IsTrunkEnabled mocks base method.
+func (m *MockEC2Instance) IsTrunkEnabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTrunkEnabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsTrunkEnabled indicates an expected call of IsTrunkEnabled.
+func (mr *MockEC2InstanceMockRecorder) IsTrunkEnabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTrunkEnabled", reflect.TypeOf((*MockEC2Instance)(nil).IsTrunkEnabled))
+}
+
+// Limits mocks base method.
+func (m *MockEC2Instance) Limits() (*vpc.VPCLimits, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Limits")
+	ret0, _ := ret[0].(*vpc.VPCLimits)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Limits indicates an expected call of Limits.
+func (mr *MockEC2InstanceMockRecorder) Limits() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Limits", reflect.TypeOf((*MockEC2Instance)(nil).Limits))
+}
+
 // LoadDetails mocks base method.
 func (m *MockEC2Instance) LoadDetails(arg0 api.EC2APIHelper) error {
 	m.ctrl.T.Helper()
@@ -130,6 +189,20 @@ func (mr *MockEC2InstanceMockRecorder) Name() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockEC2Instance)(nil).Name))
 }
 
+// NetworkCards mocks base method.
+func (m *MockEC2Instance) NetworkCards() []vpc.NetworkCard {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkCards")
+	ret0, _ := ret[0].([]vpc.NetworkCard)
+	return ret0
+}
+
+// NetworkCards indicates an expected call of NetworkCards.
+func (mr *MockEC2InstanceMockRecorder) NetworkCards() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkCards", reflect.TypeOf((*MockEC2Instance)(nil).NetworkCards))
+}
+
 // Os mocks base method.
 func (m *MockEC2Instance) Os() string {
 	m.ctrl.T.Helper()
@@ -144,6 +217,20 @@ func (mr *MockEC2InstanceMockRecorder) Os() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Os", reflect.TypeOf((*MockEC2Instance)(nil).Os))
 }
 
+// PrimaryIPv6Address mocks base method.
+func (m *MockEC2Instance) PrimaryIPv6Address() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrimaryIPv6Address")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// PrimaryIPv6Address indicates an expected call of PrimaryIPv6Address.
+func (mr *MockEC2InstanceMockRecorder) PrimaryIPv6Address() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrimaryIPv6Address", reflect.TypeOf((*MockEC2Instance)(nil).PrimaryIPv6Address))
+}
+
 // PrimaryNetworkInterfaceID mocks base method.
 func (m *MockEC2Instance) PrimaryNetworkInterfaceID() string {
 	m.ctrl.T.Helper()
@@ -158,6 +245,44 @@ func (mr *MockEC2InstanceMockRecorder) PrimaryNetworkInterfaceID() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrimaryNetworkInterfaceID", reflect.TypeOf((*MockEC2Instance)(nil).PrimaryNetworkInterfaceID))
 }
 
+// RefreshDeviceIndexes mocks base method.
+func (m *MockEC2Instance) RefreshDeviceIndexes(arg0 api.EC2APIHelper) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshDeviceIndexes", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefreshDeviceIndexes indicates an expected call of RefreshDeviceIndexes.
+func (mr *MockEC2InstanceMockRecorder) RefreshDeviceIndexes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshDeviceIndexes", reflect.TypeOf((*MockEC2Instance)(nil).RefreshDeviceIndexes), arg0)
+}
+
+// SetFallbackSubnets mocks base method.
+func (m *MockEC2Instance) SetFallbackSubnets(arg0 []string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFallbackSubnets", arg0)
+}
+
+// SetFallbackSubnets indicates an expected call of SetFallbackSubnets.
+func (mr *MockEC2InstanceMockRecorder) SetFallbackSubnets(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFallbackSubnets", reflect.TypeOf((*MockEC2Instance)(nil).SetFallbackSubnets), arg0)
+}
+
+// SetNetworkCardPolicy mocks base method.
+func (m *MockEC2Instance) SetNetworkCardPolicy(arg0 ec2.NetworkCardPolicy) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetNetworkCardPolicy", arg0)
+}
+
+// SetNetworkCardPolicy indicates an expected call of SetNetworkCardPolicy.
+func (mr *MockEC2InstanceMockRecorder) SetNetworkCardPolicy(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNetworkCardPolicy", reflect.TypeOf((*MockEC2Instance)(nil).SetNetworkCardPolicy), arg0)
+}
+
 // SetNewCustomNetworkingSpec mocks base method.
 func (m *MockEC2Instance) SetNewCustomNetworkingSpec(arg0 string, arg1 []string) {
 	m.ctrl.T.Helper()
@@ -170,6 +295,30 @@ func (mr *MockEC2InstanceMockRecorder) SetNewCustomNetworkingSpec(arg0, arg1 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNewCustomNetworkingSpec", reflect.TypeOf((*MockEC2Instance)(nil).SetNewCustomNetworkingSpec), arg0, arg1)
 }
 
+// SetTrunkEnabled mocks base method.
+func (m *MockEC2Instance) SetTrunkEnabled(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTrunkEnabled", arg0)
+}
+
+// SetTrunkEnabled indicates an expected call of SetTrunkEnabled.
+func (mr *MockEC2InstanceMockRecorder) SetTrunkEnabled(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrunkEnabled", reflect.TypeOf((*MockEC2Instance)(nil).SetTrunkEnabled), arg0)
+}
+
+// SetTrunkNetworkingSpec mocks base method.
+func (m *MockEC2Instance) SetTrunkNetworkingSpec(arg0 string, arg1 []string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTrunkNetworkingSpec", arg0, arg1)
+}
+
+// SetTrunkNetworkingSpec indicates an expected call of SetTrunkNetworkingSpec.
+func (mr *MockEC2InstanceMockRecorder) SetTrunkNetworkingSpec(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrunkNetworkingSpec", reflect.TypeOf((*MockEC2Instance)(nil).SetTrunkNetworkingSpec), arg0, arg1)
+}
+
 // SubnetCidrBlock mocks base method.
 func (m *MockEC2Instance) SubnetCidrBlock() string {
 	m.ctrl.T.Helper()
@@ -212,6 +361,62 @@ func (mr *MockEC2InstanceMockRecorder) SubnetMask() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubnetMask", reflect.TypeOf((*MockEC2Instance)(nil).SubnetMask))
 }
 
+// SubnetV6Mask mocks base method.
+func (m *MockEC2Instance) SubnetV6Mask() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubnetV6Mask")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// SubnetV6Mask indicates an expected call of SubnetV6Mask.
+func (mr *MockEC2InstanceMockRecorder) SubnetV6Mask() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubnetV6Mask", reflect.TypeOf((*MockEC2Instance)(nil).SubnetV6Mask))
+}
+
+// TrunkNetworkCardIndex mocks base method.
+func (m *MockEC2Instance) TrunkNetworkCardIndex() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrunkNetworkCardIndex")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// TrunkNetworkCardIndex indicates an expected call of TrunkNetworkCardIndex.
+func (mr *MockEC2InstanceMockRecorder) TrunkNetworkCardIndex() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrunkNetworkCardIndex", reflect.TypeOf((*MockEC2Instance)(nil).TrunkNetworkCardIndex))
+}
+
+// TrunkSecurityGroup mocks base method.
+func (m *MockEC2Instance) TrunkSecurityGroup() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrunkSecurityGroup")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// TrunkSecurityGroup indicates an expected call of TrunkSecurityGroup.
+func (mr *MockEC2InstanceMockRecorder) TrunkSecurityGroup() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrunkSecurityGroup", reflect.TypeOf((*MockEC2Instance)(nil).TrunkSecurityGroup))
+}
+
+// TrunkSubnetID mocks base method.
+func (m *MockEC2Instance) TrunkSubnetID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrunkSubnetID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// TrunkSubnetID indicates an expected call of TrunkSubnetID.
+func (mr *MockEC2InstanceMockRecorder) TrunkSubnetID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrunkSubnetID", reflect.TypeOf((*MockEC2Instance)(nil).TrunkSubnetID))
+}
+
 // Type mocks base method.
 func (m *MockEC2Instance) Type() string {
 	m.ctrl.T.Helper()
@@ -0,0 +1,75 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aws/amazon-vpc-resource-controller-k8s/pkg/endpoint (interfaces: EndpointTracker)
+
+// Package mock_endpoint is a generated GoMock package.
+package mock_endpoint
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockEndpointTracker is a mock of EndpointTracker interface.
+type MockEndpointTracker struct {
+	ctrl     *gomock.Controller
+	recorder *MockEndpointTrackerMockRecorder
+}
+
+// MockEndpointTrackerMockRecorder is the mock recorder for MockEndpointTracker.
+type MockEndpointTrackerMockRecorder struct {
+	mock *MockEndpointTracker
+}
+
+// NewMockEndpointTracker creates a new mock instance.
+func NewMockEndpointTracker(ctrl *gomock.Controller) *MockEndpointTracker {
+	mock := &MockEndpointTracker{ctrl: ctrl}
+	mock.recorder = &MockEndpointTrackerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEndpointTracker) EXPECT() *MockEndpointTrackerMockRecorder {
+	return m.recorder
+}
+
+// IsAddressInUse mocks base method.
+func (m *MockEndpointTracker) IsAddressInUse(arg0 string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAddressInUse", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsAddressInUse indicates an expected call of IsAddressInUse.
+func (mr *MockEndpointTrackerMockRecorder) IsAddressInUse(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAddressInUse", reflect.TypeOf((*MockEndpointTracker)(nil).IsAddressInUse), arg0)
+}
+
+// IsPrefixInUse mocks base method.
+func (m *MockEndpointTracker) IsPrefixInUse(arg0 string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsPrefixInUse", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsPrefixInUse indicates an expected call of IsPrefixInUse.
+func (mr *MockEndpointTrackerMockRecorder) IsPrefixInUse(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsPrefixInUse", reflect.TypeOf((*MockEndpointTracker)(nil).IsPrefixInUse), arg0)
+}
@@ -63,6 +63,20 @@ func (mr *MockPodClientAPIWrapperMockRecorder) AnnotatePod(arg0, arg1, arg2, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnnotatePod", reflect.TypeOf((*MockPodClientAPIWrapper)(nil).AnnotatePod), arg0, arg1, arg2, arg3, arg4)
 }
 
+// AnnotatePodWithValues mocks base method.
+func (m *MockPodClientAPIWrapper) AnnotatePodWithValues(arg0, arg1 string, arg2 types.UID, arg3 map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnnotatePodWithValues", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AnnotatePodWithValues indicates an expected call of AnnotatePodWithValues.
+func (mr *MockPodClientAPIWrapperMockRecorder) AnnotatePodWithValues(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnnotatePodWithValues", reflect.TypeOf((*MockPodClientAPIWrapper)(nil).AnnotatePodWithValues), arg0, arg1, arg2, arg3)
+}
+
 // GetPod mocks base method.
 func (m *MockPodClientAPIWrapper) GetPod(arg0, arg1 string) (*v1.Pod, error) {
 	m.ctrl.T.Helper()
@@ -122,3 +136,17 @@ func (mr *MockPodClientAPIWrapperMockRecorder) ListPods(arg0 interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPods", reflect.TypeOf((*MockPodClientAPIWrapper)(nil).ListPods), arg0)
 }
+
+// UpdatePodCondition mocks base method.
+func (m *MockPodClientAPIWrapper) UpdatePodCondition(arg0, arg1 string, arg2 types.UID, arg3 v1.PodCondition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePodCondition", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePodCondition indicates an expected call of UpdatePodCondition.
+func (mr *MockPodClientAPIWrapperMockRecorder) UpdatePodCondition(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePodCondition", reflect.TypeOf((*MockPodClientAPIWrapper)(nil).UpdatePodCondition), arg0, arg1, arg2, arg3)
+}
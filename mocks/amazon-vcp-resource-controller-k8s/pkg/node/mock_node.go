@@ -20,6 +20,7 @@ package mock_node
 import (
 	reflect "reflect"
 
+	ec2 "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
 	api "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
 	resource "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/resource"
 	gomock "github.com/golang/mock/gomock"
@@ -104,6 +105,20 @@ func (mr *MockNodeMockRecorder) IsReady() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsReady", reflect.TypeOf((*MockNode)(nil).IsReady))
 }
 
+// IsTrunkEnabled mocks base method.
+func (m *MockNode) IsTrunkEnabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTrunkEnabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsTrunkEnabled indicates an expected call of IsTrunkEnabled.
+func (mr *MockNodeMockRecorder) IsTrunkEnabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTrunkEnabled", reflect.TypeOf((*MockNode)(nil).IsTrunkEnabled))
+}
+
 // UpdateCustomNetworkingSpecs mocks base method.
 func (m *MockNode) UpdateCustomNetworkingSpecs(arg0 string, arg1 []string) {
 	m.ctrl.T.Helper()
@@ -116,6 +131,30 @@ func (mr *MockNodeMockRecorder) UpdateCustomNetworkingSpecs(arg0, arg1 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCustomNetworkingSpecs", reflect.TypeOf((*MockNode)(nil).UpdateCustomNetworkingSpecs), arg0, arg1)
 }
 
+// UpdateFallbackSubnets mocks base method.
+func (m *MockNode) UpdateFallbackSubnets(arg0 []string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateFallbackSubnets", arg0)
+}
+
+// UpdateFallbackSubnets indicates an expected call of UpdateFallbackSubnets.
+func (mr *MockNodeMockRecorder) UpdateFallbackSubnets(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFallbackSubnets", reflect.TypeOf((*MockNode)(nil).UpdateFallbackSubnets), arg0)
+}
+
+// UpdateNetworkCardPolicy mocks base method.
+func (m *MockNode) UpdateNetworkCardPolicy(arg0 ec2.NetworkCardPolicy) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateNetworkCardPolicy", arg0)
+}
+
+// UpdateNetworkCardPolicy indicates an expected call of UpdateNetworkCardPolicy.
+func (mr *MockNodeMockRecorder) UpdateNetworkCardPolicy(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNetworkCardPolicy", reflect.TypeOf((*MockNode)(nil).UpdateNetworkCardPolicy), arg0)
+}
+
 // UpdateResources mocks base method.
 func (m *MockNode) UpdateResources(arg0 resource.ResourceManager, arg1 api.EC2APIHelper) error {
 	m.ctrl.T.Helper()
@@ -129,3 +168,41 @@ func (mr *MockNodeMockRecorder) UpdateResources(arg0, arg1 interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateResources", reflect.TypeOf((*MockNode)(nil).UpdateResources), arg0, arg1)
 }
+
+// UpdateTrunkEnabled mocks base method.
+func (m *MockNode) UpdateTrunkEnabled(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateTrunkEnabled", arg0)
+}
+
+// UpdateTrunkEnabled indicates an expected call of UpdateTrunkEnabled.
+func (mr *MockNodeMockRecorder) UpdateTrunkEnabled(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTrunkEnabled", reflect.TypeOf((*MockNode)(nil).UpdateTrunkEnabled), arg0)
+}
+
+// UpdateTrunkNetworkingSpecs mocks base method.
+func (m *MockNode) UpdateTrunkNetworkingSpecs(arg0 string, arg1 []string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateTrunkNetworkingSpecs", arg0, arg1)
+}
+
+// UpdateTrunkNetworkingSpecs indicates an expected call of UpdateTrunkNetworkingSpecs.
+func (mr *MockNodeMockRecorder) UpdateTrunkNetworkingSpecs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTrunkNetworkingSpecs", reflect.TypeOf((*MockNode)(nil).UpdateTrunkNetworkingSpecs), arg0, arg1)
+}
+
+// UpdateTrunkResources mocks base method.
+func (m *MockNode) UpdateTrunkResources(arg0 resource.ResourceManager, arg1 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTrunkResources", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTrunkResources indicates an expected call of UpdateTrunkResources.
+func (mr *MockNodeMockRecorder) UpdateTrunkResources(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTrunkResources", reflect.TypeOf((*MockNode)(nil).UpdateTrunkResources), arg0, arg1)
+}
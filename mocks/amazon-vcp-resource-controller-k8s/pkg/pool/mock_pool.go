@@ -64,6 +64,36 @@ func (mr *MockPoolMockRecorder) AssignResource(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignResource", reflect.TypeOf((*MockPool)(nil).AssignResource), arg0)
 }
 
+// AssignResources mocks base method.
+func (m *MockPool) AssignResources(arg0 string, arg1 int, arg2 bool) ([]string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignResources", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AssignResources indicates an expected call of AssignResources.
+func (mr *MockPoolMockRecorder) AssignResources(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignResources", reflect.TypeOf((*MockPool)(nil).AssignResources), arg0, arg1, arg2)
+}
+
+// DrainResources mocks base method.
+func (m *MockPool) DrainResources(arg0 []string) *worker.WarmPoolJob {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DrainResources", arg0)
+	ret0, _ := ret[0].(*worker.WarmPoolJob)
+	return ret0
+}
+
+// DrainResources indicates an expected call of DrainResources.
+func (mr *MockPoolMockRecorder) DrainResources(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DrainResources", reflect.TypeOf((*MockPool)(nil).DrainResources), arg0)
+}
+
 // FreeResource mocks base method.
 func (m *MockPool) FreeResource(arg0, arg1 string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +109,21 @@ func (mr *MockPoolMockRecorder) FreeResource(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreeResource", reflect.TypeOf((*MockPool)(nil).FreeResource), arg0, arg1)
 }
 
+// FreeResources mocks base method.
+func (m *MockPool) FreeResources(arg0 string, arg1 []string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreeResources", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FreeResources indicates an expected call of FreeResources.
+func (mr *MockPoolMockRecorder) FreeResources(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreeResources", reflect.TypeOf((*MockPool)(nil).FreeResources), arg0, arg1)
+}
+
 // GetAssignedResource mocks base method.
 func (m *MockPool) GetAssignedResource(arg0 string) (string, bool) {
 	m.ctrl.T.Helper()
@@ -94,6 +139,21 @@ func (mr *MockPoolMockRecorder) GetAssignedResource(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssignedResource", reflect.TypeOf((*MockPool)(nil).GetAssignedResource), arg0)
 }
 
+// GetAssignedResources mocks base method.
+func (m *MockPool) GetAssignedResources(arg0 string) ([]string, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAssignedResources", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetAssignedResources indicates an expected call of GetAssignedResources.
+func (mr *MockPoolMockRecorder) GetAssignedResources(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssignedResources", reflect.TypeOf((*MockPool)(nil).GetAssignedResources), arg0)
+}
+
 // Introspect mocks base method.
 func (m *MockPool) Introspect() pool.IntrospectResponse {
 	m.ctrl.T.Helper()
@@ -148,6 +208,42 @@ func (mr *MockPoolMockRecorder) ReconcilePool() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcilePool", reflect.TypeOf((*MockPool)(nil).ReconcilePool))
 }
 
+// SetInUseChecker mocks base method.
+func (m *MockPool) SetInUseChecker(arg0 func(string) bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetInUseChecker", arg0)
+}
+
+// SetInUseChecker indicates an expected call of SetInUseChecker.
+func (mr *MockPoolMockRecorder) SetInUseChecker(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInUseChecker", reflect.TypeOf((*MockPool)(nil).SetInUseChecker), arg0)
+}
+
+// SetResourceRanker mocks base method.
+func (m *MockPool) SetResourceRanker(arg0 func(string) int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetResourceRanker", arg0)
+}
+
+// SetResourceRanker indicates an expected call of SetResourceRanker.
+func (mr *MockPoolMockRecorder) SetResourceRanker(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetResourceRanker", reflect.TypeOf((*MockPool)(nil).SetResourceRanker), arg0)
+}
+
+// SetResourcesPerENI mocks base method.
+func (m *MockPool) SetResourcesPerENI(arg0 int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetResourcesPerENI", arg0)
+}
+
+// SetResourcesPerENI indicates an expected call of SetResourcesPerENI.
+func (mr *MockPoolMockRecorder) SetResourcesPerENI(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetResourcesPerENI", reflect.TypeOf((*MockPool)(nil).SetResourcesPerENI), arg0)
+}
+
 // UpdatePool mocks base method.
 func (m *MockPool) UpdatePool(arg0 *worker.WarmPoolJob, arg1 bool) bool {
 	m.ctrl.T.Helper()
@@ -77,9 +77,11 @@ func (mr *MockTrunkENIMockRecorder) DeleteAllBranchENIs() *gomock.Call {
 }
 
 // DeleteCooledDownENIs mocks base method.
-func (m *MockTrunkENI) DeleteCooledDownENIs() {
+func (m *MockTrunkENI) DeleteCooledDownENIs() []trunk.ENIDetails {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "DeleteCooledDownENIs")
+	ret := m.ctrl.Call(m, "DeleteCooledDownENIs")
+	ret0, _ := ret[0].([]trunk.ENIDetails)
+	return ret0
 }
 
 // DeleteCooledDownENIs indicates an expected call of DeleteCooledDownENIs.
@@ -88,6 +90,48 @@ func (mr *MockTrunkENIMockRecorder) DeleteCooledDownENIs() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCooledDownENIs", reflect.TypeOf((*MockTrunkENI)(nil).DeleteCooledDownENIs))
 }
 
+// ForgetDeadLetterENI mocks base method.
+func (m *MockTrunkENI) ForgetDeadLetterENI(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgetDeadLetterENI", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgetDeadLetterENI indicates an expected call of ForgetDeadLetterENI.
+func (mr *MockTrunkENIMockRecorder) ForgetDeadLetterENI(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgetDeadLetterENI", reflect.TypeOf((*MockTrunkENI)(nil).ForgetDeadLetterENI), arg0)
+}
+
+// GetBranchENICount mocks base method.
+func (m *MockTrunkENI) GetBranchENICount() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBranchENICount")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GetBranchENICount indicates an expected call of GetBranchENICount.
+func (mr *MockTrunkENIMockRecorder) GetBranchENICount() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBranchENICount", reflect.TypeOf((*MockTrunkENI)(nil).GetBranchENICount))
+}
+
+// HasCoolingDownENIs mocks base method.
+func (m *MockTrunkENI) HasCoolingDownENIs() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasCoolingDownENIs")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasCoolingDownENIs indicates an expected call of HasCoolingDownENIs.
+func (mr *MockTrunkENIMockRecorder) HasCoolingDownENIs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasCoolingDownENIs", reflect.TypeOf((*MockTrunkENI)(nil).HasCoolingDownENIs))
+}
+
 // InitTrunk mocks base method.
 func (m *MockTrunkENI) InitTrunk(arg0 ec2.EC2Instance, arg1 []v1.Pod) error {
 	m.ctrl.T.Helper()
@@ -153,3 +197,17 @@ func (mr *MockTrunkENIMockRecorder) Reconcile(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconcile", reflect.TypeOf((*MockTrunkENI)(nil).Reconcile), arg0)
 }
+
+// RetryDeadLetterENI mocks base method.
+func (m *MockTrunkENI) RetryDeadLetterENI(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryDeadLetterENI", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryDeadLetterENI indicates an expected call of RetryDeadLetterENI.
+func (mr *MockTrunkENIMockRecorder) RetryDeadLetterENI(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryDeadLetterENI", reflect.TypeOf((*MockTrunkENI)(nil).RetryDeadLetterENI), arg0)
+}
@@ -78,6 +78,34 @@ func (mr *MockENIManagerMockRecorder) DeleteIPV4Address(arg0, arg1, arg2 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIPV4Address", reflect.TypeOf((*MockENIManager)(nil).DeleteIPV4Address), arg0, arg1, arg2)
 }
 
+// GetCompactableIPs mocks base method.
+func (m *MockENIManager) GetCompactableIPs(arg0 []string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCompactableIPs", arg0)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetCompactableIPs indicates an expected call of GetCompactableIPs.
+func (mr *MockENIManagerMockRecorder) GetCompactableIPs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompactableIPs", reflect.TypeOf((*MockENIManager)(nil).GetCompactableIPs), arg0)
+}
+
+// GetIPRank mocks base method.
+func (m *MockENIManager) GetIPRank(arg0 string) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIPRank", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GetIPRank indicates an expected call of GetIPRank.
+func (mr *MockENIManagerMockRecorder) GetIPRank(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIPRank", reflect.TypeOf((*MockENIManager)(nil).GetIPRank), arg0)
+}
+
 // InitResources mocks base method.
 func (m *MockENIManager) InitResources(arg0 api.EC2APIHelper) ([]string, error) {
 	m.ctrl.T.Helper()
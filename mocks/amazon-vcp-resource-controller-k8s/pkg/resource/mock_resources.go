@@ -22,6 +22,7 @@ import (
 
 	handler "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/handler"
 	provider "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	worker "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -76,3 +77,17 @@ func (mr *MockResourceManagerMockRecorder) GetResourceProviders() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResourceProviders", reflect.TypeOf((*MockResourceManager)(nil).GetResourceProviders))
 }
+
+// GetResourceWorkers mocks base method.
+func (m *MockResourceManager) GetResourceWorkers() map[string]worker.Worker {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResourceWorkers")
+	ret0, _ := ret[0].(map[string]worker.Worker)
+	return ret0
+}
+
+// GetResourceWorkers indicates an expected call of GetResourceWorkers.
+func (mr *MockResourceManagerMockRecorder) GetResourceWorkers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResourceWorkers", reflect.TypeOf((*MockResourceManager)(nil).GetResourceWorkers))
+}
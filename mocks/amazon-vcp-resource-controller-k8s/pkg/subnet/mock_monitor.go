@@ -0,0 +1,74 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/aws/amazon-vpc-resource-controller-k8s/pkg/subnet (interfaces: SubnetMonitor)
+
+// Package mock_subnet is a generated GoMock package.
+package mock_subnet
+
+import (
+	reflect "reflect"
+
+	ec2 "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSubnetMonitor is a mock of SubnetMonitor interface.
+type MockSubnetMonitor struct {
+	ctrl     *gomock.Controller
+	recorder *MockSubnetMonitorMockRecorder
+}
+
+// MockSubnetMonitorMockRecorder is the mock recorder for MockSubnetMonitor.
+type MockSubnetMonitorMockRecorder struct {
+	mock *MockSubnetMonitor
+}
+
+// NewMockSubnetMonitor creates a new mock instance.
+func NewMockSubnetMonitor(ctrl *gomock.Controller) *MockSubnetMonitor {
+	mock := &MockSubnetMonitor{ctrl: ctrl}
+	mock.recorder = &MockSubnetMonitorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSubnetMonitor) EXPECT() *MockSubnetMonitorMockRecorder {
+	return m.recorder
+}
+
+// Forget mocks base method.
+func (m *MockSubnetMonitor) Forget(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Forget", arg0)
+}
+
+// Forget indicates an expected call of Forget.
+func (mr *MockSubnetMonitorMockRecorder) Forget(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Forget", reflect.TypeOf((*MockSubnetMonitor)(nil).Forget), arg0)
+}
+
+// LimitCapacity mocks base method.
+func (m *MockSubnetMonitor) LimitCapacity(arg0 ec2.EC2Instance, arg1 string, arg2 int, arg3 func() int) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LimitCapacity", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// LimitCapacity indicates an expected call of LimitCapacity.
+func (mr *MockSubnetMonitorMockRecorder) LimitCapacity(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LimitCapacity", reflect.TypeOf((*MockSubnetMonitor)(nil).LimitCapacity), arg0, arg1, arg2, arg3)
+}
@@ -21,6 +21,7 @@ import (
 	reflect "reflect"
 	time "time"
 
+	worker "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 	gomock "github.com/golang/mock/gomock"
 	reconcile "sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -48,6 +49,34 @@ func (m *MockWorker) EXPECT() *MockWorkerMockRecorder {
 	return m.recorder
 }
 
+// CancelNodeJobs mocks base method.
+func (m *MockWorker) CancelNodeJobs(arg0 string) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelNodeJobs", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// CancelNodeJobs indicates an expected call of CancelNodeJobs.
+func (mr *MockWorkerMockRecorder) CancelNodeJobs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelNodeJobs", reflect.TypeOf((*MockWorker)(nil).CancelNodeJobs), arg0)
+}
+
+// Introspect mocks base method.
+func (m *MockWorker) Introspect() []worker.JobInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Introspect")
+	ret0, _ := ret[0].([]worker.JobInfo)
+	return ret0
+}
+
+// Introspect indicates an expected call of Introspect.
+func (mr *MockWorkerMockRecorder) Introspect() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Introspect", reflect.TypeOf((*MockWorker)(nil).Introspect))
+}
+
 // StartWorkerPool mocks base method.
 func (m *MockWorker) StartWorkerPool(arg0 func(interface{}) (reconcile.Result, error)) error {
 	m.ctrl.T.Helper()
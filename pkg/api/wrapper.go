@@ -15,8 +15,10 @@ package api
 
 import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/endpoint"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s/pod"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/subnet"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 )
 
@@ -26,4 +28,8 @@ type Wrapper struct {
 	K8sAPI k8s.K8sWrapper
 	PodAPI pod.PodClientAPIWrapper
 	SGPAPI utils.SecurityGroupForPodsAPI
+	// SubnetAPI is optional and limits the advertised capacity to the subnet's free addresses
+	SubnetAPI subnet.SubnetMonitor
+	// EndpointAPI is optional and releases the cooling down resources once they are removed from all the endpoints
+	EndpointAPI endpoint.EndpointTracker
 }
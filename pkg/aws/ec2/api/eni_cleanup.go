@@ -32,7 +32,6 @@ type ENICleaner struct {
 	Log         logr.Logger
 
 	availableENIs     map[string]struct{}
-	shutdown          bool
 	clusterNameTagKey string
 	ctx               context.Context
 }
@@ -48,19 +47,21 @@ func (e *ENICleaner) SetupWithManager(ctx context.Context, mgr ctrl.Manager) err
 // StartENICleaner starts the ENI Cleaner routine that cleans up dangling ENIs created by the controller
 func (e *ENICleaner) Start(ctx context.Context) error {
 	e.Log.Info("starting eni clean up routine")
-	// Start routine to listen for shut down signal, on receiving the signal it set shutdown to true
-	go func() {
-		<-ctx.Done()
-		e.shutdown = true
-	}()
-	// Perform ENI cleanup after fixed time intervals till shut down variable is set to true on receiving the shutdown
-	// signal
-	for !e.shutdown {
-		e.cleanUpAvailableENIs()
-		time.Sleep(config.ENICleanUpInterval)
-	}
+	e.cleanUpAvailableENIs()
+
+	// Perform ENI cleanup after fixed time intervals till the context is done on shut down
+	ticker := time.NewTicker(config.ENICleanUpInterval)
+	defer ticker.Stop()
 
-	return nil
+	for {
+		select {
+		case <-ctx.Done():
+			e.Log.Info("stopping eni clean up routine")
+			return nil
+		case <-ticker.C:
+			e.cleanUpAvailableENIs()
+		}
+	}
 }
 
 // cleanUpAvailableENIs describes all the network interfaces in available status that are created by the controller,
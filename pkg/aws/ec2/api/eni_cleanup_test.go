@@ -106,12 +106,18 @@ func TestENICleaner_cleanUpAvailableENIs(t *testing.T) {
 		map[string]struct{}{mockNetworkInterfaceId3: {}}, eniCleaner.availableENIs))
 }
 
-// TestENICleaner_StartENICleaner_Shutdown tests that ENICleaner would not start if shutdown is set to true.
+// TestENICleaner_StartENICleaner_Shutdown tests that ENICleaner runs a single clean up and returns once the context
+// is done.
 func TestENICleaner_StartENICleaner_Shutdown(t *testing.T) {
 	ctrl := gomock.NewController(t)
-	eniCleaner, _ := getMockENICleaner(ctrl)
+	eniCleaner, mockWrapper := getMockENICleaner(ctrl)
+
+	mockWrapper.EXPECT().DescribeNetworkInterfaces(mockDescribeNetworkInterfaceIp).
+		Return(&ec2.DescribeNetworkInterfacesOutput{}, nil)
 
-	eniCleaner.shutdown = true
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
 
-	eniCleaner.Start(context.TODO())
+	err := eniCleaner.Start(ctx)
+	assert.NoError(t, err)
 }
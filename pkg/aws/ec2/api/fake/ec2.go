@@ -0,0 +1,401 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package fake provides an in-memory EC2 simulator implementing the EC2Wrapper, so the EC2 API helper, the
+// resource providers and the ENI cleaner can be exercised together in tests and local runs without AWS.
+package fake
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"golang.org/x/time/rate"
+)
+
+// EC2 error codes returned by the simulator
+const (
+	ErrCodeRequestLimitExceeded          = "RequestLimitExceeded"
+	ErrCodeInvalidParameterValue         = "InvalidParameterValue"
+	ErrCodeInvalidNextToken              = "InvalidNextToken"
+	ErrCodeVPCNotFound                   = "InvalidVpcID.NotFound"
+	ErrCodeSubnetNotFound                = "InvalidSubnetID.NotFound"
+	ErrCodeInstanceNotFound              = "InvalidInstanceID.NotFound"
+	ErrCodeNetworkInterfaceNotFound      = "InvalidNetworkInterfaceID.NotFound"
+	ErrCodeAttachmentNotFound            = "InvalidAttachmentID.NotFound"
+	ErrCodeNetworkInterfaceInUse         = "InvalidNetworkInterface.InUse"
+	ErrCodeAttachmentLimitExceeded       = "AttachmentLimitExceeded"
+	ErrCodePrivateIPAddressLimitExceeded = "PrivateIpAddressLimitExceeded"
+	ErrCodeInsufficientFreeAddresses     = api.ErrCodeInsufficientFreeAddressesInSubnet
+	ErrCodeTrunkAssociationLimitExceeded = "TrunkInterfaceAssociationLimitExceeded"
+	ErrCodeUnsupportedOperation          = "UnsupportedOperation"
+	ErrCodeAssociationNotFound           = "InvalidAssociationID.NotFound"
+	ErrCodeInvalidInstanceType           = "InvalidInstanceType"
+)
+
+const (
+	// reservedSubnetAddresses is the number of addresses EC2 reserves at the start of each subnet
+	reservedSubnetAddresses = 4
+	// ipv6PrefixLength is the length of the IPv6 prefixes delegated to the network interfaces
+	ipv6PrefixLength = 80
+)
+
+// EC2 is an in-memory EC2 simulator implementing the EC2Wrapper. It models the VPCs, the subnets with a finite
+// number of addresses, the instances with the network interface and address limits from vpc.Limits, the trunk
+// associations, the tags, the pagination of the describe calls and the throttling of each operation
+type EC2 struct {
+	// PageSize is the maximum number of items returned by the paginated describe calls if the request doesn't
+	// set the max results. All the items are returned in a single page if 0
+	PageSize int
+
+	lock sync.Mutex // guards the following
+	// idCounter is used to generate the unique ids of the resources
+	idCounter int
+	vpcs      map[string]*vpcState
+	subnets   map[string]*subnetState
+	instances map[string]*instanceState
+	// networkInterfaces is the state of each network interface by its id
+	networkInterfaces map[string]*networkInterfaceState
+	// associations is the trunk association by its id
+	associations map[string]*ec2.TrunkInterfaceAssociation
+	// limiters throttles the operation once its tokens are exhausted
+	limiters map[string]*rate.Limiter
+	// calls is the number of calls made to each operation, including the throttled calls
+	calls map[string]int
+}
+
+type vpcState struct {
+	id        string
+	cidrBlock string
+	// defaultSecurityGroup is used for the network interfaces created without a security group
+	defaultSecurityGroup string
+}
+
+type subnetState struct {
+	id               string
+	vpcID            string
+	availabilityZone string
+	cidrBlock        *net.IPNet
+	ipv6CidrBlock    *net.IPNet
+	// usedIPs is the set of IPv4 addresses allocated from the subnet, including the reserved addresses
+	usedIPs map[string]bool
+	// usedIPv6 is the set of IPv6 addresses and prefixes allocated from the subnet
+	usedIPv6 map[string]bool
+	// ipv6Counter and prefixCounter generate the next IPv6 address and prefix
+	ipv6Counter   uint64
+	prefixCounter uint16
+}
+
+type instanceState struct {
+	id           string
+	instanceType string
+	subnetID     string
+	// primaryENIID is the network interface created with the instance at device index 0
+	primaryENIID string
+	// ipv6Address is the primary IPv6 address of the instance, if the instance was created with one
+	ipv6Address string
+}
+
+type networkInterfaceState struct {
+	nwInterface *ec2.NetworkInterface
+	// permissions is the list of permissions granted on the network interface
+	permissions []*ec2.NetworkInterfacePermission
+}
+
+// Instance is the configuration of an instance added to the simulator
+type Instance struct {
+	ID           string
+	InstanceType string
+	SubnetID     string
+	// SecurityGroups of the primary network interface, the default security group of the VPC if empty
+	SecurityGroups []string
+	// AssignIPv6Address assigns an IPv6 address to the primary network interface of the instance
+	AssignIPv6Address bool
+}
+
+// NewEC2 returns an empty EC2 simulator
+func NewEC2() *EC2 {
+	return &EC2{
+		vpcs:              map[string]*vpcState{},
+		subnets:           map[string]*subnetState{},
+		instances:         map[string]*instanceState{},
+		networkInterfaces: map[string]*networkInterfaceState{},
+		associations:      map[string]*ec2.TrunkInterfaceAssociation{},
+		limiters:          map[string]*rate.Limiter{},
+		calls:             map[string]int{},
+	}
+}
+
+// AddVPC adds a VPC with the IPv4 CIDR block
+func (e *EC2) AddVPC(vpcID string, cidrBlock string) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, _, err := net.ParseCIDR(cidrBlock); err != nil {
+		return err
+	}
+	e.vpcs[vpcID] = &vpcState{
+		id:                   vpcID,
+		cidrBlock:            cidrBlock,
+		defaultSecurityGroup: "sg-" + strings.TrimPrefix(vpcID, "vpc-"),
+	}
+	return nil
+}
+
+// AddSubnet adds a subnet to the VPC, the IPv6 CIDR block is optional. The first four and the last address of
+// the IPv4 CIDR block are reserved like in EC2
+func (e *EC2) AddSubnet(subnetID string, vpcID string, availabilityZone string, cidrBlock string,
+	ipv6CidrBlock string) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, found := e.vpcs[vpcID]; !found {
+		return awserr.New(ErrCodeVPCNotFound, fmt.Sprintf("the vpc %s does not exist", vpcID), nil)
+	}
+	_, ipNet, err := net.ParseCIDR(cidrBlock)
+	if err != nil || ipNet.IP.To4() == nil {
+		return fmt.Errorf("invalid IPv4 cidr block %s: %v", cidrBlock, err)
+	}
+	subnet := &subnetState{
+		id:               subnetID,
+		vpcID:            vpcID,
+		availabilityZone: availabilityZone,
+		cidrBlock:        ipNet,
+		usedIPs:          map[string]bool{},
+		usedIPv6:         map[string]bool{},
+	}
+	for i := 0; i < reservedSubnetAddresses; i++ {
+		subnet.usedIPs[addToIP(ipNet.IP, uint64(i)).String()] = true
+	}
+	subnet.usedIPs[lastIP(ipNet).String()] = true
+
+	if ipv6CidrBlock != "" {
+		_, ipv6Net, err := net.ParseCIDR(ipv6CidrBlock)
+		if err != nil || ipv6Net.IP.To4() != nil {
+			return fmt.Errorf("invalid IPv6 cidr block %s: %v", ipv6CidrBlock, err)
+		}
+		if ones, _ := ipv6Net.Mask.Size(); ones > ipv6PrefixLength-16 {
+			return fmt.Errorf("IPv6 cidr block %s is too small to delegate prefixes", ipv6CidrBlock)
+		}
+		subnet.ipv6CidrBlock = ipv6Net
+	}
+	e.subnets[subnetID] = subnet
+	return nil
+}
+
+// AddInstance adds a running instance with its primary network interface attached at device index 0. The
+// instance type must be present in vpc.Limits
+func (e *EC2) AddInstance(instance Instance) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, found := e.instances[instance.ID]; found {
+		return fmt.Errorf("the instance %s already exists", instance.ID)
+	}
+	if _, found := vpc.Limits[instance.InstanceType]; !found {
+		return awserr.New(ErrCodeInvalidInstanceType,
+			fmt.Sprintf("the instance type %s is not supported", instance.InstanceType), nil)
+	}
+	nwInterface, err := e.createNetworkInterface(instance.SubnetID, instance.SecurityGroups, "", nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	e.instances[instance.ID] = &instanceState{
+		id:           instance.ID,
+		instanceType: instance.InstanceType,
+		subnetID:     instance.SubnetID,
+		primaryENIID: aws.StringValue(nwInterface.NetworkInterfaceId),
+	}
+	e.attach(nwInterface, instance.ID, 0, 0)
+	nwInterface.Attachment.DeleteOnTermination = aws.Bool(true)
+
+	if instance.AssignIPv6Address {
+		addresses, err := e.allocateIPv6(e.subnets[instance.SubnetID], 1, false)
+		if err != nil {
+			return err
+		}
+		nwInterface.Ipv6Addresses = append(nwInterface.Ipv6Addresses,
+			&ec2.NetworkInterfaceIpv6Address{Ipv6Address: aws.String(addresses[0])})
+		e.instances[instance.ID].ipv6Address = addresses[0]
+	}
+	return nil
+}
+
+// TerminateInstance removes the instance, the network interfaces attached with delete on termination are
+// deleted and the other network interfaces are detached
+func (e *EC2) TerminateInstance(instanceID string) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, found := e.instances[instanceID]; !found {
+		return awserr.New(ErrCodeInstanceNotFound, fmt.Sprintf("the instance %s does not exist", instanceID), nil)
+	}
+	for _, state := range e.networkInterfaces {
+		attachment := state.nwInterface.Attachment
+		if attachment == nil || aws.StringValue(attachment.InstanceId) != instanceID {
+			continue
+		}
+		if aws.BoolValue(attachment.DeleteOnTermination) {
+			e.deleteNetworkInterface(state.nwInterface)
+		} else {
+			e.detach(state.nwInterface)
+		}
+	}
+	delete(e.instances, instanceID)
+	return nil
+}
+
+// SetThrottle throttles the operation, for example DescribeNetworkInterfaces, once more than burst calls are
+// made within a second at the given rate. A rate of 0 throttles all the calls after the first burst calls
+func (e *EC2) SetThrottle(operation string, callsPerSecond float64, burst int) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	limit := rate.Limit(callsPerSecond)
+	if limit <= 0 {
+		// The limiter doesn't handle a zero rate consistently across versions, refill a single token a day instead
+		limit = rate.Every(24 * time.Hour)
+	}
+	e.limiters[operation] = rate.NewLimiter(limit, burst)
+}
+
+// RemoveThrottle stops throttling the operation
+func (e *EC2) RemoveThrottle(operation string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	delete(e.limiters, operation)
+}
+
+// CallCount returns the number of calls made to the operation, including the throttled calls
+func (e *EC2) CallCount(operation string) int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.calls[operation]
+}
+
+// call records the call to the operation and returns the throttling error if the operation has no token left,
+// must be called with the lock held
+func (e *EC2) call(operation string) error {
+	e.calls[operation]++
+	if limiter, found := e.limiters[operation]; found && !limiter.Allow() {
+		return awserr.New(ErrCodeRequestLimitExceeded, "Request limit exceeded.", nil)
+	}
+	return nil
+}
+
+// nextID returns a new unique id with the given prefix, must be called with the lock held
+func (e *EC2) nextID(prefix string) string {
+	e.idCounter++
+	return fmt.Sprintf("%s-%017x", prefix, e.idCounter)
+}
+
+// paginate returns the start and the end index of the page of the sorted ids along with the next token. The token
+// is the id of the first item of the next page, so the items deleted between the calls don't shift the pages. The
+// page size is the max results of the request or the simulator's page size
+func (e *EC2) paginate(ids []string, nextToken *string, maxResults *int64) (int, int, *string, error) {
+	start := 0
+	if nextToken != nil {
+		if aws.StringValue(nextToken) == "" {
+			return 0, 0, nil, awserr.New(ErrCodeInvalidNextToken, "the next token is empty", nil)
+		}
+		start = sort.SearchStrings(ids, aws.StringValue(nextToken))
+	}
+	pageSize := e.PageSize
+	if maxResults != nil {
+		pageSize = int(*maxResults)
+	}
+	if pageSize <= 0 || start+pageSize >= len(ids) {
+		return start, len(ids), nil, nil
+	}
+	return start, start + pageSize, aws.String(ids[start+pageSize]), nil
+}
+
+// matchesFilters returns true if the values of the resource match all the filters, a filter matches if any of
+// its values is equal to one of the values of the resource for the filter name. Returns an error on unsupported
+// filters
+func matchesFilters(filters []*ec2.Filter, values func(name string) ([]string, bool)) (bool, error) {
+	for _, filter := range filters {
+		name := aws.StringValue(filter.Name)
+		resourceValues, supported := values(name)
+		if !supported {
+			return false, awserr.New(ErrCodeInvalidParameterValue,
+				fmt.Sprintf("the filter '%s' is invalid", name), nil)
+		}
+		matched := false
+		for _, value := range aws.StringValueSlice(filter.Values) {
+			for _, resourceValue := range resourceValues {
+				if value == resourceValue {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// tagFilterValues returns the values of the tag filters, tag:<key> and tag-key
+func tagFilterValues(tags []*ec2.Tag, name string) ([]string, bool) {
+	if name == "tag-key" {
+		var keys []string
+		for _, tag := range tags {
+			keys = append(keys, aws.StringValue(tag.Key))
+		}
+		return keys, true
+	}
+	if strings.HasPrefix(name, "tag:") {
+		key := strings.TrimPrefix(name, "tag:")
+		for _, tag := range tags {
+			if aws.StringValue(tag.Key) == key {
+				return []string{aws.StringValue(tag.Value)}, true
+			}
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+// addToIP returns the IP incremented by the given value
+func addToIP(ip net.IP, value uint64) net.IP {
+	result := make(net.IP, len(ip))
+	copy(result, ip)
+	for i := len(result) - 1; i >= 0 && value > 0; i-- {
+		sum := uint64(result[i]) + value&0xff
+		result[i] = byte(sum)
+		value = value>>8 + sum>>8
+	}
+	return result
+}
+
+// lastIP returns the broadcast address of the IPv4 CIDR block
+func lastIP(ipNet *net.IPNet) net.IP {
+	ip := ipNet.IP.To4()
+	result := make(net.IP, len(ip))
+	for i := range ip {
+		result[i] = ip[i] | ^ipNet.Mask[i]
+	}
+	return result
+}
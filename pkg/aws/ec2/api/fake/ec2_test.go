@@ -0,0 +1,376 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fake
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	clusterName = "cluster-name"
+
+	vpcID        = "vpc-00000000000000000"
+	subnetID     = "subnet-00000000000000000"
+	subnetCIDR   = "192.168.0.0/24"
+	subnetIPv6   = "2600:1f13:0:1::/64"
+	instanceID   = "i-00000000000000000"
+	instanceType = "c5a.large"
+	description  = "description"
+
+	securityGroups = []string{"sg-1"}
+	trunkType      = aws.String(ec2.NetworkInterfaceTypeTrunk)
+)
+
+// getFakeEC2 returns the simulator with a VPC, a subnet with the given cidr and a running instance
+func getFakeEC2(t *testing.T, cidr string) (*EC2, api.EC2APIHelper) {
+	fake := NewEC2()
+	assert.NoError(t, fake.AddVPC(vpcID, "192.168.0.0/16"))
+	assert.NoError(t, fake.AddSubnet(subnetID, vpcID, "us-west-2a", cidr, subnetIPv6))
+	assert.NoError(t, fake.AddInstance(Instance{ID: instanceID, InstanceType: instanceType, SubnetID: subnetID,
+		SecurityGroups: securityGroups}))
+	return fake, api.NewEC2APIHelper(fake, clusterName)
+}
+
+func errorCode(err error) string {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code()
+	}
+	return ""
+}
+
+// TestEC2_CreateAndAttachNetworkInterface tests the network interface created and attached by the helper is
+// returned with the instance details and its addresses are allocated from the subnet
+func TestEC2_CreateAndAttachNetworkInterface(t *testing.T) {
+	fake, helper := getFakeEC2(t, subnetCIDR)
+
+	nwInterface, err := helper.CreateAndAttachNetworkInterface(&instanceID, &subnetID, securityGroups, nil,
+		aws.Int64(1), nil, &description, nil, 2)
+	assert.NoError(t, err)
+	assert.Len(t, nwInterface.PrivateIpAddresses, 3)
+
+	instance, err := helper.GetInstanceDetails(&instanceID)
+	assert.NoError(t, err)
+	assert.Equal(t, "192.168.0.4", *instance.PrivateIpAddress)
+	assert.Len(t, instance.NetworkInterfaces, 2)
+
+	subnet, err := helper.GetSubnet(&subnetID)
+	assert.NoError(t, err)
+	// 256 addresses less the 5 reserved ones, the primary address of the instance and the 3 new addresses
+	assert.Equal(t, int64(247), *subnet.AvailableIpAddressCount)
+
+	ips, err := helper.AssignIPv4AddressesAndWaitTillReady(*nwInterface.NetworkInterfaceId, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.0.8", "192.168.0.9"}, ips)
+	assert.Equal(t, 1, fake.CallCount("AssignPrivateIpAddresses"))
+
+	assert.NoError(t, helper.UnassignPrivateIpAddresses(*nwInterface.NetworkInterfaceId, ips[:1]))
+	nwInterfaces, err := helper.DescribeNetworkInterfaces([]*string{nwInterface.NetworkInterfaceId})
+	assert.NoError(t, err)
+	assert.Len(t, nwInterfaces[0].PrivateIpAddresses, 4)
+	assert.Equal(t, int64(1), *nwInterfaces[0].Attachment.DeviceIndex)
+	assert.True(t, *nwInterfaces[0].Attachment.DeleteOnTermination)
+}
+
+// TestEC2_AttachNetworkInterface_Limits tests the device index and the network interface count of the instance
+// type are enforced
+func TestEC2_AttachNetworkInterface_Limits(t *testing.T) {
+	_, helper := getFakeEC2(t, subnetCIDR)
+
+	_, err := helper.CreateAndAttachNetworkInterface(&instanceID, &subnetID, securityGroups, nil,
+		aws.Int64(0), nil, &description, nil, 0)
+	assert.Equal(t, ErrCodeInvalidParameterValue, errorCode(err))
+
+	// c5a.large supports 3 network interfaces
+	for deviceIndex := int64(1); deviceIndex < 3; deviceIndex++ {
+		_, err = helper.CreateAndAttachNetworkInterface(&instanceID, &subnetID, securityGroups, nil,
+			aws.Int64(deviceIndex), nil, &description, nil, 0)
+		assert.NoError(t, err)
+	}
+	_, err = helper.CreateAndAttachNetworkInterface(&instanceID, &subnetID, securityGroups, nil,
+		aws.Int64(3), nil, &description, nil, 0)
+	assert.Equal(t, ErrCodeAttachmentLimitExceeded, errorCode(err))
+
+	// The network interfaces that failed to attach are deleted by the helper
+	subnet, err := helper.GetSubnet(&subnetID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(248), *subnet.AvailableIpAddressCount)
+}
+
+// TestEC2_AttachNetworkInterface_NetworkCards tests the device indexes are per network card and the network
+// interfaces can't be attached beyond the limit of the network card
+func TestEC2_AttachNetworkInterface_NetworkCards(t *testing.T) {
+	fake, helper := getFakeEC2(t, subnetCIDR)
+	multiCardInstanceID := "i-00000000000000001"
+	assert.NoError(t, fake.AddInstance(Instance{ID: multiCardInstanceID, InstanceType: "p4d.24xlarge",
+		SubnetID: subnetID, SecurityGroups: securityGroups}))
+
+	for _, networkCardIndex := range []int64{0, 1} {
+		nwInterface, err := helper.CreateAndAttachNetworkInterface(&multiCardInstanceID, &subnetID, securityGroups,
+			nil, aws.Int64(1), aws.Int64(networkCardIndex), &description, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, networkCardIndex, *nwInterface.Attachment.NetworkCardIndex)
+	}
+
+	_, err := helper.CreateAndAttachNetworkInterface(&multiCardInstanceID, &subnetID, securityGroups, nil,
+		aws.Int64(15), aws.Int64(1), &description, nil, 0)
+	assert.Equal(t, ErrCodeAttachmentLimitExceeded, errorCode(err))
+
+	_, err = helper.CreateAndAttachNetworkInterface(&multiCardInstanceID, &subnetID, securityGroups, nil,
+		aws.Int64(1), aws.Int64(4), &description, nil, 0)
+	assert.Equal(t, ErrCodeInvalidParameterValue, errorCode(err))
+}
+
+// TestEC2_AssignPrivateIPAddresses_Limits tests the addresses are limited by the instance type and by the free
+// addresses of the subnet
+func TestEC2_AssignPrivateIPAddresses_Limits(t *testing.T) {
+	_, helper := getFakeEC2(t, "192.168.0.0/27")
+
+	nwInterface, err := helper.CreateAndAttachNetworkInterface(&instanceID, &subnetID, securityGroups, nil,
+		aws.Int64(1), nil, &description, nil, 0)
+	assert.NoError(t, err)
+
+	// c5a.large supports 10 addresses per network interface including the primary address
+	_, err = helper.AssignIPv4AddressesAndWaitTillReady(*nwInterface.NetworkInterfaceId, 10)
+	assert.Equal(t, ErrCodePrivateIPAddressLimitExceeded, errorCode(err))
+
+	ips, err := helper.AssignIPv4AddressesAndWaitTillReady(*nwInterface.NetworkInterfaceId, 9)
+	assert.NoError(t, err)
+	assert.Len(t, ips, 9)
+
+	// 32 addresses less the 5 reserved ones and the 11 used addresses leaves 16 free addresses
+	_, err = helper.CreateNetworkInterface(&description, &subnetID, nil, nil, 16, nil)
+	assert.True(t, api.IsInsufficientFreeAddressesError(err))
+
+	_, err = helper.CreateNetworkInterface(&description, &subnetID, nil, nil, 15, nil)
+	assert.NoError(t, err)
+}
+
+// TestEC2_AssignIPv6Addresses tests the IPv6 addresses and prefixes are allocated from the subnet and released
+// once unassigned
+func TestEC2_AssignIPv6Addresses(t *testing.T) {
+	_, helper := getFakeEC2(t, subnetCIDR)
+
+	nwInterface, err := helper.CreateAndAttachNetworkInterface(&instanceID, &subnetID, securityGroups, nil,
+		aws.Int64(1), nil, &description, nil, 0)
+	assert.NoError(t, err)
+	eniID := *nwInterface.NetworkInterfaceId
+
+	addresses, err := helper.AssignIPv6AddressesAndWaitTillReady(eniID, 2, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2600:1f13:0:1::1", "2600:1f13:0:1::2"}, addresses)
+
+	prefixes, err := helper.AssignIPv6AddressesAndWaitTillReady(eniID, 1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2600:1f13:0:1:1::/80"}, prefixes)
+
+	assert.NoError(t, helper.UnassignIPv6Addresses(eniID, prefixes, true))
+	assert.NoError(t, helper.UnassignIPv6Addresses(eniID, addresses[:1], false))
+
+	nwInterfaces, err := helper.DescribeNetworkInterfaces([]*string{&eniID})
+	assert.NoError(t, err)
+	assert.Empty(t, nwInterfaces[0].Ipv6Prefixes)
+	assert.Len(t, nwInterfaces[0].Ipv6Addresses, 1)
+}
+
+// TestEC2_AssociateBranchToTrunk tests the branch network interfaces are associated with the trunk up to the limit
+// of the instance type and are returned page by page
+func TestEC2_AssociateBranchToTrunk(t *testing.T) {
+	fake, helper := getFakeEC2(t, subnetCIDR)
+	fake.PageSize = 2
+
+	trunk, err := helper.CreateAndAttachNetworkInterface(&instanceID, &subnetID, securityGroups, nil,
+		aws.Int64(1), nil, &description, trunkType, 0)
+	assert.NoError(t, err)
+
+	branchTags := []*ec2.Tag{{Key: aws.String(config.TrunkENIIDTag), Value: trunk.NetworkInterfaceId}}
+	// c5a.large supports 9 branch network interfaces
+	for vlanID := 1; vlanID <= 10; vlanID++ {
+		branch, err := helper.CreateNetworkInterface(&description, &subnetID, securityGroups, branchTags, 0, nil)
+		assert.NoError(t, err)
+
+		_, err = helper.AssociateBranchToTrunk(trunk.NetworkInterfaceId, branch.NetworkInterfaceId, vlanID)
+		if vlanID <= 9 {
+			assert.NoError(t, err)
+		} else {
+			assert.Equal(t, ErrCodeTrunkAssociationLimitExceeded, errorCode(err))
+		}
+	}
+
+	describeCalls := fake.CallCount("DescribeNetworkInterfaces")
+	branches, err := helper.GetBranchNetworkInterface(trunk.NetworkInterfaceId)
+	assert.NoError(t, err)
+	assert.Len(t, branches, 10)
+	assert.Equal(t, describeCalls+5, fake.CallCount("DescribeNetworkInterfaces"))
+
+	// The helper doesn't paginate the trunk associations
+	fake.PageSize = 0
+
+	associations, err := helper.DescribeTrunkInterfaceAssociation(trunk.NetworkInterfaceId)
+	assert.NoError(t, err)
+	assert.Len(t, associations, 9)
+
+	// Deleting the branch removes its association
+	assert.NoError(t, helper.DeleteNetworkInterface(associations[0].BranchInterfaceId))
+	associations, err = helper.DescribeTrunkInterfaceAssociation(trunk.NetworkInterfaceId)
+	assert.NoError(t, err)
+	assert.Len(t, associations, 8)
+}
+
+// TestEC2_AssociateTrunkInterface_DuplicateVlan tests a vlan id can't be associated twice with the same trunk
+func TestEC2_AssociateTrunkInterface_DuplicateVlan(t *testing.T) {
+	_, helper := getFakeEC2(t, subnetCIDR)
+
+	trunk, err := helper.CreateAndAttachNetworkInterface(&instanceID, &subnetID, securityGroups, nil,
+		aws.Int64(1), nil, &description, trunkType, 0)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		branch, err := helper.CreateNetworkInterface(&description, &subnetID, securityGroups, nil, 0, nil)
+		assert.NoError(t, err)
+		_, err = helper.AssociateBranchToTrunk(trunk.NetworkInterfaceId, branch.NetworkInterfaceId, 1)
+		if i == 0 {
+			assert.NoError(t, err)
+		} else {
+			assert.Equal(t, ErrCodeInvalidParameterValue, errorCode(err))
+		}
+	}
+}
+
+// TestEC2_DescribeNetworkInterfaces_DeleteWhilePaginating tests no available network interface is skipped when the
+// network interfaces are deleted between the pages, like the ENI cleaner does
+func TestEC2_DescribeNetworkInterfaces_DeleteWhilePaginating(t *testing.T) {
+	fake, helper := getFakeEC2(t, subnetCIDR)
+	fake.PageSize = 3
+
+	for i := 0; i < 7; i++ {
+		_, err := helper.CreateNetworkInterface(&description, &subnetID, nil, nil, 0, nil)
+		assert.NoError(t, err)
+	}
+
+	input := &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("status"),
+				Values: []*string{aws.String(ec2.NetworkInterfaceStatusAvailable)},
+			},
+			{
+				Name:   aws.String("tag:" + fmt.Sprintf(config.ClusterNameTagKeyFormat, clusterName)),
+				Values: []*string{aws.String(config.ClusterNameTagValue)},
+			},
+		},
+	}
+	deleted := 0
+	for {
+		output, err := fake.DescribeNetworkInterfaces(input)
+		assert.NoError(t, err)
+		for _, nwInterface := range output.NetworkInterfaces {
+			assert.True(t, strings.HasPrefix(*nwInterface.Description, api.CreateENIDescriptionPrefix))
+			_, err = fake.DeleteNetworkInterface(&ec2.DeleteNetworkInterfaceInput{
+				NetworkInterfaceId: nwInterface.NetworkInterfaceId,
+			})
+			assert.NoError(t, err)
+			deleted++
+		}
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	assert.Equal(t, 7, deleted)
+
+	// Only the primary network interface of the instance is left
+	output, err := fake.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{})
+	assert.NoError(t, err)
+	assert.Len(t, output.NetworkInterfaces, 1)
+}
+
+// TestEC2_DescribeNetworkInterfaces_InvalidFilter tests the unsupported filters are rejected instead of ignored
+func TestEC2_DescribeNetworkInterfaces_InvalidFilter(t *testing.T) {
+	fake, _ := getFakeEC2(t, subnetCIDR)
+
+	_, err := fake.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("unknown"), Values: []*string{aws.String("value")}}},
+	})
+	assert.Equal(t, ErrCodeInvalidParameterValue, errorCode(err))
+}
+
+// TestEC2_SetThrottle tests the calls are throttled once the burst is exhausted and are allowed again once the
+// throttle is removed
+func TestEC2_SetThrottle(t *testing.T) {
+	fake, helper := getFakeEC2(t, subnetCIDR)
+	fake.SetThrottle("DescribeSubnets", 0, 1)
+
+	_, err := helper.GetSubnet(&subnetID)
+	assert.NoError(t, err)
+	_, err = helper.GetSubnet(&subnetID)
+	assert.Equal(t, ErrCodeRequestLimitExceeded, errorCode(err))
+
+	fake.RemoveThrottle("DescribeSubnets")
+	_, err = helper.GetSubnet(&subnetID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, fake.CallCount("DescribeSubnets"))
+}
+
+// TestEC2_DescribeInstanceTypes tests the limits of the instance type are described from the generated limits and
+// unknown instance types are rejected
+func TestEC2_DescribeInstanceTypes(t *testing.T) {
+	_, helper := getFakeEC2(t, subnetCIDR)
+
+	limits, networkCards, err := helper.GetInstanceTypeLimits(instanceType)
+	assert.NoError(t, err)
+	assert.Equal(t, vpc.Limits[instanceType].Interface, limits.Interface)
+	assert.Equal(t, vpc.Limits[instanceType].IPv4PerInterface, limits.IPv4PerInterface)
+	assert.Equal(t, vpc.GetNetworkCards(instanceType), networkCards)
+
+	_, _, err = helper.GetInstanceTypeLimits("unknown.large")
+	assert.Equal(t, ErrCodeInvalidInstanceType, errorCode(err))
+}
+
+// TestEC2_TerminateInstance tests the network interfaces with delete on termination are deleted and the other
+// network interfaces are detached when the instance is terminated
+func TestEC2_TerminateInstance(t *testing.T) {
+	fake, helper := getFakeEC2(t, subnetCIDR)
+
+	deleted, err := helper.CreateAndAttachNetworkInterface(&instanceID, &subnetID, securityGroups, nil,
+		aws.Int64(1), nil, &description, nil, 0)
+	assert.NoError(t, err)
+	detached, err := helper.CreateNetworkInterface(&description, &subnetID, securityGroups, nil, 0, nil)
+	assert.NoError(t, err)
+	_, err = helper.AttachNetworkInterfaceToInstance(&instanceID, detached.NetworkInterfaceId, aws.Int64(2), nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, fake.TerminateInstance(instanceID))
+
+	_, err = helper.DescribeNetworkInterfaces([]*string{deleted.NetworkInterfaceId})
+	assert.Equal(t, ErrCodeNetworkInterfaceNotFound, errorCode(err))
+
+	nwInterfaces, err := helper.DescribeNetworkInterfaces([]*string{detached.NetworkInterfaceId})
+	assert.NoError(t, err)
+	assert.Equal(t, ec2.NetworkInterfaceStatusAvailable, *nwInterfaces[0].Status)
+	assert.Nil(t, nwInterfaces[0].Attachment)
+
+	_, err = helper.GetInstanceDetails(&instanceID)
+	assert.Equal(t, ErrCodeInstanceNotFound, errorCode(err))
+}
@@ -0,0 +1,418 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fake
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// getNetworkInterface returns the network interface with the given id, must be called with the lock held
+func (e *EC2) getNetworkInterface(nwInterfaceID string) (*ec2.NetworkInterface, error) {
+	state, found := e.networkInterfaces[nwInterfaceID]
+	if !found {
+		return nil, awserr.New(ErrCodeNetworkInterfaceNotFound,
+			fmt.Sprintf("The networkInterface ID '%s' does not exist", nwInterfaceID), nil)
+	}
+	return state.nwInterface, nil
+}
+
+// createNetworkInterface creates an available network interface in the subnet with the primary and the secondary
+// IPv4 addresses, must be called with the lock held
+func (e *EC2) createNetworkInterface(subnetID string, groups []string, description string, tags []*ec2.Tag,
+	secondaryIPCount int, interfaceType *string) (*ec2.NetworkInterface, error) {
+	subnet, found := e.subnets[subnetID]
+	if !found {
+		return nil, awserr.New(ErrCodeSubnetNotFound,
+			fmt.Sprintf("The subnet ID '%s' does not exist", subnetID), nil)
+	}
+	if interfaceType != nil && aws.StringValue(interfaceType) != ec2.NetworkInterfaceTypeTrunk &&
+		aws.StringValue(interfaceType) != ec2.NetworkInterfaceTypeEfa {
+		return nil, awserr.New(ErrCodeInvalidParameterValue,
+			fmt.Sprintf("The interface type '%s' is invalid", aws.StringValue(interfaceType)), nil)
+	}
+	if len(groups) == 0 {
+		groups = []string{e.vpcs[subnet.vpcID].defaultSecurityGroup}
+	}
+
+	addresses, err := e.allocateIPv4(subnet, secondaryIPCount+1)
+	if err != nil {
+		return nil, err
+	}
+
+	id := e.nextID("eni")
+	nwInterface := &ec2.NetworkInterface{
+		NetworkInterfaceId: aws.String(id),
+		AvailabilityZone:   aws.String(subnet.availabilityZone),
+		Description:        aws.String(description),
+		Groups:             toGroupIdentifiers(groups),
+		InterfaceType:      aws.String(ec2.NetworkInterfaceTypeInterface),
+		MacAddress:         aws.String(macAddress(e.idCounter)),
+		PrivateIpAddress:   aws.String(addresses[0]),
+		Status:             aws.String(ec2.NetworkInterfaceStatusAvailable),
+		SubnetId:           aws.String(subnet.id),
+		VpcId:              aws.String(subnet.vpcID),
+		TagSet:             mergeTags(nil, tags),
+	}
+	if interfaceType != nil {
+		nwInterface.InterfaceType = aws.String(aws.StringValue(interfaceType))
+	}
+	for i, address := range addresses {
+		nwInterface.PrivateIpAddresses = append(nwInterface.PrivateIpAddresses, &ec2.NetworkInterfacePrivateIpAddress{
+			Primary:          aws.Bool(i == 0),
+			PrivateIpAddress: aws.String(address),
+		})
+	}
+	e.networkInterfaces[id] = &networkInterfaceState{nwInterface: nwInterface}
+	return nwInterface, nil
+}
+
+// attach attaches the network interface to the instance at the device index, must be called with the lock held
+func (e *EC2) attach(nwInterface *ec2.NetworkInterface, instanceID string, networkCardIndex int64, deviceIndex int64) {
+	nwInterface.Attachment = &ec2.NetworkInterfaceAttachment{
+		AttachmentId:        aws.String(e.nextID("eni-attach")),
+		DeleteOnTermination: aws.Bool(false),
+		DeviceIndex:         aws.Int64(deviceIndex),
+		InstanceId:          aws.String(instanceID),
+		NetworkCardIndex:    aws.Int64(networkCardIndex),
+		Status:              aws.String(ec2.AttachmentStatusAttached),
+	}
+	nwInterface.Status = aws.String(ec2.NetworkInterfaceStatusInUse)
+}
+
+// detach detaches the network interface from its instance, must be called with the lock held
+func (e *EC2) detach(nwInterface *ec2.NetworkInterface) {
+	nwInterface.Attachment = nil
+	nwInterface.Status = aws.String(ec2.NetworkInterfaceStatusAvailable)
+}
+
+// deleteNetworkInterface deletes the network interface along with its trunk associations and releases its
+// addresses to the subnet, must be called with the lock held
+func (e *EC2) deleteNetworkInterface(nwInterface *ec2.NetworkInterface) {
+	id := aws.StringValue(nwInterface.NetworkInterfaceId)
+	for associationID, association := range e.associations {
+		if aws.StringValue(association.BranchInterfaceId) == id || aws.StringValue(association.TrunkInterfaceId) == id {
+			delete(e.associations, associationID)
+		}
+	}
+	subnet := e.subnets[aws.StringValue(nwInterface.SubnetId)]
+	for _, address := range nwInterface.PrivateIpAddresses {
+		delete(subnet.usedIPs, aws.StringValue(address.PrivateIpAddress))
+	}
+	for _, address := range nwInterface.Ipv6Addresses {
+		delete(subnet.usedIPv6, aws.StringValue(address.Ipv6Address))
+	}
+	for _, prefix := range nwInterface.Ipv6Prefixes {
+		delete(subnet.usedIPv6, aws.StringValue(prefix.Ipv6Prefix))
+	}
+	delete(e.networkInterfaces, id)
+}
+
+// attachedNetworkInterfaces returns the network interfaces attached to the instance, must be called with the
+// lock held
+func (e *EC2) attachedNetworkInterfaces(instanceID string) []*ec2.NetworkInterface {
+	var attached []*ec2.NetworkInterface
+	for _, state := range e.networkInterfaces {
+		if state.nwInterface.Attachment != nil && aws.StringValue(state.nwInterface.Attachment.InstanceId) == instanceID {
+			attached = append(attached, state.nwInterface)
+		}
+	}
+	return attached
+}
+
+// ipv4Limit returns the number of addresses allowed on the network interface by its instance type. The
+// addresses of the network interfaces that are not attached to an instance are not limited
+func (e *EC2) ipv4Limit(nwInterface *ec2.NetworkInterface) (int, bool) {
+	if nwInterface.Attachment == nil {
+		return 0, false
+	}
+	instance, found := e.instances[aws.StringValue(nwInterface.Attachment.InstanceId)]
+	if !found {
+		return 0, false
+	}
+	return vpc.Limits[instance.instanceType].IPv4PerInterface, true
+}
+
+// allocateIPv4 allocates the lowest free IPv4 addresses of the subnet, no address is allocated if the subnet
+// doesn't have enough free addresses. Must be called with the lock held
+func (e *EC2) allocateIPv4(subnet *subnetState, count int) ([]string, error) {
+	if count > availableIPv4Count(subnet) {
+		return nil, awserr.New(ErrCodeInsufficientFreeAddresses, fmt.Sprintf("The specified subnet %s does "+
+			"not have enough free addresses to satisfy the request.", subnet.id), nil)
+	}
+	var addresses []string
+	for ip := subnet.cidrBlock.IP; len(addresses) < count; ip = addToIP(ip, 1) {
+		if !subnet.usedIPs[ip.String()] {
+			addresses = append(addresses, ip.String())
+			subnet.usedIPs[ip.String()] = true
+		}
+	}
+	return addresses, nil
+}
+
+// allocateSpecificIPv4 allocates the given IPv4 addresses of the subnet if all of them are free, must be called
+// with the lock held
+func (e *EC2) allocateSpecificIPv4(subnet *subnetState, addresses []string) ([]string, error) {
+	for _, address := range addresses {
+		ip := net.ParseIP(address)
+		if ip == nil || !subnet.cidrBlock.Contains(ip) {
+			return nil, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("The address %s is not in the "+
+				"subnet %s", address, subnet.id), nil)
+		}
+		if subnet.usedIPs[ip.String()] {
+			return nil, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("The address %s is already "+
+				"in use", address), nil)
+		}
+	}
+	var allocated []string
+	for _, address := range addresses {
+		ip := net.ParseIP(address).String()
+		subnet.usedIPs[ip] = true
+		allocated = append(allocated, ip)
+	}
+	return allocated, nil
+}
+
+// allocateIPv6 allocates new IPv6 addresses or /80 prefixes from the subnet. The addresses are allocated from the
+// first /80 of the subnet and the prefixes from the ones after it. Must be called with the lock held
+func (e *EC2) allocateIPv6(subnet *subnetState, count int, prefixes bool) ([]string, error) {
+	if subnet.ipv6CidrBlock == nil {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("The subnet %s has no IPv6 cidr "+
+			"block", subnet.id), nil)
+	}
+	if (prefixes && int(subnet.prefixCounter)+count > 0xffff) ||
+		(!prefixes && subnet.ipv6Counter+uint64(count) >= 1<<48) {
+		return nil, awserr.New(ErrCodeInsufficientFreeAddresses, fmt.Sprintf("The specified subnet %s does "+
+			"not have enough free IPv6 addresses to satisfy the request.", subnet.id), nil)
+	}
+
+	var addresses []string
+	for i := 0; i < count; i++ {
+		ip := make(net.IP, net.IPv6len)
+		copy(ip, subnet.ipv6CidrBlock.IP)
+		var address string
+		if prefixes {
+			subnet.prefixCounter++
+			binary.BigEndian.PutUint16(ip[8:10], subnet.prefixCounter)
+			address = fmt.Sprintf("%s/%d", ip.String(), ipv6PrefixLength)
+		} else {
+			subnet.ipv6Counter++
+			counter := make([]byte, 8)
+			binary.BigEndian.PutUint64(counter, subnet.ipv6Counter)
+			copy(ip[10:], counter[2:])
+			address = ip.String()
+		}
+		subnet.usedIPv6[address] = true
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+// availableIPv4Count returns the number of free IPv4 addresses in the subnet
+func availableIPv4Count(subnet *subnetState) int {
+	ones, bits := subnet.cidrBlock.Mask.Size()
+	return 1<<(bits-ones) - len(subnet.usedIPs)
+}
+
+// describeInstance returns the instance along with its attached network interfaces, must be called with the
+// lock held
+func (e *EC2) describeInstance(instance *instanceState) *ec2.Instance {
+	subnet := e.subnets[instance.subnetID]
+	described := &ec2.Instance{
+		InstanceId:   aws.String(instance.id),
+		InstanceType: aws.String(instance.instanceType),
+		SubnetId:     aws.String(subnet.id),
+		VpcId:        aws.String(subnet.vpcID),
+		Placement:    &ec2.Placement{AvailabilityZone: aws.String(subnet.availabilityZone)},
+		State:        &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+	}
+	if instance.ipv6Address != "" {
+		described.Ipv6Address = aws.String(instance.ipv6Address)
+	}
+	if primary, found := e.networkInterfaces[instance.primaryENIID]; found {
+		described.PrivateIpAddress = aws.String(aws.StringValue(primary.nwInterface.PrivateIpAddress))
+	}
+
+	attached := e.attachedNetworkInterfaces(instance.id)
+	for _, nwInterface := range attached {
+		instanceNwInterface := &ec2.InstanceNetworkInterface{
+			Attachment: &ec2.InstanceNetworkInterfaceAttachment{
+				AttachmentId:        aws.String(aws.StringValue(nwInterface.Attachment.AttachmentId)),
+				DeleteOnTermination: aws.Bool(aws.BoolValue(nwInterface.Attachment.DeleteOnTermination)),
+				DeviceIndex:         aws.Int64(aws.Int64Value(nwInterface.Attachment.DeviceIndex)),
+				NetworkCardIndex:    aws.Int64(aws.Int64Value(nwInterface.Attachment.NetworkCardIndex)),
+				Status:              aws.String(aws.StringValue(nwInterface.Attachment.Status)),
+			},
+			Description:        aws.String(aws.StringValue(nwInterface.Description)),
+			InterfaceType:      aws.String(aws.StringValue(nwInterface.InterfaceType)),
+			MacAddress:         aws.String(aws.StringValue(nwInterface.MacAddress)),
+			NetworkInterfaceId: aws.String(aws.StringValue(nwInterface.NetworkInterfaceId)),
+			PrivateIpAddress:   aws.String(aws.StringValue(nwInterface.PrivateIpAddress)),
+			Status:             aws.String(aws.StringValue(nwInterface.Status)),
+			SubnetId:           aws.String(aws.StringValue(nwInterface.SubnetId)),
+			VpcId:              aws.String(aws.StringValue(nwInterface.VpcId)),
+		}
+		for _, group := range nwInterface.Groups {
+			copied := *group
+			instanceNwInterface.Groups = append(instanceNwInterface.Groups, &copied)
+		}
+		for _, address := range nwInterface.PrivateIpAddresses {
+			instanceNwInterface.PrivateIpAddresses = append(instanceNwInterface.PrivateIpAddresses,
+				&ec2.InstancePrivateIpAddress{
+					Primary:          aws.Bool(aws.BoolValue(address.Primary)),
+					PrivateIpAddress: aws.String(aws.StringValue(address.PrivateIpAddress)),
+				})
+		}
+		for _, address := range nwInterface.Ipv6Addresses {
+			instanceNwInterface.Ipv6Addresses = append(instanceNwInterface.Ipv6Addresses,
+				&ec2.InstanceIpv6Address{Ipv6Address: aws.String(aws.StringValue(address.Ipv6Address))})
+		}
+		for _, prefix := range nwInterface.Ipv6Prefixes {
+			instanceNwInterface.Ipv6Prefixes = append(instanceNwInterface.Ipv6Prefixes,
+				&ec2.InstanceIpv6Prefix{Ipv6Prefix: aws.String(aws.StringValue(prefix.Ipv6Prefix))})
+		}
+		described.NetworkInterfaces = append(described.NetworkInterfaces, instanceNwInterface)
+	}
+	return described
+}
+
+// describeSubnet returns the subnet along with its available IPv4 address count
+func (e *EC2) describeSubnet(subnet *subnetState) *ec2.Subnet {
+	described := &ec2.Subnet{
+		SubnetId:                aws.String(subnet.id),
+		VpcId:                   aws.String(subnet.vpcID),
+		AvailabilityZone:        aws.String(subnet.availabilityZone),
+		CidrBlock:               aws.String(subnet.cidrBlock.String()),
+		AvailableIpAddressCount: aws.Int64(int64(availableIPv4Count(subnet))),
+		State:                   aws.String(ec2.SubnetStateAvailable),
+	}
+	if subnet.ipv6CidrBlock != nil {
+		described.Ipv6CidrBlockAssociationSet = []*ec2.SubnetIpv6CidrBlockAssociation{{
+			AssociationId: aws.String("subnet-cidr-assoc-" + subnet.id),
+			Ipv6CidrBlock: aws.String(subnet.ipv6CidrBlock.String()),
+			Ipv6CidrBlockState: &ec2.SubnetCidrBlockState{
+				State: aws.String(ec2.SubnetCidrBlockStateCodeAssociated),
+			},
+		}}
+	}
+	return described
+}
+
+// networkInterfaceFilterValues returns the values of the network interface for the filter name, returns false if
+// the filter is not supported
+func networkInterfaceFilterValues(nwInterface *ec2.NetworkInterface, name string) ([]string, bool) {
+	switch name {
+	case "network-interface-id":
+		return []string{aws.StringValue(nwInterface.NetworkInterfaceId)}, true
+	case "status":
+		return []string{aws.StringValue(nwInterface.Status)}, true
+	case "subnet-id":
+		return []string{aws.StringValue(nwInterface.SubnetId)}, true
+	case "vpc-id":
+		return []string{aws.StringValue(nwInterface.VpcId)}, true
+	case "availability-zone":
+		return []string{aws.StringValue(nwInterface.AvailabilityZone)}, true
+	case "interface-type":
+		return []string{aws.StringValue(nwInterface.InterfaceType)}, true
+	case "description":
+		return []string{aws.StringValue(nwInterface.Description)}, true
+	case "attachment.instance-id":
+		if nwInterface.Attachment == nil {
+			return nil, true
+		}
+		return []string{aws.StringValue(nwInterface.Attachment.InstanceId)}, true
+	case "attachment.status":
+		if nwInterface.Attachment == nil {
+			return nil, true
+		}
+		return []string{aws.StringValue(nwInterface.Attachment.Status)}, true
+	}
+	return tagFilterValues(nwInterface.TagSet, name)
+}
+
+// copyNetworkInterface returns a copy of the network interface, so the callers can't modify the simulator's state
+func copyNetworkInterface(nwInterface *ec2.NetworkInterface) *ec2.NetworkInterface {
+	copied := *nwInterface
+	if nwInterface.Attachment != nil {
+		attachment := *nwInterface.Attachment
+		copied.Attachment = &attachment
+	}
+	copied.Groups = nil
+	for _, group := range nwInterface.Groups {
+		copiedGroup := *group
+		copied.Groups = append(copied.Groups, &copiedGroup)
+	}
+	copied.PrivateIpAddresses = nil
+	for _, address := range nwInterface.PrivateIpAddresses {
+		copiedAddress := *address
+		copied.PrivateIpAddresses = append(copied.PrivateIpAddresses, &copiedAddress)
+	}
+	copied.Ipv6Addresses = nil
+	for _, address := range nwInterface.Ipv6Addresses {
+		copiedAddress := *address
+		copied.Ipv6Addresses = append(copied.Ipv6Addresses, &copiedAddress)
+	}
+	copied.Ipv6Prefixes = nil
+	for _, prefix := range nwInterface.Ipv6Prefixes {
+		copiedPrefix := *prefix
+		copied.Ipv6Prefixes = append(copied.Ipv6Prefixes, &copiedPrefix)
+	}
+	copied.TagSet = mergeTags(nil, nwInterface.TagSet)
+	return &copied
+}
+
+// mergeTags returns the tags with the new tags added, the value of an existing key is overwritten
+func mergeTags(tags []*ec2.Tag, newTags []*ec2.Tag) []*ec2.Tag {
+	var merged []*ec2.Tag
+	for _, tag := range tags {
+		merged = append(merged, &ec2.Tag{Key: aws.String(aws.StringValue(tag.Key)),
+			Value: aws.String(aws.StringValue(tag.Value))})
+	}
+	for _, newTag := range newTags {
+		updated := false
+		for _, tag := range merged {
+			if aws.StringValue(tag.Key) == aws.StringValue(newTag.Key) {
+				tag.Value = aws.String(aws.StringValue(newTag.Value))
+				updated = true
+			}
+		}
+		if !updated {
+			merged = append(merged, &ec2.Tag{Key: aws.String(aws.StringValue(newTag.Key)),
+				Value: aws.String(aws.StringValue(newTag.Value))})
+		}
+	}
+	return merged
+}
+
+// toGroupIdentifiers returns the group identifiers of the security groups
+func toGroupIdentifiers(groups []string) []*ec2.GroupIdentifier {
+	var identifiers []*ec2.GroupIdentifier
+	for _, group := range groups {
+		identifiers = append(identifiers, &ec2.GroupIdentifier{GroupId: aws.String(group)})
+	}
+	return identifiers
+}
+
+// macAddress returns a locally administered MAC address generated from the counter
+func macAddress(counter int) string {
+	return fmt.Sprintf("02:00:%02x:%02x:%02x:%02x", byte(counter>>24), byte(counter>>16), byte(counter>>8),
+		byte(counter))
+}
@@ -0,0 +1,758 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fake
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+var _ api.EC2Wrapper = &EC2{}
+
+// DescribeInstances returns the instances with the given ids, or all the instances if no id is given
+func (e *EC2) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("DescribeInstances"); err != nil {
+		return nil, err
+	}
+	if len(input.Filters) > 0 {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, "filters are not supported", nil)
+	}
+
+	instanceIDs := aws.StringValueSlice(input.InstanceIds)
+	if len(instanceIDs) == 0 {
+		for instanceID := range e.instances {
+			instanceIDs = append(instanceIDs, instanceID)
+		}
+	}
+	sort.Strings(instanceIDs)
+
+	var instances []*ec2.Instance
+	for _, instanceID := range instanceIDs {
+		instance, found := e.instances[instanceID]
+		if !found {
+			return nil, awserr.New(ErrCodeInstanceNotFound,
+				fmt.Sprintf("The instance ID '%s' does not exist", instanceID), nil)
+		}
+		instances = append(instances, e.describeInstance(instance))
+	}
+
+	start, end, nextToken, err := e.paginate(instanceIDs, input.NextToken, input.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	output := &ec2.DescribeInstancesOutput{NextToken: nextToken}
+	if end > start {
+		output.Reservations = []*ec2.Reservation{{Instances: instances[start:end]}}
+	}
+	return output, nil
+}
+
+// CreateNetworkInterface creates a network interface in the subnet with the primary and the secondary IPv4
+// addresses allocated from the subnet
+func (e *EC2) CreateNetworkInterface(input *ec2.CreateNetworkInterfaceInput) (
+	*ec2.CreateNetworkInterfaceOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("CreateNetworkInterface"); err != nil {
+		return nil, err
+	}
+
+	var tags []*ec2.Tag
+	for _, tagSpecification := range input.TagSpecifications {
+		if aws.StringValue(tagSpecification.ResourceType) != ec2.ResourceTypeNetworkInterface {
+			return nil, awserr.New(ErrCodeInvalidParameterValue, "unsupported tag specification resource type", nil)
+		}
+		tags = append(tags, tagSpecification.Tags...)
+	}
+
+	nwInterface, err := e.createNetworkInterface(aws.StringValue(input.SubnetId),
+		aws.StringValueSlice(input.Groups), aws.StringValue(input.Description), tags,
+		int(aws.Int64Value(input.SecondaryPrivateIpAddressCount)), input.InterfaceType)
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.CreateNetworkInterfaceOutput{NetworkInterface: copyNetworkInterface(nwInterface)}, nil
+}
+
+// AttachNetworkInterface attaches the available network interface to the instance at the device index, the
+// attachment is complete when the call returns
+func (e *EC2) AttachNetworkInterface(input *ec2.AttachNetworkInterfaceInput) (
+	*ec2.AttachNetworkInterfaceOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("AttachNetworkInterface"); err != nil {
+		return nil, err
+	}
+	nwInterface, err := e.getNetworkInterface(aws.StringValue(input.NetworkInterfaceId))
+	if err != nil {
+		return nil, err
+	}
+	instance, found := e.instances[aws.StringValue(input.InstanceId)]
+	if !found {
+		return nil, awserr.New(ErrCodeInstanceNotFound,
+			fmt.Sprintf("The instance ID '%s' does not exist", aws.StringValue(input.InstanceId)), nil)
+	}
+	if aws.StringValue(nwInterface.Status) != ec2.NetworkInterfaceStatusAvailable {
+		return nil, awserr.New(ErrCodeNetworkInterfaceInUse,
+			fmt.Sprintf("Interface: [%s] in use.", aws.StringValue(nwInterface.NetworkInterfaceId)), nil)
+	}
+	if aws.StringValue(nwInterface.InterfaceType) == ec2.NetworkInterfaceTypeBranch {
+		return nil, awserr.New(ErrCodeUnsupportedOperation, "branch interfaces can't be attached", nil)
+	}
+	if aws.StringValue(nwInterface.AvailabilityZone) != e.subnets[instance.subnetID].availabilityZone {
+		return nil, awserr.New(ErrCodeInvalidParameterValue,
+			"the network interface and the instance must be in the same availability zone", nil)
+	}
+
+	deviceIndex := aws.Int64Value(input.DeviceIndex)
+	networkCardIndex := aws.Int64Value(input.NetworkCardIndex)
+	limits := vpc.Limits[instance.instanceType]
+	var networkCard *vpc.NetworkCard
+	networkCards := vpc.GetNetworkCards(instance.instanceType)
+	for index := range networkCards {
+		if int64(networkCards[index].Index) == networkCardIndex {
+			networkCard = &networkCards[index]
+		}
+	}
+	if networkCard == nil {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("Network card index %d is not "+
+			"supported by %s", networkCardIndex, instance.instanceType), nil)
+	}
+	attached := e.attachedNetworkInterfaces(instance.id)
+	var attachedToCard []*ec2.NetworkInterface
+	for _, other := range attached {
+		if aws.Int64Value(other.Attachment.NetworkCardIndex) == networkCardIndex {
+			attachedToCard = append(attachedToCard, other)
+		}
+	}
+	if len(attached) >= limits.Interface || len(attachedToCard) >= networkCard.Interface || deviceIndex < 0 ||
+		deviceIndex >= int64(networkCard.Interface) {
+		return nil, awserr.New(ErrCodeAttachmentLimitExceeded, fmt.Sprintf("Interface count %d exceeds the "+
+			"limit for %s", len(attached)+1, instance.instanceType), nil)
+	}
+	for _, other := range attachedToCard {
+		if aws.Int64Value(other.Attachment.DeviceIndex) == deviceIndex {
+			return nil, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("Instance '%s' already has an "+
+				"interface attached at device index '%d' of network card '%d'.", instance.id, deviceIndex,
+				networkCardIndex), nil)
+		}
+	}
+	if len(nwInterface.PrivateIpAddresses) > limits.IPv4PerInterface {
+		return nil, awserr.New(ErrCodePrivateIPAddressLimitExceeded, fmt.Sprintf("Number of private addresses "+
+			"exceeds the limit of %d for %s", limits.IPv4PerInterface, instance.instanceType), nil)
+	}
+
+	e.attach(nwInterface, instance.id, networkCardIndex, deviceIndex)
+	return &ec2.AttachNetworkInterfaceOutput{
+		AttachmentId:     nwInterface.Attachment.AttachmentId,
+		NetworkCardIndex: aws.Int64(networkCardIndex),
+	}, nil
+}
+
+// DetachNetworkInterface detaches the network interface, the network interface is available once the call
+// returns
+func (e *EC2) DetachNetworkInterface(input *ec2.DetachNetworkInterfaceInput) (
+	*ec2.DetachNetworkInterfaceOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("DetachNetworkInterface"); err != nil {
+		return nil, err
+	}
+	for _, state := range e.networkInterfaces {
+		attachment := state.nwInterface.Attachment
+		if attachment != nil && aws.StringValue(attachment.AttachmentId) == aws.StringValue(input.AttachmentId) {
+			if aws.Int64Value(attachment.DeviceIndex) == 0 {
+				return nil, awserr.New(ErrCodeUnsupportedOperation,
+					"The network interface at device index 0 cannot be detached.", nil)
+			}
+			e.detach(state.nwInterface)
+			return &ec2.DetachNetworkInterfaceOutput{}, nil
+		}
+	}
+	return nil, awserr.New(ErrCodeAttachmentNotFound,
+		fmt.Sprintf("The attachment ID '%s' does not exist", aws.StringValue(input.AttachmentId)), nil)
+}
+
+// DeleteNetworkInterface deletes the available network interface and releases its addresses to the subnet, the
+// trunk association of a branch network interface is removed along with it
+func (e *EC2) DeleteNetworkInterface(input *ec2.DeleteNetworkInterfaceInput) (
+	*ec2.DeleteNetworkInterfaceOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("DeleteNetworkInterface"); err != nil {
+		return nil, err
+	}
+	nwInterface, err := e.getNetworkInterface(aws.StringValue(input.NetworkInterfaceId))
+	if err != nil {
+		return nil, err
+	}
+	if nwInterface.Attachment != nil {
+		return nil, awserr.New(ErrCodeNetworkInterfaceInUse, fmt.Sprintf("The network interface '%s' is "+
+			"currently in use.", aws.StringValue(nwInterface.NetworkInterfaceId)), nil)
+	}
+	for _, association := range e.associations {
+		if aws.StringValue(association.TrunkInterfaceId) == aws.StringValue(nwInterface.NetworkInterfaceId) {
+			return nil, awserr.New(ErrCodeNetworkInterfaceInUse, "The trunk network interface has branch "+
+				"interfaces associated", nil)
+		}
+	}
+	e.deleteNetworkInterface(nwInterface)
+	return &ec2.DeleteNetworkInterfaceOutput{}, nil
+}
+
+// AssignPrivateIPAddresses assigns the given or the given count of secondary IPv4 addresses from the subnet
+func (e *EC2) AssignPrivateIPAddresses(input *ec2.AssignPrivateIpAddressesInput) (
+	*ec2.AssignPrivateIpAddressesOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("AssignPrivateIpAddresses"); err != nil {
+		return nil, err
+	}
+	nwInterface, err := e.getNetworkInterface(aws.StringValue(input.NetworkInterfaceId))
+	if err != nil {
+		return nil, err
+	}
+	subnet := e.subnets[aws.StringValue(nwInterface.SubnetId)]
+
+	count := int(aws.Int64Value(input.SecondaryPrivateIpAddressCount))
+	if len(input.PrivateIpAddresses) > 0 {
+		count = len(input.PrivateIpAddresses)
+	}
+	if count <= 0 {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, "the address count must be positive", nil)
+	}
+	if limit, limited := e.ipv4Limit(nwInterface); limited && len(nwInterface.PrivateIpAddresses)+count > limit {
+		return nil, awserr.New(ErrCodePrivateIPAddressLimitExceeded, fmt.Sprintf("Number of private addresses "+
+			"will exceed limit of %d for the network interface", limit), nil)
+	}
+
+	var addresses []string
+	if len(input.PrivateIpAddresses) > 0 {
+		addresses, err = e.allocateSpecificIPv4(subnet, aws.StringValueSlice(input.PrivateIpAddresses))
+	} else {
+		addresses, err = e.allocateIPv4(subnet, count)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ec2.AssignPrivateIpAddressesOutput{NetworkInterfaceId: nwInterface.NetworkInterfaceId}
+	for _, address := range addresses {
+		nwInterface.PrivateIpAddresses = append(nwInterface.PrivateIpAddresses,
+			&ec2.NetworkInterfacePrivateIpAddress{Primary: aws.Bool(false), PrivateIpAddress: aws.String(address)})
+		output.AssignedPrivateIpAddresses = append(output.AssignedPrivateIpAddresses,
+			&ec2.AssignedPrivateIpAddress{PrivateIpAddress: aws.String(address)})
+	}
+	return output, nil
+}
+
+// UnassignPrivateIPAddresses releases the secondary IPv4 addresses of the network interface to the subnet
+func (e *EC2) UnassignPrivateIPAddresses(input *ec2.UnassignPrivateIpAddressesInput) (
+	*ec2.UnassignPrivateIpAddressesOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("UnassignPrivateIpAddresses"); err != nil {
+		return nil, err
+	}
+	nwInterface, err := e.getNetworkInterface(aws.StringValue(input.NetworkInterfaceId))
+	if err != nil {
+		return nil, err
+	}
+
+	toUnassign := map[string]bool{}
+	for _, address := range aws.StringValueSlice(input.PrivateIpAddresses) {
+		toUnassign[address] = true
+	}
+	var remaining []*ec2.NetworkInterfacePrivateIpAddress
+	for _, address := range nwInterface.PrivateIpAddresses {
+		if !toUnassign[aws.StringValue(address.PrivateIpAddress)] {
+			remaining = append(remaining, address)
+			continue
+		}
+		if aws.BoolValue(address.Primary) {
+			return nil, awserr.New(ErrCodeInvalidParameterValue, "the primary address can't be unassigned", nil)
+		}
+		delete(toUnassign, aws.StringValue(address.PrivateIpAddress))
+	}
+	for address := range toUnassign {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("Some of the specified addresses "+
+			"are not assigned to interface %s: %s", aws.StringValue(nwInterface.NetworkInterfaceId), address), nil)
+	}
+
+	subnet := e.subnets[aws.StringValue(nwInterface.SubnetId)]
+	for _, address := range aws.StringValueSlice(input.PrivateIpAddresses) {
+		delete(subnet.usedIPs, address)
+	}
+	nwInterface.PrivateIpAddresses = remaining
+	return &ec2.UnassignPrivateIpAddressesOutput{}, nil
+}
+
+// AssignIPv6Addresses assigns the given count of IPv6 addresses or /80 IPv6 prefixes from the subnet
+func (e *EC2) AssignIPv6Addresses(input *ec2.AssignIpv6AddressesInput) (*ec2.AssignIpv6AddressesOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("AssignIpv6Addresses"); err != nil {
+		return nil, err
+	}
+	nwInterface, err := e.getNetworkInterface(aws.StringValue(input.NetworkInterfaceId))
+	if err != nil {
+		return nil, err
+	}
+	subnet := e.subnets[aws.StringValue(nwInterface.SubnetId)]
+	if subnet.ipv6CidrBlock == nil {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("The subnet %s has no IPv6 cidr "+
+			"block", subnet.id), nil)
+	}
+
+	usePrefixes := input.Ipv6PrefixCount != nil
+	count := int(aws.Int64Value(input.Ipv6AddressCount))
+	if usePrefixes {
+		count = int(aws.Int64Value(input.Ipv6PrefixCount))
+	}
+	if count <= 0 || (usePrefixes && input.Ipv6AddressCount != nil) {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, "either a positive address or prefix count "+
+			"must be set", nil)
+	}
+	// The IPv6 addresses and prefixes are limited to the IPv4 addresses per interface
+	assigned := len(nwInterface.Ipv6Addresses) + len(nwInterface.Ipv6Prefixes)
+	if limit, limited := e.ipv4Limit(nwInterface); limited && assigned+count > limit {
+		return nil, awserr.New(ErrCodePrivateIPAddressLimitExceeded, fmt.Sprintf("Number of IPv6 addresses "+
+			"will exceed limit of %d for the network interface", limit), nil)
+	}
+
+	addresses, err := e.allocateIPv6(subnet, count, usePrefixes)
+	if err != nil {
+		return nil, err
+	}
+	output := &ec2.AssignIpv6AddressesOutput{NetworkInterfaceId: nwInterface.NetworkInterfaceId}
+	for _, address := range addresses {
+		if usePrefixes {
+			nwInterface.Ipv6Prefixes = append(nwInterface.Ipv6Prefixes,
+				&ec2.Ipv6PrefixSpecification{Ipv6Prefix: aws.String(address)})
+			output.AssignedIpv6Prefixes = append(output.AssignedIpv6Prefixes, aws.String(address))
+		} else {
+			nwInterface.Ipv6Addresses = append(nwInterface.Ipv6Addresses,
+				&ec2.NetworkInterfaceIpv6Address{Ipv6Address: aws.String(address)})
+			output.AssignedIpv6Addresses = append(output.AssignedIpv6Addresses, aws.String(address))
+		}
+	}
+	return output, nil
+}
+
+// UnassignIPv6Addresses releases the IPv6 addresses and prefixes of the network interface to the subnet
+func (e *EC2) UnassignIPv6Addresses(input *ec2.UnassignIpv6AddressesInput) (
+	*ec2.UnassignIpv6AddressesOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("UnassignIpv6Addresses"); err != nil {
+		return nil, err
+	}
+	nwInterface, err := e.getNetworkInterface(aws.StringValue(input.NetworkInterfaceId))
+	if err != nil {
+		return nil, err
+	}
+
+	toUnassign := map[string]bool{}
+	for _, address := range append(aws.StringValueSlice(input.Ipv6Addresses),
+		aws.StringValueSlice(input.Ipv6Prefixes)...) {
+		toUnassign[address] = true
+	}
+	var remainingAddresses []*ec2.NetworkInterfaceIpv6Address
+	for _, address := range nwInterface.Ipv6Addresses {
+		if toUnassign[aws.StringValue(address.Ipv6Address)] {
+			delete(toUnassign, aws.StringValue(address.Ipv6Address))
+		} else {
+			remainingAddresses = append(remainingAddresses, address)
+		}
+	}
+	var remainingPrefixes []*ec2.Ipv6PrefixSpecification
+	for _, prefix := range nwInterface.Ipv6Prefixes {
+		if toUnassign[aws.StringValue(prefix.Ipv6Prefix)] {
+			delete(toUnassign, aws.StringValue(prefix.Ipv6Prefix))
+		} else {
+			remainingPrefixes = append(remainingPrefixes, prefix)
+		}
+	}
+	for address := range toUnassign {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("Some of the specified addresses "+
+			"are not assigned to interface %s: %s", aws.StringValue(nwInterface.NetworkInterfaceId), address), nil)
+	}
+
+	subnet := e.subnets[aws.StringValue(nwInterface.SubnetId)]
+	for _, address := range append(aws.StringValueSlice(input.Ipv6Addresses),
+		aws.StringValueSlice(input.Ipv6Prefixes)...) {
+		delete(subnet.usedIPv6, address)
+	}
+	nwInterface.Ipv6Addresses = remainingAddresses
+	nwInterface.Ipv6Prefixes = remainingPrefixes
+	return &ec2.UnassignIpv6AddressesOutput{
+		NetworkInterfaceId:      nwInterface.NetworkInterfaceId,
+		UnassignedIpv6Addresses: input.Ipv6Addresses,
+		UnassignedIpv6Prefixes:  input.Ipv6Prefixes,
+	}, nil
+}
+
+// DescribeNetworkInterfaces returns the network interfaces with the given ids or the network interfaces that match
+// the filters, one page at a time
+func (e *EC2) DescribeNetworkInterfaces(input *ec2.DescribeNetworkInterfacesInput) (
+	*ec2.DescribeNetworkInterfacesOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("DescribeNetworkInterfaces"); err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	if len(input.NetworkInterfaceIds) > 0 {
+		for _, id := range aws.StringValueSlice(input.NetworkInterfaceIds) {
+			if _, err := e.getNetworkInterface(id); err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+	} else {
+		for id := range e.networkInterfaces {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var matchedIDs []string
+	var nwInterfaces []*ec2.NetworkInterface
+	for _, id := range ids {
+		nwInterface := e.networkInterfaces[id].nwInterface
+		matched, err := matchesFilters(input.Filters, func(name string) ([]string, bool) {
+			return networkInterfaceFilterValues(nwInterface, name)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matchedIDs = append(matchedIDs, id)
+			nwInterfaces = append(nwInterfaces, copyNetworkInterface(nwInterface))
+		}
+	}
+
+	start, end, nextToken, err := e.paginate(matchedIDs, input.NextToken, input.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.DescribeNetworkInterfacesOutput{
+		NetworkInterfaces: nwInterfaces[start:end],
+		NextToken:         nextToken,
+	}, nil
+}
+
+// CreateTags adds or overwrites the tags of the network interfaces
+func (e *EC2) CreateTags(input *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("CreateTags"); err != nil {
+		return nil, err
+	}
+	var nwInterfaces []*ec2.NetworkInterface
+	for _, id := range aws.StringValueSlice(input.Resources) {
+		nwInterface, err := e.getNetworkInterface(id)
+		if err != nil {
+			return nil, err
+		}
+		nwInterfaces = append(nwInterfaces, nwInterface)
+	}
+	for _, nwInterface := range nwInterfaces {
+		nwInterface.TagSet = mergeTags(nwInterface.TagSet, input.Tags)
+	}
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+// DescribeSubnets returns the subnets with the given ids or the subnets that match the filters along with their
+// available IPv4 address count
+func (e *EC2) DescribeSubnets(input *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("DescribeSubnets"); err != nil {
+		return nil, err
+	}
+
+	ids := aws.StringValueSlice(input.SubnetIds)
+	if len(ids) == 0 {
+		for id := range e.subnets {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var matchedIDs []string
+	var subnets []*ec2.Subnet
+	for _, id := range ids {
+		subnet, found := e.subnets[id]
+		if !found {
+			return nil, awserr.New(ErrCodeSubnetNotFound, fmt.Sprintf("The subnet ID '%s' does not exist", id), nil)
+		}
+		matched, err := matchesFilters(input.Filters, func(name string) ([]string, bool) {
+			switch name {
+			case "subnet-id":
+				return []string{subnet.id}, true
+			case "vpc-id":
+				return []string{subnet.vpcID}, true
+			case "availability-zone":
+				return []string{subnet.availabilityZone}, true
+			}
+			return nil, false
+		})
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matchedIDs = append(matchedIDs, id)
+			subnets = append(subnets, e.describeSubnet(subnet))
+		}
+	}
+
+	start, end, nextToken, err := e.paginate(matchedIDs, input.NextToken, input.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.DescribeSubnetsOutput{Subnets: subnets[start:end], NextToken: nextToken}, nil
+}
+
+// DescribeInstanceTypes returns the network limits and the network cards of the given instance types from vpc.Limits
+func (e *EC2) DescribeInstanceTypes(input *ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("DescribeInstanceTypes"); err != nil {
+		return nil, err
+	}
+
+	var instanceTypes []*ec2.InstanceTypeInfo
+	for _, instanceType := range aws.StringValueSlice(input.InstanceTypes) {
+		limits, found := vpc.Limits[instanceType]
+		if !found {
+			return nil, awserr.New(ErrCodeInvalidInstanceType,
+				fmt.Sprintf("The following supplied instance types do not exist: [%s]", instanceType), nil)
+		}
+		var networkCards []*ec2.NetworkCardInfo
+		for _, card := range vpc.GetNetworkCards(instanceType) {
+			networkCards = append(networkCards, &ec2.NetworkCardInfo{
+				NetworkCardIndex:         aws.Int64(int64(card.Index)),
+				MaximumNetworkInterfaces: aws.Int64(int64(card.Interface)),
+			})
+		}
+		instanceTypes = append(instanceTypes, &ec2.InstanceTypeInfo{
+			InstanceType: aws.String(instanceType),
+			NetworkInfo: &ec2.NetworkInfo{
+				DefaultNetworkCardIndex:   aws.Int64(0),
+				MaximumNetworkCards:       aws.Int64(int64(len(networkCards))),
+				MaximumNetworkInterfaces:  aws.Int64(int64(limits.Interface)),
+				Ipv4AddressesPerInterface: aws.Int64(int64(limits.IPv4PerInterface)),
+				NetworkCards:              networkCards,
+			},
+		})
+	}
+	return &ec2.DescribeInstanceTypesOutput{InstanceTypes: instanceTypes}, nil
+}
+
+// AssociateTrunkInterface associates the available branch network interface with the trunk network interface
+// attached to an instance, the number of branches is limited by the instance type
+func (e *EC2) AssociateTrunkInterface(input *ec2.AssociateTrunkInterfaceInput) (
+	*ec2.AssociateTrunkInterfaceOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("AssociateTrunkInterface"); err != nil {
+		return nil, err
+	}
+	trunk, err := e.getNetworkInterface(aws.StringValue(input.TrunkInterfaceId))
+	if err != nil {
+		return nil, err
+	}
+	branch, err := e.getNetworkInterface(aws.StringValue(input.BranchInterfaceId))
+	if err != nil {
+		return nil, err
+	}
+	if aws.StringValue(trunk.InterfaceType) != ec2.NetworkInterfaceTypeTrunk || trunk.Attachment == nil {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("The interface %s is not an attached "+
+			"trunk interface", aws.StringValue(trunk.NetworkInterfaceId)), nil)
+	}
+	if aws.StringValue(branch.Status) != ec2.NetworkInterfaceStatusAvailable {
+		return nil, awserr.New(ErrCodeNetworkInterfaceInUse,
+			fmt.Sprintf("Interface: [%s] in use.", aws.StringValue(branch.NetworkInterfaceId)), nil)
+	}
+
+	vlanID := aws.Int64Value(input.VlanId)
+	branchCount := 0
+	for _, association := range e.associations {
+		if aws.StringValue(association.TrunkInterfaceId) != aws.StringValue(trunk.NetworkInterfaceId) {
+			continue
+		}
+		branchCount++
+		if aws.Int64Value(association.VlanId) == vlanID {
+			return nil, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("The vlan id %d is already "+
+				"associated with the trunk", vlanID), nil)
+		}
+	}
+	instanceType := e.instances[aws.StringValue(trunk.Attachment.InstanceId)].instanceType
+	if limit := vpc.Limits[instanceType].BranchInterface; branchCount >= limit {
+		return nil, awserr.New(ErrCodeTrunkAssociationLimitExceeded, fmt.Sprintf("The trunk interface has "+
+			"reached the limit of %d branch interfaces", limit), nil)
+	}
+
+	association := &ec2.TrunkInterfaceAssociation{
+		AssociationId:     aws.String(e.nextID("trunk-assoc")),
+		BranchInterfaceId: branch.NetworkInterfaceId,
+		TrunkInterfaceId:  trunk.NetworkInterfaceId,
+		InterfaceProtocol: aws.String(ec2.InterfaceProtocolTypeVlan),
+		VlanId:            aws.Int64(vlanID),
+	}
+	e.associations[aws.StringValue(association.AssociationId)] = association
+	branch.InterfaceType = aws.String(ec2.NetworkInterfaceTypeBranch)
+	branch.Status = aws.String(ec2.NetworkInterfaceStatusInUse)
+
+	copied := *association
+	return &ec2.AssociateTrunkInterfaceOutput{InterfaceAssociation: &copied}, nil
+}
+
+// DescribeTrunkInterfaceAssociations returns the trunk associations with the given ids or the associations that
+// match the filters, one page at a time
+func (e *EC2) DescribeTrunkInterfaceAssociations(input *ec2.DescribeTrunkInterfaceAssociationsInput) (
+	*ec2.DescribeTrunkInterfaceAssociationsOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("DescribeTrunkInterfaceAssociations"); err != nil {
+		return nil, err
+	}
+
+	ids := aws.StringValueSlice(input.AssociationIds)
+	if len(ids) == 0 {
+		for id := range e.associations {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var matchedIDs []string
+	var associations []*ec2.TrunkInterfaceAssociation
+	for _, id := range ids {
+		association, found := e.associations[id]
+		if !found {
+			return nil, awserr.New(ErrCodeAssociationNotFound,
+				fmt.Sprintf("The association ID '%s' does not exist", id), nil)
+		}
+		matched, err := matchesFilters(input.Filters, func(name string) ([]string, bool) {
+			switch name {
+			case "trunk-interface-association.trunk-interface-id":
+				return []string{aws.StringValue(association.TrunkInterfaceId)}, true
+			case "trunk-interface-association.branch-interface-id":
+				return []string{aws.StringValue(association.BranchInterfaceId)}, true
+			}
+			return nil, false
+		})
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			copied := *association
+			matchedIDs = append(matchedIDs, id)
+			associations = append(associations, &copied)
+		}
+	}
+
+	start, end, nextToken, err := e.paginate(matchedIDs, input.NextToken, input.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.DescribeTrunkInterfaceAssociationsOutput{
+		InterfaceAssociations: associations[start:end],
+		NextToken:             nextToken,
+	}, nil
+}
+
+// ModifyNetworkInterfaceAttribute modifies the delete on termination of the attachment, the security groups or
+// the description of the network interface
+func (e *EC2) ModifyNetworkInterfaceAttribute(input *ec2.ModifyNetworkInterfaceAttributeInput) (
+	*ec2.ModifyNetworkInterfaceAttributeOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("ModifyNetworkInterfaceAttribute"); err != nil {
+		return nil, err
+	}
+	nwInterface, err := e.getNetworkInterface(aws.StringValue(input.NetworkInterfaceId))
+	if err != nil {
+		return nil, err
+	}
+	if input.Attachment != nil {
+		if nwInterface.Attachment == nil || aws.StringValue(nwInterface.Attachment.AttachmentId) !=
+			aws.StringValue(input.Attachment.AttachmentId) {
+			return nil, awserr.New(ErrCodeAttachmentNotFound, fmt.Sprintf("The attachment ID '%s' does not "+
+				"exist", aws.StringValue(input.Attachment.AttachmentId)), nil)
+		}
+		nwInterface.Attachment.DeleteOnTermination = aws.Bool(aws.BoolValue(input.Attachment.DeleteOnTermination))
+	}
+	if len(input.Groups) > 0 {
+		nwInterface.Groups = toGroupIdentifiers(aws.StringValueSlice(input.Groups))
+	}
+	if input.Description != nil {
+		nwInterface.Description = input.Description.Value
+	}
+	return &ec2.ModifyNetworkInterfaceAttributeOutput{}, nil
+}
+
+// CreateNetworkInterfacePermission grants the permission on the network interface
+func (e *EC2) CreateNetworkInterfacePermission(input *ec2.CreateNetworkInterfacePermissionInput) (
+	*ec2.CreateNetworkInterfacePermissionOutput, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := e.call("CreateNetworkInterfacePermission"); err != nil {
+		return nil, err
+	}
+	if _, err := e.getNetworkInterface(aws.StringValue(input.NetworkInterfaceId)); err != nil {
+		return nil, err
+	}
+	permission := &ec2.NetworkInterfacePermission{
+		NetworkInterfacePermissionId: aws.String(e.nextID("eni-perm")),
+		NetworkInterfaceId:           input.NetworkInterfaceId,
+		Permission:                   input.Permission,
+		AwsAccountId:                 input.AwsAccountId,
+		AwsService:                   input.AwsService,
+	}
+	state := e.networkInterfaces[aws.StringValue(input.NetworkInterfaceId)]
+	state.permissions = append(state.permissions, permission)
+
+	copied := *permission
+	return &ec2.CreateNetworkInterfacePermissionOutput{InterfacePermission: &copied}, nil
+}
@@ -0,0 +1,145 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// AllOperations matches all the EC2 operations in a rule
+const AllOperations = "*"
+
+// Operations is the set of EC2 operations made by the EC2Wrapper that the faults can be injected into
+var Operations = map[string]struct{}{
+	"DescribeInstances":                  {},
+	"CreateNetworkInterface":             {},
+	"AttachNetworkInterface":             {},
+	"DetachNetworkInterface":             {},
+	"DeleteNetworkInterface":             {},
+	"AssignPrivateIpAddresses":           {},
+	"UnassignPrivateIpAddresses":         {},
+	"AssignIpv6Addresses":                {},
+	"UnassignIpv6Addresses":              {},
+	"DescribeNetworkInterfaces":          {},
+	"CreateTags":                         {},
+	"DescribeSubnets":                    {},
+	"DescribeInstanceTypes":              {},
+	"AssociateTrunkInterface":            {},
+	"DescribeTrunkInterfaceAssociations": {},
+	"ModifyNetworkInterfaceAttribute":    {},
+	"CreateNetworkInterfacePermission":   {},
+}
+
+// Config is the configuration of the faults injected into the EC2 API calls
+type Config struct {
+	// Seed of the random source used to evaluate the probability of the rules, the current time is used if 0
+	Seed int64 `json:"seed"`
+	// VisibilityDelay is the time the created network interfaces and the assigned IPv4 addresses are missing from
+	// the DescribeNetworkInterfaces calls, simulating the eventual consistency of EC2
+	VisibilityDelay Duration `json:"visibilityDelay"`
+	// Rules are evaluated in order on each call, all the matching rules add their latency and the first matching
+	// rule with an error code fails the call
+	Rules []Rule `json:"rules"`
+}
+
+// Rule injects a fault into the calls of an operation either randomly with a probability or on the scripted calls
+type Rule struct {
+	// Operation is the EC2 operation, for instance DescribeNetworkInterfaces, or * for all the operations
+	Operation string `json:"operation"`
+	// Probability of the rule applying to a call, between 0 and 1. Ignored if the calls are scripted
+	Probability float64 `json:"probability"`
+	// Calls are the 1-based numbers of the calls of the operation the rule applies to
+	Calls []int `json:"calls"`
+	// Latency is added to the call before it is made
+	Latency Duration `json:"latency"`
+	// ErrorCode is the code of the error returned from the call, for instance RequestLimitExceeded. No error is
+	// returned if empty. The error is not retried by the SDK and doesn't lower the rate of the client's rate limiter
+	ErrorCode string `json:"errorCode"`
+	// AfterCall makes the call to EC2 before returning the error, simulating a partial failure where the change is
+	// applied but the caller doesn't know about it
+	AfterCall bool `json:"afterCall"`
+}
+
+// Duration is a time.Duration represented as a string like 500ms in JSON
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses the duration from a string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string like 500ms: %v", err)
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
+
+// MarshalJSON returns the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// LoadConfig reads and validates the fault injection configuration from the JSON file
+func LoadConfig(path string) (Config, error) {
+	config := Config{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse fault injection config %s: %v", path, err)
+	}
+	return config, config.Validate()
+}
+
+// Validate returns an error if any of the rule is invalid
+func (c Config) Validate() error {
+	if c.VisibilityDelay.Duration < 0 {
+		return fmt.Errorf("invalid visibility delay %s, must not be negative", c.VisibilityDelay)
+	}
+	for i, rule := range c.Rules {
+		if _, found := Operations[rule.Operation]; !found && rule.Operation != AllOperations {
+			return fmt.Errorf("invalid rule %d: unknown operation %q", i, rule.Operation)
+		}
+		if rule.Probability < 0 || rule.Probability > 1 {
+			return fmt.Errorf("invalid rule %d: probability %v must be between 0 and 1", i, rule.Probability)
+		}
+		if rule.Probability == 0 && len(rule.Calls) == 0 {
+			return fmt.Errorf("invalid rule %d: either the probability or the calls must be set", i)
+		}
+		for _, call := range rule.Calls {
+			if call <= 0 {
+				return fmt.Errorf("invalid rule %d: call number %d must be positive", i, call)
+			}
+		}
+		if rule.Latency.Duration < 0 {
+			return fmt.Errorf("invalid rule %d: latency %s must not be negative", i, rule.Latency)
+		}
+		if rule.ErrorCode == "" && rule.Latency.Duration == 0 {
+			return fmt.Errorf("invalid rule %d: either the latency or the error code must be set", i)
+		}
+		if rule.AfterCall && rule.ErrorCode == "" {
+			return fmt.Errorf("invalid rule %d: after call requires an error code", i)
+		}
+	}
+	return nil
+}
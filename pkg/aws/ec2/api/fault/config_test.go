@@ -0,0 +1,73 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fault
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadConfig tests the rules and the durations are parsed from the JSON file
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fault")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{
+		"seed": 10,
+		"visibilityDelay": "3s",
+		"rules": [
+			{"operation": "*", "probability": 0.1, "latency": "200ms"},
+			{"operation": "AssignPrivateIpAddresses", "calls": [1, 4], "errorCode": "RequestLimitExceeded"}
+		]
+	}`), 0600))
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, Config{
+		Seed:            10,
+		VisibilityDelay: Duration{time.Second * 3},
+		Rules: []Rule{
+			{Operation: AllOperations, Probability: 0.1, Latency: Duration{time.Millisecond * 200}},
+			{Operation: "AssignPrivateIpAddresses", Calls: []int{1, 4}, ErrorCode: "RequestLimitExceeded"},
+		},
+	}, config)
+}
+
+// TestConfig_Validate tests the invalid rules are rejected
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{name: "unknown operation", rule: Rule{Operation: "RunInstances", Probability: 1, ErrorCode: "Error"}},
+		{name: "probability above 1", rule: Rule{Operation: AllOperations, Probability: 2, ErrorCode: "Error"}},
+		{name: "no probability or calls", rule: Rule{Operation: AllOperations, ErrorCode: "Error"}},
+		{name: "invalid call number", rule: Rule{Operation: AllOperations, Calls: []int{0}, ErrorCode: "Error"}},
+		{name: "no fault", rule: Rule{Operation: AllOperations, Probability: 1}},
+		{name: "after call without error", rule: Rule{Operation: AllOperations, Probability: 1,
+			Latency: Duration{time.Second}, AfterCall: true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Error(t, Config{Rules: []Rule{test.rule}}.Validate())
+		})
+	}
+}
@@ -0,0 +1,480 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package fault provides an EC2Wrapper decorator injecting latency, errors, partial failures and eventual
+// consistency delays into the EC2 API calls, so the retry, resync and clean up paths of the controller can be
+// validated without waiting for EC2 to misbehave.
+//
+// The faults are injected above the SDK clients of the wrapped EC2Wrapper, so an injected error is returned to the
+// caller as if the SDK had exhausted its retries. Injected throttling errors are not retried by the SDK and are not
+// seen by the adaptive rate limiter of the clients, which only adapts its rate to the throttling of EC2 itself.
+package fault
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/go-logr/logr"
+)
+
+// ErrCodeNetworkInterfaceNotFound is returned when a network interface that is not visible yet is described
+const ErrCodeNetworkInterfaceNotFound = "InvalidNetworkInterfaceID.NotFound"
+
+// Injector is an EC2Wrapper injecting the faults of its configuration into the calls to the wrapped EC2Wrapper
+type Injector struct {
+	ec2Wrapper api.EC2Wrapper
+	config     Config
+	log        logr.Logger
+	// now and sleep are replaced in the unit tests
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	// injectorState is shared with the Injectors returned by WithPriority
+	*injectorState
+}
+
+// injectorState is the state of the faults injected into the calls
+type injectorState struct {
+	lock sync.Mutex // guards the following
+	rand *rand.Rand
+	// calls is the number of calls made to each operation
+	calls map[string]int
+	// hiddenENIs is the time each network interface becomes visible in the describe calls
+	hiddenENIs map[string]time.Time
+	// hiddenIPs is the time each IPv4 address of a network interface becomes visible in the describe calls
+	hiddenIPs map[string]map[string]time.Time
+}
+
+// fault is the outcome of the rules evaluated for a single call
+type fault struct {
+	latency   time.Duration
+	err       error
+	afterCall bool
+}
+
+var _ api.EC2Wrapper = &Injector{}
+var _ api.Prioritizer = &Injector{}
+
+// NewInjector returns the EC2Wrapper injecting the faults of the validated configuration into the calls to the
+// given EC2Wrapper
+func NewInjector(ec2Wrapper api.EC2Wrapper, config Config, log logr.Logger) (*Injector, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Injector{
+		ec2Wrapper: ec2Wrapper,
+		config:     config,
+		log:        log,
+		now:        time.Now,
+		sleep:      time.Sleep,
+		injectorState: &injectorState{
+			rand:       rand.New(rand.NewSource(seed)),
+			calls:      map[string]int{},
+			hiddenENIs: map[string]time.Time{},
+			hiddenIPs:  map[string]map[string]time.Time{},
+		},
+	}, nil
+}
+
+// WithPriority returns the Injector injecting the faults into the calls to the wrapped EC2Wrapper made with the
+// given priority. The calls and the hidden resources are shared with this Injector
+func (i *Injector) WithPriority(priority api.Priority) api.EC2Wrapper {
+	prioritized := *i
+	prioritized.ec2Wrapper = api.WithPriority(i.ec2Wrapper, priority)
+	return &prioritized
+}
+
+// inject evaluates the rules for the call to the operation and waits for the latency of the matching rules
+func (i *Injector) inject(operation string) fault {
+	i.lock.Lock()
+	i.calls[operation]++
+	call := i.calls[operation]
+
+	f := fault{}
+	for _, rule := range i.config.Rules {
+		if rule.Operation != operation && rule.Operation != AllOperations {
+			continue
+		}
+		if !i.applies(rule, call) {
+			continue
+		}
+		f.latency += rule.Latency.Duration
+		if f.err == nil && rule.ErrorCode != "" {
+			f.err = awserr.New(rule.ErrorCode, fmt.Sprintf("injected fault on call %d of %s", call, operation), nil)
+			f.afterCall = rule.AfterCall
+		}
+	}
+	i.lock.Unlock()
+
+	if f.latency > 0 || f.err != nil {
+		i.log.V(1).Info("injecting fault", "operation", operation, "call", call, "latency", f.latency,
+			"error", f.err, "after call", f.afterCall)
+	}
+	if f.latency > 0 {
+		i.sleep(f.latency)
+	}
+	return f
+}
+
+// applies returns true if the rule applies to the nth call of its operation, must be called with the lock held
+func (i *Injector) applies(rule Rule, call int) bool {
+	if len(rule.Calls) > 0 {
+		for _, scripted := range rule.Calls {
+			if scripted == call {
+				return true
+			}
+		}
+		return false
+	}
+	return i.rand.Float64() < rule.Probability
+}
+
+// before returns the error if the call must fail without being made
+func (f fault) before() error {
+	if f.err != nil && !f.afterCall {
+		return f.err
+	}
+	return nil
+}
+
+// after returns the error of the call or the injected error if the call must fail after being made
+func (f fault) after(err error) error {
+	if err != nil {
+		return err
+	}
+	return f.err
+}
+
+// hide hides the network interface or its IPv4 addresses from the describe calls for the visibility delay
+func (i *Injector) hide(nwInterfaceID string, addresses []string) {
+	if i.config.VisibilityDelay.Duration == 0 {
+		return
+	}
+	visibleAt := i.now().Add(i.config.VisibilityDelay.Duration)
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if len(addresses) == 0 {
+		i.hiddenENIs[nwInterfaceID] = visibleAt
+		return
+	}
+	if _, found := i.hiddenIPs[nwInterfaceID]; !found {
+		i.hiddenIPs[nwInterfaceID] = map[string]time.Time{}
+	}
+	for _, address := range addresses {
+		i.hiddenIPs[nwInterfaceID][address] = visibleAt
+	}
+}
+
+// isHidden returns true if the network interface is not visible yet, must be called with the lock held
+func (i *Injector) isHidden(nwInterfaceID string, now time.Time) bool {
+	visibleAt, found := i.hiddenENIs[nwInterfaceID]
+	if found && !now.Before(visibleAt) {
+		delete(i.hiddenENIs, nwInterfaceID)
+		return false
+	}
+	return found
+}
+
+// visibleAddresses returns the IPv4 addresses of the network interface that are visible, must be called with the
+// lock held
+func (i *Injector) visibleAddresses(nwInterface *ec2.NetworkInterface,
+	now time.Time) []*ec2.NetworkInterfacePrivateIpAddress {
+	hidden := i.hiddenIPs[aws.StringValue(nwInterface.NetworkInterfaceId)]
+	if len(hidden) == 0 {
+		return nwInterface.PrivateIpAddresses
+	}
+	var visible []*ec2.NetworkInterfacePrivateIpAddress
+	for _, address := range nwInterface.PrivateIpAddresses {
+		visibleAt, found := hidden[aws.StringValue(address.PrivateIpAddress)]
+		if found && now.Before(visibleAt) {
+			continue
+		}
+		delete(hidden, aws.StringValue(address.PrivateIpAddress))
+		visible = append(visible, address)
+	}
+	if len(hidden) == 0 {
+		delete(i.hiddenIPs, aws.StringValue(nwInterface.NetworkInterfaceId))
+	}
+	return visible
+}
+
+// DescribeNetworkInterfaces returns the network interfaces without the network interfaces and the IPv4 addresses
+// that are not visible yet. Describing a network interface that is not visible yet by its id fails
+func (i *Injector) DescribeNetworkInterfaces(input *ec2.DescribeNetworkInterfacesInput) (
+	*ec2.DescribeNetworkInterfacesOutput, error) {
+	f := i.inject("DescribeNetworkInterfaces")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.DescribeNetworkInterfaces(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if len(i.hiddenENIs) == 0 && len(i.hiddenIPs) == 0 {
+		return output, nil
+	}
+	now := i.now()
+	for _, nwInterfaceID := range input.NetworkInterfaceIds {
+		if i.isHidden(aws.StringValue(nwInterfaceID), now) {
+			return nil, awserr.New(ErrCodeNetworkInterfaceNotFound, fmt.Sprintf("The networkInterface ID '%s' "+
+				"does not exist", aws.StringValue(nwInterfaceID)), nil)
+		}
+	}
+	filtered := *output
+	filtered.NetworkInterfaces = nil
+	for _, nwInterface := range output.NetworkInterfaces {
+		if i.isHidden(aws.StringValue(nwInterface.NetworkInterfaceId), now) {
+			continue
+		}
+		visible := *nwInterface
+		visible.PrivateIpAddresses = i.visibleAddresses(nwInterface, now)
+		filtered.NetworkInterfaces = append(filtered.NetworkInterfaces, &visible)
+	}
+	return &filtered, nil
+}
+
+// CreateNetworkInterface creates the network interface and hides it from the describe calls for the visibility delay
+func (i *Injector) CreateNetworkInterface(input *ec2.CreateNetworkInterfaceInput) (
+	*ec2.CreateNetworkInterfaceOutput, error) {
+	f := i.inject("CreateNetworkInterface")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.CreateNetworkInterface(input)
+	if err == nil && output != nil && output.NetworkInterface != nil {
+		i.hide(aws.StringValue(output.NetworkInterface.NetworkInterfaceId), nil)
+	}
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// AssignPrivateIPAddresses assigns the IPv4 addresses and hides them from the describe calls for the visibility
+// delay
+func (i *Injector) AssignPrivateIPAddresses(input *ec2.AssignPrivateIpAddressesInput) (
+	*ec2.AssignPrivateIpAddressesOutput, error) {
+	f := i.inject("AssignPrivateIpAddresses")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.AssignPrivateIPAddresses(input)
+	if err == nil && output != nil {
+		var addresses []string
+		for _, address := range output.AssignedPrivateIpAddresses {
+			addresses = append(addresses, aws.StringValue(address.PrivateIpAddress))
+		}
+		if len(addresses) > 0 {
+			i.hide(aws.StringValue(input.NetworkInterfaceId), addresses)
+		}
+	}
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	f := i.inject("DescribeInstances")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.DescribeInstances(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) AttachNetworkInterface(input *ec2.AttachNetworkInterfaceInput) (
+	*ec2.AttachNetworkInterfaceOutput, error) {
+	f := i.inject("AttachNetworkInterface")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.AttachNetworkInterface(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) DetachNetworkInterface(input *ec2.DetachNetworkInterfaceInput) (
+	*ec2.DetachNetworkInterfaceOutput, error) {
+	f := i.inject("DetachNetworkInterface")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.DetachNetworkInterface(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) DeleteNetworkInterface(input *ec2.DeleteNetworkInterfaceInput) (
+	*ec2.DeleteNetworkInterfaceOutput, error) {
+	f := i.inject("DeleteNetworkInterface")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.DeleteNetworkInterface(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) UnassignPrivateIPAddresses(input *ec2.UnassignPrivateIpAddressesInput) (
+	*ec2.UnassignPrivateIpAddressesOutput, error) {
+	f := i.inject("UnassignPrivateIpAddresses")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.UnassignPrivateIPAddresses(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) AssignIPv6Addresses(input *ec2.AssignIpv6AddressesInput) (*ec2.AssignIpv6AddressesOutput, error) {
+	f := i.inject("AssignIpv6Addresses")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.AssignIPv6Addresses(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) UnassignIPv6Addresses(input *ec2.UnassignIpv6AddressesInput) (
+	*ec2.UnassignIpv6AddressesOutput, error) {
+	f := i.inject("UnassignIpv6Addresses")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.UnassignIPv6Addresses(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) CreateTags(input *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+	f := i.inject("CreateTags")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.CreateTags(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) DescribeSubnets(input *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error) {
+	f := i.inject("DescribeSubnets")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.DescribeSubnets(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) DescribeInstanceTypes(input *ec2.DescribeInstanceTypesInput) (
+	*ec2.DescribeInstanceTypesOutput, error) {
+	f := i.inject("DescribeInstanceTypes")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.DescribeInstanceTypes(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) AssociateTrunkInterface(input *ec2.AssociateTrunkInterfaceInput) (
+	*ec2.AssociateTrunkInterfaceOutput, error) {
+	f := i.inject("AssociateTrunkInterface")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.AssociateTrunkInterface(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) DescribeTrunkInterfaceAssociations(input *ec2.DescribeTrunkInterfaceAssociationsInput) (
+	*ec2.DescribeTrunkInterfaceAssociationsOutput, error) {
+	f := i.inject("DescribeTrunkInterfaceAssociations")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.DescribeTrunkInterfaceAssociations(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) ModifyNetworkInterfaceAttribute(input *ec2.ModifyNetworkInterfaceAttributeInput) (
+	*ec2.ModifyNetworkInterfaceAttributeOutput, error) {
+	f := i.inject("ModifyNetworkInterfaceAttribute")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.ModifyNetworkInterfaceAttribute(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (i *Injector) CreateNetworkInterfacePermission(input *ec2.CreateNetworkInterfacePermissionInput) (
+	*ec2.CreateNetworkInterfacePermissionOutput, error) {
+	f := i.inject("CreateNetworkInterfacePermission")
+	if err := f.before(); err != nil {
+		return nil, err
+	}
+	output, err := i.ec2Wrapper.CreateNetworkInterfacePermission(input)
+	if err = f.after(err); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
@@ -0,0 +1,189 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api/fake"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	subnetID    = "subnet-00000000000000000"
+	description = "description"
+
+	throttleCode = "RequestLimitExceeded"
+)
+
+// getInjector returns the injector wrapping the EC2 simulator with a subnet, the time and the sleep of the
+// injector are controlled by the test
+func getInjector(t *testing.T, config Config) (*Injector, *fake.EC2, *time.Time, *time.Duration) {
+	fakeEC2 := fake.NewEC2()
+	assert.NoError(t, fakeEC2.AddVPC("vpc-00000000000000000", "192.168.0.0/16"))
+	assert.NoError(t, fakeEC2.AddSubnet(subnetID, "vpc-00000000000000000", "us-west-2a", "192.168.0.0/24", ""))
+
+	injector, err := NewInjector(fakeEC2, config, zap.New(zap.UseDevMode(true)))
+	assert.NoError(t, err)
+
+	now := time.Now()
+	slept := time.Duration(0)
+	injector.now = func() time.Time { return now }
+	injector.sleep = func(duration time.Duration) { slept += duration }
+	return injector, fakeEC2, &now, &slept
+}
+
+func errorCode(err error) string {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code()
+	}
+	return ""
+}
+
+// TestInjector_ScriptedCalls tests the rule fails only the scripted calls of the operation
+func TestInjector_ScriptedCalls(t *testing.T) {
+	injector, fakeEC2, _, _ := getInjector(t, Config{Rules: []Rule{
+		{Operation: "DescribeSubnets", Calls: []int{2, 3}, ErrorCode: throttleCode},
+	}})
+	helper := api.NewEC2APIHelper(injector, "cluster-name")
+
+	var codes []string
+	for i := 0; i < 4; i++ {
+		_, err := helper.GetSubnet(&subnetID)
+		codes = append(codes, errorCode(err))
+	}
+
+	assert.Equal(t, []string{"", throttleCode, throttleCode, ""}, codes)
+	// The failed calls are not made to EC2
+	assert.Equal(t, 2, fakeEC2.CallCount("DescribeSubnets"))
+}
+
+// TestInjector_Latency tests the latency of all the matching rules is added to the call
+func TestInjector_Latency(t *testing.T) {
+	injector, _, _, slept := getInjector(t, Config{Rules: []Rule{
+		{Operation: AllOperations, Probability: 1, Latency: Duration{time.Second}},
+		{Operation: "DescribeSubnets", Probability: 1, Latency: Duration{time.Millisecond * 500}},
+	}})
+
+	_, err := injector.DescribeSubnets(&ec2.DescribeSubnetsInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Millisecond*1500, *slept)
+
+	_, err = injector.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Millisecond*2500, *slept)
+}
+
+// TestInjector_Probability tests the rule applies to the expected share of the calls
+func TestInjector_Probability(t *testing.T) {
+	injector, fakeEC2, _, _ := getInjector(t, Config{Seed: 1, Rules: []Rule{
+		{Operation: "DescribeSubnets", Probability: 0.3, ErrorCode: throttleCode},
+	}})
+
+	failed := 0
+	for i := 0; i < 1000; i++ {
+		if _, err := injector.DescribeSubnets(&ec2.DescribeSubnetsInput{}); err != nil {
+			failed++
+		}
+	}
+
+	assert.InDelta(t, 300, failed, 50)
+	assert.Equal(t, 1000-failed, fakeEC2.CallCount("DescribeSubnets"))
+}
+
+// TestInjector_AfterCall tests the partial failure returns the error after the network interface is created
+func TestInjector_AfterCall(t *testing.T) {
+	injector, fakeEC2, _, _ := getInjector(t, Config{Rules: []Rule{
+		{Operation: "CreateNetworkInterface", Calls: []int{1}, ErrorCode: "InternalError", AfterCall: true},
+	}})
+	helper := api.NewEC2APIHelper(injector, "cluster-name")
+
+	_, err := helper.CreateNetworkInterface(&description, &subnetID, nil, nil, 0, nil)
+	assert.Equal(t, "InternalError", errorCode(err))
+
+	// The network interface is leaked and must be cleaned up by the ENI cleaner
+	output, err := fakeEC2.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{})
+	assert.NoError(t, err)
+	assert.Len(t, output.NetworkInterfaces, 1)
+}
+
+// TestInjector_VisibilityDelay tests the created network interface and the assigned addresses are not returned by
+// the describe calls until the visibility delay has passed
+func TestInjector_VisibilityDelay(t *testing.T) {
+	injector, _, now, _ := getInjector(t, Config{VisibilityDelay: Duration{time.Second * 2}})
+	helper := api.NewEC2APIHelper(injector, "cluster-name")
+
+	nwInterface, err := helper.CreateNetworkInterface(&description, &subnetID, nil, nil, 0, nil)
+	assert.NoError(t, err)
+
+	_, err = helper.DescribeNetworkInterfaces([]*string{nwInterface.NetworkInterfaceId})
+	assert.Equal(t, ErrCodeNetworkInterfaceNotFound, errorCode(err))
+	output, err := injector.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{})
+	assert.NoError(t, err)
+	assert.Empty(t, output.NetworkInterfaces)
+
+	*now = now.Add(time.Second * 2)
+	nwInterfaces, err := helper.DescribeNetworkInterfaces([]*string{nwInterface.NetworkInterfaceId})
+	assert.NoError(t, err)
+	assert.Len(t, nwInterfaces, 1)
+
+	_, err = injector.AssignPrivateIPAddresses(&ec2.AssignPrivateIpAddressesInput{
+		NetworkInterfaceId:             nwInterface.NetworkInterfaceId,
+		SecondaryPrivateIpAddressCount: aws.Int64(2),
+	})
+	assert.NoError(t, err)
+
+	nwInterfaces, err = helper.DescribeNetworkInterfaces([]*string{nwInterface.NetworkInterfaceId})
+	assert.NoError(t, err)
+	assert.Len(t, nwInterfaces[0].PrivateIpAddresses, 1)
+
+	*now = now.Add(time.Second * 2)
+	nwInterfaces, err = helper.DescribeNetworkInterfaces([]*string{nwInterface.NetworkInterfaceId})
+	assert.NoError(t, err)
+	assert.Len(t, nwInterfaces[0].PrivateIpAddresses, 3)
+	assert.Empty(t, injector.hiddenENIs)
+	assert.Empty(t, injector.hiddenIPs)
+}
+
+// TestInjector_WithPriority tests the injector returned for a priority shares the calls and the hidden network
+// interfaces with the original injector
+func TestInjector_WithPriority(t *testing.T) {
+	injector, fakeEC2, _, _ := getInjector(t, Config{VisibilityDelay: Duration{time.Second * 2}, Rules: []Rule{
+		{Operation: "DescribeSubnets", Calls: []int{2}, ErrorCode: throttleCode},
+	}})
+	prioritized := api.WithPriority(injector, api.PriorityBackground)
+	assert.IsType(t, &Injector{}, prioritized)
+	assert.NotSame(t, injector, prioritized)
+
+	_, err := api.NewEC2APIHelper(injector, "cluster-name").GetSubnet(&subnetID)
+	assert.NoError(t, err)
+	// The second call is made through the prioritized injector
+	helper := api.NewEC2APIHelper(prioritized, "cluster-name")
+	_, err = helper.GetSubnet(&subnetID)
+	assert.Equal(t, throttleCode, errorCode(err))
+	assert.Equal(t, 1, fakeEC2.CallCount("DescribeSubnets"))
+
+	nwInterface, err := api.NewEC2APIHelper(injector, "cluster-name").
+		CreateNetworkInterface(&description, &subnetID, nil, nil, 0, nil)
+	assert.NoError(t, err)
+	_, err = helper.DescribeNetworkInterfaces([]*string{nwInterface.NetworkInterfaceId})
+	assert.Equal(t, ErrCodeNetworkInterfaceNotFound, errorCode(err))
+}
@@ -15,18 +15,32 @@ package api
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 )
 
 const (
 	CreateENIDescriptionPrefix = "aws-k8s-"
+	// ErrCodeInsufficientFreeAddressesInSubnet is the EC2 error code returned when the subnet doesn't have
+	// enough free IP addresses
+	ErrCodeInsufficientFreeAddressesInSubnet = "InsufficientFreeAddressesInSubnet"
+	// ErrCodeNetworkInterfaceNotFound is the EC2 error code returned when the network interface doesn't exist
+	ErrCodeNetworkInterfaceNotFound = "InvalidNetworkInterfaceID.NotFound"
+	// ErrCodeAttachmentLimitExceeded is the EC2 error code returned when the instance or the network card can't
+	// have more network interfaces attached
+	ErrCodeAttachmentLimitExceeded = "AttachmentLimitExceeded"
+	// ErrCodeInvalidParameterValue is the EC2 error code returned for an invalid parameter, including a device
+	// index already used by another network interface
+	ErrCodeInvalidParameterValue = "InvalidParameterValue"
 )
 
 var (
@@ -79,20 +93,26 @@ type EC2APIHelper interface {
 		secondaryPrivateIPCount int, interfaceType *string) (*ec2.NetworkInterface, error)
 	DeleteNetworkInterface(interfaceId *string) error
 	GetSubnet(subnetId *string) (*ec2.Subnet, error)
+	GetSubnets(subnetIds []*string) ([]*ec2.Subnet, error)
 	GetBranchNetworkInterface(trunkID *string) ([]*ec2.NetworkInterface, error)
 	GetInstanceNetworkInterface(instanceId *string) ([]*ec2.InstanceNetworkInterface, error)
 	DescribeNetworkInterfaces(nwInterfaceIds []*string) ([]*ec2.NetworkInterface, error)
 	DescribeTrunkInterfaceAssociation(trunkInterfaceId *string) ([]*ec2.TrunkInterfaceAssociation, error)
 	CreateAndAttachNetworkInterface(instanceId *string, subnetId *string, securityGroups []string, tags []*ec2.Tag,
-		deviceIndex *int64, description *string, interfaceType *string, secondaryIPCount int) (*ec2.NetworkInterface, error)
-	AttachNetworkInterfaceToInstance(instanceId *string, nwInterfaceId *string, deviceIndex *int64) (*string, error)
+		deviceIndex *int64, networkCardIndex *int64, description *string, interfaceType *string,
+		secondaryIPCount int) (*ec2.NetworkInterface, error)
+	AttachNetworkInterfaceToInstance(instanceId *string, nwInterfaceId *string, deviceIndex *int64,
+		networkCardIndex *int64) (*string, error)
 	SetDeleteOnTermination(attachmentId *string, eniId *string) error
 	DetachNetworkInterfaceFromInstance(attachmentId *string) error
 	DetachAndDeleteNetworkInterface(attachmentId *string, nwInterfaceId *string) error
 	WaitForNetworkInterfaceStatusChange(networkInterfaceId *string, desiredStatus string) error
 	GetInstanceDetails(instanceId *string) (*ec2.Instance, error)
+	GetInstanceTypeLimits(instanceType string) (*vpc.VPCLimits, []vpc.NetworkCard, error)
 	AssignIPv4AddressesAndWaitTillReady(eniID string, count int) ([]string, error)
 	UnassignPrivateIpAddresses(eniID string, ips []string) error
+	AssignIPv6AddressesAndWaitTillReady(eniID string, count int, usePrefixes bool) ([]string, error)
+	UnassignIPv6Addresses(eniID string, addresses []string, usePrefixes bool) error
 }
 
 // CreateNetworkInterface creates a new network interface
@@ -186,6 +206,50 @@ func (h *ec2APIHelper) GetSubnet(subnetId *string) (*ec2.Subnet, error) {
 	return describeSubnetOutput.Subnets[0], nil
 }
 
+// GetSubnets returns the subnet details of all the given subnets
+func (h *ec2APIHelper) GetSubnets(subnetIds []*string) ([]*ec2.Subnet, error) {
+	describeSubnetInput := &ec2.DescribeSubnetsInput{
+		SubnetIds: subnetIds,
+	}
+
+	describeSubnetOutput, err := h.ec2Wrapper.DescribeSubnets(describeSubnetInput)
+	if err != nil {
+		return nil, err
+	}
+	if describeSubnetOutput == nil {
+		return nil, fmt.Errorf("subnets not found %v", aws.StringValueSlice(subnetIds))
+	}
+
+	return describeSubnetOutput.Subnets, nil
+}
+
+// IsInsufficientFreeAddressesError returns true if the error is returned because the subnet has run out of
+// free IP addresses
+func IsInsufficientFreeAddressesError(err error) bool {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == ErrCodeInsufficientFreeAddressesInSubnet
+	}
+	return false
+}
+
+// IsNetworkInterfaceNotFoundError returns true if the error is returned because the network interface doesn't exist
+func IsNetworkInterfaceNotFoundError(err error) bool {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == ErrCodeNetworkInterfaceNotFound
+	}
+	return false
+}
+
+// IsDeviceIndexConflictError returns true if the network interface could not be attached because the device index
+// is already used or no more network interfaces can be attached to the instance
+func IsDeviceIndexConflictError(err error) bool {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == ErrCodeAttachmentLimitExceeded ||
+			(awsErr.Code() == ErrCodeInvalidParameterValue && strings.Contains(awsErr.Message(), "device index"))
+	}
+	return false
+}
+
 // DeleteNetworkInterface deletes a network interface with retries with exponential back offs
 func (h *ec2APIHelper) DeleteNetworkInterface(interfaceId *string) error {
 	deleteNetworkInterface := &ec2.DeleteNetworkInterfaceInput{
@@ -296,10 +360,11 @@ func (h *ec2APIHelper) AssociateBranchToTrunk(trunkInterfaceId *string, branchIn
 		*associateTrunkInterfaceIP)
 }
 
-// CreateAndAttachNetworkInterface creates and attaches the network interface to the instance. The function will
-// wait till the interface is successfully attached
+// CreateAndAttachNetworkInterface creates and attaches the network interface to the instance at the device index of
+// the network card. The function will wait till the interface is successfully attached
 func (h *ec2APIHelper) CreateAndAttachNetworkInterface(instanceId *string, subnetId *string, securityGroups []string,
-	tags []*ec2.Tag, deviceIndex *int64, description *string, interfaceType *string, secondaryIPCount int) (*ec2.NetworkInterface, error) {
+	tags []*ec2.Tag, deviceIndex *int64, networkCardIndex *int64, description *string, interfaceType *string,
+	secondaryIPCount int) (*ec2.NetworkInterface, error) {
 
 	nwInterface, err := h.CreateNetworkInterface(description, subnetId, securityGroups, tags, secondaryIPCount, interfaceType)
 	if err != nil {
@@ -308,7 +373,8 @@ func (h *ec2APIHelper) CreateAndAttachNetworkInterface(instanceId *string, subne
 
 	var attachmentId *string
 
-	attachmentId, err = h.AttachNetworkInterfaceToInstance(instanceId, nwInterface.NetworkInterfaceId, deviceIndex)
+	attachmentId, err = h.AttachNetworkInterfaceToInstance(instanceId, nwInterface.NetworkInterfaceId, deviceIndex,
+		networkCardIndex)
 	if err != nil {
 		errDelete := h.DeleteNetworkInterface(nwInterface.NetworkInterfaceId)
 		if errDelete != nil {
@@ -338,6 +404,14 @@ func (h *ec2APIHelper) CreateAndAttachNetworkInterface(instanceId *string, subne
 		return nil, err
 	}
 
+	// Populate the attachment so the caller can detach the interface before deleting it
+	nwInterface.Attachment = &ec2.NetworkInterfaceAttachment{
+		AttachmentId:     attachmentId,
+		DeviceIndex:      deviceIndex,
+		NetworkCardIndex: networkCardIndex,
+		InstanceId:       instanceId,
+	}
+
 	return nwInterface, nil
 }
 
@@ -356,11 +430,14 @@ func (h *ec2APIHelper) SetDeleteOnTermination(attachmentId *string, eniId *strin
 	return err
 }
 
-// AttachNetworkInterfaceToInstance attaches the network interface to the instance
-func (h *ec2APIHelper) AttachNetworkInterfaceToInstance(instanceId *string, nwInterfaceId *string, deviceIndex *int64) (*string, error) {
+// AttachNetworkInterfaceToInstance attaches the network interface to the instance at the device index of the network
+// card, the network interface is attached to the default network card if the network card index is nil
+func (h *ec2APIHelper) AttachNetworkInterfaceToInstance(instanceId *string, nwInterfaceId *string, deviceIndex *int64,
+	networkCardIndex *int64) (*string, error) {
 	attachNetworkInterfaceInput := &ec2.AttachNetworkInterfaceInput{
 		DeviceIndex:        deviceIndex,
 		InstanceId:         instanceId,
+		NetworkCardIndex:   networkCardIndex,
 		NetworkInterfaceId: nwInterfaceId,
 	}
 
@@ -401,9 +478,12 @@ func (h *ec2APIHelper) WaitForNetworkInterfaceStatusChange(networkInterfaceId *s
 		}, func() error {
 			interfaces, err := h.DescribeNetworkInterfaces([]*string{networkInterfaceId})
 			if err == nil && len(interfaces) == 1 {
+				// A detached interface has no attachment, its status is available
 				attachment := interfaces[0].Attachment
 				if attachment != nil && attachment.Status != nil && *attachment.Status == desiredStatus {
 					return nil
+				} else if aws.StringValue(interfaces[0].Status) == desiredStatus {
+					return nil
 				} else {
 					return ErrRetryAttachmentStatusCheck
 				}
@@ -434,6 +514,37 @@ func (h *ec2APIHelper) GetInstanceDetails(instanceId *string) (*ec2.Instance, er
 	return nil, fmt.Errorf("failed to find instance details for input %v", *describeInstanceInput)
 }
 
+// GetInstanceTypeLimits returns the network interface and IPv4 address limits and the network cards of the instance
+// type from the EC2 API. The branch interface limit is not exposed by the API, so the instance type is not trunking
+// compatible
+func (h *ec2APIHelper) GetInstanceTypeLimits(instanceType string) (*vpc.VPCLimits, []vpc.NetworkCard, error) {
+	describeInstanceTypesInput := &ec2.DescribeInstanceTypesInput{
+		InstanceTypes: []*string{&instanceType},
+	}
+
+	describeInstanceTypesOutput, err := h.ec2Wrapper.DescribeInstanceTypes(describeInstanceTypesInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	if describeInstanceTypesOutput == nil || len(describeInstanceTypesOutput.InstanceTypes) == 0 ||
+		describeInstanceTypesOutput.InstanceTypes[0].NetworkInfo == nil {
+		return nil, nil, fmt.Errorf("failed to find network info for instance type %s", instanceType)
+	}
+
+	networkInfo := describeInstanceTypesOutput.InstanceTypes[0].NetworkInfo
+	var networkCards []vpc.NetworkCard
+	for _, card := range networkInfo.NetworkCards {
+		networkCards = append(networkCards, vpc.NetworkCard{
+			Index:     int(aws.Int64Value(card.NetworkCardIndex)),
+			Interface: int(aws.Int64Value(card.MaximumNetworkInterfaces)),
+		})
+	}
+	return &vpc.VPCLimits{
+		Interface:        int(aws.Int64Value(networkInfo.MaximumNetworkInterfaces)),
+		IPv4PerInterface: int(aws.Int64Value(networkInfo.Ipv4AddressesPerInterface)),
+	}, networkCards, nil
+}
+
 // AssignIPv4AddressesAndWaitTillReady assigns IPv4 Address to the interface and waits till the IP Address is attached
 // to the instance
 func (h *ec2APIHelper) AssignIPv4AddressesAndWaitTillReady(eniID string, count int) ([]string, error) {
@@ -509,6 +620,88 @@ func (h *ec2APIHelper) UnassignPrivateIpAddresses(eniID string, ips []string) er
 	return err
 }
 
+// AssignIPv6AddressesAndWaitTillReady assigns IPv6 addresses or /80 IPv6 prefixes if usePrefixes is set to the
+// interface and waits till the addresses are attached to the instance
+func (h *ec2APIHelper) AssignIPv6AddressesAndWaitTillReady(eniID string, count int, usePrefixes bool) ([]string, error) {
+	var assignedAddresses []string
+
+	input := &ec2.AssignIpv6AddressesInput{
+		NetworkInterfaceId: &eniID,
+	}
+	if usePrefixes {
+		input.Ipv6PrefixCount = aws.Int64(int64(count))
+	} else {
+		input.Ipv6AddressCount = aws.Int64(int64(count))
+	}
+
+	assignIPv6Output, err := h.ec2Wrapper.AssignIPv6Addresses(input)
+	if err != nil {
+		return assignedAddresses, err
+	}
+
+	var requestedAddresses []*string
+	if assignIPv6Output != nil {
+		if usePrefixes {
+			requestedAddresses = assignIPv6Output.AssignedIpv6Prefixes
+		} else {
+			requestedAddresses = assignIPv6Output.AssignedIpv6Addresses
+		}
+	}
+	if len(requestedAddresses) == 0 {
+		return assignedAddresses, fmt.Errorf("failed to assign %d ipv6 addresses to eni %s", count, eniID)
+	}
+
+	ErrIPNotAttachedYet := fmt.Errorf("ipv6 address is not attached yet")
+
+	err = retry.OnError(waitForIPAttachment,
+		func(err error) bool {
+			// Retry in case addresses are not attached yet
+			return err == ErrIPNotAttachedYet
+		}, func() error {
+			interfaces, err := h.DescribeNetworkInterfaces([]*string{&eniID})
+			// Re initialize the slice so we don't add addresses multiple time
+			assignedAddresses = []string{}
+			if err != nil || len(interfaces) != 1 {
+				return err
+			}
+			attachedAddresses := map[string]bool{}
+			if usePrefixes {
+				for _, prefix := range interfaces[0].Ipv6Prefixes {
+					attachedAddresses[aws.StringValue(prefix.Ipv6Prefix)] = true
+				}
+			} else {
+				for _, address := range interfaces[0].Ipv6Addresses {
+					attachedAddresses[aws.StringValue(address.Ipv6Address)] = true
+				}
+			}
+			// Only return the addresses that are returned by the describe network interface call
+			for _, address := range aws.StringValueSlice(requestedAddresses) {
+				if !attachedAddresses[address] {
+					err = ErrIPNotAttachedYet
+				} else {
+					assignedAddresses = append(assignedAddresses, address)
+				}
+			}
+			return err
+		})
+
+	return assignedAddresses, err
+}
+
+// UnassignIPv6Addresses unassigns the IPv6 addresses or the IPv6 prefixes if usePrefixes is set from the interface
+func (h *ec2APIHelper) UnassignIPv6Addresses(eniID string, addresses []string, usePrefixes bool) error {
+	input := &ec2.UnassignIpv6AddressesInput{
+		NetworkInterfaceId: &eniID,
+	}
+	if usePrefixes {
+		input.Ipv6Prefixes = aws.StringSlice(addresses)
+	} else {
+		input.Ipv6Addresses = aws.StringSlice(addresses)
+	}
+	_, err := h.ec2Wrapper.UnassignIPv6Addresses(input)
+	return err
+}
+
 func (h *ec2APIHelper) GetBranchNetworkInterface(trunkID *string) ([]*ec2.NetworkInterface, error) {
 	filters := []*ec2.Filter{{
 		Name:   aws.String("tag:" + config.TrunkENIIDTag),
@@ -19,9 +19,11 @@ import (
 	"time"
 
 	mock_api "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -42,6 +44,7 @@ var (
 	attachmentId       = "attach-000000000000000"
 	eniID              = "eni-00000000000000003"
 	deviceIndex        = int64(0)
+	networkCardIndex   = int64(1)
 
 	ipAddress1 = "192.168.1.1"
 	ipAddress2 = "192.168.1.2"
@@ -225,6 +228,7 @@ var (
 		InstanceId:         &instanceId,
 		NetworkInterfaceId: &branchInterfaceId,
 		DeviceIndex:        &deviceIndex,
+		NetworkCardIndex:   &networkCardIndex,
 	}
 
 	attachNetworkInterfaceOutput = &ec2.AttachNetworkInterfaceOutput{AttachmentId: &attachmentId}
@@ -537,6 +541,76 @@ func TestEc2APIHelper_GetSubnet_NoSubnetReturned(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+// TestEc2APIHelper_GetSubnets tests that all the subnets returned by the ec2 api call are returned
+func TestEc2APIHelper_GetSubnets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	mockWrapper.EXPECT().DescribeSubnets(describeSubnetInput).Return(describeSubnetOutput, nil)
+
+	subnets, err := ec2ApiHelper.GetSubnets([]*string{&subnetId})
+	assert.NoError(t, err)
+	assert.Equal(t, describeSubnetOutput.Subnets, subnets)
+}
+
+// TestEc2APIHelper_GetInstanceTypeLimits tests the network interface and IPv4 address limits and the network cards
+// are returned from the network info of the instance type
+func TestEc2APIHelper_GetInstanceTypeLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	mockWrapper.EXPECT().DescribeInstanceTypes(&ec2.DescribeInstanceTypesInput{
+		InstanceTypes: []*string{aws.String("c7g.large")},
+	}).Return(&ec2.DescribeInstanceTypesOutput{InstanceTypes: []*ec2.InstanceTypeInfo{{
+		InstanceType: aws.String("c7g.large"),
+		NetworkInfo: &ec2.NetworkInfo{
+			MaximumNetworkInterfaces:  aws.Int64(4),
+			Ipv4AddressesPerInterface: aws.Int64(10),
+			NetworkCards: []*ec2.NetworkCardInfo{
+				{NetworkCardIndex: aws.Int64(0), MaximumNetworkInterfaces: aws.Int64(2)},
+				{NetworkCardIndex: aws.Int64(1), MaximumNetworkInterfaces: aws.Int64(2)},
+			},
+		},
+	}}}, nil)
+
+	limits, networkCards, err := ec2ApiHelper.GetInstanceTypeLimits("c7g.large")
+	assert.NoError(t, err)
+	assert.Equal(t, &vpc.VPCLimits{Interface: 4, IPv4PerInterface: 10}, limits)
+	assert.Equal(t, []vpc.NetworkCard{{Index: 0, Interface: 2}, {Index: 1, Interface: 2}}, networkCards)
+}
+
+// TestEc2APIHelper_GetInstanceTypeLimits_NoInstanceType tests an error is returned if the instance type is not
+// returned by the ec2 api call
+func TestEc2APIHelper_GetInstanceTypeLimits_NoInstanceType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	mockWrapper.EXPECT().DescribeInstanceTypes(gomock.Any()).Return(&ec2.DescribeInstanceTypesOutput{}, nil)
+
+	_, _, err := ec2ApiHelper.GetInstanceTypeLimits("c7g.large")
+	assert.Error(t, err)
+}
+
+// TestIsInsufficientFreeAddressesError tests that only the insufficient free addresses error code is matched
+func TestIsInsufficientFreeAddressesError(t *testing.T) {
+	assert.True(t, IsInsufficientFreeAddressesError(
+		awserr.New(ErrCodeInsufficientFreeAddressesInSubnet, "", nil)))
+	assert.False(t, IsInsufficientFreeAddressesError(awserr.New("RequestLimitExceeded", "", nil)))
+	assert.False(t, IsInsufficientFreeAddressesError(mockError))
+}
+
+// TestIsDeviceIndexConflictError tests that the attachment limit and the used device index errors are matched
+func TestIsDeviceIndexConflictError(t *testing.T) {
+	assert.True(t, IsDeviceIndexConflictError(awserr.New(ErrCodeAttachmentLimitExceeded, "", nil)))
+	assert.True(t, IsDeviceIndexConflictError(awserr.New(ErrCodeInvalidParameterValue,
+		"Instance 'i-1' already has an interface attached at device index '2'.", nil)))
+	assert.False(t, IsDeviceIndexConflictError(awserr.New(ErrCodeInvalidParameterValue, "invalid subnet", nil)))
+	assert.False(t, IsDeviceIndexConflictError(mockError))
+}
+
 // TestEc2APIHelper_GetSubnet_Error tests that the error form ec2 api call is propagated to the caller.
 func TestEc2APIHelper_GetSubnet_Error(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -673,13 +747,14 @@ func TestEc2APIHelper_CreateAndAttachNetworkInterface(t *testing.T) {
 		Return(describeNetworkInterfaceOutputUsingOneInterfaceId, nil)
 
 	nwInterface, err := ec2ApiHelper.CreateAndAttachNetworkInterface(&instanceId, &subnetId, securityGroups, tags,
-		&deviceIndex, &eniDescription, nil, 0)
+		&deviceIndex, &networkCardIndex, &eniDescription, nil, 0)
 
 	// Clean up
 	describeNetworkInterfaceOutputUsingOneInterfaceId.NetworkInterfaces[0].Attachment.Status = oldStatus
 
 	assert.NoError(t, err)
 	assert.Equal(t, branchInterfaceId, *nwInterface.NetworkInterfaceId)
+	assert.Equal(t, networkCardIndex, *nwInterface.Attachment.NetworkCardIndex)
 }
 
 // TestEc2APIHelper_CreateAndAttachNetworkInterface_DeleteOnAttachFailed tests that delete is invoked if the attach
@@ -697,7 +772,7 @@ func TestEc2APIHelper_CreateAndAttachNetworkInterface_DeleteOnAttachFailed(t *te
 	mockWrapper.EXPECT().DeleteNetworkInterface(deleteNetworkInterfaceInput).Return(nil, nil)
 
 	nwInterface, err := ec2ApiHelper.CreateAndAttachNetworkInterface(&instanceId, &subnetId, securityGroups, tags,
-		&deviceIndex, &eniDescription, nil, 0)
+		&deviceIndex, &networkCardIndex, &eniDescription, nil, 0)
 
 	assert.NotNil(t, err)
 	assert.Nil(t, nwInterface)
@@ -722,7 +797,7 @@ func TestEc2APIHelper_CreateAndAttachNetworkInterface_DeleteOnSetTerminationFail
 	mockWrapper.EXPECT().DeleteNetworkInterface(deleteNetworkInterfaceInput).Return(nil, nil)
 
 	nwInterface, err := ec2ApiHelper.CreateAndAttachNetworkInterface(&instanceId, &subnetId, securityGroups, tags,
-		&deviceIndex, &eniDescription, nil, 0)
+		&deviceIndex, &networkCardIndex, &eniDescription, nil, 0)
 
 	assert.NotNil(t, err)
 	assert.Nil(t, nwInterface)
@@ -766,7 +841,8 @@ func TestEC2APIHelper_AttachNetworkInterfaceToInstance(t *testing.T) {
 	mockWrapper.EXPECT().AttachNetworkInterface(attachNetworkInterfaceInput).
 		Return(attachNetworkInterfaceOutput, nil)
 
-	id, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex)
+	id, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex,
+		&networkCardIndex)
 	assert.NoError(t, err)
 	assert.Equal(t, attachmentId, *id)
 }
@@ -782,7 +858,8 @@ func TestEC2APIHelper_AttachNetworkInterfaceToInstance_NoAttachmentId(t *testing
 	mockWrapper.EXPECT().AttachNetworkInterface(attachNetworkInterfaceInput).
 		Return(&ec2.AttachNetworkInterfaceOutput{AttachmentId: nil}, nil)
 
-	_, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex)
+	_, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex,
+		&networkCardIndex)
 	assert.NotNil(t, err)
 }
 
@@ -795,7 +872,8 @@ func TestEC2APIHelper_AttachNetworkInterfaceToInstance_Error(t *testing.T) {
 
 	mockWrapper.EXPECT().AttachNetworkInterface(attachNetworkInterfaceInput).Return(nil, mockError)
 
-	_, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex)
+	_, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex,
+		&networkCardIndex)
 	assert.Error(t, mockError, err)
 }
 
@@ -836,6 +914,30 @@ func TestEC2APIHelper_WaitForNetworkInterfaceStatusChange(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestEC2APIHelper_WaitForNetworkInterfaceStatusChange_Detached tests the wait returns once the detached interface
+// without attachment is available
+func TestEC2APIHelper_WaitForNetworkInterfaceStatusChange_Detached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+
+	gomock.InOrder(
+		// Still detaching, must retry
+		mockWrapper.EXPECT().DescribeNetworkInterfaces(describeNetworkInterfaceInputUsingOneInterfaceId).
+			Return(&ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: []*ec2.NetworkInterface{{
+				Status:     aws.String(ec2.NetworkInterfaceStatusInUse),
+				Attachment: &ec2.NetworkInterfaceAttachment{Status: aws.String(ec2.AttachmentStatusDetaching)}}}}, nil),
+		// Detached, must return
+		mockWrapper.EXPECT().DescribeNetworkInterfaces(describeNetworkInterfaceInputUsingOneInterfaceId).
+			Return(&ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: []*ec2.NetworkInterface{
+				{Status: aws.String(ec2.NetworkInterfaceStatusAvailable)}}}, nil),
+	)
+
+	err := ec2ApiHelper.WaitForNetworkInterfaceStatusChange(&branchInterfaceId, ec2.NetworkInterfaceStatusAvailable)
+	assert.NoError(t, err)
+}
+
 // TestEC2ADIHelper_WaitForNetworkInterfaceStatusChange_NonRetryableError tests call immediately returns in case of
 // a non retryable error
 func TestEC2ADIHelper_WaitForNetworkInterfaceStatusChange_NonRetryableError(t *testing.T) {
@@ -1030,3 +1132,89 @@ func TestEc2APIHelper_GetBranchNetworkInterface_PaginatedResults(t *testing.T) {
 	assert.NoError(t, err)
 	assert.ElementsMatch(t, []*ec2.NetworkInterface{&networkInterface1, &networkInterface2}, branchInterfaces)
 }
+
+// TestEC2APIHelper_AssignIPv6AddressesAndWaitTillReady tests that the IPv6 addresses are returned once attached
+func TestEC2APIHelper_AssignIPv6AddressesAndWaitTillReady(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+
+	ipv6Address1, ipv6Address2 := "2600::1", "2600::2"
+	mockWrapper.EXPECT().AssignIPv6Addresses(&ec2.AssignIpv6AddressesInput{
+		NetworkInterfaceId: &eniID,
+		Ipv6AddressCount:   aws.Int64(2),
+	}).Return(&ec2.AssignIpv6AddressesOutput{
+		AssignedIpv6Addresses: []*string{&ipv6Address1, &ipv6Address2},
+	}, nil)
+	mockWrapper.EXPECT().DescribeNetworkInterfaces(describeNetworkInterfaceInput).Return(
+		&ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: []*ec2.NetworkInterface{
+			{Ipv6Addresses: []*ec2.NetworkInterfaceIpv6Address{
+				{Ipv6Address: &ipv6Address1}, {Ipv6Address: &ipv6Address2},
+			}}}}, nil)
+
+	createdIPs, err := ec2ApiHelper.AssignIPv6AddressesAndWaitTillReady(eniID, 2, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ipv6Address1, ipv6Address2}, createdIPs)
+}
+
+// TestEC2APIHelper_AssignIPv6AddressesAndWaitTillReady_Prefixes tests that the IPv6 prefixes are returned once
+// attached when the prefixes are requested
+func TestEC2APIHelper_AssignIPv6AddressesAndWaitTillReady_Prefixes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+
+	prefix := "2600:0:0:1::/80"
+	mockWrapper.EXPECT().AssignIPv6Addresses(&ec2.AssignIpv6AddressesInput{
+		NetworkInterfaceId: &eniID,
+		Ipv6PrefixCount:    aws.Int64(1),
+	}).Return(&ec2.AssignIpv6AddressesOutput{AssignedIpv6Prefixes: []*string{&prefix}}, nil)
+	gomock.InOrder(
+		// First call doesn't return the prefix
+		mockWrapper.EXPECT().DescribeNetworkInterfaces(describeNetworkInterfaceInput).Return(
+			&ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: []*ec2.NetworkInterface{{}}}, nil),
+		mockWrapper.EXPECT().DescribeNetworkInterfaces(describeNetworkInterfaceInput).Return(
+			&ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: []*ec2.NetworkInterface{
+				{Ipv6Prefixes: []*ec2.Ipv6PrefixSpecification{{Ipv6Prefix: &prefix}}}}}, nil),
+	)
+
+	createdPrefixes, err := ec2ApiHelper.AssignIPv6AddressesAndWaitTillReady(eniID, 1, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{prefix}, createdPrefixes)
+}
+
+// TestEC2APIHelper_AssignIPv6AddressesAndWaitTillReady_Error tests that error is returned if the assign call fails
+func TestEC2APIHelper_AssignIPv6AddressesAndWaitTillReady_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+
+	mockWrapper.EXPECT().AssignIPv6Addresses(gomock.Any()).Return(nil, mockError)
+
+	_, err := ec2ApiHelper.AssignIPv6AddressesAndWaitTillReady(eniID, 2, false)
+
+	assert.Equal(t, mockError, err)
+}
+
+// TestEC2APIHelper_UnassignIPv6Addresses tests that the prefixes are unassigned when prefixes are used
+func TestEC2APIHelper_UnassignIPv6Addresses(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+
+	prefix := "2600:0:0:1::/80"
+	mockWrapper.EXPECT().UnassignIPv6Addresses(&ec2.UnassignIpv6AddressesInput{
+		NetworkInterfaceId: &eniID,
+		Ipv6Prefixes:       []*string{&prefix},
+	}).Return(&ec2.UnassignIpv6AddressesOutput{}, nil)
+
+	err := ec2ApiHelper.UnassignIPv6Addresses(eniID, []string{prefix}, true)
+
+	assert.NoError(t, err)
+}
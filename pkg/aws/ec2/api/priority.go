@@ -0,0 +1,89 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
+)
+
+// Priority is the class of an EC2 API call, each class has a reserved share of the client's QPS and borrows the
+// share of the other classes when they are idle
+type Priority int
+
+const (
+	// PriorityPodCritical is the class of the calls creating and associating the resources of pods waiting to start
+	PriorityPodCritical Priority = iota
+	// PriorityNodeInit is the class of the calls initializing the resources of the nodes
+	PriorityNodeInit
+	// PriorityBackground is the class of the calls releasing and cleaning up the resources
+	PriorityBackground
+)
+
+// Priorities is the number of priority classes
+const Priorities = 3
+
+// operationPriority is the priority of the EC2 API calls made without an explicit priority
+var operationPriority = map[string]Priority{
+	"CreateNetworkInterface":             PriorityPodCritical,
+	"CreateNetworkInterfacePermission":   PriorityPodCritical,
+	"AssignPrivateIpAddresses":           PriorityPodCritical,
+	"AssignIpv6Addresses":                PriorityPodCritical,
+	"AssociateTrunkInterface":            PriorityPodCritical,
+	"CreateTags":                         PriorityPodCritical,
+	"DescribeInstances":                  PriorityNodeInit,
+	"DescribeNetworkInterfaces":          PriorityNodeInit,
+	"DescribeSubnets":                    PriorityNodeInit,
+	"DescribeInstanceTypes":              PriorityNodeInit,
+	"DescribeTrunkInterfaceAssociations": PriorityNodeInit,
+	"AttachNetworkInterface":             PriorityNodeInit,
+	"ModifyNetworkInterfaceAttribute":    PriorityNodeInit,
+	"DeleteNetworkInterface":             PriorityBackground,
+	"DetachNetworkInterface":             PriorityBackground,
+	"UnassignPrivateIpAddresses":         PriorityBackground,
+	"UnassignIpv6Addresses":              PriorityBackground,
+}
+
+// Prioritizer is implemented by the EC2Wrappers decorating another EC2Wrapper, so the priority is applied to the
+// calls made by the decorated EC2Wrapper
+type Prioritizer interface {
+	WithPriority(priority Priority) EC2Wrapper
+}
+
+// WithPriority returns the EC2Wrapper making all the EC2 API calls with the given priority, instead of the
+// priority of each operation. EC2Wrappers without priority classes are returned unchanged
+func WithPriority(wrapper EC2Wrapper, priority Priority) EC2Wrapper {
+	switch e := wrapper.(type) {
+	case *ec2Wrapper:
+		prioritized := *e
+		prioritized.priority = &priority
+		return &prioritized
+	case Prioritizer:
+		return e.WithPriority(priority)
+	}
+	return wrapper
+}
+
+// priorityContext returns the context carrying the priority of the EC2 API call to the client's rate limiter
+func (e *ec2Wrapper) priorityContext(operation string) context.Context {
+	priority, ok := operationPriority[operation]
+	if e.priority != nil {
+		priority, ok = *e.priority, true
+	}
+	if !ok {
+		priority = PriorityBackground
+	}
+	return utils.ContextWithPriority(context.Background(), int(priority))
+}
@@ -0,0 +1,71 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEc2Wrapper_PriorityContext tests the calls have the priority of their operation unless the wrapper has an
+// explicit priority
+func TestEc2Wrapper_PriorityContext(t *testing.T) {
+	wrapper := &ec2Wrapper{}
+	assert.Equal(t, int(PriorityPodCritical),
+		utils.PriorityFromContext(wrapper.priorityContext("AssociateTrunkInterface")))
+	assert.Equal(t, int(PriorityNodeInit),
+		utils.PriorityFromContext(wrapper.priorityContext("DescribeNetworkInterfaces")))
+	assert.Equal(t, int(PriorityBackground),
+		utils.PriorityFromContext(wrapper.priorityContext("DeleteNetworkInterface")))
+
+	background := WithPriority(wrapper, PriorityBackground).(*ec2Wrapper)
+	assert.Equal(t, int(PriorityBackground),
+		utils.PriorityFromContext(background.priorityContext("DescribeNetworkInterfaces")))
+	// The priority of the original wrapper is unchanged
+	assert.Nil(t, wrapper.priority)
+}
+
+// TestWithPriority_UnsupportedWrapper tests the wrappers without priority classes are returned unchanged
+func TestWithPriority_UnsupportedWrapper(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWrapper := mock_api.NewMockEC2Wrapper(ctrl)
+	assert.Equal(t, EC2Wrapper(mockWrapper), WithPriority(mockWrapper, PriorityBackground))
+}
+
+// prioritizer is an EC2Wrapper decorating another EC2Wrapper
+type prioritizer struct {
+	EC2Wrapper
+}
+
+func (p *prioritizer) WithPriority(priority Priority) EC2Wrapper {
+	return &prioritizer{EC2Wrapper: WithPriority(p.EC2Wrapper, priority)}
+}
+
+// TestWithPriority_Prioritizer tests the priority is applied to the EC2Wrapper decorated by the wrapper
+func TestWithPriority_Prioritizer(t *testing.T) {
+	wrapper := &prioritizer{EC2Wrapper: &ec2Wrapper{}}
+
+	background := WithPriority(wrapper, PriorityBackground).(*prioritizer).EC2Wrapper.(*ec2Wrapper)
+	assert.Equal(t, int(PriorityBackground),
+		utils.PriorityFromContext(background.priorityContext("DescribeNetworkInterfaces")))
+	// The priority of the decorated wrapper is unchanged
+	assert.Nil(t, wrapper.EC2Wrapper.(*ec2Wrapper).priority)
+}
@@ -18,7 +18,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -27,6 +26,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/sts"
@@ -48,6 +48,8 @@ type EC2Wrapper interface {
 	DeleteNetworkInterface(input *ec2.DeleteNetworkInterfaceInput) (*ec2.DeleteNetworkInterfaceOutput, error)
 	AssignPrivateIPAddresses(input *ec2.AssignPrivateIpAddressesInput) (*ec2.AssignPrivateIpAddressesOutput, error)
 	UnassignPrivateIPAddresses(input *ec2.UnassignPrivateIpAddressesInput) (*ec2.UnassignPrivateIpAddressesOutput, error)
+	AssignIPv6Addresses(input *ec2.AssignIpv6AddressesInput) (*ec2.AssignIpv6AddressesOutput, error)
+	UnassignIPv6Addresses(input *ec2.UnassignIpv6AddressesInput) (*ec2.UnassignIpv6AddressesOutput, error)
 	DescribeNetworkInterfaces(input *ec2.DescribeNetworkInterfacesInput) (*ec2.DescribeNetworkInterfacesOutput, error)
 	CreateTags(input *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error)
 	DescribeSubnets(input *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error)
@@ -55,6 +57,7 @@ type EC2Wrapper interface {
 	DescribeTrunkInterfaceAssociations(input *ec2.DescribeTrunkInterfaceAssociationsInput) (*ec2.DescribeTrunkInterfaceAssociationsOutput, error)
 	ModifyNetworkInterfaceAttribute(input *ec2.ModifyNetworkInterfaceAttributeInput) (*ec2.ModifyNetworkInterfaceAttributeOutput, error)
 	CreateNetworkInterfacePermission(input *ec2.CreateNetworkInterfacePermissionInput) (*ec2.CreateNetworkInterfacePermissionOutput, error)
+	DescribeInstanceTypes(input *ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error)
 }
 
 var (
@@ -184,6 +187,34 @@ var (
 		},
 	)
 
+	ec2AssignIPv6AddressAPICallCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_assign_ipv6_address_api_req_count",
+			Help: "The number calls made to ec2 for assigning ipv6 addresses or prefixes on network interface",
+		},
+	)
+
+	ec2AssignIPv6AddressAPIErrCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_assign_ipv6_address_api_err_count",
+			Help: "The number of errors encountered while assigning ipv6 addresses or prefixes on network interface",
+		},
+	)
+
+	ec2UnassignIPv6AddressAPICallCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_unassign_ipv6_address_api_req_count",
+			Help: "The number calls made to ec2 for unassigning ipv6 addresses or prefixes on network interface",
+		},
+	)
+
+	ec2UnassignIPv6AddressAPIErrCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_unassign_ipv6_address_api_err_count",
+			Help: "The number of errors encountered while unassigning ipv6 addresses or prefixes on network interface",
+		},
+	)
+
 	ec2DetachNetworkInterfaceAPICallCnt = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Name: "ec2_detach_network_interface_api_req_count",
@@ -226,6 +257,20 @@ var (
 		},
 	)
 
+	ec2DescribeInstanceTypesAPICallCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_describe_instance_types_api_req_count",
+			Help: "The number of calls made to EC2 for describing instance types",
+		},
+	)
+
+	ec2DescribeInstanceTypesAPIErrCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_describe_instance_types_api_err_count",
+			Help: "The number of errors encountered while describing instance types",
+		},
+	)
+
 	ec2AssociateTrunkInterfaceAPICallCnt = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Name: "ec2_associate_trunk_interface_api_req_count",
@@ -290,6 +335,22 @@ var (
 		},
 	)
 
+	ec2ClientQPS = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ec2_client_qps",
+			Help: "The current rate limit of the EC2 API calls made by the client",
+		},
+		[]string{"client"},
+	)
+
+	ec2ClientThrottledCnt = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ec2_client_throttled_count",
+			Help: "The number of EC2 API calls made by the client that were throttled",
+		},
+		[]string{"client"},
+	)
+
 	prometheusRegistered = false
 )
 
@@ -313,19 +374,27 @@ func prometheusRegister() {
 			numUnassignedSecondaryIPAddress,
 			ec2AssignPrivateIPAddressAPICallCnt,
 			ec2AssignPrivateIPAddressAPIErrCnt,
+			ec2AssignIPv6AddressAPICallCnt,
+			ec2AssignIPv6AddressAPIErrCnt,
+			ec2UnassignIPv6AddressAPICallCnt,
+			ec2UnassignIPv6AddressAPIErrCnt,
 			ec2DetachNetworkInterfaceAPICallCnt,
 			ec2DetachNetworkInterfaceAPIErrCnt,
 			ec2DeleteNetworkInterfaceAPICallCnt,
 			ec2DeleteNetworkInterfaceAPIErrCnt,
 			ec2DescribeSubnetsAPICallCnt,
 			ec2DescribeSubnetsAPIErrCnt,
+			ec2DescribeInstanceTypesAPICallCnt,
+			ec2DescribeInstanceTypesAPIErrCnt,
 			ec2AssociateTrunkInterfaceAPICallCnt,
 			ec2AssociateTrunkInterfaceAPIErrCnt,
 			ec2describeTrunkInterfaceAssociationAPICallCnt,
 			ec2describeTrunkInterfaceAssociationAPIErrCnt,
 			ec2modifyNetworkInterfaceAttributeAPICallCnt,
 			ec2modifyNetworkInterfaceAttributeAPIErrCnt,
-			ec2APICallLatencies)
+			ec2APICallLatencies,
+			ec2ClientQPS,
+			ec2ClientThrottledCnt)
 
 		prometheusRegistered = true
 	}
@@ -336,11 +405,20 @@ type ec2Wrapper struct {
 	instanceServiceClient *ec2.EC2
 	userServiceClient     *ec2.EC2
 	accountID             string
+	// priority overrides the priority of the operations when set
+	priority *Priority
+}
+
+// ClientRateLimits are the rate limits of the EC2 API calls of the user and the instance service clients
+type ClientRateLimits struct {
+	User     utils.AdaptiveRateLimiterConfig
+	Instance utils.AdaptiveRateLimiterConfig
 }
 
 // NewEC2Wrapper takes the roleARN that will be assumed to make all the EC2 API Calls, if no roleARN
-// is passed then the ec2 client will be initialized with the instance's service role account.
-func NewEC2Wrapper(roleARN string, log logr.Logger) (EC2Wrapper, error) {
+// is passed then the ec2 client will be initialized with the instance's service role account. The rate
+// of the EC2 API calls of each client adapts to the throttling within the client's rate limits
+func NewEC2Wrapper(roleARN string, rateLimits ClientRateLimits, log logr.Logger) (EC2Wrapper, error) {
 	// Register the metrics
 	prometheusRegister()
 
@@ -354,16 +432,20 @@ func NewEC2Wrapper(roleARN string, log logr.Logger) (EC2Wrapper, error) {
 	// Role ARN is passed, assume the role ARN to make EC2 API Calls
 	if roleARN != "" {
 		// Create the instance service client with low QPS, it will be only used fro associate branch to trunk calls
-		instanceServiceClient, err := ec2Wrapper.getInstanceServiceClient(config.InstanceServiceClientQPS,
-			config.InstanceServiceClientBurst, instanceSession)
+		instanceLimiter, err := newClientRateLimiter("instance", rateLimits.Instance)
 		if err != nil {
 			return nil, err
 		}
-		ec2Wrapper.instanceServiceClient = instanceServiceClient
+		ec2Wrapper.instanceServiceClient = ec2Wrapper.getInstanceServiceClient("instance", instanceLimiter,
+			instanceSession)
 
 		// Create the user service client with higher QPS, this will be used to make rest of the EC2 API Calls
+		userLimiter, err := newClientRateLimiter("user", rateLimits.User)
+		if err != nil {
+			return nil, err
+		}
 		userServiceClient, err := ec2Wrapper.getClientUsingAssumedRole(*instanceSession.Config.Region, roleARN,
-			config.UserServiceClientQPS, config.UserServiceClientQPSBurst)
+			userLimiter)
 		if err != nil {
 			return nil, err
 		}
@@ -371,11 +453,11 @@ func NewEC2Wrapper(roleARN string, log logr.Logger) (EC2Wrapper, error) {
 	} else {
 		// Role ARN is not provided, assuming that instance service client is whitelisted for ENI branching and use
 		// the instance service client as the user service client with higher QPS.
-		instanceServiceClient, err := ec2Wrapper.getInstanceServiceClient(config.UserServiceClientQPS,
-			config.UserServiceClientQPSBurst, instanceSession)
+		userLimiter, err := newClientRateLimiter("user", rateLimits.User)
 		if err != nil {
 			return nil, err
 		}
+		instanceServiceClient := ec2Wrapper.getInstanceServiceClient("user", userLimiter, instanceSession)
 		ec2Wrapper.instanceServiceClient = instanceServiceClient
 		ec2Wrapper.userServiceClient = instanceServiceClient
 	}
@@ -383,6 +465,33 @@ func NewEC2Wrapper(roleARN string, log logr.Logger) (EC2Wrapper, error) {
 	return ec2Wrapper, nil
 }
 
+// newClientRateLimiter returns the adaptive rate limiter of the client exporting its rate as a metric
+func newClientRateLimiter(client string, limits utils.AdaptiveRateLimiterConfig) (*utils.AdaptiveRateLimiter, error) {
+	limiter, err := utils.NewAdaptiveRateLimiter(limits, func(qps float64) {
+		ec2ClientQPS.WithLabelValues(client).Set(qps)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate limits for the %s service client: %v", client, err)
+	}
+	return limiter, nil
+}
+
+// adaptRateHandler reports the outcome of each attempt of the EC2 API calls to the client's rate limiter, so
+// the rate is decreased on throttling and increased on success
+func adaptRateHandler(client string, limiter *utils.AdaptiveRateLimiter) request.NamedHandler {
+	return request.NamedHandler{
+		Name: "vpc-resource-controller/adaptive-rate-limit",
+		Fn: func(r *request.Request) {
+			if r.Error == nil {
+				limiter.OnSuccess()
+			} else if request.IsErrorThrottle(r.Error) {
+				ec2ClientThrottledCnt.WithLabelValues(client).Inc()
+				limiter.OnThrottle()
+			}
+		},
+	}
+}
+
 func (e *ec2Wrapper) getInstanceSession() (instanceSession *session.Session, err error) {
 	// Create a new session
 	instanceSession = session.Must(session.NewSession())
@@ -412,17 +521,19 @@ func (e *ec2Wrapper) getInstanceSession() (instanceSession *session.Session, err
 	return instanceSession, nil
 }
 
-func (e *ec2Wrapper) getInstanceServiceClient(qps int, burst int, instanceSession *session.Session) (*ec2.EC2, error) {
-	instanceClient, err := utils.NewRateLimitedClient(qps, burst)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create reate limited client with %d qps and %d burst: %v",
-			qps, burst, err)
-	}
-	return ec2.New(instanceSession, aws.NewConfig().WithMaxRetries(MaxRetries).
-		WithRegion(*instanceSession.Config.Region).WithHTTPClient(instanceClient)), nil
+// getInstanceServiceClient returns the client using the instance's service role, the throttling is reported with
+// the label of the client whose rate limiter is used
+func (e *ec2Wrapper) getInstanceServiceClient(client string, limiter *utils.AdaptiveRateLimiter,
+	instanceSession *session.Session) *ec2.EC2 {
+	instanceClient := utils.NewAdaptiveRateLimitedClient(limiter)
+	serviceClient := ec2.New(instanceSession, aws.NewConfig().WithMaxRetries(MaxRetries).
+		WithRegion(*instanceSession.Config.Region).WithHTTPClient(instanceClient))
+	serviceClient.Handlers.CompleteAttempt.PushBackNamed(adaptRateHandler(client, limiter))
+	return serviceClient
 }
 
-func (e *ec2Wrapper) getClientUsingAssumedRole(instanceRegion string, roleARN string, qps int, burst int) (*ec2.EC2, error) {
+func (e *ec2Wrapper) getClientUsingAssumedRole(instanceRegion string, roleARN string,
+	limiter *utils.AdaptiveRateLimiter) (*ec2.EC2, error) {
 	var providers []credentials.Provider
 
 	userStsSession := session.Must(session.NewSession())
@@ -430,11 +541,8 @@ func (e *ec2Wrapper) getClientUsingAssumedRole(instanceRegion string, roleARN st
 	injectUserAgent(&userStsSession.Handlers)
 
 	// Create a rate limited http client for the
-	client, err := utils.NewRateLimitedClient(qps, burst)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create reate limited client with %d qps and %d burst: %v", qps, burst, err)
-	}
-	e.log.Info("created rate limited http client", "qps", qps, "burst", burst)
+	client := utils.NewAdaptiveRateLimitedClient(limiter)
+	e.log.Info("created rate limited http client", "qps", limiter.QPS())
 
 	// Get the regional sts end point
 	regionalSTSEndpoint, err := endpoints.DefaultResolver().
@@ -477,7 +585,10 @@ func (e *ec2Wrapper) getClientUsingAssumedRole(instanceRegion string, roleARN st
 
 	userStsSession.Config.Credentials = credentials.NewChainCredentials(providers)
 
-	return ec2.New(userStsSession, aws.NewConfig().WithHTTPClient(client)), nil
+	userServiceClient := ec2.New(userStsSession, aws.NewConfig().WithHTTPClient(client))
+	userServiceClient.Handlers.CompleteAttempt.PushBackNamed(adaptRateHandler("user", limiter))
+
+	return userServiceClient, nil
 
 }
 
@@ -487,7 +598,7 @@ func timeSinceMs(start time.Time) float64 {
 
 func (e *ec2Wrapper) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
 	start := time.Now()
-	describeInstancesOutput, err := e.userServiceClient.DescribeInstances(input)
+	describeInstancesOutput, err := e.userServiceClient.DescribeInstancesWithContext(e.priorityContext("DescribeInstances"), input)
 	ec2APICallLatencies.WithLabelValues("describe_network_interface").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -504,7 +615,7 @@ func (e *ec2Wrapper) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.
 
 func (e *ec2Wrapper) CreateNetworkInterface(input *ec2.CreateNetworkInterfaceInput) (*ec2.CreateNetworkInterfaceOutput, error) {
 	start := time.Now()
-	createNetworkInterfaceOutput, err := e.userServiceClient.CreateNetworkInterface(input)
+	createNetworkInterfaceOutput, err := e.userServiceClient.CreateNetworkInterfaceWithContext(e.priorityContext("CreateNetworkInterface"), input)
 	ec2APICallLatencies.WithLabelValues("create_network_interface").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -522,7 +633,7 @@ func (e *ec2Wrapper) CreateNetworkInterface(input *ec2.CreateNetworkInterfaceInp
 
 func (e *ec2Wrapper) AttachNetworkInterface(input *ec2.AttachNetworkInterfaceInput) (*ec2.AttachNetworkInterfaceOutput, error) {
 	start := time.Now()
-	attachNetworkInterfaceOutput, err := e.userServiceClient.AttachNetworkInterface(input)
+	attachNetworkInterfaceOutput, err := e.userServiceClient.AttachNetworkInterfaceWithContext(e.priorityContext("AttachNetworkInterface"), input)
 	ec2APICallLatencies.WithLabelValues("attach_network_interface").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -539,7 +650,7 @@ func (e *ec2Wrapper) AttachNetworkInterface(input *ec2.AttachNetworkInterfaceInp
 
 func (e *ec2Wrapper) DeleteNetworkInterface(input *ec2.DeleteNetworkInterfaceInput) (*ec2.DeleteNetworkInterfaceOutput, error) {
 	start := time.Now()
-	deleteNetworkInterfaceOutput, err := e.userServiceClient.DeleteNetworkInterface(input)
+	deleteNetworkInterfaceOutput, err := e.userServiceClient.DeleteNetworkInterfaceWithContext(e.priorityContext("DeleteNetworkInterface"), input)
 	ec2APICallLatencies.WithLabelValues("delete_network_interface").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -556,7 +667,7 @@ func (e *ec2Wrapper) DeleteNetworkInterface(input *ec2.DeleteNetworkInterfaceInp
 
 func (e *ec2Wrapper) DetachNetworkInterface(input *ec2.DetachNetworkInterfaceInput) (*ec2.DetachNetworkInterfaceOutput, error) {
 	start := time.Now()
-	detachNetworkInterfaceOutput, err := e.userServiceClient.DetachNetworkInterface(input)
+	detachNetworkInterfaceOutput, err := e.userServiceClient.DetachNetworkInterfaceWithContext(e.priorityContext("DetachNetworkInterface"), input)
 	ec2APICallLatencies.WithLabelValues("detach_network_interface").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -573,7 +684,7 @@ func (e *ec2Wrapper) DetachNetworkInterface(input *ec2.DetachNetworkInterfaceInp
 
 func (e *ec2Wrapper) DescribeNetworkInterfaces(input *ec2.DescribeNetworkInterfacesInput) (*ec2.DescribeNetworkInterfacesOutput, error) {
 	start := time.Now()
-	describeNetworkInterfacesOutput, err := e.userServiceClient.DescribeNetworkInterfaces(input)
+	describeNetworkInterfacesOutput, err := e.userServiceClient.DescribeNetworkInterfacesWithContext(e.priorityContext("DescribeNetworkInterfaces"), input)
 	ec2APICallLatencies.WithLabelValues("describe_network_interface").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -590,7 +701,7 @@ func (e *ec2Wrapper) DescribeNetworkInterfaces(input *ec2.DescribeNetworkInterfa
 
 func (e *ec2Wrapper) AssignPrivateIPAddresses(input *ec2.AssignPrivateIpAddressesInput) (*ec2.AssignPrivateIpAddressesOutput, error) {
 	start := time.Now()
-	assignPrivateIPAddressesOutput, err := e.userServiceClient.AssignPrivateIpAddresses(input)
+	assignPrivateIPAddressesOutput, err := e.userServiceClient.AssignPrivateIpAddressesWithContext(e.priorityContext("AssignPrivateIpAddresses"), input)
 	ec2APICallLatencies.WithLabelValues("assign_private_ip").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -608,7 +719,7 @@ func (e *ec2Wrapper) AssignPrivateIPAddresses(input *ec2.AssignPrivateIpAddresse
 
 func (e *ec2Wrapper) UnassignPrivateIPAddresses(input *ec2.UnassignPrivateIpAddressesInput) (*ec2.UnassignPrivateIpAddressesOutput, error) {
 	start := time.Now()
-	unAssignPrivateIPAddressesOutput, err := e.userServiceClient.UnassignPrivateIpAddresses(input)
+	unAssignPrivateIPAddressesOutput, err := e.userServiceClient.UnassignPrivateIpAddressesWithContext(e.priorityContext("UnassignPrivateIpAddresses"), input)
 	ec2APICallLatencies.WithLabelValues("unassign_private_ip").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -624,9 +735,43 @@ func (e *ec2Wrapper) UnassignPrivateIPAddresses(input *ec2.UnassignPrivateIpAddr
 	return unAssignPrivateIPAddressesOutput, err
 }
 
+func (e *ec2Wrapper) AssignIPv6Addresses(input *ec2.AssignIpv6AddressesInput) (*ec2.AssignIpv6AddressesOutput, error) {
+	start := time.Now()
+	assignIPv6AddressesOutput, err := e.userServiceClient.AssignIpv6AddressesWithContext(e.priorityContext("AssignIpv6Addresses"), input)
+	ec2APICallLatencies.WithLabelValues("assign_ipv6_address").Observe(timeSinceMs(start))
+
+	// Metric updates
+	ec2APICallCnt.Inc()
+	ec2AssignIPv6AddressAPICallCnt.Inc()
+
+	if err != nil {
+		ec2APIErrCnt.Inc()
+		ec2AssignIPv6AddressAPIErrCnt.Inc()
+	}
+
+	return assignIPv6AddressesOutput, err
+}
+
+func (e *ec2Wrapper) UnassignIPv6Addresses(input *ec2.UnassignIpv6AddressesInput) (*ec2.UnassignIpv6AddressesOutput, error) {
+	start := time.Now()
+	unassignIPv6AddressesOutput, err := e.userServiceClient.UnassignIpv6AddressesWithContext(e.priorityContext("UnassignIpv6Addresses"), input)
+	ec2APICallLatencies.WithLabelValues("unassign_ipv6_address").Observe(timeSinceMs(start))
+
+	// Metric updates
+	ec2APICallCnt.Inc()
+	ec2UnassignIPv6AddressAPICallCnt.Inc()
+
+	if err != nil {
+		ec2APIErrCnt.Inc()
+		ec2UnassignIPv6AddressAPIErrCnt.Inc()
+	}
+
+	return unassignIPv6AddressesOutput, err
+}
+
 func (e *ec2Wrapper) CreateTags(input *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
 	start := time.Now()
-	createTagsOutput, err := e.userServiceClient.CreateTags(input)
+	createTagsOutput, err := e.userServiceClient.CreateTagsWithContext(e.priorityContext("CreateTags"), input)
 	ec2APICallLatencies.WithLabelValues("create_tags").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -643,7 +788,7 @@ func (e *ec2Wrapper) CreateTags(input *ec2.CreateTagsInput) (*ec2.CreateTagsOutp
 
 func (e *ec2Wrapper) DescribeSubnets(input *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error) {
 	start := time.Now()
-	output, err := e.userServiceClient.DescribeSubnets(input)
+	output, err := e.userServiceClient.DescribeSubnetsWithContext(e.priorityContext("DescribeSubnets"), input)
 	ec2APICallLatencies.WithLabelValues("describe_subnets").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -658,10 +803,27 @@ func (e *ec2Wrapper) DescribeSubnets(input *ec2.DescribeSubnetsInput) (*ec2.Desc
 	return output, err
 }
 
+func (e *ec2Wrapper) DescribeInstanceTypes(input *ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error) {
+	start := time.Now()
+	output, err := e.userServiceClient.DescribeInstanceTypesWithContext(e.priorityContext("DescribeInstanceTypes"), input)
+	ec2APICallLatencies.WithLabelValues("describe_instance_types").Observe(timeSinceMs(start))
+
+	// Metric updates
+	ec2APICallCnt.Inc()
+	ec2DescribeInstanceTypesAPICallCnt.Inc()
+
+	if err != nil {
+		ec2APIErrCnt.Inc()
+		ec2DescribeInstanceTypesAPIErrCnt.Inc()
+	}
+
+	return output, err
+}
+
 // DescribeTrunkInterfaceAssociations cannot be used as it's not public yet.
 func (e *ec2Wrapper) DescribeTrunkInterfaceAssociations(input *ec2.DescribeTrunkInterfaceAssociationsInput) (*ec2.DescribeTrunkInterfaceAssociationsOutput, error) {
 	start := time.Now()
-	describeTrunkInterfaceAssociationInput, err := e.instanceServiceClient.DescribeTrunkInterfaceAssociations(input)
+	describeTrunkInterfaceAssociationInput, err := e.instanceServiceClient.DescribeTrunkInterfaceAssociationsWithContext(e.priorityContext("DescribeTrunkInterfaceAssociations"), input)
 	ec2APICallLatencies.WithLabelValues("describe_trunk_association").Observe(timeSinceMs(start))
 
 	// Metric Update
@@ -678,7 +840,7 @@ func (e *ec2Wrapper) DescribeTrunkInterfaceAssociations(input *ec2.DescribeTrunk
 
 func (e *ec2Wrapper) AssociateTrunkInterface(input *ec2.AssociateTrunkInterfaceInput) (*ec2.AssociateTrunkInterfaceOutput, error) {
 	start := time.Now()
-	associateTrunkInterfaceOutput, err := e.instanceServiceClient.AssociateTrunkInterface(input)
+	associateTrunkInterfaceOutput, err := e.instanceServiceClient.AssociateTrunkInterfaceWithContext(e.priorityContext("AssociateTrunkInterface"), input)
 	ec2APICallLatencies.WithLabelValues("associate_trunk_to_branch").Observe(timeSinceMs(start))
 
 	// Metric Update
@@ -695,7 +857,7 @@ func (e *ec2Wrapper) AssociateTrunkInterface(input *ec2.AssociateTrunkInterfaceI
 
 func (e *ec2Wrapper) ModifyNetworkInterfaceAttribute(input *ec2.ModifyNetworkInterfaceAttributeInput) (*ec2.ModifyNetworkInterfaceAttributeOutput, error) {
 	start := time.Now()
-	modifyNetworkInterfaceAttributeOutput, err := e.userServiceClient.ModifyNetworkInterfaceAttribute(input)
+	modifyNetworkInterfaceAttributeOutput, err := e.userServiceClient.ModifyNetworkInterfaceAttributeWithContext(e.priorityContext("ModifyNetworkInterfaceAttribute"), input)
 	ec2APICallLatencies.WithLabelValues("modify_network_interface_attribute").Observe(timeSinceMs(start))
 
 	// Metric Update
@@ -713,7 +875,7 @@ func (e *ec2Wrapper) ModifyNetworkInterfaceAttribute(input *ec2.ModifyNetworkInt
 func (e *ec2Wrapper) CreateNetworkInterfacePermission(input *ec2.CreateNetworkInterfacePermissionInput) (*ec2.CreateNetworkInterfacePermissionOutput, error) {
 	// Add the account ID of the instance running the controller
 	input.AwsAccountId = &e.accountID
-	output, err := e.userServiceClient.CreateNetworkInterfacePermission(input)
+	output, err := e.userServiceClient.CreateNetworkInterfacePermissionWithContext(e.priorityContext("CreateNetworkInterfacePermission"), input)
 
 	// Metric Update
 	ec2APICallCnt.Inc()
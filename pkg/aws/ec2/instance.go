@@ -20,6 +20,10 @@ import (
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
 )
 
 // ec2Instance stores all the information that can be shared across the providers for an instance
@@ -46,8 +50,20 @@ type ec2Instance struct {
 	currentInstanceSecurityGroup []string
 	// subnetMask is the mask of the subnet CIDR block
 	subnetMask string
-	// deviceIndexes is the list of indexes used by the EC2 Instance
-	deviceIndexes []bool
+	// subnetV6Mask is the mask of the subnet IPv6 CIDR block, empty if the subnet has no IPv6 CIDR block
+	subnetV6Mask string
+	// primaryIPv6Address is the IPv6 address of the instance, not available to the pods
+	primaryIPv6Address string
+	// deviceIndexes is the list of indexes used by the EC2 Instance per network card index
+	deviceIndexes map[int64][]bool
+	// limits are the limits of the instance type with the operator overrides loaded with the instance details
+	limits *vpc.VPCLimits
+	// networkCards is the list of network cards of the instance type
+	networkCards []vpc.NetworkCard
+	// defaultNetworkCard is the network card index of the primary network interface
+	defaultNetworkCard int64
+	// networkCardPolicy chooses the network card of the network interfaces attached by the controller
+	networkCardPolicy NetworkCardPolicy
 	// instanceSecurityGroups is the security group used by the primary network interface
 	instanceSecurityGroups []string
 	// primaryENIID is the ID of the primary network interface of the instance
@@ -56,24 +72,71 @@ type ec2Instance struct {
 	newCustomNetworkingSubnetID string
 	// newCustomNetworkingSecurityGroup is the security group from the ENIConfig
 	newCustomNetworkingSecurityGroup []string
+	// trunkSubnetID is the subnet ID in which the trunk ENI should be created, if different from the
+	// current subnet of the instance
+	trunkSubnetID string
+	// trunkSecurityGroups is the security group to be used by the trunk ENI, if different from the
+	// current security group of the instance
+	trunkSecurityGroups []string
+	// rejectedTrunkSubnetID is the trunk subnet found outside the availability zone of the instance when the
+	// instance details were loaded, the trunk ENI is created in the current subnet of the instance instead
+	rejectedTrunkSubnetID string
+	// fallbackSubnetIDs is the ordered list of subnet IDs to use when the current subnet runs out of IP addresses
+	fallbackSubnetIDs []string
+	// fallbackSubnets is the list of fallback subnets in the same availability zone as the instance
+	fallbackSubnets []Subnet
+	// trunkEnabled is true if the node is labelled by the VPC CNI as capable of attaching a trunk ENI
+	trunkEnabled bool
+}
+
+// Subnet is a subnet in which the network interfaces of the instance can be created
+type Subnet struct {
+	// ID is the subnet ID
+	ID string
+	// CIDRBlock is the CIDR block of the subnet
+	CIDRBlock string
+}
+
+// NetworkCardPolicy is the policy choosing the network card of the network interfaces attached by the controller,
+// one of the config network card policies. An empty policy is the default policy
+type NetworkCardPolicy struct {
+	// Trunk is the policy of the trunk network interface
+	Trunk string
+	// ENI is the policy of the secondary network interfaces
+	ENI string
 }
 
 // EC2Instance exposes the immutable details of an ec2 instance and common operations on an EC2 Instance
 type EC2Instance interface {
 	LoadDetails(ec2APIHelper api.EC2APIHelper) error
-	GetHighestUnusedDeviceIndex() (int64, error)
-	FreeDeviceIndex(index int64)
+	GetHighestUnusedDeviceIndex(networkCardIndex int64) (int64, error)
+	FreeDeviceIndex(networkCardIndex int64, index int64)
+	RefreshDeviceIndexes(ec2APIHelper api.EC2APIHelper) error
+	Limits() (*vpc.VPCLimits, bool)
+	NetworkCards() []vpc.NetworkCard
+	SetNetworkCardPolicy(policy NetworkCardPolicy)
+	TrunkNetworkCardIndex() int64
+	ENINetworkCardIndex() int64
 	Name() string
 	Os() string
 	Type() string
 	InstanceID() string
 	SubnetID() string
 	SubnetMask() string
+	SubnetV6Mask() string
+	PrimaryIPv6Address() string
 	SubnetCidrBlock() string
 	PrimaryNetworkInterfaceID() string
 	InstanceSecurityGroup() []string
 	SetNewCustomNetworkingSpec(subnetID string, securityGroup []string)
 	UpdateCurrentSubnetAndCidrBlock(helper api.EC2APIHelper) error
+	SetTrunkNetworkingSpec(subnetID string, securityGroup []string)
+	TrunkSubnetID() string
+	TrunkSecurityGroup() []string
+	SetFallbackSubnets(subnetIDs []string)
+	CandidateSubnets() []Subnet
+	SetTrunkEnabled(enabled bool)
+	IsTrunkEnabled() bool
 }
 
 // NewEC2Instance returns a new EC2 Instance type
@@ -111,20 +174,48 @@ func (i *ec2Instance) LoadDetails(ec2APIHelper api.EC2APIHelper) error {
 	i.instanceSubnetCidrBlock = *instanceSubnet.CidrBlock
 
 	i.subnetMask = strings.Split(i.instanceSubnetCidrBlock, "/")[1]
+	for _, association := range instanceSubnet.Ipv6CidrBlockAssociationSet {
+		cidrBlock := aws.StringValue(association.Ipv6CidrBlock)
+		if strings.Contains(cidrBlock, "/") {
+			i.subnetV6Mask = strings.Split(cidrBlock, "/")[1]
+			break
+		}
+	}
+	i.primaryIPv6Address = aws.StringValue(instance.Ipv6Address)
 	i.instanceType = *instance.InstanceType
-	limits, ok := vpc.Limits[i.instanceType]
+	limits, ok := vpc.GetLimits(i.instanceType)
 	if !ok {
-		return fmt.Errorf("unsupported instance type, couldn't find ENI Limit for instance %s", i.instanceType)
+		// The instance type is missing from the generated table, discover its limits from the EC2 API
+		discovered, discoveredCards, err := ec2APIHelper.GetInstanceTypeLimits(i.instanceType)
+		if err != nil {
+			return fmt.Errorf("unsupported instance type, couldn't find ENI Limit for instance %s: %v",
+				i.instanceType, err)
+		}
+		vpc.SetDiscoveredLimits(i.instanceType, discovered, discoveredCards)
+		// Apply the operator overrides to the discovered limits
+		limits, _ = vpc.GetLimits(i.instanceType)
 	}
 
-	i.deviceIndexes = make([]bool, limits.Interface)
+	// The overrides loaded later apply to the instances loaded after them
+	i.limits = limits
+	i.networkCards = vpc.GetNetworkCards(i.instanceType)
+	if len(i.networkCards) == 0 {
+		i.networkCards = []vpc.NetworkCard{{Index: 0, Interface: limits.Interface}}
+	}
+	i.deviceIndexes = make(map[int64][]bool, len(i.networkCards))
+	for _, card := range i.networkCards {
+		i.deviceIndexes[int64(card.Index)] = make([]bool, card.Interface)
+		// Device index 0 is reserved for the primary network interface
+		i.markDeviceIndex(int64(card.Index), 0)
+	}
 	for _, nwInterface := range instance.NetworkInterfaces {
-		index := nwInterface.Attachment.DeviceIndex
-		i.deviceIndexes[*index] = true
+		networkCardIndex := aws.Int64Value(nwInterface.Attachment.NetworkCardIndex)
+		i.markDeviceIndex(networkCardIndex, aws.Int64Value(nwInterface.Attachment.DeviceIndex))
 
 		// Load the Security group of the primary network interface
 		if i.instanceSecurityGroups == nil && *nwInterface.PrivateIpAddress == *instance.PrivateIpAddress {
 			i.primaryENIID = *nwInterface.NetworkInterfaceId
+			i.defaultNetworkCard = networkCardIndex
 			// TODO: Group can change, should be refreshed each time we want to use this
 			for _, group := range nwInterface.Groups {
 				i.instanceSecurityGroups = append(i.instanceSecurityGroups, *group.GroupId)
@@ -132,9 +223,66 @@ func (i *ec2Instance) LoadDetails(ec2APIHelper api.EC2APIHelper) error {
 		}
 	}
 
+	if err := i.loadFallbackSubnets(ec2APIHelper, aws.StringValue(instanceSubnet.AvailabilityZone)); err != nil {
+		return err
+	}
+	if err := i.checkTrunkSubnet(ec2APIHelper, aws.StringValue(instanceSubnet.AvailabilityZone)); err != nil {
+		return err
+	}
+
 	return i.updateCurrentSubnetAndCidrBlock(ec2APIHelper)
 }
 
+// loadFallbackSubnets loads the CIDR block of the fallback subnets, subnets outside the availability zone of the
+// instance are ignored as network interfaces can only be created in the instance's availability zone
+func (i *ec2Instance) loadFallbackSubnets(ec2APIHelper api.EC2APIHelper, availabilityZone string) error {
+	i.fallbackSubnets = nil
+	if len(i.fallbackSubnetIDs) == 0 {
+		return nil
+	}
+
+	subnets, err := ec2APIHelper.GetSubnets(aws.StringSlice(i.fallbackSubnetIDs))
+	if err != nil {
+		return fmt.Errorf("failed to describe fallback subnets %v: %v", i.fallbackSubnetIDs, err)
+	}
+
+	subnetByID := map[string]*awsEC2.Subnet{}
+	for _, subnet := range subnets {
+		subnetByID[aws.StringValue(subnet.SubnetId)] = subnet
+	}
+
+	// Retain the order in which the fallback subnets were configured
+	for _, subnetID := range i.fallbackSubnetIDs {
+		subnet, found := subnetByID[subnetID]
+		if !found || subnet.CidrBlock == nil ||
+			aws.StringValue(subnet.AvailabilityZone) != availabilityZone {
+			continue
+		}
+		i.fallbackSubnets = append(i.fallbackSubnets, Subnet{ID: subnetID, CIDRBlock: *subnet.CidrBlock})
+	}
+
+	return nil
+}
+
+// checkTrunkSubnet rejects the trunk subnet if it's outside the availability zone of the instance, as network
+// interfaces can only be created in the instance's availability zone
+func (i *ec2Instance) checkTrunkSubnet(ec2APIHelper api.EC2APIHelper, availabilityZone string) error {
+	i.rejectedTrunkSubnetID = ""
+	if i.trunkSubnetID == "" {
+		return nil
+	}
+
+	trunkSubnet, err := ec2APIHelper.GetSubnet(&i.trunkSubnetID)
+	if err != nil {
+		return fmt.Errorf("failed to describe trunk subnet %s: %v", i.trunkSubnetID, err)
+	}
+	if trunkSubnet == nil || aws.StringValue(trunkSubnet.AvailabilityZone) != availabilityZone {
+		i.rejectedTrunkSubnetID = i.trunkSubnetID
+	}
+
+	return nil
+}
+
 // Os returns the os of the instance
 func (i *ec2Instance) Os() string {
 	return i.os
@@ -183,27 +331,171 @@ func (i *ec2Instance) InstanceSecurityGroup() []string {
 	return i.currentInstanceSecurityGroup
 }
 
-// GetHighestUnusedDeviceIndex assigns a free device index from the end of the list since IPAMD assigns indexes from
-// the beginning of the list
-func (i *ec2Instance) GetHighestUnusedDeviceIndex() (int64, error) {
+// TrunkSubnetID returns the subnet id in which the trunk ENI should be created. If no separate
+// subnet is configured for the trunk or the subnet is outside the availability zone of the instance,
+// the current subnet of the instance is returned
+func (i *ec2Instance) TrunkSubnetID() string {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	if i.trunkSubnetID != "" && i.trunkSubnetID != i.rejectedTrunkSubnetID {
+		return i.trunkSubnetID
+	}
+	return i.currentSubnetID
+}
+
+// TrunkSecurityGroup returns the security group to be used by the trunk ENI. If no separate
+// security group is configured for the trunk, the current instance security group is returned
+func (i *ec2Instance) TrunkSecurityGroup() []string {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	if len(i.trunkSecurityGroups) > 0 {
+		return i.trunkSecurityGroups
+	}
+	return i.currentInstanceSecurityGroup
+}
+
+// CandidateSubnets returns the current subnet of the instance followed by the fallback subnets in the order
+// in which new network interfaces should be created
+func (i *ec2Instance) CandidateSubnets() []Subnet {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	candidates := []Subnet{{ID: i.currentSubnetID, CIDRBlock: i.currentSubnetCIDRBlock}}
+	for _, subnet := range i.fallbackSubnets {
+		if subnet.ID != i.currentSubnetID {
+			candidates = append(candidates, subnet)
+		}
+	}
+	return candidates
+}
+
+// GetHighestUnusedDeviceIndex assigns a free device index of the network card from the end of the list since IPAMD
+// assigns indexes from the beginning of the list
+func (i *ec2Instance) GetHighestUnusedDeviceIndex(networkCardIndex int64) (int64, error) {
 	i.lock.Lock()
 	defer i.lock.Unlock()
 
-	for index := len(i.deviceIndexes) - 1; index >= 0; index-- {
-		if i.deviceIndexes[index] == false {
-			i.deviceIndexes[index] = true
+	deviceIndexes, ok := i.deviceIndexes[networkCardIndex]
+	if !ok {
+		return 0, fmt.Errorf("network card %d not found on instance type %s", networkCardIndex, i.instanceType)
+	}
+	for index := len(deviceIndexes) - 1; index >= 0; index-- {
+		if deviceIndexes[index] == false {
+			deviceIndexes[index] = true
 			return int64(index), nil
 		}
 	}
-	return 0, fmt.Errorf("no free device index found")
+	return 0, fmt.Errorf("no free device index found on network card %d", networkCardIndex)
 }
 
-// FreeDeviceIndex frees a device index from the list of managed index
-func (i *ec2Instance) FreeDeviceIndex(index int64) {
+// FreeDeviceIndex frees a device index of the network card from the list of managed index
+func (i *ec2Instance) FreeDeviceIndex(networkCardIndex int64, index int64) {
 	i.lock.Lock()
 	defer i.lock.Unlock()
 
-	i.deviceIndexes[index] = false
+	if deviceIndexes, ok := i.deviceIndexes[networkCardIndex]; ok && index >= 0 && index < int64(len(deviceIndexes)) {
+		deviceIndexes[index] = false
+	}
+}
+
+// markDeviceIndex marks the device index of the network card as used, indexes out of the range of the network
+// cards are ignored. Must be called with the lock held
+func (i *ec2Instance) markDeviceIndex(networkCardIndex int64, index int64) {
+	if deviceIndexes, ok := i.deviceIndexes[networkCardIndex]; ok && index >= 0 && index < int64(len(deviceIndexes)) {
+		deviceIndexes[index] = true
+	}
+}
+
+// Limits returns the limits of the instance type loaded with the instance details, returns false if the details
+// are not loaded
+func (i *ec2Instance) Limits() (*vpc.VPCLimits, bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return i.limits, i.limits != nil
+}
+
+// NetworkCards returns the network cards of the instance with their network interface limit
+func (i *ec2Instance) NetworkCards() []vpc.NetworkCard {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return i.networkCards
+}
+
+// SetNetworkCardPolicy sets the policy choosing the network card of the network interfaces
+func (i *ec2Instance) SetNetworkCardPolicy(policy NetworkCardPolicy) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.networkCardPolicy = policy
+}
+
+// TrunkNetworkCardIndex returns the network card index to attach the trunk network interface to
+func (i *ec2Instance) TrunkNetworkCardIndex() int64 {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return i.selectNetworkCard(i.networkCardPolicy.Trunk)
+}
+
+// ENINetworkCardIndex returns the network card index to attach the next secondary network interface to
+func (i *ec2Instance) ENINetworkCardIndex() int64 {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return i.selectNetworkCard(i.networkCardPolicy.ENI)
+}
+
+// selectNetworkCard returns the network card index chosen by the policy, the spread policy prefers the default
+// network card when several network cards have the same number of free device indexes. Must be called with the
+// lock held
+func (i *ec2Instance) selectNetworkCard(policy string) int64 {
+	if policy != config.NetworkCardPolicySpread {
+		return i.defaultNetworkCard
+	}
+
+	selected, maxFree := i.defaultNetworkCard, freeDeviceIndexes(i.deviceIndexes[i.defaultNetworkCard])
+	for _, card := range i.networkCards {
+		if free := freeDeviceIndexes(i.deviceIndexes[int64(card.Index)]); free > maxFree {
+			selected, maxFree = int64(card.Index), free
+		}
+	}
+	return selected
+}
+
+// freeDeviceIndexes returns the number of free device indexes
+func freeDeviceIndexes(deviceIndexes []bool) int {
+	free := 0
+	for _, used := range deviceIndexes {
+		if !used {
+			free++
+		}
+	}
+	return free
+}
+
+// RefreshDeviceIndexes marks the device indexes of the network interfaces currently attached to the instance as
+// used. On Linux nodes the VPC CNI attaches network interfaces independently of the controller, the indexes
+// must be refreshed before choosing a free index to avoid attaching two interfaces at the same index
+func (i *ec2Instance) RefreshDeviceIndexes(ec2APIHelper api.EC2APIHelper) error {
+	nwInterfaces, err := ec2APIHelper.GetInstanceNetworkInterface(&i.instanceID)
+	if err != nil {
+		return err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	for _, nwInterface := range nwInterfaces {
+		if nwInterface.Attachment == nil || nwInterface.Attachment.DeviceIndex == nil {
+			continue
+		}
+		i.markDeviceIndex(aws.Int64Value(nwInterface.Attachment.NetworkCardIndex), *nwInterface.Attachment.DeviceIndex)
+	}
+	return nil
 }
 
 func (i *ec2Instance) SubnetMask() string {
@@ -213,6 +505,22 @@ func (i *ec2Instance) SubnetMask() string {
 	return i.subnetMask
 }
 
+// SubnetV6Mask returns the mask of the IPv6 CIDR block of the instance subnet
+func (i *ec2Instance) SubnetV6Mask() string {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return i.subnetV6Mask
+}
+
+// PrimaryIPv6Address returns the IPv6 address of the instance
+func (i *ec2Instance) PrimaryIPv6Address() string {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return i.primaryIPv6Address
+}
+
 // SetNewCustomNetworkingSpec updates the subnet ID and subnet CIDR block for the instance
 func (i *ec2Instance) SetNewCustomNetworkingSpec(subnet string, securityGroups []string) {
 	i.lock.Lock()
@@ -222,6 +530,25 @@ func (i *ec2Instance) SetNewCustomNetworkingSpec(subnet string, securityGroups [
 	i.newCustomNetworkingSecurityGroup = securityGroups
 }
 
+// SetTrunkNetworkingSpec updates the subnet ID and security group to be used by the trunk ENI,
+// empty values revert to using the current subnet and security group of the instance
+func (i *ec2Instance) SetTrunkNetworkingSpec(subnet string, securityGroups []string) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.trunkSubnetID = subnet
+	i.trunkSecurityGroups = securityGroups
+}
+
+// SetFallbackSubnets sets the ordered list of subnets used when the current subnet runs out of IP addresses,
+// the subnet details are loaded along with the instance details
+func (i *ec2Instance) SetFallbackSubnets(subnetIDs []string) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.fallbackSubnetIDs = subnetIDs
+}
+
 // UpdateCurrentSubnetAndCidrBlock updates the subnet details under a write lock
 func (i *ec2Instance) UpdateCurrentSubnetAndCidrBlock(ec2APIHelper api.EC2APIHelper) error {
 	i.lock.Lock()
@@ -259,3 +586,19 @@ func (i *ec2Instance) updateCurrentSubnetAndCidrBlock(ec2APIHelper api.EC2APIHel
 
 	return nil
 }
+
+// SetTrunkEnabled sets whether the node can attach a trunk ENI for the pod-eni resource
+func (i *ec2Instance) SetTrunkEnabled(enabled bool) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.trunkEnabled = enabled
+}
+
+// IsTrunkEnabled returns true if the node can attach a trunk ENI for the pod-eni resource
+func (i *ec2Instance) IsTrunkEnabled() bool {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return i.trunkEnabled
+}
@@ -18,6 +18,8 @@ import (
 	"testing"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -117,9 +119,86 @@ func TestEc2Instance_LoadDetails(t *testing.T) {
 	assert.Equal(t, subnetID, ec2Instance.SubnetID())
 	assert.Equal(t, subnetCidrBlock, ec2Instance.SubnetCidrBlock())
 	assert.Equal(t, instanceType, ec2Instance.Type())
-	assert.Equal(t, []bool{true, false, true}, ec2Instance.deviceIndexes)
+	assert.Equal(t, map[int64][]bool{0: {true, false, true}}, ec2Instance.deviceIndexes)
 	assert.Equal(t, []string{securityGroup1, securityGroup2}, ec2Instance.InstanceSecurityGroup())
 	assert.Equal(t, primaryInterfaceID, ec2Instance.PrimaryNetworkInterfaceID())
+	assert.Empty(t, ec2Instance.SubnetV6Mask())
+}
+
+// TestEc2Instance_LoadDetails_LimitsOverrides tests the limits are loaded with the instance details, the overrides
+// loaded afterwards don't change the limits of the instance
+func TestEc2Instance_LoadDetails_LimitsOverrides(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer vpc.SetLimitsOverrides(map[string]vpc.LimitsOverride{})
+
+	ec2Instance, mockEC2ApiHelper := getMockInstance(ctrl)
+	_, found := ec2Instance.Limits()
+	assert.False(t, found)
+
+	mockEC2ApiHelper.EXPECT().GetInstanceDetails(&instanceID).Return(nwInterfaces, nil)
+	mockEC2ApiHelper.EXPECT().GetSubnet(&subnetID).Return(subnet, nil)
+
+	err := ec2Instance.LoadDetails(mockEC2ApiHelper)
+	assert.NoError(t, err)
+
+	ipv4PerInterface := vpc.Limits[instanceType].IPv4PerInterface + 10
+	vpc.SetLimitsOverrides(map[string]vpc.LimitsOverride{instanceType: {IPv4PerInterface: &ipv4PerInterface}})
+
+	limits, found := ec2Instance.Limits()
+	assert.True(t, found)
+	assert.Equal(t, vpc.Limits[instanceType].IPv4PerInterface, limits.IPv4PerInterface)
+}
+
+// TestEc2Instance_LoadDetails_DiscoverLimits tests the limits of an instance type missing from the generated limits
+// are discovered from the EC2 API and cached
+func TestEc2Instance_LoadDetails_DiscoverLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2Instance, mockEC2ApiHelper := getMockInstance(ctrl)
+	newInstanceType := "c99.large"
+	instance := *nwInterfaces
+	instance.InstanceType = &newInstanceType
+
+	mockEC2ApiHelper.EXPECT().GetInstanceDetails(&instanceID).Return(&instance, nil)
+	mockEC2ApiHelper.EXPECT().GetSubnet(&subnetID).Return(subnet, nil)
+	mockEC2ApiHelper.EXPECT().GetInstanceTypeLimits(newInstanceType).
+		Return(&vpc.VPCLimits{Interface: 4, IPv4PerInterface: 15}, nil, nil)
+
+	err := ec2Instance.LoadDetails(mockEC2ApiHelper)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64][]bool{0: {true, false, true, false}}, ec2Instance.deviceIndexes)
+
+	limits, found := vpc.GetLimits(newInstanceType)
+	assert.True(t, found)
+	assert.Equal(t, 15, limits.IPv4PerInterface)
+}
+
+// TestEc2Instance_LoadDetails_SubnetIPv6CidrBlock tests that the mask of the subnet IPv6 CIDR block and the IPv6
+// address of the instance are loaded
+func TestEc2Instance_LoadDetails_SubnetIPv6CidrBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2Instance, mockEC2ApiHelper := getMockInstance(ctrl)
+	dualStackSubnet := &ec2.Subnet{
+		CidrBlock: &subnetCidrBlock,
+		Ipv6CidrBlockAssociationSet: []*ec2.SubnetIpv6CidrBlockAssociation{
+			{Ipv6CidrBlock: aws.String("2600:1f13:a0d:a700::/64")},
+		},
+	}
+
+	dualStackInstance := *nwInterfaces
+	dualStackInstance.Ipv6Address = aws.String("2600:1f13:a0d:a700::1")
+
+	mockEC2ApiHelper.EXPECT().GetInstanceDetails(&instanceID).Return(&dualStackInstance, nil)
+	mockEC2ApiHelper.EXPECT().GetSubnet(&subnetID).Return(dualStackSubnet, nil)
+
+	err := ec2Instance.LoadDetails(mockEC2ApiHelper)
+	assert.NoError(t, err)
+	assert.Equal(t, "64", ec2Instance.SubnetV6Mask())
+	assert.Equal(t, "2600:1f13:a0d:a700::1", ec2Instance.PrimaryIPv6Address())
 }
 
 // TestEc2Instance_LoadDetails_InstanceDetailsIsNull tests error is returned if the instance details
@@ -241,8 +320,8 @@ func TestEc2Instance_LoadDetails_ErrGetSubnet(t *testing.T) {
 	assert.Error(t, mockError, err)
 }
 
-// TestEc2Instance_LoadDetails_InstanceENILimitNotFound tests that the instance ENI limit is not found then the operation
-// fails
+// TestEc2Instance_LoadDetails_InstanceENILimitNotFound tests that the instance ENI limit is not found and can't be
+// discovered then the operation fails
 func TestEc2Instance_LoadDetails_InstanceENILimitNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -255,6 +334,7 @@ func TestEc2Instance_LoadDetails_InstanceENILimitNotFound(t *testing.T) {
 
 	mockEC2ApiHelper.EXPECT().GetInstanceDetails(&instanceID).Return(nwInterfaces, nil)
 	mockEC2ApiHelper.EXPECT().GetSubnet(&subnetID).Return(subnet, nil)
+	mockEC2ApiHelper.EXPECT().GetInstanceTypeLimits(unsupportedInstance).Return(nil, nil, mockError)
 
 	err := ec2Instance.LoadDetails(mockEC2ApiHelper)
 	assert.NotNil(t, err)
@@ -269,9 +349,9 @@ func TestEc2Instance_GetHighestUnusedDeviceIndex(t *testing.T) {
 	defer ctrl.Finish()
 
 	ec2Instance, _ := getMockInstance(ctrl)
-	ec2Instance.deviceIndexes = []bool{true, false, true}
+	ec2Instance.deviceIndexes = map[int64][]bool{0: {true, false, true}}
 
-	index, err := ec2Instance.GetHighestUnusedDeviceIndex()
+	index, err := ec2Instance.GetHighestUnusedDeviceIndex(0)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), index)
 }
@@ -282,9 +362,22 @@ func TestEc2Instance_GetHighestUnusedDeviceIndex_NoFreeIndex(t *testing.T) {
 	defer ctrl.Finish()
 
 	ec2Instance, _ := getMockInstance(ctrl)
-	ec2Instance.deviceIndexes = []bool{true, true, true}
+	ec2Instance.deviceIndexes = map[int64][]bool{0: {true, true, true}}
 
-	_, err := ec2Instance.GetHighestUnusedDeviceIndex()
+	_, err := ec2Instance.GetHighestUnusedDeviceIndex(0)
+	assert.NotNil(t, err)
+}
+
+// TestEc2Instance_GetHighestUnusedDeviceIndex_UnknownNetworkCard tests that error is returned if the network card
+// doesn't exist on the instance
+func TestEc2Instance_GetHighestUnusedDeviceIndex_UnknownNetworkCard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2Instance, _ := getMockInstance(ctrl)
+	ec2Instance.deviceIndexes = map[int64][]bool{0: {true, false, false}}
+
+	_, err := ec2Instance.GetHighestUnusedDeviceIndex(1)
 	assert.NotNil(t, err)
 }
 
@@ -294,12 +387,97 @@ func TestEc2Instance_FreeDeviceIndex(t *testing.T) {
 	defer ctrl.Finish()
 
 	ec2Instance, _ := getMockInstance(ctrl)
-	ec2Instance.deviceIndexes = []bool{true, true, true}
+	ec2Instance.deviceIndexes = map[int64][]bool{0: {true, true, true}}
 
 	indexToFree := int64(2)
-	ec2Instance.FreeDeviceIndex(indexToFree)
+	ec2Instance.FreeDeviceIndex(0, indexToFree)
+
+	assert.False(t, ec2Instance.deviceIndexes[0][2])
+}
+
+// TestEc2Instance_RefreshDeviceIndexes tests the indexes of interfaces attached outside the controller are marked used
+func TestEc2Instance_RefreshDeviceIndexes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2Instance, mockEC2ApiHelper := getMockInstance(ctrl)
+	ec2Instance.deviceIndexes = map[int64][]bool{0: {true, false, false}}
+
+	mockEC2ApiHelper.EXPECT().GetInstanceNetworkInterface(&instanceID).Return([]*ec2.InstanceNetworkInterface{
+		{Attachment: &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(0)}},
+		{Attachment: &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(2)}},
+	}, nil)
+
+	err := ec2Instance.RefreshDeviceIndexes(mockEC2ApiHelper)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64][]bool{0: {true, false, true}}, ec2Instance.deviceIndexes)
+
+	index, err := ec2Instance.GetHighestUnusedDeviceIndex(0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), index)
+}
+
+// TestEc2Instance_LoadDetails_NetworkCards tests the device indexes are tracked per network card of the instance
+// type, with the device index 0 reserved on each network card
+func TestEc2Instance_LoadDetails_NetworkCards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2Instance, mockEC2ApiHelper := getMockInstance(ctrl)
+	multiCardInstanceType := "p4d.24xlarge"
+	instance := *nwInterfaces
+	instance.InstanceType = &multiCardInstanceType
+	instance.NetworkInterfaces = []*ec2.InstanceNetworkInterface{
+		nwInterfaces.NetworkInterfaces[0],
+		{
+			PrivateIpAddress: aws.String("192.168.1.2"),
+			Attachment: &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(1),
+				NetworkCardIndex: aws.Int64(2)},
+		},
+	}
+
+	mockEC2ApiHelper.EXPECT().GetInstanceDetails(&instanceID).Return(&instance, nil)
+	mockEC2ApiHelper.EXPECT().GetSubnet(&subnetID).Return(subnet, nil)
+
+	err := ec2Instance.LoadDetails(mockEC2ApiHelper)
+	assert.NoError(t, err)
+	assert.Equal(t, vpc.GetNetworkCards(multiCardInstanceType), ec2Instance.NetworkCards())
+	assert.Len(t, ec2Instance.deviceIndexes, 4)
+	for networkCardIndex, deviceIndexes := range ec2Instance.deviceIndexes {
+		assert.Len(t, deviceIndexes, 15)
+		assert.True(t, deviceIndexes[0])
+		assert.Equal(t, networkCardIndex == 2, deviceIndexes[1])
+	}
 
-	assert.False(t, ec2Instance.deviceIndexes[2])
+	index, err := ec2Instance.GetHighestUnusedDeviceIndex(2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(14), index)
+}
+
+// TestEc2Instance_NetworkCardIndex tests the default policy returns the network card of the primary network
+// interface and the spread policy returns the network card with the most free device indexes
+func TestEc2Instance_NetworkCardIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2Instance, _ := getMockInstance(ctrl)
+	ec2Instance.networkCards = []vpc.NetworkCard{{Index: 0, Interface: 3}, {Index: 1, Interface: 3},
+		{Index: 2, Interface: 3}}
+	ec2Instance.deviceIndexes = map[int64][]bool{0: {true, false, false}, 1: {true, true, false},
+		2: {true, false, false}}
+
+	assert.Equal(t, int64(0), ec2Instance.TrunkNetworkCardIndex())
+	assert.Equal(t, int64(0), ec2Instance.ENINetworkCardIndex())
+
+	ec2Instance.SetNetworkCardPolicy(NetworkCardPolicy{Trunk: config.NetworkCardPolicyDefault,
+		ENI: config.NetworkCardPolicySpread})
+	assert.Equal(t, int64(0), ec2Instance.TrunkNetworkCardIndex())
+	// Network cards 0 and 2 have the same free device indexes, the default network card is preferred
+	assert.Equal(t, int64(0), ec2Instance.ENINetworkCardIndex())
+
+	_, err := ec2Instance.GetHighestUnusedDeviceIndex(0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), ec2Instance.ENINetworkCardIndex())
 }
 
 // TestEc2Instance_E2E tests end to end workflow of loading the instance details and then assigning a free index and
@@ -318,14 +496,143 @@ func TestEc2Instance_E2E(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Check index is not used, assign index and verify index is used now
-	assert.False(t, ec2Instance.deviceIndexes[1])
-	index, err := ec2Instance.GetHighestUnusedDeviceIndex()
+	assert.False(t, ec2Instance.deviceIndexes[0][1])
+	index, err := ec2Instance.GetHighestUnusedDeviceIndex(0)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), index)
-	assert.True(t, ec2Instance.deviceIndexes[1])
+	assert.True(t, ec2Instance.deviceIndexes[0][1])
 
 	// Check index is used and then free that index
-	assert.True(t, ec2Instance.deviceIndexes[1])
-	ec2Instance.FreeDeviceIndex(deviceIndex0)
-	assert.False(t, ec2Instance.deviceIndexes[deviceIndex0])
+	assert.True(t, ec2Instance.deviceIndexes[0][1])
+	ec2Instance.FreeDeviceIndex(0, deviceIndex0)
+	assert.False(t, ec2Instance.deviceIndexes[0][deviceIndex0])
+}
+
+// TestEc2Instance_TrunkNetworkingSpec_NotSet tests that the trunk subnet and security group default to the current
+// subnet and security group of the instance when no separate trunk networking spec is set
+func TestEc2Instance_TrunkNetworkingSpec_NotSet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2Instance, mockEC2ApiHelper := getMockInstance(ctrl)
+
+	mockEC2ApiHelper.EXPECT().GetInstanceDetails(&instanceID).Return(nwInterfaces, nil)
+	mockEC2ApiHelper.EXPECT().GetSubnet(&subnetID).Return(subnet, nil)
+
+	err := ec2Instance.LoadDetails(mockEC2ApiHelper)
+	assert.NoError(t, err)
+	assert.Equal(t, subnetID, ec2Instance.TrunkSubnetID())
+	assert.Equal(t, []string{securityGroup1, securityGroup2}, ec2Instance.TrunkSecurityGroup())
+}
+
+// TestEc2Instance_TrunkNetworkingSpec tests that the trunk subnet and security group are independent of the
+// instance subnet and security group once set, and that the security group falls back to the instance security
+// group if not present in the spec
+func TestEc2Instance_TrunkNetworkingSpec(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2Instance, mockEC2ApiHelper := getMockInstance(ctrl)
+	trunkSubnetID := "trunk-subnet"
+	trunkSecurityGroups := []string{securityGroup3}
+
+	mockEC2ApiHelper.EXPECT().GetInstanceDetails(&instanceID).Return(nwInterfaces, nil)
+	mockEC2ApiHelper.EXPECT().GetSubnet(&subnetID).Return(subnet, nil)
+
+	err := ec2Instance.LoadDetails(mockEC2ApiHelper)
+	assert.NoError(t, err)
+
+	ec2Instance.SetTrunkNetworkingSpec(trunkSubnetID, trunkSecurityGroups)
+	assert.Equal(t, trunkSubnetID, ec2Instance.TrunkSubnetID())
+	assert.Equal(t, trunkSecurityGroups, ec2Instance.TrunkSecurityGroup())
+	assert.Equal(t, subnetID, ec2Instance.SubnetID())
+	assert.Equal(t, []string{securityGroup1, securityGroup2}, ec2Instance.InstanceSecurityGroup())
+
+	ec2Instance.SetTrunkNetworkingSpec(trunkSubnetID, nil)
+	assert.Equal(t, trunkSubnetID, ec2Instance.TrunkSubnetID())
+	assert.Equal(t, []string{securityGroup1, securityGroup2}, ec2Instance.TrunkSecurityGroup())
+}
+
+// TestEc2Instance_LoadDetails_TrunkSubnet tests the trunk subnet is used if it's in the availability zone of the
+// instance, otherwise the trunk ENI falls back to the current subnet
+func TestEc2Instance_LoadDetails_TrunkSubnet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	az := "us-west-2a"
+	trunkSubnetID := "trunk-subnet"
+	instanceSubnet := &ec2.Subnet{CidrBlock: &subnetCidrBlock, AvailabilityZone: &az}
+
+	tests := []struct {
+		name           string
+		trunkSubnetAZ  string
+		expectedSubnet string
+	}{
+		{name: "same availability zone", trunkSubnetAZ: az, expectedSubnet: trunkSubnetID},
+		{name: "other availability zone", trunkSubnetAZ: "us-west-2b", expectedSubnet: subnetID},
+	}
+
+	for _, test := range tests {
+		ec2Instance, mockEC2ApiHelper := getMockInstance(ctrl)
+		ec2Instance.SetTrunkNetworkingSpec(trunkSubnetID, nil)
+
+		mockEC2ApiHelper.EXPECT().GetInstanceDetails(&instanceID).Return(nwInterfaces, nil)
+		mockEC2ApiHelper.EXPECT().GetSubnet(&subnetID).Return(instanceSubnet, nil)
+		mockEC2ApiHelper.EXPECT().GetSubnet(&trunkSubnetID).Return(
+			&ec2.Subnet{SubnetId: &trunkSubnetID, AvailabilityZone: aws.String(test.trunkSubnetAZ)}, nil)
+
+		err := ec2Instance.LoadDetails(mockEC2ApiHelper)
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.expectedSubnet, ec2Instance.TrunkSubnetID(), test.name)
+	}
+}
+
+// TestEc2Instance_LoadDetails_FallbackSubnets tests that only the fallback subnets in the availability zone of the
+// instance are loaded, in the configured order and after the current subnet
+func TestEc2Instance_LoadDetails_FallbackSubnets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2Instance, mockEC2ApiHelper := getMockInstance(ctrl)
+
+	az := "us-west-2a"
+	fallbackSubnet1, fallbackSubnet2, otherAZSubnet := "subnet-1", "subnet-2", "subnet-3"
+	fallbackCidr1, fallbackCidr2 := "192.169.0.0/20", "192.170.0.0/24"
+	ec2Instance.SetFallbackSubnets([]string{fallbackSubnet2, otherAZSubnet, fallbackSubnet1})
+
+	instanceSubnet := &ec2.Subnet{CidrBlock: &subnetCidrBlock, AvailabilityZone: &az}
+
+	mockEC2ApiHelper.EXPECT().GetInstanceDetails(&instanceID).Return(nwInterfaces, nil)
+	mockEC2ApiHelper.EXPECT().GetSubnet(&subnetID).Return(instanceSubnet, nil)
+	mockEC2ApiHelper.EXPECT().GetSubnets(aws.StringSlice([]string{fallbackSubnet2, otherAZSubnet, fallbackSubnet1})).
+		Return([]*ec2.Subnet{
+			{SubnetId: &fallbackSubnet1, CidrBlock: &fallbackCidr1, AvailabilityZone: &az},
+			{SubnetId: &otherAZSubnet, CidrBlock: &fallbackCidr1, AvailabilityZone: aws.String("us-west-2b")},
+			{SubnetId: &fallbackSubnet2, CidrBlock: &fallbackCidr2, AvailabilityZone: &az},
+		}, nil)
+
+	err := ec2Instance.LoadDetails(mockEC2ApiHelper)
+	assert.NoError(t, err)
+	assert.Equal(t, []Subnet{
+		{ID: subnetID, CIDRBlock: subnetCidrBlock},
+		{ID: fallbackSubnet2, CIDRBlock: fallbackCidr2},
+		{ID: fallbackSubnet1, CIDRBlock: fallbackCidr1},
+	}, ec2Instance.CandidateSubnets())
+}
+
+// TestEc2Instance_LoadDetails_FallbackSubnets_Error tests that the error is returned if the fallback subnets cannot
+// be described
+func TestEc2Instance_LoadDetails_FallbackSubnets_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2Instance, mockEC2ApiHelper := getMockInstance(ctrl)
+	ec2Instance.SetFallbackSubnets([]string{"subnet-1"})
+
+	mockEC2ApiHelper.EXPECT().GetInstanceDetails(&instanceID).Return(nwInterfaces, nil)
+	mockEC2ApiHelper.EXPECT().GetSubnet(&subnetID).Return(subnet, nil)
+	mockEC2ApiHelper.EXPECT().GetSubnets(aws.StringSlice([]string{"subnet-1"})).Return(nil, mockError)
+
+	err := ec2Instance.LoadDetails(mockEC2ApiHelper)
+	assert.Error(t, err)
 }
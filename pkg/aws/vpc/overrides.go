@@ -0,0 +1,90 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package vpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=core,resources=configmaps,namespace=kube-system,resourceNames=vpc-resource-controller-limits,verbs=get
+
+// LimitsOverrideLoader periodically loads the operator overrides of the limits from a ConfigMap. The overrides
+// apply to the nodes initialized after they are loaded, the limits of a node are loaded once with its details
+type LimitsOverrideLoader struct {
+	// Reader reads the ConfigMap, the cache of the manager is restricted to the VPC CNI ConfigMap
+	Reader    client.Reader
+	Namespace string
+	Name      string
+	Log       logr.Logger
+	// Interval between two consecutive loads of the ConfigMap
+	Interval time.Duration
+}
+
+// SetupWithManager loads the overrides once so they apply to the nodes initialized on start up, and adds the
+// loader to the manager
+func (l *LimitsOverrideLoader) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	l.loadOverrides(ctx)
+
+	return mgr.Add(l)
+}
+
+// Start starts the routine that loads the overrides after fixed intervals till shut down
+func (l *LimitsOverrideLoader) Start(ctx context.Context) error {
+	l.Log.Info("starting limits override loader", "namespace", l.Namespace, "name", l.Name,
+		"interval", l.Interval)
+
+	ticker := time.NewTicker(l.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.Log.Info("stopping limits override loader")
+			return nil
+		case <-ticker.C:
+			l.loadOverrides(ctx)
+		}
+	}
+}
+
+// loadOverrides replaces the overrides with the content of the ConfigMap, the overrides are removed if the
+// ConfigMap doesn't exist and kept as is if the ConfigMap can't be read or parsed
+func (l *LimitsOverrideLoader) loadOverrides(ctx context.Context) {
+	configMap := &v1.ConfigMap{}
+	err := l.Reader.Get(ctx, types.NamespacedName{Namespace: l.Namespace, Name: l.Name}, configMap)
+	if errors.IsNotFound(err) {
+		SetLimitsOverrides(map[string]LimitsOverride{})
+		return
+	}
+	if err != nil {
+		l.Log.Error(err, "failed to get the limits overrides config map")
+		return
+	}
+
+	overrides, err := ParseLimitsOverrides(configMap.Data)
+	if err != nil {
+		l.Log.Error(err, "failed to parse the limits overrides, keeping the previous overrides")
+		return
+	}
+	SetLimitsOverrides(overrides)
+	l.Log.V(1).Info("loaded the limits overrides", "instance types", len(overrides))
+}
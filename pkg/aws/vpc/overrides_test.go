@@ -0,0 +1,106 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package vpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var overridesConfigMap = &corev1.ConfigMap{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "limits",
+		Namespace: "kube-system",
+	},
+	Data: map[string]string{
+		"c94.large": `{"interface": 3, "ipv4PerInterface": 10}`,
+	},
+}
+
+func getLoader(objects ...runtime.Object) *LimitsOverrideLoader {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	return &LimitsOverrideLoader{
+		Reader:    fakeClient.NewFakeClientWithScheme(scheme, objects...),
+		Namespace: "kube-system",
+		Name:      "limits",
+		Log:       zap.New(zap.UseDevMode(true)),
+	}
+}
+
+// TestLimitsOverrideLoader_LoadOverrides tests the overrides are loaded from the config map and removed once the
+// config map is deleted
+func TestLimitsOverrideLoader_LoadOverrides(t *testing.T) {
+	defer SetLimitsOverrides(map[string]LimitsOverride{})
+
+	getLoader(overridesConfigMap.DeepCopy()).loadOverrides(context.Background())
+	limits, found := GetLimits("c94.large")
+	assert.True(t, found)
+	assert.Equal(t, 10, limits.IPv4PerInterface)
+
+	getLoader().loadOverrides(context.Background())
+	_, found = GetLimits("c94.large")
+	assert.False(t, found)
+}
+
+// TestLimitsOverrideLoader_LoadOverrides_Invalid tests the previous overrides are kept if the config map is invalid
+func TestLimitsOverrideLoader_LoadOverrides_Invalid(t *testing.T) {
+	defer SetLimitsOverrides(map[string]LimitsOverride{})
+
+	getLoader(overridesConfigMap.DeepCopy()).loadOverrides(context.Background())
+
+	invalid := overridesConfigMap.DeepCopy()
+	invalid.Data["c94.large"] = "{"
+	getLoader(invalid).loadOverrides(context.Background())
+
+	_, found := GetLimits("c94.large")
+	assert.True(t, found)
+}
+
+// TestLimitsOverrideLoader_Start tests the loader loads the overrides after each interval and stops once the context
+// is done
+func TestLimitsOverrideLoader_Start(t *testing.T) {
+	defer SetLimitsOverrides(map[string]LimitsOverride{})
+
+	loader := getLoader(overridesConfigMap.DeepCopy())
+	loader.Interval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- loader.Start(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, found := GetLimits("c94.large")
+		return found
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("loader didn't stop after the context was done")
+	}
+}
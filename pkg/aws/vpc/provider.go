@@ -0,0 +1,169 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package vpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+var (
+	limitsLock sync.RWMutex
+	// discoveredLimits are the limits of the instance types missing from the generated table, discovered at runtime
+	// with the EC2 API
+	discoveredLimits = map[string]*VPCLimits{}
+	// discoveredNetworkCards are the network cards of the instance types with discovered limits
+	discoveredNetworkCards = map[string][]NetworkCard{}
+	// limitsOverrides are the limits set by the operator, they take precedence over the generated and the
+	// discovered limits
+	limitsOverrides = map[string]LimitsOverride{}
+)
+
+// networkCards are the network cards of the instance types of the generated table with multiple network cards, the
+// other instance types of the generated table have a single network card with all the network interfaces
+var networkCards = map[string][]NetworkCard{
+	"dl1.24xlarge": {{Index: 0, Interface: 15}, {Index: 1, Interface: 15}, {Index: 2, Interface: 15},
+		{Index: 3, Interface: 15}},
+	"p4d.24xlarge": {{Index: 0, Interface: 15}, {Index: 1, Interface: 15}, {Index: 2, Interface: 15},
+		{Index: 3, Interface: 15}},
+}
+
+// NetworkCard is a network card of an instance type, each network card has its own range of device indexes
+type NetworkCard struct {
+	// Index is the network card index
+	Index int `json:"index"`
+	// Interface is the maximum number of network interfaces attached to the network card
+	Interface int `json:"interface"`
+}
+
+// LimitsOverride overrides the limits of an instance type, the fields that are not set keep the generated or the
+// discovered value. The branch interface limit and the trunking compatibility are not exposed by the EC2 API so
+// they must be overridden for the instance types missing from the generated table to support trunking
+type LimitsOverride struct {
+	Interface            *int  `json:"interface,omitempty"`
+	IPv4PerInterface     *int  `json:"ipv4PerInterface,omitempty"`
+	IsTrunkingCompatible *bool `json:"isTrunkingCompatible,omitempty"`
+	BranchInterface      *int  `json:"branchInterface,omitempty"`
+	// NetworkCards overrides the network cards of the instance type
+	NetworkCards []NetworkCard `json:"networkCards,omitempty"`
+}
+
+// GetLimits returns the limits of the instance type from the generated table or the limits discovered at runtime,
+// with the operator overrides applied. Returns false if the limits of the instance type are unknown
+func GetLimits(instanceType string) (*VPCLimits, bool) {
+	limitsLock.RLock()
+	defer limitsLock.RUnlock()
+
+	limits, found := Limits[instanceType]
+	if !found {
+		limits, found = discoveredLimits[instanceType]
+	}
+	override, overridden := limitsOverrides[instanceType]
+	if !overridden {
+		return limits, found
+	}
+
+	// Don't modify the generated or the discovered limits
+	merged := &VPCLimits{}
+	if found {
+		*merged = *limits
+	}
+	if override.Interface != nil {
+		merged.Interface = *override.Interface
+	}
+	if override.IPv4PerInterface != nil {
+		merged.IPv4PerInterface = *override.IPv4PerInterface
+	}
+	if override.IsTrunkingCompatible != nil {
+		merged.IsTrunkingCompatible = *override.IsTrunkingCompatible
+	}
+	if override.BranchInterface != nil {
+		merged.BranchInterface = *override.BranchInterface
+	}
+	// An override alone must define the limits required to manage the instance
+	if !found && (merged.Interface == 0 || merged.IPv4PerInterface == 0) {
+		return nil, false
+	}
+	return merged, true
+}
+
+// GetNetworkCards returns the network cards of the instance type from the operator overrides, the network cards
+// discovered at runtime or the network cards of the generated table. Instance types without known network cards
+// have a single network card with all the network interfaces. Returns nil if the limits of the instance type are
+// unknown
+func GetNetworkCards(instanceType string) []NetworkCard {
+	limits, found := GetLimits(instanceType)
+	if !found {
+		return nil
+	}
+
+	limitsLock.RLock()
+	defer limitsLock.RUnlock()
+
+	if override, ok := limitsOverrides[instanceType]; ok && len(override.NetworkCards) > 0 {
+		return override.NetworkCards
+	}
+	if cards, ok := discoveredNetworkCards[instanceType]; ok && len(cards) > 0 {
+		return cards
+	}
+	if cards, ok := networkCards[instanceType]; ok {
+		return cards
+	}
+	return []NetworkCard{{Index: 0, Interface: limits.Interface}}
+}
+
+// SetDiscoveredLimits caches the limits and the network cards of the instance type discovered at runtime
+func SetDiscoveredLimits(instanceType string, limits *VPCLimits, cards []NetworkCard) {
+	limitsLock.Lock()
+	defer limitsLock.Unlock()
+
+	discoveredLimits[instanceType] = limits
+	discoveredNetworkCards[instanceType] = cards
+}
+
+// SetLimitsOverrides replaces the operator overrides of the limits
+func SetLimitsOverrides(overrides map[string]LimitsOverride) {
+	limitsLock.Lock()
+	defer limitsLock.Unlock()
+
+	limitsOverrides = overrides
+}
+
+// ParseLimitsOverrides parses the overrides from the data of the ConfigMap, keyed by instance type with a JSON
+// LimitsOverride as value. For example, "c7g.large": {"isTrunkingCompatible": true, "branchInterface": 9}
+func ParseLimitsOverrides(data map[string]string) (map[string]LimitsOverride, error) {
+	overrides := make(map[string]LimitsOverride, len(data))
+	for instanceType, value := range data {
+		override := LimitsOverride{}
+		if err := json.Unmarshal([]byte(value), &override); err != nil {
+			return nil, fmt.Errorf("invalid limits override for instance type %s: %v", instanceType, err)
+		}
+		for name, limit := range map[string]*int{"interface": override.Interface,
+			"ipv4PerInterface": override.IPv4PerInterface, "branchInterface": override.BranchInterface} {
+			if limit != nil && *limit < 0 {
+				return nil, fmt.Errorf("invalid limits override for instance type %s: %s must not be negative",
+					instanceType, name)
+			}
+		}
+		for _, card := range override.NetworkCards {
+			if card.Index < 0 || card.Interface <= 0 {
+				return nil, fmt.Errorf("invalid limits override for instance type %s: invalid network card %+v",
+					instanceType, card)
+			}
+		}
+		overrides[instanceType] = override
+	}
+	return overrides, nil
+}
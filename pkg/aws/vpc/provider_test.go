@@ -0,0 +1,125 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package vpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// TestGetLimits tests the limits are returned from the generated table, then from the discovered limits, and
+// unknown instance types are not found
+func TestGetLimits(t *testing.T) {
+	defer SetLimitsOverrides(map[string]LimitsOverride{})
+
+	limits, found := GetLimits("c5.large")
+	assert.True(t, found)
+	assert.Equal(t, Limits["c5.large"], limits)
+
+	_, found = GetLimits("c97.large")
+	assert.False(t, found)
+
+	SetDiscoveredLimits("c97.large", &VPCLimits{Interface: 3, IPv4PerInterface: 10}, nil)
+	limits, found = GetLimits("c97.large")
+	assert.True(t, found)
+	assert.Equal(t, &VPCLimits{Interface: 3, IPv4PerInterface: 10}, limits)
+}
+
+// TestGetLimits_Overrides tests the overridden fields replace the generated limits without modifying the generated
+// table
+func TestGetLimits_Overrides(t *testing.T) {
+	defer SetLimitsOverrides(map[string]LimitsOverride{})
+
+	generated := *Limits["c5.large"]
+	SetLimitsOverrides(map[string]LimitsOverride{
+		"c5.large": {BranchInterface: intPtr(2), IsTrunkingCompatible: boolPtr(false)},
+	})
+
+	limits, found := GetLimits("c5.large")
+	assert.True(t, found)
+	assert.Equal(t, 2, limits.BranchInterface)
+	assert.False(t, limits.IsTrunkingCompatible)
+	assert.Equal(t, generated.Interface, limits.Interface)
+	assert.Equal(t, generated, *Limits["c5.large"])
+}
+
+// TestGetLimits_OverrideOnly tests an instance type known only from the overrides is found only if the overrides
+// define its interface and IPv4 limits
+func TestGetLimits_OverrideOnly(t *testing.T) {
+	defer SetLimitsOverrides(map[string]LimitsOverride{})
+
+	SetLimitsOverrides(map[string]LimitsOverride{
+		"c96.large": {Interface: intPtr(3), IPv4PerInterface: intPtr(10), IsTrunkingCompatible: boolPtr(true),
+			BranchInterface: intPtr(9)},
+		"c95.large": {IsTrunkingCompatible: boolPtr(true), BranchInterface: intPtr(9)},
+	})
+
+	limits, found := GetLimits("c96.large")
+	assert.True(t, found)
+	assert.Equal(t, &VPCLimits{Interface: 3, IPv4PerInterface: 10, IsTrunkingCompatible: true,
+		BranchInterface: 9}, limits)
+
+	_, found = GetLimits("c95.large")
+	assert.False(t, found)
+}
+
+// TestGetNetworkCards tests the network cards are returned from the overrides, the discovered network cards and the
+// generated table, and the instance types without known network cards have a single network card
+func TestGetNetworkCards(t *testing.T) {
+	defer SetLimitsOverrides(map[string]LimitsOverride{})
+
+	assert.Equal(t, []NetworkCard{{Index: 0, Interface: Limits["c5.large"].Interface}}, GetNetworkCards("c5.large"))
+	assert.Len(t, GetNetworkCards("p4d.24xlarge"), 4)
+	assert.Nil(t, GetNetworkCards("c93.large"))
+
+	discoveredCards := []NetworkCard{{Index: 0, Interface: 2}, {Index: 1, Interface: 2}}
+	SetDiscoveredLimits("c93.large", &VPCLimits{Interface: 4, IPv4PerInterface: 10}, discoveredCards)
+	assert.Equal(t, discoveredCards, GetNetworkCards("c93.large"))
+
+	overriddenCards := []NetworkCard{{Index: 0, Interface: 1}, {Index: 1, Interface: 3}}
+	SetLimitsOverrides(map[string]LimitsOverride{"c93.large": {NetworkCards: overriddenCards}})
+	assert.Equal(t, overriddenCards, GetNetworkCards("c93.large"))
+}
+
+// TestParseLimitsOverrides tests the JSON overrides are parsed per instance type
+func TestParseLimitsOverrides(t *testing.T) {
+	overrides, err := ParseLimitsOverrides(map[string]string{
+		"c7g.large": `{"isTrunkingCompatible": true, "branchInterface": 9}`,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]LimitsOverride{
+		"c7g.large": {IsTrunkingCompatible: boolPtr(true), BranchInterface: intPtr(9)},
+	}, overrides)
+}
+
+// TestParseLimitsOverrides_Invalid tests malformed and negative overrides and empty network cards are rejected
+func TestParseLimitsOverrides_Invalid(t *testing.T) {
+	_, err := ParseLimitsOverrides(map[string]string{"c7g.large": `{"branchInterface": "nine"}`})
+	assert.Error(t, err)
+
+	_, err = ParseLimitsOverrides(map[string]string{"c7g.large": `{"interface": -1}`})
+	assert.Error(t, err)
+
+	_, err = ParseLimitsOverrides(map[string]string{"c7g.large": `{"networkCards": [{"index": 1, "interface": 0}]}`})
+	assert.Error(t, err)
+}
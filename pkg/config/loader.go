@@ -13,17 +13,34 @@
 
 package config
 
+import "time"
+
 const (
 	WorkQueueDefaultMaxRetries = 5
 
 	// Default Configuration for Pod ENI resource type
-	PodENIDefaultWorker = 2
+	PodENIDefaultWorker    = 2
+	PodENIDefaultMaxWorker = 10
 
 	// Default Configuration for IPv4 resource type
-	IPv4DefaultWorker  = 2
-	IPv4DefaultWPSize  = 3
-	IPv4DefaultMaxDev  = 1
-	IPv4DefaultResSize = 0
+	IPv4DefaultWorker    = 2
+	IPv4DefaultMaxWorker = 10
+	IPv4DefaultWPSize    = 3
+	IPv4DefaultMaxDev    = 1
+	IPv4DefaultResSize   = 0
+	// IPv4DefaultAssignmentStrategy assigns the IPs in the order they were added to the warm pool
+	IPv4DefaultAssignmentStrategy = AssignmentStrategyFIFO
+	IPv4DefaultMinimumIPTarget    = 0
+	IPv4DefaultWarmENITarget      = 0
+	IPv4DefaultIdleTTL            = 0
+	IPv4DefaultIdleDesiredSize    = 0
+
+	// Default Configuration for IPv6 resource type
+	IPv6DefaultWorker    = 2
+	IPv6DefaultMaxWorker = 10
+	IPv6DefaultWPSize    = 3
+	IPv6DefaultMaxDev    = 1
+	IPv6DefaultResSize   = 0
 
 	// EC2 API QPS for user service client
 	UserServiceClientQPS      = 6
@@ -33,6 +50,19 @@ const (
 	InstanceServiceClientQPS   = 2
 	InstanceServiceClientBurst = 3
 
+	// Bounds of the adaptive EC2 API QPS, the QPS starts at the client's QPS and is increased on each successful
+	// call and decreased on throttling. The maximum defaults to the client's QPS, so the QPS is only decreased on
+	// throttling unless a higher maximum is configured
+	UserServiceClientMinQPS     = 1
+	UserServiceClientMaxQPS     = UserServiceClientQPS
+	InstanceServiceClientMinQPS = 1
+	InstanceServiceClientMaxQPS = InstanceServiceClientQPS
+	ClientQPSIncreaseStep       = 0.05
+	ClientQPSDecreaseFactor     = 0.5
+	ClientQPSDecreaseInterval   = time.Second
+	// Shares of the EC2 API QPS reserved to the pod critical, node initialization and background calls
+	DefaultEC2PriorityShares = "50,30,20"
+
 	// API Server QPS
 	DefaultAPIServerQPS   = 10
 	DefaultAPIServerBurst = 15
@@ -53,6 +83,7 @@ func getDefaultResourceConfig() map[string]ResourceConfig {
 	podENIConfig := ResourceConfig{
 		Name:           ResourceNamePodENI,
 		WorkerCount:    PodENIDefaultWorker,
+		MaxWorkerCount: PodENIDefaultMaxWorker,
 		SupportedOS:    map[string]bool{OSWindows: false, OSLinux: true},
 		WarmPoolConfig: nil,
 	}
@@ -60,17 +91,38 @@ func getDefaultResourceConfig() map[string]ResourceConfig {
 
 	// Create default configuration for IPv4 Resource
 	ipV4WarmPoolConfig := WarmPoolConfig{
-		DesiredSize:  IPv4DefaultWPSize,
-		MaxDeviation: IPv4DefaultMaxDev,
-		ReservedSize: IPv4DefaultResSize,
+		DesiredSize:        IPv4DefaultWPSize,
+		MaxDeviation:       IPv4DefaultMaxDev,
+		ReservedSize:       IPv4DefaultResSize,
+		AssignmentStrategy: IPv4DefaultAssignmentStrategy,
+		MinimumIPTarget:    IPv4DefaultMinimumIPTarget,
+		WarmENITarget:      IPv4DefaultWarmENITarget,
+		IdleTTL:            IPv4DefaultIdleTTL,
+		IdleDesiredSize:    IPv4DefaultIdleDesiredSize,
 	}
 	ipV4Config := ResourceConfig{
 		Name:           ResourceNameIPAddress,
 		WorkerCount:    IPv4DefaultWorker,
+		MaxWorkerCount: IPv4DefaultMaxWorker,
 		SupportedOS:    map[string]bool{OSWindows: true, OSLinux: false},
 		WarmPoolConfig: &ipV4WarmPoolConfig,
 	}
 	config[ResourceNameIPAddress] = ipV4Config
 
+	// Create default configuration for IPv6 Resource
+	ipV6WarmPoolConfig := WarmPoolConfig{
+		DesiredSize:  IPv6DefaultWPSize,
+		MaxDeviation: IPv6DefaultMaxDev,
+		ReservedSize: IPv6DefaultResSize,
+	}
+	ipV6Config := ResourceConfig{
+		Name:           ResourceNameIPv6Address,
+		WorkerCount:    IPv6DefaultWorker,
+		MaxWorkerCount: IPv6DefaultMaxWorker,
+		SupportedOS:    map[string]bool{OSWindows: true, OSLinux: false},
+		WarmPoolConfig: &ipV6WarmPoolConfig,
+	}
+	config[ResourceNameIPv6Address] = ipV6Config
+
 	return config
 }
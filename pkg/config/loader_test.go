@@ -15,6 +15,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -27,6 +28,7 @@ func TestLoadResourceConfig(t *testing.T) {
 	podENIConfig := defaultResourceConfig[ResourceNamePodENI]
 	assert.Equal(t, ResourceNamePodENI, podENIConfig.Name)
 	assert.Equal(t, PodENIDefaultWorker, podENIConfig.WorkerCount)
+	assert.Equal(t, PodENIDefaultMaxWorker, podENIConfig.MaxWorkerCount)
 	assert.Equal(t, map[string]bool{OSLinux: true, OSWindows: false}, podENIConfig.SupportedOS)
 	assert.Nil(t, podENIConfig.WarmPoolConfig)
 
@@ -34,6 +36,7 @@ func TestLoadResourceConfig(t *testing.T) {
 	ipV4Config := defaultResourceConfig[ResourceNameIPAddress]
 	assert.Equal(t, ResourceNameIPAddress, ipV4Config.Name)
 	assert.Equal(t, IPv4DefaultWorker, ipV4Config.WorkerCount)
+	assert.Equal(t, IPv4DefaultMaxWorker, ipV4Config.MaxWorkerCount)
 	assert.Equal(t, map[string]bool{OSLinux: false, OSWindows: true}, ipV4Config.SupportedOS)
 
 	// Verify default Warm pool configuration for IPv4 Address
@@ -41,5 +44,23 @@ func TestLoadResourceConfig(t *testing.T) {
 	assert.Equal(t, IPv4DefaultWPSize, ipV4WPConfig.DesiredSize)
 	assert.Equal(t, IPv4DefaultMaxDev, ipV4WPConfig.MaxDeviation)
 	assert.Equal(t, IPv4DefaultResSize, ipV4WPConfig.ReservedSize)
+	assert.Equal(t, IPv4DefaultAssignmentStrategy, ipV4WPConfig.AssignmentStrategy)
+	assert.Equal(t, IPv4DefaultMinimumIPTarget, ipV4WPConfig.MinimumIPTarget)
+	assert.Equal(t, IPv4DefaultWarmENITarget, ipV4WPConfig.WarmENITarget)
+	assert.Equal(t, time.Duration(IPv4DefaultIdleTTL), ipV4WPConfig.IdleTTL)
+	assert.Equal(t, IPv4DefaultIdleDesiredSize, ipV4WPConfig.IdleDesiredSize)
 
+	// Verify default resource configuration for resource IPv6 Address
+	ipV6Config := defaultResourceConfig[ResourceNameIPv6Address]
+	assert.Equal(t, ResourceNameIPv6Address, ipV6Config.Name)
+	assert.Equal(t, IPv6DefaultWorker, ipV6Config.WorkerCount)
+	assert.Equal(t, IPv6DefaultMaxWorker, ipV6Config.MaxWorkerCount)
+	assert.Equal(t, map[string]bool{OSLinux: false, OSWindows: true}, ipV6Config.SupportedOS)
+	assert.False(t, ipV6Config.UsePrefixes)
+
+	// Verify default Warm pool configuration for IPv6 Address
+	ipV6WPConfig := ipV6Config.WarmPoolConfig
+	assert.Equal(t, IPv6DefaultWPSize, ipV6WPConfig.DesiredSize)
+	assert.Equal(t, IPv6DefaultMaxDev, ipV6WPConfig.MaxDeviation)
+	assert.Equal(t, IPv6DefaultResSize, ipV6WPConfig.ReservedSize)
 }
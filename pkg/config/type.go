@@ -27,8 +27,40 @@ const (
 	ResourceNamePodENI = VPCResourcePrefix + "pod-eni"
 	// ResourceNameIPAddress is the extended resource name for private IP addresses
 	ResourceNameIPAddress = VPCResourcePrefix + "PrivateIPv4Address"
+	// PodENIReadinessGate is the readiness gate condition type set on pods once the branch ENI is associated
+	// with the trunk and the pod is annotated with the branch ENI details
+	PodENIReadinessGate = VPCResourcePrefix + "pod-eni-attached"
+	// IPv4AddressCountAnnotation is set by the user on Windows pods requesting more than one IPv4 address
+	IPv4AddressCountAnnotation = VPCResourcePrefix + "PrivateIPv4AddressCount"
+	// IPv4AddressesAnnotation is the JSON list of all the IPv4 addresses allocated to a pod that requested
+	// more than one IPv4 address. The first address is also set in the ResourceNameIPAddress annotation
+	IPv4AddressesAnnotation = VPCResourcePrefix + "PrivateIPv4Addresses"
+	// ResourceNameIPv6Address is the extended resource name for IPv6 addresses or IPv6 prefixes
+	ResourceNameIPv6Address = VPCResourcePrefix + "PrivateIPv6Address"
+	// IPv6AddressCountAnnotation is set by the user on Windows pods requesting more than one IPv6 address
+	IPv6AddressCountAnnotation = VPCResourcePrefix + "PrivateIPv6AddressCount"
+	// IPv6AddressesAnnotation is the JSON list of all the IPv6 addresses allocated to a pod that requested
+	// more than one IPv6 address. The first address is also set in the ResourceNameIPv6Address annotation
+	IPv6AddressesAnnotation = VPCResourcePrefix + "PrivateIPv6Addresses"
+	// CriticalPodAnnotation is set to "true" by the user on pods that can be assigned the reserved resources of
+	// the warm pool, in addition to the pods with a system critical priority class
+	CriticalPodAnnotation = VPCResourcePrefix + "critical"
 )
 
+// CriticalPriorityClasses are the priority classes of the pods that can be assigned the reserved resources of the
+// warm pool
+var CriticalPriorityClasses = map[string]bool{
+	"system-cluster-critical": true,
+	"system-node-critical":    true,
+}
+
+// ResourcesAnnotation is the annotation with the list of all the resources allocated to a pod that requested more
+// than one resource, for the resources that can be requested more than once
+var ResourcesAnnotation = map[string]string{
+	ResourceNameIPAddress:   IPv4AddressesAnnotation,
+	ResourceNameIPv6Address: IPv6AddressesAnnotation,
+}
+
 // K8s Pod Labels
 const (
 	// ControllerName is the name of the VPC Resource Controller
@@ -37,6 +69,9 @@ const (
 	HasTrunkAttachedLabel = "vpc.amazonaws.com/has-trunk-attached"
 	// CustomNetworkingLabel is the label with the name of ENIConfig to be used by the node for custom networking
 	CustomNetworkingLabel = "vpc.amazonaws.com/eniConfig"
+	// TrunkENIConfigLabel is the label with the name of ENIConfig whose subnet and security groups are used
+	// for creating the trunk ENI, independent of the node's primary network interface or custom networking
+	TrunkENIConfigLabel = "vpc.amazonaws.com/trunk-eniConfig"
 	// NodeLabelOS is the Kubernetes Operating System label
 	NodeLabelOS = "kubernetes.io/os"
 	// NodeLabelOS is the Kubernetes Operating System label used before k8s version 1.16
@@ -59,6 +94,11 @@ const (
 	NetworkInterfaceOwnerTagKey         = "eks:eni:owner"
 	NetworkInterfaceOwnerTagValue       = "eks-vpc-resource-controller"
 	NetworkInterfaceOwnerVPCCNITagValue = "amazon-vpc-cni"
+
+	// NoManageTagKey is the tag of the network interfaces that the VPC CNI must not manage, set on the network
+	// interfaces created by the controller on Linux nodes
+	NoManageTagKey   = "node.k8s.amazonaws.com/no_manage"
+	NoManageTagValue = "true"
 )
 
 const (
@@ -70,13 +110,58 @@ const (
 	KubeSystemNamespace            = "kube-system"
 	VpcCNIDaemonSetName            = "aws-node"
 	OldVPCControllerDeploymentName = "vpc-resource-controller"
+
+	// LimitsOverridesConfigMapName is the ConfigMap in the kube-system namespace with the operator overrides of
+	// the instance type limits
+	LimitsOverridesConfigMapName = "vpc-resource-controller-limits"
 )
 
 var (
 	// CoolDownPeriod is the time to let kube-proxy propagates IP tables rules before assigning the resource back to new pod
 	CoolDownPeriod = time.Second * 30
+	// EndpointCoolDownCheckInterval is the time interval between each check of the cool down queue in the endpoint
+	// cool down mode, the resources are released once their addresses are removed from all the endpoints
+	EndpointCoolDownCheckInterval = time.Second * 5
 	// ENICleanUpInterval is the time interval between each dangling ENI clean up task
 	ENICleanUpInterval = time.Minute * 30
+	// SubnetMonitorInterval is the default time interval between each refresh of the subnet available IP addresses
+	SubnetMonitorInterval = time.Minute * 5
+	// LimitsOverridesRefreshInterval is the time interval between each load of the instance type limits overrides
+	LimitsOverridesRefreshInterval = time.Minute
+)
+
+// Cool down modes of the freed resources
+const (
+	// CoolDownModeTimer holds the freed resources for the fixed cool down period
+	CoolDownModeTimer = "timer"
+	// CoolDownModeEndpoint holds the freed resources until their addresses are removed from all the EndpointSlices,
+	// for at most the cool down period
+	CoolDownModeEndpoint = "endpoint"
+)
+
+// SubnetIPThreshold is the default available IP address count of a subnet below which warning events are broadcasted
+// on the nodes using the subnet
+const SubnetIPThreshold = 16
+
+// Network card policies choosing the network card of the network interfaces attached by the controller on instances
+// with multiple network cards
+const (
+	// NetworkCardPolicyDefault attaches the network interfaces to the network card of the primary network interface
+	NetworkCardPolicyDefault = "default"
+	// NetworkCardPolicySpread attaches the network interfaces to the network card with the most free device indexes
+	NetworkCardPolicySpread = "spread"
+)
+
+// Warm resource assignment strategies
+const (
+	// AssignmentStrategyFIFO assigns the warm resources in the order they were added to the warm pool
+	AssignmentStrategyFIFO = "fifo"
+	// AssignmentStrategyLRU assigns the resources that were never used or freed the earliest first
+	AssignmentStrategyLRU = "lru"
+	// AssignmentStrategyPacking assigns the resources from the lowest index ENI first
+	AssignmentStrategyPacking = "packing"
+	// AssignmentStrategySpreading assigns the resources from each ENI in turn
+	AssignmentStrategySpreading = "spreading"
 )
 
 // ResourceConfig is the configuration for each resource type
@@ -85,18 +170,39 @@ type ResourceConfig struct {
 	Name string
 	// WorkerCount is the number of routines that will process items for the buffer
 	WorkerCount int
+	// MaxWorkerCount is the number of routines the workers scale up to when the items queue up in the buffer
+	MaxWorkerCount int
+	// MaxNodeConcurrency is the maximum number of jobs of a node processed at the same time. Optional, when set
+	// the jobs of the nodes are processed in round-robin instead of in the order they were submitted
+	MaxNodeConcurrency int
 	// SupportedOS is the map of operating system that supports the resource
 	SupportedOS map[string]bool
 	// WarmPoolConfig represents the configuration of warm pool for resources that support warm resources. Optional
 	WarmPoolConfig *WarmPoolConfig
+	// UsePrefixes allocates a prefix instead of a single address for each resource. Only supported by IPv6
+	UsePrefixes bool
 }
 
 // WarmPoolConfig is the configuration of Warm Pool of a resource
 type WarmPoolConfig struct {
 	// Number of resources to keep in warm pool per node
 	DesiredSize int
-	// Number of resources not to use in the warm pool
+	// Number of resources kept in the warm pool on top of the desired size, only assigned to the critical pods
 	ReservedSize int
 	// The maximum number by which the warm pool can deviate from the desired size
 	MaxDeviation int
+	// AssignmentStrategy is the order in which the warm resources are assigned, defaults to fifo
+	AssignmentStrategy string
+	// MinimumIPTarget is the total number of resources to keep allocated to the node, including the used
+	// resources. Optional, the warm pool is grown in a single step to reach the target
+	MinimumIPTarget int
+	// WarmENITarget is the number of whole ENIs without any used resource to keep in the warm pool, on top of the
+	// free slots of the ENIs with used resources. Optional
+	WarmENITarget int
+	// IdleTTL is the duration after which a node with no resource assigned or freed is considered idle and
+	// its warm pool is shrunk to the IdleDesiredSize. Optional, idle resources are never reclaimed if 0
+	IdleTTL time.Duration
+	// IdleDesiredSize is the number of resources to keep in the warm pool of an idle node, the warm pool
+	// is restored to the full target on the next assignment
+	IdleDesiredSize int
 }
@@ -0,0 +1,212 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package endpoint
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// ipv6PrefixLength is the length of the IPv6 prefixes assigned to the pods, the addresses are indexed by their
+// prefix of this length
+const ipv6PrefixLength = 80
+
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+
+// EndpointTracker tracks the addresses present in the EndpointSlices of the cluster, so the resources
+// of deleted pods can be released as soon as no service routes traffic to them anymore
+type EndpointTracker interface {
+	// IsAddressInUse returns true if the address, with or without the prefix length, is present in any
+	// endpoint. All addresses are considered in use until the EndpointSlices are synced
+	IsAddressInUse(address string) bool
+	// IsPrefixInUse returns true if any endpoint address belongs to the prefix. All prefixes are considered in
+	// use until the EndpointSlices are synced
+	IsPrefixInUse(prefix string) bool
+}
+
+// Tracker watches the EndpointSlices and keeps the reference count of each endpoint address
+type Tracker struct {
+	Log logr.Logger
+
+	lock sync.RWMutex // guards the following
+	// sliceAddresses is the list of addresses of each EndpointSlice
+	sliceAddresses map[types.NamespacedName][]string
+	// addressCount is the number of EndpointSlices referencing the address
+	addressCount map[string]int
+	// prefixCount is the number of references to the addresses of each /80 IPv6 prefix
+	prefixCount map[string]int
+	// hasSynced returns true once the EndpointSlice informer has synced
+	hasSynced func() bool
+}
+
+// SetupWithManager registers the tracker with the EndpointSlice informer of the manager's cache
+func (t *Tracker) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	t.sliceAddresses = make(map[types.NamespacedName][]string)
+	t.addressCount = make(map[string]int)
+	t.prefixCount = make(map[string]int)
+
+	informer, err := mgr.GetCache().GetInformer(ctx, &discoveryv1.EndpointSlice{})
+	if err != nil {
+		return err
+	}
+	informer.AddEventHandler(t)
+	t.hasSynced = informer.HasSynced
+
+	t.Log.Info("tracking the endpoint slice addresses")
+
+	return nil
+}
+
+// IsAddressInUse returns true if the address is referenced by any EndpointSlice or if the
+// EndpointSlices are not synced yet
+func (t *Tracker) IsAddressInUse(address string) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.hasSynced == nil || !t.hasSynced() {
+		return true
+	}
+	return t.addressCount[normalize(address)] > 0
+}
+
+// IsPrefixInUse returns true if any address referenced by the EndpointSlices belongs to the prefix or
+// if the EndpointSlices are not synced yet. The /80 IPv6 prefixes are looked up in the prefix index, the
+// other prefixes are matched against each address
+func (t *Tracker) IsPrefixInUse(prefix string) bool {
+	_, ipNet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return t.IsAddressInUse(prefix)
+	}
+
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.hasSynced == nil || !t.hasSynced() {
+		return true
+	}
+	if ones, bits := ipNet.Mask.Size(); ones == ipv6PrefixLength && bits == net.IPv6len*8 {
+		return t.prefixCount[ipNet.String()] > 0
+	}
+	for address := range t.addressCount {
+		if ip := net.ParseIP(address); ip != nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// OnAdd tracks the addresses of the new EndpointSlice
+func (t *Tracker) OnAdd(obj interface{}) {
+	if slice, ok := obj.(*discoveryv1.EndpointSlice); ok {
+		t.updateSlice(slice)
+	}
+}
+
+// OnUpdate replaces the addresses of the updated EndpointSlice
+func (t *Tracker) OnUpdate(_, newObj interface{}) {
+	if slice, ok := newObj.(*discoveryv1.EndpointSlice); ok {
+		t.updateSlice(slice)
+	}
+}
+
+// OnDelete stops tracking the addresses of the deleted EndpointSlice
+func (t *Tracker) OnDelete(obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if slice, ok := obj.(*discoveryv1.EndpointSlice); ok {
+		t.deleteSlice(types.NamespacedName{Namespace: slice.Namespace, Name: slice.Name})
+	}
+}
+
+// updateSlice replaces the tracked addresses of the EndpointSlice with its current addresses
+func (t *Tracker) updateSlice(slice *discoveryv1.EndpointSlice) {
+	var addresses []string
+	if slice.AddressType == discoveryv1.AddressTypeIPv4 || slice.AddressType == discoveryv1.AddressTypeIPv6 {
+		for _, endpoint := range slice.Endpoints {
+			for _, address := range endpoint.Addresses {
+				addresses = append(addresses, normalize(address))
+			}
+		}
+	}
+
+	key := types.NamespacedName{Namespace: slice.Namespace, Name: slice.Name}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.removeAddresses(key)
+	if len(addresses) == 0 {
+		return
+	}
+	t.sliceAddresses[key] = addresses
+	for _, address := range addresses {
+		t.addressCount[address]++
+		if prefix, ok := getIPv6Prefix(address); ok {
+			t.prefixCount[prefix]++
+		}
+	}
+}
+
+// deleteSlice removes the tracked addresses of the EndpointSlice
+func (t *Tracker) deleteSlice(key types.NamespacedName) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.removeAddresses(key)
+}
+
+// removeAddresses decrements the reference count of the addresses of the EndpointSlice, must be
+// called with the lock held
+func (t *Tracker) removeAddresses(key types.NamespacedName) {
+	for _, address := range t.sliceAddresses[key] {
+		if t.addressCount[address]--; t.addressCount[address] <= 0 {
+			delete(t.addressCount, address)
+		}
+		if prefix, ok := getIPv6Prefix(address); ok {
+			if t.prefixCount[prefix]--; t.prefixCount[prefix] <= 0 {
+				delete(t.prefixCount, prefix)
+			}
+		}
+	}
+	delete(t.sliceAddresses, key)
+}
+
+// normalize strips the prefix length and returns the canonical form of the address, so the IPv6
+// addresses match irrespective of their notation
+func normalize(address string) string {
+	address = strings.Split(address, "/")[0]
+	if ip := net.ParseIP(address); ip != nil {
+		return ip.String()
+	}
+	return address
+}
+
+// getIPv6Prefix returns the /80 prefix of the normalized IPv6 address, false if the address is not an IPv6 address
+func getIPv6Prefix(address string) (string, bool) {
+	ip := net.ParseIP(address)
+	if ip == nil || ip.To4() != nil {
+		return "", false
+	}
+	mask := net.CIDRMask(ipv6PrefixLength, net.IPv6len*8)
+	return (&net.IPNet{IP: ip.Mask(mask), Mask: mask}).String(), true
+}
@@ -0,0 +1,125 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package endpoint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	ipv4Address1 = "192.168.1.1"
+	ipv4Address2 = "192.168.1.2"
+	ipv6Address  = "2600:0:0:1::5"
+)
+
+func getMockTracker(synced bool) *Tracker {
+	return &Tracker{
+		Log:            zap.New(zap.UseDevMode(true)),
+		sliceAddresses: map[types.NamespacedName][]string{},
+		addressCount:   map[string]int{},
+		prefixCount:    map[string]int{},
+		hasSynced:      func() bool { return synced },
+	}
+}
+
+func getEndpointSlice(name string, addressType discoveryv1.AddressType,
+	addresses ...string) *discoveryv1.EndpointSlice {
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta:  metav1.ObjectMeta{Name: name, Namespace: "default"},
+		AddressType: addressType,
+	}
+	for _, address := range addresses {
+		slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{Addresses: []string{address}})
+	}
+	return slice
+}
+
+// TestTracker_IsAddressInUse tests the address is in use while it's present in any endpoint slice
+func TestTracker_IsAddressInUse(t *testing.T) {
+	tracker := getMockTracker(true)
+
+	slice1 := getEndpointSlice("slice-1", discoveryv1.AddressTypeIPv4, ipv4Address1, ipv4Address2)
+	slice2 := getEndpointSlice("slice-2", discoveryv1.AddressTypeIPv4, ipv4Address1)
+	tracker.OnAdd(slice1)
+	tracker.OnAdd(slice2)
+
+	assert.True(t, tracker.IsAddressInUse(ipv4Address1))
+	assert.True(t, tracker.IsAddressInUse(ipv4Address2))
+
+	// Address removed from one slice is still in use by the other
+	tracker.OnUpdate(slice1, getEndpointSlice("slice-1", discoveryv1.AddressTypeIPv4, ipv4Address2))
+	assert.True(t, tracker.IsAddressInUse(ipv4Address1))
+
+	tracker.OnDelete(toolscache.DeletedFinalStateUnknown{Obj: slice2})
+	assert.False(t, tracker.IsAddressInUse(ipv4Address1))
+	assert.True(t, tracker.IsAddressInUse(ipv4Address2))
+
+	tracker.OnDelete(slice1)
+	assert.False(t, tracker.IsAddressInUse(ipv4Address2))
+	assert.Empty(t, tracker.addressCount)
+	assert.Empty(t, tracker.sliceAddresses)
+}
+
+// TestTracker_IsAddressInUse_IPv6 tests the IPv6 address is matched irrespective of the notation and the prefix length
+func TestTracker_IsAddressInUse_IPv6(t *testing.T) {
+	tracker := getMockTracker(true)
+
+	tracker.OnAdd(getEndpointSlice("slice-1", discoveryv1.AddressTypeIPv6, "2600:0000:0000:0001:0000:0000:0000:0005"))
+
+	assert.True(t, tracker.IsAddressInUse(ipv6Address+"/64"))
+	assert.False(t, tracker.IsAddressInUse("2600:0:0:1::6/64"))
+}
+
+// TestTracker_IsPrefixInUse tests the prefix is in use while any of its address is present in an endpoint slice
+func TestTracker_IsPrefixInUse(t *testing.T) {
+	tracker := getMockTracker(true)
+
+	tracker.OnAdd(getEndpointSlice("slice-1", discoveryv1.AddressTypeIPv6, ipv6Address))
+
+	assert.True(t, tracker.IsPrefixInUse("2600:0:0:1::/80"))
+	assert.True(t, tracker.IsPrefixInUse("2600:0000:0000:0001::/80"))
+	assert.False(t, tracker.IsPrefixInUse("2600:0:0:2::/80"))
+	// Prefixes of other lengths are matched against each address
+	assert.True(t, tracker.IsPrefixInUse("2600:0:0:1::/64"))
+	assert.False(t, tracker.IsPrefixInUse("2600:0:0:1:1::/96"))
+
+	tracker.OnDelete(getEndpointSlice("slice-1", discoveryv1.AddressTypeIPv6, ipv6Address))
+	assert.False(t, tracker.IsPrefixInUse("2600:0:0:1::/80"))
+	assert.Empty(t, tracker.prefixCount)
+}
+
+// TestTracker_NotSynced tests all the addresses are in use until the endpoint slices are synced
+func TestTracker_NotSynced(t *testing.T) {
+	tracker := getMockTracker(false)
+
+	assert.True(t, tracker.IsAddressInUse(ipv4Address1))
+	assert.True(t, tracker.IsPrefixInUse("2600:0:0:2::/80"))
+}
+
+// TestTracker_FQDNAddressType tests the addresses of the FQDN endpoint slices are not tracked
+func TestTracker_FQDNAddressType(t *testing.T) {
+	tracker := getMockTracker(true)
+
+	tracker.OnAdd(getEndpointSlice("slice-1", discoveryv1.AddressTypeFQDN, "example.com"))
+
+	assert.False(t, tracker.IsAddressInUse("example.com"))
+	assert.Empty(t, tracker.sliceAddresses)
+}
@@ -40,7 +40,7 @@ func NewOnDemandHandler(log logr.Logger, resourceName string,
 
 // HandleCreate provides the resource to the on demand resource by passing the Create Job to the respective Worker
 func (h *onDemandResourceHandler) HandleCreate(requestCount int, pod *v1.Pod) (ctrl.Result, error) {
-	job := worker.NewOnDemandCreateJob(pod.Namespace, pod.Name, requestCount)
+	job := worker.NewOnDemandCreateJob(pod.Spec.NodeName, pod.Namespace, pod.Name, requestCount)
 	h.resourceProvider.SubmitAsyncJob(job)
 
 	return ctrl.Result{}, nil
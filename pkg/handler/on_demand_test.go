@@ -50,6 +50,7 @@ var (
 
 	createJob = worker.OnDemandJob{
 		Operation:    worker.OperationCreate,
+		NodeName:     mockNodeName,
 		PodName:      mockPodName,
 		PodNamespace: mockPodNamespace,
 		RequestCount: 1,
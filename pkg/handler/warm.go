@@ -15,10 +15,13 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
@@ -55,7 +58,7 @@ func NewWarmResourceHandler(log logr.Logger, wrapper api.Wrapper,
 	}
 }
 
-func (w *warmResourceHandler) HandleCreate(_ int, pod *v1.Pod) (ctrl.Result, error) {
+func (w *warmResourceHandler) HandleCreate(requestCount int, pod *v1.Pod) (ctrl.Result, error) {
 	resourcePool, err := w.getResourcePool(pod.Spec.NodeName)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -68,7 +71,7 @@ func (w *warmResourceHandler) HandleCreate(_ int, pod *v1.Pod) (ctrl.Result, err
 	log := w.log.WithValues("UID", string(pod.UID), "namespace",
 		pod.Namespace, "name", pod.Name)
 
-	resID, shouldReconcile, err := resourcePool.AssignResource(string(pod.UID))
+	resIDs, shouldReconcile, err := w.assignResources(resourcePool, string(pod.UID), requestCount, isCriticalPod(pod))
 	if err != nil {
 		// Reconcile the pool before retrying or returning an error
 		w.reconcilePool(shouldReconcile, resourcePool)
@@ -96,7 +99,7 @@ func (w *warmResourceHandler) HandleCreate(_ int, pod *v1.Pod) (ctrl.Result, err
 			if present {
 				log.Info("cache had stale entry, pod already has resource",
 					"resource from annotation", resourceID,
-					"resource from data store", resID)
+					"resource from data store", resIDs)
 				return ctrl.Result{}, nil
 			}
 			return ctrl.Result{}, err
@@ -105,24 +108,95 @@ func (w *warmResourceHandler) HandleCreate(_ int, pod *v1.Pod) (ctrl.Result, err
 		}
 	}
 
-	err = w.APIWrapper.PodAPI.AnnotatePod(pod.Namespace, pod.Name, pod.UID, w.resourceName, resID)
+	err = w.annotateResources(pod, resIDs)
 	if err != nil {
-		_, errFree := resourcePool.FreeResource(string(pod.UID), resID)
+		_, errFree := w.freeResources(pod.Spec.NodeName, resourcePool, string(pod.UID), resIDs)
 		if errFree != nil {
 			err = fmt.Errorf("failed to annotate %v, failed to free %v", err, errFree)
 		}
 	}
 
 	w.APIWrapper.K8sAPI.BroadcastEvent(pod, ReasonResourceAllocated,
-		fmt.Sprintf("Allocated Resource %s: %s to the pod", w.resourceName, resID), v1.EventTypeNormal)
+		fmt.Sprintf("Allocated Resource %s: %s to the pod", w.resourceName, strings.Join(resIDs, ",")),
+		v1.EventTypeNormal)
 
-	log.Info("successfully allocated and annotated resource", "resource id", resID)
+	log.Info("successfully allocated and annotated resource", "resource id", resIDs)
 
 	w.reconcilePool(shouldReconcile, resourcePool)
 
 	return ctrl.Result{}, err
 }
 
+// assignResources assigns the requested number of resources to the pod atomically, only critical pods are
+// assigned the reserved resources of the warm pool
+func (w *warmResourceHandler) assignResources(resourcePool pool.Pool, requesterID string,
+	requestCount int, critical bool) ([]string, bool, error) {
+	if requestCount > 1 || critical {
+		return resourcePool.AssignResources(requesterID, requestCount, critical)
+	}
+	resID, shouldReconcile, err := resourcePool.AssignResource(requesterID)
+	if err != nil {
+		return nil, shouldReconcile, err
+	}
+	return []string{resID}, shouldReconcile, nil
+}
+
+// isCriticalPod returns true if the pod has a system critical priority class or is annotated as critical
+func isCriticalPod(pod *v1.Pod) bool {
+	return config.CriticalPriorityClasses[pod.Spec.PriorityClassName] ||
+		pod.Annotations[config.CriticalPodAnnotation] == "true"
+}
+
+// annotateResources annotates the pod with the first resource, if more than one resource is assigned
+// the list of all the resources is annotated along with the first resource in a single patch
+func (w *warmResourceHandler) annotateResources(pod *v1.Pod, resIDs []string) error {
+	if len(resIDs) == 1 {
+		return w.APIWrapper.PodAPI.AnnotatePod(pod.Namespace, pod.Name, pod.UID, w.resourceName, resIDs[0])
+	}
+	resources, err := json.Marshal(resIDs)
+	if err != nil {
+		return err
+	}
+	return w.APIWrapper.PodAPI.AnnotatePodWithValues(pod.Namespace, pod.Name, pod.UID, map[string]string{
+		w.resourceName: resIDs[0],
+		config.ResourcesAnnotation[w.resourceName]: string(resources),
+	})
+}
+
+// freeResources frees all the resources assigned to the pod together, the freed resources are added to the cool
+// down queue of the node
+func (w *warmResourceHandler) freeResources(nodeName string, resourcePool pool.Pool, requesterID string,
+	resIDs []string) (shouldReconcile bool, err error) {
+	if len(resIDs) == 1 {
+		shouldReconcile, err = resourcePool.FreeResource(requesterID, resIDs[0])
+	} else {
+		shouldReconcile, err = resourcePool.FreeResources(requesterID, resIDs)
+	}
+	if err == nil {
+		if checker, ok := w.resourceProvider.(provider.CoolDownChecker); ok {
+			checker.CheckCoolDownQueue(nodeName)
+		}
+	}
+	return shouldReconcile, err
+}
+
+// getResourcesFromAnnotation returns the list of resources from the pod annotation
+func (w *warmResourceHandler) getResourcesFromAnnotation(pod *v1.Pod) ([]string, bool) {
+	if resources, present := pod.Annotations[config.ResourcesAnnotation[w.resourceName]]; present {
+		var resIDs []string
+		if err := json.Unmarshal([]byte(resources), &resIDs); err == nil && len(resIDs) > 0 {
+			return resIDs, true
+		}
+		w.log.Info("failed to parse the resources annotation, will use the pod's resource annotation",
+			"annotation", resources)
+	}
+	resourceID, present := pod.Annotations[w.resourceName]
+	if !present {
+		return nil, false
+	}
+	return []string{resourceID}, true
+}
+
 func (w *warmResourceHandler) reconcilePool(shouldReconcile bool, resourcePool pool.Pool) {
 	if shouldReconcile {
 		job := resourcePool.ReconcilePool()
@@ -140,24 +214,24 @@ func (w *warmResourceHandler) HandleDelete(pod *v1.Pod) (ctrl.Result, error) {
 			"node", pod.Spec.NodeName)
 		return ctrl.Result{}, nil
 	}
-	resourceID, present := pod.Annotations[w.resourceName]
+	resourceIDs, present := w.getResourcesFromAnnotation(pod)
 	if !present {
 		// When a Pod with TerminationGracePeriodSeconds set to 0 is created and
 		// deleted immediately, the delete event doesnt' contain the resource
 		// annotation, in such cases, query the data store to get the assigned resource
-		resourceID, present = resourcePool.GetAssignedResource(string(pod.UID))
+		resourceIDs, present = resourcePool.GetAssignedResources(string(pod.UID))
 		if !present {
 			return ctrl.Result{}, nil
 		}
 		w.log.Info("resource ID was not found in annotation, fetched from pool",
-			"resource from data store", resourceID)
+			"resource from data store", resourceIDs)
 	}
 	log := w.log.WithValues("UID", string(pod.UID), "namespace", pod.Namespace,
-		"name", pod.Name, "resource id", resourceID)
+		"name", pod.Name, "resource id", resourceIDs)
 
 	// Handle Delete can be invoked multiple times for same object. For instance
 	// Once a Pod has Succeeded/Failed and once the object is actually deleted
-	shouldReconcile, err := resourcePool.FreeResource(string(pod.UID), resourceID)
+	shouldReconcile, err := w.freeResources(pod.Spec.NodeName, resourcePool, string(pod.UID), resourceIDs)
 	if err != nil {
 		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
 			log.V(1).Info("failed to free resource, resource likely freed when pod succeed/failed")
@@ -22,6 +22,7 @@ import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	podConverter "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s/pod"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 
@@ -42,6 +43,7 @@ var (
 	podName      = "pod-1"
 	podNamespace = "pod-ns"
 	ipAddress    = "192.168.1.1"
+	ipAddress2   = "192.168.1.2"
 
 	pod = &v1.Pod{
 		TypeMeta: metav1.TypeMeta{},
@@ -83,6 +85,69 @@ func TestWarmResourceHandler_HandleCreate(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestWarmResourceHandler_HandleCreate_MultipleResources tests create assigns all the requested resources and
+// annotates the pod with the list of resources
+func TestWarmResourceHandler_HandleCreate_MultipleResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, mockK8sWrapper, mockPodAPI, mockProvider, mockPool := getHandlerAndMocks(ctrl)
+	podCopy := pod.DeepCopy()
+	delete(podCopy.Annotations, config.ResourceNameIPAddress)
+
+	mockProvider.EXPECT().GetPool(nodeName).Return(mockPool, true)
+	mockPool.EXPECT().AssignResources(uid, 2, false).Return([]string{ipAddress, ipAddress2}, true, nil)
+	mockPodAPI.EXPECT().AnnotatePodWithValues(pod.Namespace, pod.Name, types.UID(uid), map[string]string{
+		resourceName:                   ipAddress,
+		config.IPv4AddressesAnnotation: `["192.168.1.1","192.168.1.2"]`,
+	}).Return(nil)
+	mockK8sWrapper.EXPECT().BroadcastEvent(podCopy, ReasonResourceAllocated, gomock.Any(), v1.EventTypeNormal)
+
+	mockPool.EXPECT().ReconcilePool().Return(job)
+	mockProvider.EXPECT().SubmitAsyncJob(job)
+
+	_, err := handler.HandleCreate(2, podCopy)
+	assert.NoError(t, err)
+}
+
+// TestWarmResourceHandler_HandleCreate_CriticalPod tests create assigns the resource from the reserved warm resources
+// to a pod with a system critical priority class
+func TestWarmResourceHandler_HandleCreate_CriticalPod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, mockK8sWrapper, mockPodAPI, mockProvider, mockPool := getHandlerAndMocks(ctrl)
+	podCopy := pod.DeepCopy()
+	podCopy.Spec.PriorityClassName = "system-node-critical"
+	delete(podCopy.Annotations, config.ResourceNameIPAddress)
+
+	mockProvider.EXPECT().GetPool(nodeName).Return(mockPool, true)
+	mockPool.EXPECT().AssignResources(uid, 1, true).Return([]string{ipAddress}, true, nil)
+	mockPodAPI.EXPECT().AnnotatePod(pod.Namespace, pod.Name, types.UID(uid), resourceName, ipAddress).Return(nil)
+	mockK8sWrapper.EXPECT().BroadcastEvent(podCopy, ReasonResourceAllocated, gomock.Any(), v1.EventTypeNormal)
+
+	mockPool.EXPECT().ReconcilePool().Return(job)
+	mockProvider.EXPECT().SubmitAsyncJob(job)
+
+	_, err := handler.HandleCreate(1, podCopy)
+	assert.NoError(t, err)
+}
+
+// TestIsCriticalPod tests the pods with a system critical priority class or the critical annotation are critical
+// once stripped down by the converter before they are stored in the data store
+func TestIsCriticalPod(t *testing.T) {
+	converter := podConverter.PodConverter{}
+	podCopy := pod.DeepCopy()
+	assert.False(t, isCriticalPod(converter.StripDownPod(podCopy)))
+
+	podCopy.Spec.PriorityClassName = "system-cluster-critical"
+	assert.True(t, isCriticalPod(converter.StripDownPod(podCopy)))
+
+	podCopy.Spec.PriorityClassName = ""
+	podCopy.Annotations[config.CriticalPodAnnotation] = "true"
+	assert.True(t, isCriticalPod(converter.StripDownPod(podCopy)))
+}
+
 func TestWarmResourceHandler_PoolEmpty(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -134,6 +199,24 @@ func TestWarmResourceHandler_HandleDelete(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestWarmResourceHandler_HandleDelete_CheckCoolDownQueue tests the provider is asked to check the cool down queue of
+// the node once the resource is freed, if the provider checks the endpoints of the resources cooling down
+func TestWarmResourceHandler_HandleDelete_CheckCoolDownQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, _, _, mockProvider, mockPool := getHandlerAndMocks(ctrl)
+	checker := &coolDownCheckingProvider{MockResourceProvider: mockProvider}
+	handler.resourceProvider = checker
+
+	mockProvider.EXPECT().GetPool(nodeName).Return(mockPool, true)
+	mockPool.EXPECT().FreeResource(uid, ipAddress).Return(false, nil)
+
+	_, err := handler.HandleDelete(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{nodeName}, checker.checkedNodes)
+}
+
 // TestNewWarmResourceHandler_HandleDelete tests resources are deleted by calling the respective resource provider
 func TestWarmResourceHandler_HandleDelete_ReconcileAfter(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -162,7 +245,7 @@ func TestWarmResourceHandler_HandleDelete_ResourceNotExist(t *testing.T) {
 	podCopy := pod.DeepCopy()
 	delete(podCopy.Annotations, config.ResourceNameIPAddress)
 
-	mockPool.EXPECT().GetAssignedResource(uid).Return("", false)
+	mockPool.EXPECT().GetAssignedResources(uid).Return(nil, false)
 
 	_, err := handler.HandleDelete(podCopy)
 	assert.NoError(t, err)
@@ -179,13 +262,30 @@ func TestWarmResourceHandler_HandleDelete_ResourceExistsInDataStoreOnly(t *testi
 	podCopy := pod.DeepCopy()
 	delete(podCopy.Annotations, config.ResourceNameIPAddress)
 
-	mockPool.EXPECT().GetAssignedResource(string(podCopy.UID)).Return(ipAddress, true)
+	mockPool.EXPECT().GetAssignedResources(string(podCopy.UID)).Return([]string{ipAddress}, true)
 	mockPool.EXPECT().FreeResource(string(podCopy.UID), ipAddress).Return(false, nil)
 
 	_, err := handler.HandleDelete(podCopy)
 	assert.NoError(t, err)
 }
 
+// TestWarmResourceHandler_HandleDelete_MultipleResources tests all the resources in the annotation are freed
+func TestWarmResourceHandler_HandleDelete_MultipleResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, _, _, mockProvider, mockPool := getHandlerAndMocks(ctrl)
+
+	podCopy := pod.DeepCopy()
+	podCopy.Annotations[config.IPv4AddressesAnnotation] = `["192.168.1.1","192.168.1.2"]`
+
+	mockProvider.EXPECT().GetPool(nodeName).Return(mockPool, true)
+	mockPool.EXPECT().FreeResources(uid, []string{ipAddress, ipAddress2}).Return(false, nil)
+
+	_, err := handler.HandleDelete(podCopy)
+	assert.NoError(t, err)
+}
+
 // TestNewWarmResourceHandler_HandleDelete_Error asserts error is returned if the resource pool is not found
 func TestWarmResourceHandler_HandleDelete_Error(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -228,6 +328,16 @@ func TestWarmResourceHandler_getResourcePool_PoolNotFound(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+// coolDownCheckingProvider is a resource provider that records the nodes whose cool down queue is checked
+type coolDownCheckingProvider struct {
+	*mock_provider.MockResourceProvider
+	checkedNodes []string
+}
+
+func (p *coolDownCheckingProvider) CheckCoolDownQueue(nodeName string) {
+	p.checkedNodes = append(p.checkedNodes, nodeName)
+}
+
 func getHandlerAndMocks(ctrl *gomock.Controller) (*warmResourceHandler, *mock_k8s.MockK8sWrapper,
 	*mock_pod.MockPodClientAPIWrapper, *mock_provider.MockResourceProvider, *mock_pool.MockPool) {
 
@@ -15,6 +15,7 @@ package pod
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -51,6 +52,22 @@ var (
 		[]string{"annotate_key"},
 	)
 
+	updatePodConditionCallCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "update_pod_condition_call_count",
+			Help: "The number of request to update the pod status condition",
+		},
+		[]string{"condition_type"},
+	)
+
+	updatePodConditionErrCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "update_pod_condition_err_count",
+			Help: "The number of request that failed to update the pod status condition",
+		},
+		[]string{"condition_type"},
+	)
+
 	getPodFromAPIServeCallCount = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Name: "get_pod_from_api_server_call_count",
@@ -70,6 +87,8 @@ type PodClientAPIWrapper interface {
 	GetPod(namespace string, name string) (*v1.Pod, error)
 	ListPods(nodeName string) (*v1.PodList, error)
 	AnnotatePod(podNamespace string, podName string, uid types.UID, key string, val string) error
+	AnnotatePodWithValues(podNamespace string, podName string, uid types.UID, annotations map[string]string) error
+	UpdatePodCondition(podNamespace string, podName string, uid types.UID, condition v1.PodCondition) error
 	GetPodFromAPIServer(ctx context.Context, namespace string, name string) (*v1.Pod, error)
 	GetRunningPodsOnNode(nodeName string) ([]v1.Pod, error)
 }
@@ -91,6 +110,8 @@ func prometheusRegister() {
 	metrics.Registry.MustRegister(
 		annotatePodRequestCallCount,
 		annotatePodRequestErrCount,
+		updatePodConditionCallCount,
+		updatePodConditionErrCount,
 		getPodFromAPIServeCallCount,
 		getPodFromAPIServeErrCount)
 
@@ -145,7 +166,15 @@ func (p *podClientAPIWrapper) ListPods(nodeName string) (*v1.PodList, error) {
 // AnnotatePod annotates the pod with the provided key and value
 func (p *podClientAPIWrapper) AnnotatePod(podNamespace string, podName string, uid types.UID,
 	key string, val string) error {
-	annotatePodRequestCallCount.WithLabelValues(key).Inc()
+	return p.AnnotatePodWithValues(podNamespace, podName, uid, map[string]string{key: val})
+}
+
+// AnnotatePodWithValues annotates the pod with all the provided key and values in a single patch
+func (p *podClientAPIWrapper) AnnotatePodWithValues(podNamespace string, podName string, uid types.UID,
+	annotations map[string]string) error {
+	for key := range annotations {
+		annotatePodRequestCallCount.WithLabelValues(key).Inc()
+	}
 	ctx := context.Background()
 
 	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
@@ -162,13 +191,53 @@ func (p *podClientAPIWrapper) AnnotatePod(podNamespace string, podName string, u
 				"intended for Pod with UID %s", pod.UID, uid)
 		}
 		newPod := pod.DeepCopy()
-		newPod.Annotations[key] = val
+		for key, val := range annotations {
+			newPod.Annotations[key] = val
+		}
 
 		return p.client.Patch(ctx, newPod, client.MergeFrom(pod))
 	})
 
 	if err != nil {
-		annotatePodRequestErrCount.WithLabelValues(key).Inc()
+		for key := range annotations {
+			annotatePodRequestErrCount.WithLabelValues(key).Inc()
+		}
+	}
+
+	return err
+}
+
+// UpdatePodCondition sets the condition on the pod status. The conditions are patched using a strategic
+// merge patch, so only the condition with the same type is replaced and the conditions owned by the
+// kubelet are left untouched
+func (p *podClientAPIWrapper) UpdatePodCondition(podNamespace string, podName string, uid types.UID,
+	condition v1.PodCondition) error {
+	updatePodConditionCallCount.WithLabelValues(string(condition.Type)).Inc()
+
+	err := func() error {
+		pod, err := p.GetPod(podNamespace, podName)
+		if err != nil {
+			return err
+		}
+		// Prevent updating the condition of a Pod re-created with the same namespace/name
+		if pod.UID != uid {
+			return fmt.Errorf("not updating the condition of Pod with UID %s as the condition was "+
+				"intended for Pod with UID %s", pod.UID, uid)
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"status": map[string]interface{}{
+				"conditions": []v1.PodCondition{condition},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return p.client.Status().Patch(context.Background(), pod.DeepCopy(),
+			client.RawPatch(types.StrategicMergePatchType, patch))
+	}()
+
+	if err != nil {
+		updatePodConditionErrCount.WithLabelValues(string(condition.Type)).Inc()
 	}
 
 	return err
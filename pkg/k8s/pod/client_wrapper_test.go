@@ -180,6 +180,25 @@ func TestPodAPI_AnnotatePod(t *testing.T) {
 	assert.Equal(t, newAnnotationValue, updatedPod.Annotations[newAnnotation])
 }
 
+// TestPodAPI_AnnotatePodWithValues tests all the annotations are added to the pod
+func TestPodAPI_AnnotatePodWithValues(t *testing.T) {
+	podAPI, k8sClient := getMockPodAPIWithClient()
+
+	err := podAPI.AnnotatePodWithValues(podNamespace, podName, podUid,
+		map[string]string{newAnnotation: newAnnotationValue, "another-key": "another-value"})
+	assert.NoError(t, err)
+
+	updatedPod := &v1.Pod{}
+	err = k8sClient.Get(context.TODO(), types.NamespacedName{
+		Namespace: podNamespace,
+		Name:      podName,
+	}, updatedPod)
+
+	assert.NoError(t, err)
+	assert.Equal(t, newAnnotationValue, updatedPod.Annotations[newAnnotation])
+	assert.Equal(t, "another-value", updatedPod.Annotations["another-key"])
+}
+
 // TestPodAPI_AnnotatePod_PodNotExists tests that annotate pod fails if the pod doesn't exist
 func TestPodAPI_AnnotatePod_PodNotExists(t *testing.T) {
 	podAPI, _ := getMockPodAPIWithClient()
@@ -204,3 +223,37 @@ func TestPodAPI_GetPod_NotExists(t *testing.T) {
 	_, err := podAPI.GetPod(podNamespace, "not-exist")
 	assert.NotNil(t, err)
 }
+
+// TestPodAPI_UpdatePodCondition tests that the condition is added to the pod status without removing the
+// existing conditions
+func TestPodAPI_UpdatePodCondition(t *testing.T) {
+	podAPI, k8sClient := getMockPodAPIWithClient()
+
+	existingCondition := v1.PodCondition{Type: v1.PodScheduled, Status: v1.ConditionTrue}
+	pod := &v1.Pod{}
+	err := k8sClient.Get(context.TODO(), types.NamespacedName{Namespace: podNamespace, Name: podName}, pod)
+	assert.NoError(t, err)
+	pod.Status.Conditions = []v1.PodCondition{existingCondition}
+	err = k8sClient.Status().Update(context.TODO(), pod)
+	assert.NoError(t, err)
+
+	condition := v1.PodCondition{Type: "vpc.amazonaws.com/condition", Status: v1.ConditionTrue}
+	err = podAPI.UpdatePodCondition(podNamespace, podName, podUid, condition)
+	assert.NoError(t, err)
+
+	updatedPod := &v1.Pod{}
+	err = k8sClient.Get(context.TODO(), types.NamespacedName{Namespace: podNamespace, Name: podName}, updatedPod)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []v1.PodCondition{existingCondition, condition}, updatedPod.Status.Conditions)
+}
+
+// TestPodAPI_UpdatePodCondition_UID_Changed tests that the condition is not updated if the pod was re-created
+func TestPodAPI_UpdatePodCondition_UID_Changed(t *testing.T) {
+	podAPI, _ := getMockPodAPIWithClient()
+
+	newUiD := types.UID("00000000-0000-0000-0000-000000000001")
+
+	err := podAPI.UpdatePodCondition(podNamespace, podName, newUiD,
+		v1.PodCondition{Type: "vpc.amazonaws.com/condition", Status: v1.ConditionTrue})
+	assert.Error(t, err)
+}
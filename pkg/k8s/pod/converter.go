@@ -105,9 +105,12 @@ func (c *PodConverter) StripDownPod(pod *v1.Pod) *v1.Pod {
 			Containers:         getContainersWithVPCLimits(pod.Spec.Containers),
 			ServiceAccountName: pod.Spec.ServiceAccountName,
 			NodeName:           pod.Spec.NodeName,
+			PriorityClassName:  pod.Spec.PriorityClassName,
+			ReadinessGates:     getVPCControllerReadinessGates(pod.Spec.ReadinessGates),
 		},
 		Status: v1.PodStatus{
-			Phase: pod.Status.Phase,
+			Phase:      pod.Status.Phase,
+			Conditions: getVPCControllerConditions(pod.Status.Conditions),
 		},
 	}
 }
@@ -124,6 +127,29 @@ func getVPCControllerAnnotations(annotations map[string]string) map[string]strin
 	return strippedDownAnnotations
 }
 
+// getVPCControllerReadinessGates returns only the readiness gates of the conditions set by VPC Resource
+// controller
+func getVPCControllerReadinessGates(gates []v1.PodReadinessGate) []v1.PodReadinessGate {
+	var strippedDownGates []v1.PodReadinessGate
+	for _, gate := range gates {
+		if strings.HasPrefix(string(gate.ConditionType), config.VPCResourcePrefix) {
+			strippedDownGates = append(strippedDownGates, gate)
+		}
+	}
+	return strippedDownGates
+}
+
+// getVPCControllerConditions returns only the conditions set by VPC Resource controller
+func getVPCControllerConditions(conditions []v1.PodCondition) []v1.PodCondition {
+	var strippedDownConditions []v1.PodCondition
+	for _, condition := range conditions {
+		if strings.HasPrefix(string(condition.Type), config.VPCResourcePrefix) {
+			strippedDownConditions = append(strippedDownConditions, condition)
+		}
+	}
+	return strippedDownConditions
+}
+
 // getContainersWithVPCLimits returns only the container limits for vpc controller
 // resources
 func getContainersWithVPCLimits(containers []v1.Container) []v1.Container {
@@ -19,6 +19,7 @@ import (
 	"sync"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node"
@@ -37,6 +38,9 @@ type manager struct {
 	lock sync.RWMutex
 	// dataStore is the in memory data store of all the managed/un-managed nodes in the cluster
 	dataStore map[string]node.Node
+	// trunkENIConfigErrors is the last trunk ENIConfig error broadcasted on each node, the event is broadcasted
+	// again only when the error changes
+	trunkENIConfigErrors map[string]string
 	// resourceManager provides the resource provider for all supported resources
 	resourceManager resource.ResourceManager
 	// wrapper around the clients for all APIs used by controller
@@ -44,6 +48,13 @@ type manager struct {
 	// worker for performing async operation on node APIs
 	worker     asyncWorker.Worker
 	conditions condition.Conditions
+	// fallbackSubnetIDs is the ordered list of subnets used for creating network interfaces when the
+	// node's subnet runs out of IP addresses
+	fallbackSubnetIDs []string
+	// networkCardPolicy chooses the network card of the network interfaces on instances with multiple network cards
+	networkCardPolicy ec2.NetworkCardPolicy
+	// linuxIPv4Enabled manages all the Linux nodes for the IPv4 resource, irrespective of the trunk label
+	linuxIPv4Enabled bool
 }
 
 // Manager to perform operation on list of managed/un-managed node
@@ -64,11 +75,19 @@ const (
 	Init   = AsyncOperation("Init")
 	Update = AsyncOperation("Update")
 	Delete = AsyncOperation("Delete")
+	// UpdateTrunk initializes or de-initializes the providers depending on the trunk ENI before updating the node
+	UpdateTrunk = AsyncOperation("UpdateTrunk")
 )
 
 // NodeUpdateStatus represents the status of the Node on Update operation.
 type NodeUpdateStatus string
 
+const (
+	// ReasonTrunkENIConfigInvalid is the reason of the event broadcasted on the node when the trunk ENIConfig
+	// can't be used and the trunk ENI falls back to the node's subnet
+	ReasonTrunkENIConfigInvalid = "TrunkENIConfigInvalid"
+)
+
 const (
 	ManagedToUnManaged = NodeUpdateStatus("managedToUnManaged")
 	UnManagedToManaged = NodeUpdateStatus("UnManagedToManaged")
@@ -80,19 +99,26 @@ type AsyncOperationJob struct {
 	op       AsyncOperation
 	node     node.Node
 	nodeName string
+	// trunkEnabled is whether the node can attach a trunk ENI, used by the UpdateTrunk operation
+	trunkEnabled bool
 }
 
 // NewNodeManager returns a new node manager
 func NewNodeManager(logger logr.Logger, resourceManager resource.ResourceManager,
-	wrapper api.Wrapper, worker asyncWorker.Worker, conditions condition.Conditions) (Manager, error) {
+	wrapper api.Wrapper, worker asyncWorker.Worker, conditions condition.Conditions,
+	fallbackSubnetIDs []string, networkCardPolicy ec2.NetworkCardPolicy, linuxIPv4Enabled bool) (Manager, error) {
 
 	manager := &manager{
-		resourceManager: resourceManager,
-		Log:             logger,
-		dataStore:       make(map[string]node.Node),
-		wrapper:         wrapper,
-		worker:          worker,
-		conditions:      conditions,
+		resourceManager:      resourceManager,
+		Log:                  logger,
+		dataStore:            make(map[string]node.Node),
+		trunkENIConfigErrors: make(map[string]string),
+		wrapper:              wrapper,
+		worker:               worker,
+		conditions:           conditions,
+		fallbackSubnetIDs:    fallbackSubnetIDs,
+		networkCardPolicy:    networkCardPolicy,
+		linuxIPv4Enabled:     linuxIPv4Enabled,
 	}
 
 	return manager, worker.StartWorkerPool(manager.performAsyncOperation)
@@ -135,10 +161,14 @@ func (m *manager) AddNode(nodeName string) error {
 	if shouldManage {
 		newNode = node.NewManagedNode(m.Log, k8sNode.Name, GetNodeInstanceID(k8sNode),
 			GetNodeOS(k8sNode))
+		newNode.UpdateFallbackSubnets(m.fallbackSubnetIDs)
+		newNode.UpdateNetworkCardPolicy(m.networkCardPolicy)
+		newNode.UpdateTrunkEnabled(canAttachTrunk(k8sNode))
 		err := m.updateSubnetIfUsingENIConfig(newNode, k8sNode)
 		if err != nil {
 			return err
 		}
+		m.updateTrunkSubnetIfUsingENIConfig(newNode, k8sNode)
 		m.dataStore[k8sNode.Name] = newNode
 		log.Info("node added as a managed node")
 		op = Init
@@ -186,11 +216,15 @@ func (m *manager) UpdateNode(nodeName string) error {
 		log.Info("node was previously un-managed, will be added as managed node now")
 		cachedNode = node.NewManagedNode(m.Log, k8sNode.Name,
 			GetNodeInstanceID(k8sNode), GetNodeOS(k8sNode))
+		cachedNode.UpdateFallbackSubnets(m.fallbackSubnetIDs)
+		cachedNode.UpdateNetworkCardPolicy(m.networkCardPolicy)
+		cachedNode.UpdateTrunkEnabled(canAttachTrunk(k8sNode))
 		// Update the Subnet if the node has custom networking configured
 		err = m.updateSubnetIfUsingENIConfig(cachedNode, k8sNode)
 		if err != nil {
 			return err
 		}
+		m.updateTrunkSubnetIfUsingENIConfig(cachedNode, k8sNode)
 		m.dataStore[nodeName] = cachedNode
 		op = Init
 	case ManagedToUnManaged:
@@ -198,6 +232,7 @@ func (m *manager) UpdateNode(nodeName string) error {
 		// Change the node in cache, but for de initializing all resource providers
 		// pass the async job the older cached value instead
 		m.dataStore[nodeName] = node.NewUnManagedNode()
+		delete(m.trunkENIConfigErrors, nodeName)
 		op = Delete
 	case StillManaged:
 		// We only need to update the Subnet for Managed Node. This subnet is required for creating
@@ -207,7 +242,15 @@ func (m *manager) UpdateNode(nodeName string) error {
 		if err != nil {
 			return err
 		}
+		m.updateTrunkSubnetIfUsingENIConfig(cachedNode, k8sNode)
 		op = Update
+		if m.linuxIPv4Enabled && cachedNode.IsTrunkEnabled() != canAttachTrunk(k8sNode) {
+			// The trunk label changed on a Linux node managed for the IPv4 resource, only the pod-eni resource
+			// is initialized or de-initialized so the IPv4 resources of the node are retained
+			log.Info("trunk label changed on managed node, will update the trunk resources",
+				"trunk enabled", canAttachTrunk(k8sNode))
+			op = UpdateTrunk
+		}
 	case StillUnManaged:
 		log.V(1).Info("node not managed, no operation required")
 		// No async operation required for un-managed nodes
@@ -215,9 +258,10 @@ func (m *manager) UpdateNode(nodeName string) error {
 	}
 
 	m.worker.SubmitJob(AsyncOperationJob{
-		op:       op,
-		node:     cachedNode,
-		nodeName: nodeName,
+		op:           op,
+		node:         cachedNode,
+		nodeName:     nodeName,
+		trunkEnabled: canAttachTrunk(k8sNode),
 	})
 	return nil
 }
@@ -250,6 +294,7 @@ func (m *manager) DeleteNode(nodeName string) error {
 	}
 
 	delete(m.dataStore, nodeName)
+	delete(m.trunkENIConfigErrors, nodeName)
 
 	if !cachedNode.IsManaged() {
 		log.V(1).Info("un managed node removed from data store")
@@ -289,6 +334,44 @@ func (m *manager) updateSubnetIfUsingENIConfig(cachedNode node.Node, k8sNode *v1
 	return nil
 }
 
+// updateTrunkSubnetIfUsingENIConfig updates the subnet and security group used for creating the trunk ENI to the
+// one specified in the ENIConfig referenced by the trunk ENIConfig label. If the ENIConfig has no security group
+// the trunk ENI uses the node's security group. Since the trunk is created only once, changes to the label or the
+// ENIConfig only apply to trunk ENIs created after the update. If the ENIConfig is missing or has no subnet, a
+// warning event is broadcasted on the node once per error and the trunk ENI falls back to the node's subnet
+func (m *manager) updateTrunkSubnetIfUsingENIConfig(cachedNode node.Node, k8sNode *v1.Node) {
+	eniConfigName, isPresent := k8sNode.Labels[config.TrunkENIConfigLabel]
+	if !isPresent {
+		delete(m.trunkENIConfigErrors, k8sNode.Name)
+		cachedNode.UpdateTrunkNetworkingSpecs("", nil)
+		return
+	}
+
+	var err error
+	eniConfig, getErr := m.wrapper.K8sAPI.GetENIConfig(eniConfigName)
+	if getErr != nil {
+		err = fmt.Errorf("failed to find the trunk ENIConfig %s: %v", eniConfigName, getErr)
+	} else if eniConfig.Spec.Subnet == "" {
+		err = fmt.Errorf("failed to find subnet in trunk eniconfig spec %s", eniConfigName)
+	}
+	if err != nil {
+		// The node is updated on every status update, broadcast the event only when the error changes
+		if m.trunkENIConfigErrors[k8sNode.Name] != err.Error() {
+			m.Log.Error(err, "falling back to the node's subnet for the trunk ENI", "node", k8sNode.Name)
+			m.wrapper.K8sAPI.BroadcastEvent(k8sNode, ReasonTrunkENIConfigInvalid,
+				fmt.Sprintf("The trunk ENI will use the node's subnet: %v", err), v1.EventTypeWarning)
+			m.trunkENIConfigErrors[k8sNode.Name] = err.Error()
+		}
+		cachedNode.UpdateTrunkNetworkingSpecs("", nil)
+		return
+	}
+	delete(m.trunkENIConfigErrors, k8sNode.Name)
+
+	m.Log.V(1).Info("node is using separate trunk networking, updating the trunk subnet", "node", k8sNode.Name,
+		"subnet", eniConfig.Spec.Subnet, "security groups", eniConfig.Spec.SecurityGroups)
+	cachedNode.UpdateTrunkNetworkingSpecs(eniConfig.Spec.Subnet, eniConfig.Spec.SecurityGroups)
+}
+
 // performAsyncOperation performs the operation on a node without taking the node manager lock
 func (m *manager) performAsyncOperation(job interface{}) (ctrl.Result, error) {
 	asyncJob, ok := job.(AsyncOperationJob)
@@ -312,6 +395,15 @@ func (m *manager) performAsyncOperation(job interface{}) (ctrl.Result, error) {
 		// If there's no error, we need to update the node so the capacity is advertised
 		asyncJob.op = Update
 		return m.performAsyncOperation(asyncJob)
+	case UpdateTrunk:
+		err = asyncJob.node.UpdateTrunkResources(m.resourceManager, asyncJob.trunkEnabled)
+		if err != nil {
+			// The trunk resources are updated again on the next node event
+			log.Error(err, "failed to update the trunk resources")
+		}
+		// Advertise the capacity of the initialized resources
+		asyncJob.op = Update
+		return m.performAsyncOperation(asyncJob)
 	case Update:
 		err = asyncJob.node.UpdateResources(m.resourceManager, m.wrapper.EC2API)
 	case Delete:
@@ -342,7 +434,8 @@ func (m *manager) isSelectedForManagement(v1node *v1.Node) bool {
 		return false
 	}
 
-	return (isWindowsNode(v1node) && m.conditions.IsWindowsIPAMEnabled()) || canAttachTrunk(v1node)
+	return (isWindowsNode(v1node) && m.conditions.IsWindowsIPAMEnabled()) || canAttachTrunk(v1node) ||
+		(m.linuxIPv4Enabled && os == config.OSLinux)
 }
 
 // GetNodeInstanceID returns the EC2 instance ID of a node
@@ -25,6 +25,7 @@ import (
 	mock_resource "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/resource"
 	mock_worker "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/worker"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node"
 
@@ -49,6 +50,14 @@ var (
 		},
 	}
 
+	trunkENIConfigName = "trunk-eni-config-name"
+	trunkENIConfig     = &v1alpha1.ENIConfig{
+		Spec: v1alpha1.ENIConfigSpec{
+			Subnet:         "trunk-subnet-id",
+			SecurityGroups: []string{"sg-trunk"},
+		},
+	}
+
 	v1Node = &v1.Node{
 		TypeMeta: metav1.TypeMeta{},
 		ObjectMeta: metav1.ObjectMeta{
@@ -117,8 +126,9 @@ func NewMock(ctrl *gomock.Controller, existingDataStore map[string]node.Node) Mo
 
 	return Mock{
 		Manager: manager{
-			dataStore: existingDataStore,
-			Log:       zap.New(),
+			dataStore:            existingDataStore,
+			trunkENIConfigErrors: map[string]string{},
+			Log:                  zap.New(),
 			wrapper: api.Wrapper{
 				K8sAPI: mockK8sWrapper,
 				EC2API: mockEC2APIHelper,
@@ -144,7 +154,8 @@ func Test_GetNewManager(t *testing.T) {
 	mock := NewMock(ctrl, map[string]node.Node{})
 
 	mock.MockWorker.EXPECT().StartWorkerPool(gomock.Any()).Return(nil)
-	manager, err := NewNodeManager(zap.New(), nil, api.Wrapper{}, mock.MockWorker, mock.MockConditions)
+	manager, err := NewNodeManager(zap.New(), nil, api.Wrapper{}, mock.MockWorker, mock.MockConditions, nil,
+		ec2.NetworkCardPolicy{}, false)
 
 	assert.NotNil(t, manager)
 	assert.NoError(t, err)
@@ -158,7 +169,8 @@ func Test_GetNewManager_Error(t *testing.T) {
 	mock := NewMock(ctrl, map[string]node.Node{})
 
 	mock.MockWorker.EXPECT().StartWorkerPool(gomock.Any()).Return(mockError)
-	manager, err := NewNodeManager(zap.New(), nil, api.Wrapper{}, mock.MockWorker, mock.MockConditions)
+	manager, err := NewNodeManager(zap.New(), nil, api.Wrapper{}, mock.MockWorker, mock.MockConditions, nil,
+		ec2.NetworkCardPolicy{}, false)
 
 	assert.NotNil(t, manager)
 	assert.Error(t, err, mockError)
@@ -260,6 +272,157 @@ func Test_AddNode_CustomNetworking_NoENIConfig(t *testing.T) {
 	assert.Error(t, err, mockError)
 }
 
+func Test_AddNode_TrunkENIConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, map[string]node.Node{})
+
+	job := AsyncOperationJob{
+		op:       Init,
+		nodeName: nodeName,
+		node:     managedNode,
+	}
+
+	nodeWithTrunkENIConfig := v1Node.DeepCopy()
+	nodeWithTrunkENIConfig.Labels[config.TrunkENIConfigLabel] = trunkENIConfigName
+
+	mock.MockK8sAPI.EXPECT().GetNode(nodeName).Return(nodeWithTrunkENIConfig, nil)
+	mock.MockK8sAPI.EXPECT().GetENIConfig(trunkENIConfigName).Return(trunkENIConfig, nil)
+	mock.MockWorker.EXPECT().SubmitJob(gomock.All(NewAsyncOperationMatcher(job)))
+
+	err := mock.Manager.AddNode(nodeName)
+	assert.NoError(t, err)
+	assert.Contains(t, mock.Manager.dataStore, nodeName)
+	assert.True(t, AreNodesEqual(mock.Manager.dataStore[nodeName], managedNode))
+}
+
+// Test_AddNode_TrunkENIConfig_NoSubnet tests the node is added with the trunk ENI in the node's subnet and a warning
+// event is broadcasted if the trunk ENIConfig has no subnet
+func Test_AddNode_TrunkENIConfig_NoSubnet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, map[string]node.Node{})
+
+	job := AsyncOperationJob{
+		op:       Init,
+		nodeName: nodeName,
+		node:     managedNode,
+	}
+
+	nodeWithTrunkENIConfig := v1Node.DeepCopy()
+	nodeWithTrunkENIConfig.Labels[config.TrunkENIConfigLabel] = trunkENIConfigName
+
+	mock.MockK8sAPI.EXPECT().GetNode(nodeName).Return(nodeWithTrunkENIConfig, nil)
+	mock.MockK8sAPI.EXPECT().GetENIConfig(trunkENIConfigName).Return(&v1alpha1.ENIConfig{}, nil)
+	mock.MockK8sAPI.EXPECT().BroadcastEvent(nodeWithTrunkENIConfig, ReasonTrunkENIConfigInvalid, gomock.Any(),
+		v1.EventTypeWarning)
+	mock.MockWorker.EXPECT().SubmitJob(gomock.All(NewAsyncOperationMatcher(job)))
+
+	err := mock.Manager.AddNode(nodeName)
+	assert.NoError(t, err)
+	assert.Contains(t, mock.Manager.dataStore, nodeName)
+}
+
+// Test_AddNode_TrunkENIConfig_NotFound tests the node is added with the trunk ENI in the node's subnet and a warning
+// event is broadcasted if the trunk ENIConfig doesn't exist
+func Test_AddNode_TrunkENIConfig_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, map[string]node.Node{})
+
+	job := AsyncOperationJob{
+		op:       Init,
+		nodeName: nodeName,
+		node:     managedNode,
+	}
+
+	nodeWithTrunkENIConfig := v1Node.DeepCopy()
+	nodeWithTrunkENIConfig.Labels[config.TrunkENIConfigLabel] = trunkENIConfigName
+
+	mock.MockK8sAPI.EXPECT().GetNode(nodeName).Return(nodeWithTrunkENIConfig, nil)
+	mock.MockK8sAPI.EXPECT().GetENIConfig(trunkENIConfigName).Return(nil, mockError)
+	mock.MockK8sAPI.EXPECT().BroadcastEvent(nodeWithTrunkENIConfig, ReasonTrunkENIConfigInvalid, gomock.Any(),
+		v1.EventTypeWarning)
+	mock.MockWorker.EXPECT().SubmitJob(gomock.All(NewAsyncOperationMatcher(job)))
+
+	err := mock.Manager.AddNode(nodeName)
+	assert.NoError(t, err)
+	assert.Contains(t, mock.Manager.dataStore, nodeName)
+	assert.True(t, AreNodesEqual(mock.Manager.dataStore[nodeName], managedNode))
+}
+
+func Test_UpdateNode_Managed_TrunkENIConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The async job matcher invokes the cached node while the worker mock holds the controller lock,
+	// so the node is mocked using a separate controller
+	nodeCtrl := gomock.NewController(t)
+	defer nodeCtrl.Finish()
+	mockNode := mock_node.NewMockNode(nodeCtrl)
+
+	mock := NewMock(ctrl, map[string]node.Node{nodeName: mockNode})
+
+	job := AsyncOperationJob{
+		op:       Update,
+		nodeName: nodeName,
+		node:     managedNode,
+	}
+
+	nodeWithTrunkENIConfig := v1Node.DeepCopy()
+	nodeWithTrunkENIConfig.Labels[config.TrunkENIConfigLabel] = trunkENIConfigName
+
+	mock.MockK8sAPI.EXPECT().GetNode(nodeName).Return(nodeWithTrunkENIConfig, nil)
+	mock.MockK8sAPI.EXPECT().GetENIConfig(trunkENIConfigName).Return(trunkENIConfig, nil)
+	mockNode.EXPECT().IsManaged().Return(true).AnyTimes()
+	mockNode.EXPECT().IsReady().Return(false).AnyTimes()
+	mockNode.EXPECT().UpdateCustomNetworkingSpecs("", nil)
+	mockNode.EXPECT().UpdateTrunkNetworkingSpecs(trunkENIConfig.Spec.Subnet, trunkENIConfig.Spec.SecurityGroups)
+	mock.MockWorker.EXPECT().SubmitJob(gomock.All(NewAsyncOperationMatcher(job)))
+
+	err := mock.Manager.UpdateNode(nodeName)
+	assert.NoError(t, err)
+}
+
+// Test_UpdateNode_Managed_TrunkENIConfigInvalid tests the warning event is broadcasted once while the trunk ENIConfig
+// stays invalid across the node updates, and again if the ENIConfig becomes invalid after being fixed
+func Test_UpdateNode_Managed_TrunkENIConfigInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nodeCtrl := gomock.NewController(t)
+	defer nodeCtrl.Finish()
+	mockNode := mock_node.NewMockNode(nodeCtrl)
+
+	mock := NewMock(ctrl, map[string]node.Node{nodeName: mockNode})
+
+	nodeWithTrunkENIConfig := v1Node.DeepCopy()
+	nodeWithTrunkENIConfig.Labels[config.TrunkENIConfigLabel] = trunkENIConfigName
+
+	mock.MockK8sAPI.EXPECT().GetNode(nodeName).Return(nodeWithTrunkENIConfig, nil).Times(4)
+	gomock.InOrder(
+		mock.MockK8sAPI.EXPECT().GetENIConfig(trunkENIConfigName).Return(nil, mockError).Times(2),
+		mock.MockK8sAPI.EXPECT().GetENIConfig(trunkENIConfigName).Return(trunkENIConfig, nil),
+		mock.MockK8sAPI.EXPECT().GetENIConfig(trunkENIConfigName).Return(nil, mockError),
+	)
+	mock.MockK8sAPI.EXPECT().BroadcastEvent(nodeWithTrunkENIConfig, ReasonTrunkENIConfigInvalid, gomock.Any(),
+		v1.EventTypeWarning).Times(2)
+	mockNode.EXPECT().IsManaged().Return(true).AnyTimes()
+	mockNode.EXPECT().IsReady().Return(false).AnyTimes()
+	mockNode.EXPECT().UpdateCustomNetworkingSpecs("", nil).Times(4)
+	mockNode.EXPECT().UpdateTrunkNetworkingSpecs("", nil).Times(3)
+	mockNode.EXPECT().UpdateTrunkNetworkingSpecs(trunkENIConfig.Spec.Subnet, trunkENIConfig.Spec.SecurityGroups)
+	mock.MockWorker.EXPECT().SubmitJob(gomock.Any()).Times(4)
+
+	for i := 0; i < 4; i++ {
+		err := mock.Manager.UpdateNode(nodeName)
+		assert.NoError(t, err)
+	}
+}
+
 func Test_UpdateNode_Managed(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -542,6 +705,104 @@ func Test_isSelectedForManagement_WindowsIPAMEnabled_True(t *testing.T) {
 	assert.True(t, isSelected)
 }
 
+// Test_isSelectedForManagement_LinuxIPv4Enabled tests Linux nodes without the trunk label are selected only when
+// the IPv4 resource is enabled for Linux
+func Test_isSelectedForManagement_LinuxIPv4Enabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	linuxNode := v1Node.DeepCopy()
+	linuxNode.Labels = map[string]string{config.NodeLabelOS: config.OSLinux}
+	mock := NewMock(ctrl, map[string]node.Node{})
+
+	assert.False(t, mock.Manager.isSelectedForManagement(linuxNode))
+
+	mock.Manager.linuxIPv4Enabled = true
+	assert.True(t, mock.Manager.isSelectedForManagement(linuxNode))
+}
+
+// Test_UpdateNode_LinuxIPv4_TrunkLabelAdded tests the node managed for IPv4 resource stays managed when the trunk
+// label is added, and the trunk resources are updated in the same update so the IPv4 resources are retained
+func Test_UpdateNode_LinuxIPv4_TrunkLabelAdded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ipv4ManagedNode := node.NewManagedNode(zap.New(), nodeName, instanceID, config.OSLinux)
+	ipv4ManagedNode.UpdateTrunkEnabled(false)
+
+	mock := NewMock(ctrl, map[string]node.Node{nodeName: ipv4ManagedNode})
+	mock.Manager.linuxIPv4Enabled = true
+
+	job := AsyncOperationJob{
+		op:       UpdateTrunk,
+		nodeName: nodeName,
+		node:     ipv4ManagedNode,
+	}
+
+	mock.MockK8sAPI.EXPECT().GetNode(nodeName).Return(v1Node, nil)
+	mock.MockWorker.EXPECT().SubmitJob(gomock.All(NewAsyncOperationMatcher(job))).Do(func(job interface{}) {
+		assert.True(t, job.(AsyncOperationJob).trunkEnabled)
+	})
+
+	err := mock.Manager.UpdateNode(nodeName)
+	assert.NoError(t, err)
+	assert.Equal(t, ipv4ManagedNode, mock.Manager.dataStore[nodeName])
+}
+
+// Test_UpdateNode_LinuxIPv4_TrunkLabelRemoved tests the node managed for IPv4 resource stays managed when the trunk
+// label is removed, and the trunk resources are updated in the same update
+func Test_UpdateNode_LinuxIPv4_TrunkLabelRemoved(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkManagedNode := node.NewManagedNode(zap.New(), nodeName, instanceID, config.OSLinux)
+	trunkManagedNode.UpdateTrunkEnabled(true)
+
+	mock := NewMock(ctrl, map[string]node.Node{nodeName: trunkManagedNode})
+	mock.Manager.linuxIPv4Enabled = true
+
+	job := AsyncOperationJob{
+		op:       UpdateTrunk,
+		nodeName: nodeName,
+		node:     trunkManagedNode,
+	}
+
+	k8sNode := v1Node.DeepCopy()
+	delete(k8sNode.Labels, config.HasTrunkAttachedLabel)
+
+	mock.MockK8sAPI.EXPECT().GetNode(nodeName).Return(k8sNode, nil)
+	mock.MockWorker.EXPECT().SubmitJob(gomock.All(NewAsyncOperationMatcher(job))).Do(func(job interface{}) {
+		assert.False(t, job.(AsyncOperationJob).trunkEnabled)
+	})
+
+	err := mock.Manager.UpdateNode(nodeName)
+	assert.NoError(t, err)
+	assert.Equal(t, trunkManagedNode, mock.Manager.dataStore[nodeName])
+}
+
+// Test_performAsyncOperation_UpdateTrunk tests the trunk resources are updated and the capacity is advertised, even
+// if the trunk resources failed to update
+func Test_performAsyncOperation_UpdateTrunk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, map[string]node.Node{nodeName: managedNode})
+
+	job := AsyncOperationJob{
+		node:         mock.MockNode,
+		nodeName:     nodeName,
+		op:           UpdateTrunk,
+		trunkEnabled: true,
+	}
+
+	mock.MockNode.EXPECT().UpdateTrunkResources(mock.MockResourceManager, true).Return(mockError)
+	mock.MockNode.EXPECT().UpdateResources(mock.MockResourceManager, mock.MockEC2API).Return(nil)
+
+	_, err := mock.Manager.performAsyncOperation(job)
+	assert.NoError(t, err)
+	assert.Contains(t, mock.Manager.dataStore, nodeName)
+}
+
 func Test_UpdateNode_Windows_UnManagedToManaged(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
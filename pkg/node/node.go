@@ -53,8 +53,14 @@ type Node interface {
 	InitResources(resourceManager resource.ResourceManager, helper api.EC2APIHelper) error
 	DeleteResources(resourceManager resource.ResourceManager, helper api.EC2APIHelper) error
 	UpdateResources(resourceManager resource.ResourceManager, helper api.EC2APIHelper) error
+	UpdateTrunkResources(resourceManager resource.ResourceManager, enabled bool) error
 
 	UpdateCustomNetworkingSpecs(subnetID string, securityGroup []string)
+	UpdateTrunkNetworkingSpecs(subnetID string, securityGroup []string)
+	UpdateFallbackSubnets(subnetIDs []string)
+	UpdateNetworkCardPolicy(policy ec2.NetworkCardPolicy)
+	UpdateTrunkEnabled(enabled bool)
+	IsTrunkEnabled() bool
 	IsReady() bool
 	IsManaged() bool
 }
@@ -181,11 +187,104 @@ func (n *node) DeleteResources(resourceManager resource.ResourceManager, _ api.E
 	return nil
 }
 
+// UpdateTrunkResources updates whether the node can attach a trunk ENI, and initializes or de-initializes only the
+// providers whose support for the instance changed, so the resources of the other providers are retained. If a
+// provider fails to initialize, the previous value is restored so the update is retried on the next node event
+func (n *node) UpdateTrunkResources(resourceManager resource.ResourceManager, enabled bool) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.instance.IsTrunkEnabled() == enabled {
+		return nil
+	}
+	if !n.ready {
+		// The providers are initialized with the updated value
+		n.instance.SetTrunkEnabled(enabled)
+		return nil
+	}
+
+	providers := resourceManager.GetResourceProviders()
+	wasSupported := make(map[string]bool)
+	for resourceName, resourceProvider := range providers {
+		wasSupported[resourceName] = resourceProvider.IsInstanceSupported(n.instance)
+	}
+
+	n.instance.SetTrunkEnabled(enabled)
+
+	var toInit, toDeInit []provider.ResourceProvider
+	for resourceName, resourceProvider := range providers {
+		isSupported := resourceProvider.IsInstanceSupported(n.instance)
+		if isSupported && !wasSupported[resourceName] {
+			toInit = append(toInit, resourceProvider)
+		} else if !isSupported && wasSupported[resourceName] {
+			toDeInit = append(toDeInit, resourceProvider)
+		}
+	}
+
+	var initializedProviders []provider.ResourceProvider
+	for _, resourceProvider := range toInit {
+		errInit := resourceProvider.InitResource(n.instance)
+		if errInit != nil {
+			// de-init the providers that were already initialized and restore the previous value
+			for _, initializedProvider := range initializedProviders {
+				errDeInit := initializedProvider.DeInitResource(n.instance)
+				n.log.Error(errDeInit, "failed to de initialize resource")
+			}
+			n.instance.SetTrunkEnabled(!enabled)
+			n.log.Error(errInit, "failed to init resource")
+			return fmt.Errorf("failed to init resources: %v", errInit)
+		}
+		initializedProviders = append(initializedProviders, resourceProvider)
+	}
+
+	var errDelete []error
+	for _, resourceProvider := range toDeInit {
+		err := resourceProvider.DeInitResource(n.instance)
+		if err != nil {
+			errDelete = append(errDelete, err)
+			n.log.Error(err, "failed to de initialize provider")
+		}
+	}
+	if len(errDelete) > 0 {
+		return fmt.Errorf("failed to de initialize the resources %v", errDelete)
+	}
+
+	return nil
+}
+
 // UpdateInstanceCustomSubnet updates current required custom subnet
 func (n *node) UpdateCustomNetworkingSpecs(subnetID string, securityGroup []string) {
 	n.instance.SetNewCustomNetworkingSpec(subnetID, securityGroup)
 }
 
+// UpdateTrunkNetworkingSpecs updates the subnet and security group used for creating the trunk ENI
+func (n *node) UpdateTrunkNetworkingSpecs(subnetID string, securityGroup []string) {
+	n.instance.SetTrunkNetworkingSpec(subnetID, securityGroup)
+}
+
+// UpdateFallbackSubnets updates the subnets used when the node's subnet runs out of IP addresses
+func (n *node) UpdateFallbackSubnets(subnetIDs []string) {
+	n.instance.SetFallbackSubnets(subnetIDs)
+}
+
+// UpdateNetworkCardPolicy updates the policy choosing the network card of the network interfaces of the node
+func (n *node) UpdateNetworkCardPolicy(policy ec2.NetworkCardPolicy) {
+	n.instance.SetNetworkCardPolicy(policy)
+}
+
+// UpdateTrunkEnabled updates whether the node can attach a trunk ENI for the pod-eni resource
+func (n *node) UpdateTrunkEnabled(enabled bool) {
+	n.instance.SetTrunkEnabled(enabled)
+}
+
+// IsTrunkEnabled returns true if the node can attach a trunk ENI, false for un-managed nodes
+func (n *node) IsTrunkEnabled() bool {
+	if n.instance == nil {
+		return false
+	}
+	return n.instance.IsTrunkEnabled()
+}
+
 // IsReady returns true if all the providers have been initialized
 func (n *node) IsReady() bool {
 	n.lock.RLock()
@@ -258,3 +258,93 @@ func TestNode_UpdateResources_NodeNotReady(t *testing.T) {
 	err := mock.NodeWithMock.UpdateResources(mock.MockResourceManager, mock.MockEC2API)
 	assert.Nil(t, err)
 }
+
+// TestNode_UpdateTrunkResources tests that only the provider supporting the node once the trunk is enabled is
+// initialized, the other providers keep their resources
+func TestNode_UpdateTrunkResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, 2)
+	mock.NodeWithMock.ready = true
+
+	mock.MockResourceManager.EXPECT().GetResourceProviders().Return(mock.ResourceProvider)
+	gomock.InOrder(
+		mock.MockInstance.EXPECT().IsTrunkEnabled().Return(false),
+		mock.MockInstance.EXPECT().SetTrunkEnabled(true),
+	)
+
+	// The first provider supports the node with and without the trunk
+	mock.MockProviders["0"].EXPECT().IsInstanceSupported(mock.MockInstance).Return(true).Times(2)
+	gomock.InOrder(
+		mock.MockProviders["1"].EXPECT().IsInstanceSupported(mock.MockInstance).Return(false),
+		mock.MockProviders["1"].EXPECT().IsInstanceSupported(mock.MockInstance).Return(true),
+	)
+	mock.MockProviders["1"].EXPECT().InitResource(mock.MockInstance).Return(nil)
+
+	err := mock.NodeWithMock.UpdateTrunkResources(mock.MockResourceManager, true)
+	assert.NoError(t, err)
+}
+
+// TestNode_UpdateTrunkResources_Disabled tests that only the provider no longer supporting the node once the trunk is
+// disabled is de initialized
+func TestNode_UpdateTrunkResources_Disabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, 2)
+	mock.NodeWithMock.ready = true
+
+	mock.MockResourceManager.EXPECT().GetResourceProviders().Return(mock.ResourceProvider)
+	gomock.InOrder(
+		mock.MockInstance.EXPECT().IsTrunkEnabled().Return(true),
+		mock.MockInstance.EXPECT().SetTrunkEnabled(false),
+	)
+
+	mock.MockProviders["0"].EXPECT().IsInstanceSupported(mock.MockInstance).Return(true).Times(2)
+	gomock.InOrder(
+		mock.MockProviders["1"].EXPECT().IsInstanceSupported(mock.MockInstance).Return(true),
+		mock.MockProviders["1"].EXPECT().IsInstanceSupported(mock.MockInstance).Return(false),
+	)
+	mock.MockProviders["1"].EXPECT().DeInitResource(mock.MockInstance).Return(nil)
+
+	err := mock.NodeWithMock.UpdateTrunkResources(mock.MockResourceManager, false)
+	assert.NoError(t, err)
+}
+
+// TestNode_UpdateTrunkResources_InitFails tests that the previous value is restored if the provider fails to
+// initialize, so the update is retried on the next node event
+func TestNode_UpdateTrunkResources_InitFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, 1)
+	mock.NodeWithMock.ready = true
+
+	mock.MockResourceManager.EXPECT().GetResourceProviders().Return(mock.ResourceProvider)
+	gomock.InOrder(
+		mock.MockInstance.EXPECT().IsTrunkEnabled().Return(false),
+		mock.MockProviders["0"].EXPECT().IsInstanceSupported(mock.MockInstance).Return(false),
+		mock.MockInstance.EXPECT().SetTrunkEnabled(true),
+		mock.MockProviders["0"].EXPECT().IsInstanceSupported(mock.MockInstance).Return(true),
+		mock.MockProviders["0"].EXPECT().InitResource(mock.MockInstance).Return(mockError),
+		mock.MockInstance.EXPECT().SetTrunkEnabled(false),
+	)
+
+	err := mock.NodeWithMock.UpdateTrunkResources(mock.MockResourceManager, true)
+	assert.Error(t, err)
+}
+
+// TestNode_UpdateTrunkResources_Unchanged tests that the providers are not updated if the trunk didn't change
+func TestNode_UpdateTrunkResources_Unchanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, 1)
+	mock.NodeWithMock.ready = true
+
+	mock.MockInstance.EXPECT().IsTrunkEnabled().Return(true)
+
+	err := mock.NodeWithMock.UpdateTrunkResources(mock.MockResourceManager, true)
+	assert.NoError(t, err)
+}
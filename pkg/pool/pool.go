@@ -37,12 +37,19 @@ var (
 
 type Pool interface {
 	AssignResource(requesterID string) (resourceID string, shouldReconcile bool, err error)
+	AssignResources(requesterID string, count int, useReserved bool) (resourceIDs []string, shouldReconcile bool, err error)
 	FreeResource(requesterID string, resourceID string) (shouldReconcile bool, err error)
+	FreeResources(requesterID string, resourceIDs []string) (shouldReconcile bool, err error)
 	GetAssignedResource(requesterID string) (resourceID string, ownsResource bool)
+	GetAssignedResources(requesterID string) (resourceIDs []string, ownsResource bool)
 	UpdatePool(job *worker.WarmPoolJob, didSucceed bool) (shouldReconcile bool)
 	ReSync(resources []string)
 	ReconcilePool() *worker.WarmPoolJob
 	ProcessCoolDownQueue() bool
+	SetResourceRanker(rank func(resourceID string) int)
+	SetResourcesPerENI(count int)
+	SetInUseChecker(inUse func(resourceID string) bool)
+	DrainResources(resources []string) *worker.WarmPoolJob
 	Introspect() IntrospectResponse
 }
 
@@ -70,6 +77,25 @@ type pool struct {
 	// reSyncRequired is set if the upstream and pool are possibly out of sync due to
 	// errors in creating/deleting resources
 	reSyncRequired bool
+	// strategy orders the warm resources, the first warm resource is assigned first
+	strategy AssignmentStrategy
+	// rank returns the rank of a resource used by the assignment strategy, all resources have
+	// the same rank if not set
+	rank func(resourceID string) int
+	// lastFreed is the time the warm resources were last freed by their owner
+	lastFreed map[string]time.Time
+	// requestedSize is the number of resources requested by a single requester that couldn't be
+	// assigned from the warm pool, the warm pool is grown to this size
+	requestedSize int
+	// resourcesPerENI is the number of resources that can be created on a single ENI, used to
+	// convert the warm ENI target to the number of warm resources
+	resourcesPerENI int
+	// lastActive is the time a resource was last requested or freed on the node, the warm pool is
+	// shrunk to the idle desired size once the node is inactive for the idle TTL
+	lastActive time.Time
+	// inUse returns true if the resource in the cool down queue may still receive traffic, the resource
+	// is released before the cool down period once it's no longer in use. Not set in the timer mode
+	inUse func(resourceID string) bool
 }
 
 type CoolDownResource struct {
@@ -86,16 +112,33 @@ type IntrospectResponse struct {
 	CoolingResources []CoolDownResource
 }
 
-func NewResourcePool(log logr.Logger, poolConfig *config.WarmPoolConfig, usedResources map[string]string,
+// NewResourcePool returns the pool with the resources already assigned to the requesters, in the order of assignment,
+// and the warm resources
+func NewResourcePool(log logr.Logger, poolConfig *config.WarmPoolConfig, usedResources map[string][]string,
 	warmResources []string, nodeName string, capacity int) Pool {
+	strategy, err := NewAssignmentStrategy(poolConfig.AssignmentStrategy)
+	if err != nil {
+		log.Error(err, "falling back to fifo assignment strategy")
+		strategy, _ = NewAssignmentStrategy(config.AssignmentStrategyFIFO)
+	}
+	assignedResources := make(map[string]string)
+	for requesterID, resourceIDs := range usedResources {
+		for index, resourceID := range resourceIDs {
+			assignedResources[resourceKey(requesterID, index)] = resourceID
+		}
+	}
 	pool := &pool{
 		log:            log,
 		warmPoolConfig: poolConfig,
-		usedResources:  usedResources,
+		usedResources:  assignedResources,
 		warmResources:  warmResources,
 		capacity:       capacity,
 		nodeName:       nodeName,
+		strategy:       strategy,
+		lastFreed:      make(map[string]time.Time),
+		lastActive:     time.Now(),
 	}
+	pool.addToWarmPool()
 	return pool
 }
 
@@ -136,7 +179,7 @@ func (p *pool) ReSync(upstreamResource []string) {
 
 	if len(newResources) > 0 {
 		p.log.Info("adding new resources to warm pool", "resource", newResources)
-		p.warmResources = append(p.warmResources, newResources...)
+		p.addToWarmPool(newResources...)
 	}
 
 	if len(deletedResources) > 0 {
@@ -148,6 +191,7 @@ func (p *pool) ReSync(upstreamResource []string) {
 				if p.warmResources[i] == deletedResource {
 					p.log.Info("removing resource from warm pool",
 						"resource id", deletedResource)
+					delete(p.lastFreed, deletedResource)
 					p.warmResources = append(p.warmResources[:i], p.warmResources[i+1:]...)
 				}
 			}
@@ -163,46 +207,78 @@ func (p *pool) ReSync(upstreamResource []string) {
 }
 
 // AssignResource assigns a resources to the requester, the caller must retry in case there is capacity and the warm pool
-// is currently empty
+// is currently empty. The reserved warm resources are not assigned
 func (p *pool) AssignResource(requesterID string) (resourceID string, shouldReconcile bool, err error) {
+	resourceIDs, shouldReconcile, err := p.AssignResources(requesterID, 1, false)
+	if err != nil {
+		return "", shouldReconcile, err
+	}
+	return resourceIDs[0], shouldReconcile, nil
+}
+
+// AssignResources assigns the given number of resources to the requester atomically, either all the resources are
+// assigned or none. The caller must retry in case there is capacity and the warm pool doesn't have enough resources.
+// The reserved warm resources are only assigned to the critical requesters that set useReserved
+func (p *pool) AssignResources(requesterID string, count int,
+	useReserved bool) (resourceIDs []string, shouldReconcile bool, err error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
 	if _, isAlreadyAssigned := p.usedResources[requesterID]; isAlreadyAssigned {
-		return "", false, ErrResourceAlreadyAssigned
+		return nil, false, ErrResourceAlreadyAssigned
 	}
 
-	if len(p.usedResources) == p.capacity {
-		return "", false, ErrPoolAtMaxCapacity
+	// Restore the full warm pool target if the node was idle
+	p.lastActive = time.Now()
+
+	if len(p.usedResources)+count > p.capacity {
+		return nil, false, ErrPoolAtMaxCapacity
 	}
 
 	// Caller must retry at max by 30 seconds [Max time resource will sit in the cool down queue]
-	if len(p.usedResources)+len(p.coolDownQueue) == p.capacity {
-		return "", false, ErrResourceAreBeingCooledDown
+	if len(p.usedResources)+len(p.coolDownQueue)+count > p.capacity {
+		return nil, false, ErrResourceAreBeingCooledDown
 	}
 
 	// Caller can retry in 600 ms [Average time to create and attach a new ENI] or less
-	if len(p.usedResources)+len(p.coolDownQueue)+p.pendingCreate+p.pendingDelete == p.capacity {
-		return "", false, ErrResourcesAreBeingCreated
+	if len(p.usedResources)+len(p.coolDownQueue)+p.pendingCreate+p.pendingDelete+count > p.capacity {
+		return nil, false, ErrResourcesAreBeingCreated
+	}
+
+	// The reserved warm resources are held back for the critical requesters
+	required := count
+	if !useReserved {
+		required += p.warmPoolConfig.ReservedSize
 	}
 
 	// Caller can retry in 600 ms [Average time to create and attach a new ENI] or less
 	// Different from above check because here we want to perform reconciliation
-	if len(p.warmResources) == 0 {
-		return "", true, ErrWarmPoolEmpty
+	if len(p.warmResources) < required {
+		// Grow the warm pool to the requested size if it's larger than the desired size
+		if required > p.requestedSize {
+			p.requestedSize = required
+		}
+		return nil, true, ErrWarmPoolEmpty
 	}
 
-	// Allocate the resource
-	resourceID = p.warmResources[0]
-	p.warmResources = p.warmResources[1:]
+	// Allocate the resources
+	resourceIDs = make([]string, count)
+	copy(resourceIDs, p.warmResources[:count])
+	p.warmResources = p.warmResources[count:]
+	// The requested size is no longer required once the largest pending request is satisfied
+	if required >= p.requestedSize {
+		p.requestedSize = 0
+	}
 
-	// Add the resource in the used resource key-value pair
-	p.usedResources[requesterID] = resourceID
+	// Add the resources in the used resource key-value pair
+	for index, resourceID := range resourceIDs {
+		p.usedResources[resourceKey(requesterID, index)] = resourceID
+	}
 
 	p.log.V(1).Info("assigned resource",
-		"resource id", resourceID, "requester id", requesterID)
+		"resource id", resourceIDs, "requester id", requesterID)
 
-	return resourceID, true, nil
+	return resourceIDs, true, nil
 }
 
 func (p *pool) GetAssignedResource(requesterID string) (resourceID string, ownsResource bool) {
@@ -213,34 +289,75 @@ func (p *pool) GetAssignedResource(requesterID string) (resourceID string, ownsR
 	return
 }
 
+// GetAssignedResources returns all the resources assigned to the requester
+func (p *pool) GetAssignedResources(requesterID string) (resourceIDs []string, ownsResource bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	resourceIDs = p.getAssignedResources(requesterID)
+	return resourceIDs, len(resourceIDs) > 0
+}
+
 // FreeResource puts the resource allocated to the given requester into the cool down queue
 func (p *pool) FreeResource(requesterID string, resourceID string) (shouldReconcile bool, err error) {
+	return p.FreeResources(requesterID, []string{resourceID})
+}
+
+// FreeResources puts all the resources allocated to the given requester into the cool down queue. The resources must
+// be the same as the resources assigned to the requester
+func (p *pool) FreeResources(requesterID string, resourceIDs []string) (shouldReconcile bool, err error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	actualResourceID, isAssigned := p.usedResources[requesterID]
-	if !isAssigned {
+	actualResourceIDs := p.getAssignedResources(requesterID)
+	if len(actualResourceIDs) == 0 {
 		return false, ErrResourceDoesntExist
 	}
-	if actualResourceID != resourceID {
+	if len(utils.Difference(actualResourceIDs, resourceIDs)) != 0 ||
+		len(utils.Difference(resourceIDs, actualResourceIDs)) != 0 {
 		return false, ErrIncorrectResourceOwner
 	}
 
-	delete(p.usedResources, requesterID)
+	deletionTimestamp := time.Now()
+	p.lastActive = deletionTimestamp
+	for index, actualResourceID := range actualResourceIDs {
+		delete(p.usedResources, resourceKey(requesterID, index))
 
-	// Put the resource in cool down queue
-	resource := CoolDownResource{
-		ResourceID:        actualResourceID,
-		DeletionTimestamp: time.Now(),
+		// Put the resource in cool down queue
+		p.coolDownQueue = append(p.coolDownQueue, CoolDownResource{
+			ResourceID:        actualResourceID,
+			DeletionTimestamp: deletionTimestamp,
+		})
 	}
-	p.coolDownQueue = append(p.coolDownQueue, resource)
 
 	p.log.V(1).Info("added the resource to cool down queue",
-		"id", resourceID, "owner id", requesterID)
+		"id", resourceIDs, "owner id", requesterID)
 
 	return true, nil
 }
 
+// getAssignedResources returns the resources assigned to the requester in the order of assignment, must be called
+// with the lock held
+func (p *pool) getAssignedResources(requesterID string) []string {
+	var resourceIDs []string
+	for index := 0; ; index++ {
+		resourceID, isAssigned := p.usedResources[resourceKey(requesterID, index)]
+		if !isAssigned {
+			return resourceIDs
+		}
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+}
+
+// resourceKey returns the key of the used resource, the first resource of the requester is stored with the requester
+// ID and the additional resources with the index appended to the requester ID
+func resourceKey(requesterID string, index int) string {
+	if index == 0 {
+		return requesterID
+	}
+	return fmt.Sprintf("%s/%d", requesterID, index)
+}
+
 // UpdatePool updates the warm pool with the result of the asynchronous job executed by the provider
 func (p *pool) UpdatePool(job *worker.WarmPoolJob, didSucceed bool) (shouldReconcile bool) {
 	p.lock.Lock()
@@ -257,9 +374,7 @@ func (p *pool) UpdatePool(job *worker.WarmPoolJob, didSucceed bool) (shouldRecon
 
 	if job.Resources != nil && len(job.Resources) > 0 {
 		// Add the resources to the warm pool
-		for _, resource := range job.Resources {
-			p.warmResources = append(p.warmResources, resource)
-		}
+		p.addToWarmPool(job.Resources...)
 		log.Info("added resource to the warm pool", "resources", job.Resources)
 	}
 
@@ -275,7 +390,8 @@ func (p *pool) UpdatePool(job *worker.WarmPoolJob, didSucceed bool) (shouldRecon
 	return shouldReconcile
 }
 
-// ProcessCoolDownQueue adds the resources back to the warm pool once they have cooled down
+// ProcessCoolDownQueue adds the resources back to the warm pool once they have cooled down. A resource is cooled
+// down after the cool down period, or earlier if the in use checker is set and reports it's no longer in use
 func (p *pool) ProcessCoolDownQueue() (needFurtherProcessing bool) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -284,23 +400,24 @@ func (p *pool) ProcessCoolDownQueue() (needFurtherProcessing bool) {
 		return false
 	}
 
-	for index, resource := range p.coolDownQueue {
-		if time.Since(resource.DeletionTimestamp) >= config.CoolDownPeriod {
+	var coolingResources []CoolDownResource
+	for _, resource := range p.coolDownQueue {
+		if time.Since(resource.DeletionTimestamp) >= config.CoolDownPeriod ||
+			(p.inUse != nil && !p.inUse(resource.ResourceID)) {
 			// Add back to the cool down queue
-			p.warmResources = append(p.warmResources, resource.ResourceID)
+			p.lastFreed[resource.ResourceID] = resource.DeletionTimestamp
+			p.addToWarmPool(resource.ResourceID)
 			p.log.Info("moving the resource from delete to cool down queue",
 				"resource id", resource.ResourceID, "deletion time", resource.DeletionTimestamp)
 		} else {
-			// Remove the items from cool down queue that are processed and return
-			p.coolDownQueue = p.coolDownQueue[index:]
-			return true
+			coolingResources = append(coolingResources, resource)
 		}
 	}
 
-	// All items were processed empty the cool down queue
-	p.coolDownQueue = p.coolDownQueue[:0]
+	// Retain the resources that are still cooling down in the queue
+	p.coolDownQueue = append(p.coolDownQueue[:0], coolingResources...)
 
-	return false
+	return len(p.coolDownQueue) > 0
 }
 
 // reconcilePoolIfRequired reconciles the Warm pool to make it reach it's desired state by submitting either create or delete
@@ -316,7 +433,9 @@ func (p *pool) ReconcilePool() *worker.WarmPoolJob {
 	log := p.log.WithValues("resync", p.reSyncRequired, "warm", len(p.warmResources), "used",
 		len(p.usedResources), "pending create", p.pendingCreate, "pending delete", &p.pendingDelete,
 		"cool down queue", len(p.coolDownQueue), "total resources", totalCreatedResources,
-		"max capacity", p.capacity, "desired size", p.warmPoolConfig.DesiredSize)
+		"max capacity", p.capacity, "desired size", p.warmPoolConfig.DesiredSize, "requested size", p.requestedSize,
+		"minimum ip target", p.warmPoolConfig.MinimumIPTarget, "warm eni target", p.warmPoolConfig.WarmENITarget,
+		"idle", p.isIdle())
 
 	if p.reSyncRequired {
 		// If Pending operations are present then we can't re-sync as the upstream
@@ -337,7 +456,8 @@ func (p *pool) ReconcilePool() *worker.WarmPoolJob {
 	}
 
 	// Consider pending create as well so we don't create multiple subsequent create request
-	deviation := p.warmPoolConfig.DesiredSize - (len(p.warmResources) + p.pendingCreate)
+	desiredSize := p.getDesiredSize()
+	deviation := desiredSize - (len(p.warmResources) + p.pendingCreate)
 
 	// Need to create more resources for warm pool
 	if deviation > p.warmPoolConfig.MaxDeviation {
@@ -363,11 +483,21 @@ func (p *pool) ReconcilePool() *worker.WarmPoolJob {
 		return worker.NewWarmPoolCreateJob(p.nodeName, deviation)
 
 	} else if -deviation > p.warmPoolConfig.MaxDeviation {
-		// Need to delete from warm pool
-		deviation = -deviation
+		// Need to delete from warm pool. Only the warm resources beyond the desired size are deleted, if the
+		// excess is due to the pending create it is deleted once the created resources are in the warm pool
+		deviation = len(p.warmResources) - desiredSize
+		if deviation > len(p.warmResources) {
+			deviation = len(p.warmResources)
+		}
+		if deviation <= p.warmPoolConfig.MaxDeviation {
+			log.V(1).Info("waiting for the pending create before deleting resources")
+			return &worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired}
+		}
+
 		var resourceToDelete []string
 		for i := len(p.warmResources) - 1; i >= len(p.warmResources)-deviation; i-- {
 			resourceToDelete = append(resourceToDelete, p.warmResources[i])
+			delete(p.lastFreed, p.warmResources[i])
 		}
 
 		// Remove resources to be deleted form the warm pool
@@ -386,6 +516,151 @@ func (p *pool) ReconcilePool() *worker.WarmPoolJob {
 	return &worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired}
 }
 
+// getDesiredSize returns the number of warm resources required to satisfy all the targets of the warm pool,
+// the largest of the desired size and the warm ENI target on top of the reserved size, the requested size and
+// the minimum IP target is returned. If the node is idle, the idle desired size replaces the desired size, the
+// warm ENI target and the requested size. The desired size can drop below the pending create, ReconcilePool then only deletes the excess warm
+// resources. Must be called with the lock held
+func (p *pool) getDesiredSize() int {
+	var desiredSize int
+	if p.isIdle() {
+		desiredSize = p.warmPoolConfig.IdleDesiredSize
+	} else {
+		desiredSize = p.warmPoolConfig.DesiredSize
+		if warmENISize := p.getWarmENISize(); warmENISize > desiredSize {
+			desiredSize = warmENISize
+		}
+	}
+	// The reserved resources are only assigned to the critical requesters, keep them on top of the usable resources
+	desiredSize += p.warmPoolConfig.ReservedSize
+	// The requested size already includes the reserved size
+	if !p.isIdle() && p.requestedSize > desiredSize {
+		desiredSize = p.requestedSize
+	}
+	// Resources in the cool down queue will be back in the warm pool, so they are not counted as used
+	if minimumSize := p.warmPoolConfig.MinimumIPTarget - len(p.usedResources); minimumSize > desiredSize {
+		desiredSize = minimumSize
+	}
+	return desiredSize
+}
+
+// getWarmENISize returns the number of warm resources required to keep the warm ENI target of whole ENIs without
+// any used resource. The ENIs are identified by the rank of their resources, the free slots of the ENIs with used
+// or cooling resources are filled first so the new resources are created on the spare ENIs. Without a ranker the
+// resources of the warm ENIs are returned. Must be called with the lock held
+func (p *pool) getWarmENISize() int {
+	warmENISize := p.warmPoolConfig.WarmENITarget * p.resourcesPerENI
+	if warmENISize == 0 || p.rank == nil {
+		return warmENISize
+	}
+
+	usedENIs := map[int]struct{}{}
+	for _, resourceID := range p.usedResources {
+		usedENIs[p.rank(resourceID)] = struct{}{}
+	}
+	for _, resource := range p.coolDownQueue {
+		usedENIs[p.rank(resource.ResourceID)] = struct{}{}
+	}
+	freeSlots := len(usedENIs)*p.resourcesPerENI - len(p.usedResources) - len(p.coolDownQueue)
+	if freeSlots < 0 {
+		freeSlots = 0
+	}
+	return freeSlots + warmENISize
+}
+
+// isIdle returns true if the idle TTL is set and no resource was requested or freed on the node for
+// the idle TTL. Must be called with the lock held
+func (p *pool) isIdle() bool {
+	return p.warmPoolConfig.IdleTTL > 0 && time.Since(p.lastActive) >= p.warmPoolConfig.IdleTTL
+}
+
+// SetResourcesPerENI sets the number of resources that can be created on a single ENI, required
+// for the warm ENI target
+func (p *pool) SetResourcesPerENI(count int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.resourcesPerENI = count
+}
+
+// SetInUseChecker sets the function used to release the resources from the cool down queue as soon as they are
+// no longer in use, the cool down period remains the upper bound
+func (p *pool) SetInUseChecker(inUse func(resourceID string) bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.inUse = inUse
+}
+
+// SetResourceRanker sets the function used to rank the resources for the assignment strategy and
+// re-orders the existing warm resources
+func (p *pool) SetResourceRanker(rank func(resourceID string) int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.rank = rank
+	p.addToWarmPool()
+}
+
+// DrainResources removes the given resources from the warm pool and returns the job to delete them.
+// Resources that are no longer in the warm pool are ignored
+func (p *pool) DrainResources(resources []string) *worker.WarmPoolJob {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.reSyncRequired || p.pendingCreate != 0 || p.pendingDelete != 0 {
+		return &worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired}
+	}
+
+	toDrain := make(map[string]struct{}, len(resources))
+	for _, resource := range resources {
+		toDrain[resource] = struct{}{}
+	}
+
+	var resourceToDelete []string
+	i := 0
+	for _, resource := range p.warmResources {
+		if _, ok := toDrain[resource]; ok {
+			resourceToDelete = append(resourceToDelete, resource)
+			delete(p.lastFreed, resource)
+			continue
+		}
+		p.warmResources[i] = resource
+		i++
+	}
+	p.warmResources = p.warmResources[:i]
+
+	if len(resourceToDelete) == 0 {
+		return &worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired}
+	}
+
+	p.pendingDelete += len(resourceToDelete)
+
+	p.log.Info("created job to drain resources from warm pool", "resources to delete", resourceToDelete)
+
+	return worker.NewWarmPoolDeleteJob(p.nodeName, resourceToDelete)
+}
+
+// addToWarmPool adds the resources to the warm pool and orders the warm pool using the assignment
+// strategy
+func (p *pool) addToWarmPool(resources ...string) {
+	p.warmResources = append(p.warmResources, resources...)
+	p.strategy.Order(p.warmResources, p)
+}
+
+// Rank returns the rank of the resource, must be called with the lock held
+func (p *pool) Rank(resourceID string) int {
+	if p.rank == nil {
+		return 0
+	}
+	return p.rank(resourceID)
+}
+
+// LastFreed returns the time the resource was last freed, must be called with the lock held
+func (p *pool) LastFreed(resourceID string) time.Time {
+	return p.lastFreed[resourceID]
+}
+
 func (p *pool) Introspect() IntrospectResponse {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
@@ -397,7 +672,7 @@ func (p *pool) Introspect() IntrospectResponse {
 
 	return IntrospectResponse{
 		UsedResources:    usedResources,
-		WarmResources:    p.warmResources,
-		CoolingResources: p.coolDownQueue,
+		WarmResources:    append([]string(nil), p.warmResources...),
+		CoolingResources: append([]CoolDownResource(nil), p.coolDownQueue...),
 	}
 }
@@ -14,6 +14,7 @@
 package pool
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -61,16 +62,35 @@ func getMockPool(poolConfig *config.WarmPoolConfig, usedResources map[string]str
 		usedResources:  usedResourcesCopy,
 		warmResources:  warmResourcesCopy,
 		capacity:       capacity,
+		strategy:       fifoStrategy{},
+		lastFreed:      map[string]time.Time{},
 	}
 
 	return pool
 }
 
 func TestPool_NewResourcePool(t *testing.T) {
-	pool := NewResourcePool(zap.New(), poolConfig, usedResources, warmPoolResources, nodeName, 5)
+	pool := NewResourcePool(zap.New(), poolConfig, map[string][]string{pod1: {res1}, pod2: {res2}},
+		warmPoolResources, nodeName, 5)
 	assert.NotNil(t, pool)
 }
 
+// TestPool_NewResourcePool_MultipleResources tests the requesters with more than one resource can free all their
+// resources once the pool is created
+func TestPool_NewResourcePool_MultipleResources(t *testing.T) {
+	warmPool := NewResourcePool(zap.New(), poolConfig, map[string][]string{pod1: {res1, res2, res3}, pod2: {res4}},
+		[]string{res5}, nodeName, 7)
+
+	resourceIDs, ownsResource := warmPool.GetAssignedResources(pod1)
+	assert.True(t, ownsResource)
+	assert.Equal(t, []string{res1, res2, res3}, resourceIDs)
+
+	_, err := warmPool.FreeResources(pod1, []string{res1, res2, res3})
+	assert.NoError(t, err)
+	_, err = warmPool.FreeResource(pod2, res4)
+	assert.NoError(t, err)
+}
+
 // TestPool_AssignResource tests resource is allocated ot pod if present in the warm pool
 func TestPool_AssignResource(t *testing.T) {
 	warmPool := getMockPool(poolConfig, usedResources, warmPoolResources, 5)
@@ -158,6 +178,114 @@ func TestPool_FreeResource_IncorrectOwner(t *testing.T) {
 	assert.False(t, shouldReconcile)
 }
 
+// TestPool_AssignResources tests all the requested resources are allocated to the pod
+func TestPool_AssignResources(t *testing.T) {
+	warmPool := getMockPool(poolConfig, usedResources, []string{res3, res4, res5}, 5)
+
+	resourceIDs, shouldReconcile, err := warmPool.AssignResources(pod3, 2, false)
+
+	assert.NoError(t, err)
+	assert.True(t, shouldReconcile)
+	assert.Equal(t, []string{res3, res4}, resourceIDs)
+	assert.Equal(t, []string{res5}, warmPool.warmResources)
+	assert.Equal(t, res3, warmPool.usedResources[pod3])
+	assert.Equal(t, res4, warmPool.usedResources[pod3+"/1"])
+}
+
+// TestPool_AssignResources_NotEnoughWarmResources tests no resource is allocated if the warm pool doesn't have all the
+// requested resources and the warm pool is grown to the requested size along with the reserved size on next reconcile
+func TestPool_AssignResources_NotEnoughWarmResources(t *testing.T) {
+	warmPool := getMockPool(poolConfig, usedResources, []string{res3}, 7)
+
+	_, shouldReconcile, err := warmPool.AssignResources(pod3, 3, false)
+
+	assert.Equal(t, ErrWarmPoolEmpty, err)
+	assert.True(t, shouldReconcile)
+	assert.Equal(t, []string{res3}, warmPool.warmResources)
+	assert.Equal(t, 4, warmPool.requestedSize)
+
+	job := warmPool.ReconcilePool()
+	assert.Equal(t, worker.NewWarmPoolCreateJob("", 3), job)
+}
+
+// TestPool_AssignResource_OnlyReservedResources tests the reserved warm resources are not assigned to an ordinary
+// requester and the warm pool is grown on next reconcile
+func TestPool_AssignResource_OnlyReservedResources(t *testing.T) {
+	warmPool := getMockPool(poolConfig, usedResources, []string{res3}, 5)
+
+	_, shouldReconcile, err := warmPool.AssignResource(pod3)
+
+	assert.Equal(t, ErrWarmPoolEmpty, err)
+	assert.True(t, shouldReconcile)
+	assert.Equal(t, []string{res3}, warmPool.warmResources)
+	assert.Equal(t, 2, warmPool.requestedSize)
+}
+
+// TestPool_AssignResources_UseReserved tests the reserved warm resources are assigned to a critical requester
+func TestPool_AssignResources_UseReserved(t *testing.T) {
+	warmPool := getMockPool(poolConfig, usedResources, []string{res3}, 5)
+
+	resourceIDs, shouldReconcile, err := warmPool.AssignResources(pod3, 1, true)
+
+	assert.NoError(t, err)
+	assert.True(t, shouldReconcile)
+	assert.Equal(t, []string{res3}, resourceIDs)
+	assert.Empty(t, warmPool.warmResources)
+	assert.Equal(t, res3, warmPool.usedResources[pod3])
+}
+
+// TestPool_ReconcilePool_ReservedSize tests the warm pool keeps the desired size on top of the reserved size, so an
+// ordinary requester is assigned a resource without waiting for the warm pool to grow
+func TestPool_ReconcilePool_ReservedSize(t *testing.T) {
+	reservedConfig := &config.WarmPoolConfig{DesiredSize: 1, ReservedSize: 1, MaxDeviation: 0}
+	warmPool := getMockPool(reservedConfig, usedResources, []string{}, 7)
+
+	job := warmPool.ReconcilePool()
+	assert.Equal(t, worker.NewWarmPoolCreateJob("", 2), job)
+
+	warmPool.UpdatePool(&worker.WarmPoolJob{Operations: worker.OperationCreate, ResourceCount: 2,
+		Resources: []string{res3, res4}}, true)
+
+	resourceID, _, err := warmPool.AssignResource(pod3)
+	assert.NoError(t, err)
+	assert.Equal(t, res3, resourceID)
+	assert.Equal(t, []string{res4}, warmPool.warmResources)
+}
+
+// TestPool_AssignResources_AtCapacity tests error is returned if the pool cannot allocate all the requested resources
+func TestPool_AssignResources_AtCapacity(t *testing.T) {
+	warmPool := getMockPool(poolConfig, usedResources, []string{res3, res4}, 3)
+
+	_, shouldReconcile, err := warmPool.AssignResources(pod3, 2, false)
+
+	assert.Equal(t, ErrPoolAtMaxCapacity, err)
+	assert.False(t, shouldReconcile)
+}
+
+// TestPool_FreeResources tests all the resources of the requester are put in the cool down queue
+func TestPool_FreeResources(t *testing.T) {
+	warmPool := getMockPool(poolConfig, map[string]string{pod1: res1, pod1 + "/1": res2}, []string{}, 3)
+
+	shouldReconcile, err := warmPool.FreeResources(pod1, []string{res2, res1})
+
+	assert.NoError(t, err)
+	assert.True(t, shouldReconcile)
+	assert.Empty(t, warmPool.usedResources)
+	assert.Len(t, warmPool.coolDownQueue, 2)
+}
+
+// TestPool_FreeResources_IncorrectResources tests error is returned if the resources don't match the resources
+// assigned to the requester
+func TestPool_FreeResources_IncorrectResources(t *testing.T) {
+	warmPool := getMockPool(poolConfig, map[string]string{pod1: res1, pod1 + "/1": res2}, []string{}, 3)
+
+	shouldReconcile, err := warmPool.FreeResources(pod1, []string{res1})
+
+	assert.Equal(t, ErrIncorrectResourceOwner, err)
+	assert.False(t, shouldReconcile)
+	assert.Len(t, warmPool.usedResources, 2)
+}
+
 // TestPool_UpdatePool_OperationCreate_Succeed tests resources are added to the warm pool if resource are created
 // successfully
 func TestPool_UpdatePool_OperationCreate_Succeed(t *testing.T) {
@@ -254,6 +382,29 @@ func TestPool_ProcessCoolDownQueue_NoFurtherProcessingRequired(t *testing.T) {
 	assert.False(t, needFurtherProcessing)
 }
 
+// TestPool_ProcessCoolDownQueue_InUseChecker tests the resources that are no longer in use are added back to the warm
+// pool before the cool down period and the resources still in use remain in the queue in order
+func TestPool_ProcessCoolDownQueue_InUseChecker(t *testing.T) {
+	warmPool := getMockPool(poolConfig, usedResources, []string{}, 5)
+	warmPool.SetInUseChecker(func(resourceID string) bool {
+		return resourceID == res3 || resourceID == res5
+	})
+	warmPool.coolDownQueue = []CoolDownResource{
+		{ResourceID: res3, DeletionTimestamp: time.Now().Add(-time.Second * 5)},
+		{ResourceID: res4, DeletionTimestamp: time.Now().Add(-time.Second * 5)},
+		{ResourceID: res5, DeletionTimestamp: time.Now().Add(-time.Second * 33)},
+		{ResourceID: res6, DeletionTimestamp: time.Now()},
+	}
+
+	needFurtherProcessing := warmPool.ProcessCoolDownQueue()
+
+	assert.True(t, needFurtherProcessing)
+	// Resource 5 is still in use but has exceeded the cool down period
+	assert.Equal(t, []string{res4, res5, res6}, warmPool.warmResources)
+	assert.Len(t, warmPool.coolDownQueue, 1)
+	assert.Equal(t, res3, warmPool.coolDownQueue[0].ResourceID)
+}
+
 // TestPool_getPendingResources tests total pending resource returns the pending create and pending delete items
 func TestPool_getPendingResources(t *testing.T) {
 	warmPool := getMockPool(poolConfig, usedResources, []string{}, 7)
@@ -282,12 +433,12 @@ func TestPool_ReconcilePool_MaxCapacity(t *testing.T) {
 // then reconciliation is not triggered
 func TestPool_ReconcilePool_NotRequired(t *testing.T) {
 	warmPool := getMockPool(poolConfig, usedResources, []string{}, 7)
-	warmPool.pendingCreate = 1
+	warmPool.pendingCreate = 2
 
 	job := warmPool.ReconcilePool()
 
-	// deviation = 2(desired WP) - 1(actual WP + pending create) = 1, (deviation)1 > (max deviation)1 => false,
-	// so no need create right now
+	// deviation = 3(desired WP + reserved) - 2(actual WP + pending create) = 1, (deviation)1 > (max deviation)1 =>
+	// false, so no need create right now
 	assert.Equal(t, &worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired}, job)
 }
 
@@ -297,10 +448,150 @@ func TestPool_ReconcilePool_Create(t *testing.T) {
 
 	job := warmPool.ReconcilePool()
 
-	// deviation = 2(desired WP) - 0(actual WP + pending create) = 0, (deviation)0 > (max deviation)1 => true,
-	// create (deviation)2 resources
-	assert.Equal(t, &worker.WarmPoolJob{Operations: worker.OperationCreate, ResourceCount: 2}, job)
-	assert.Equal(t, warmPool.pendingCreate, 2)
+	// deviation = 3(desired WP + reserved) - 0(actual WP + pending create) = 3, (deviation)3 > (max deviation)1 =>
+	// true, create (deviation)3 resources
+	assert.Equal(t, &worker.WarmPoolJob{Operations: worker.OperationCreate, ResourceCount: 3}, job)
+	assert.Equal(t, warmPool.pendingCreate, 3)
+}
+
+// TestPool_ReconcilePool_Create_MinimumIPTarget tests the warm pool is grown in a single step to reach the minimum
+// IP target when it's larger than the desired size
+func TestPool_ReconcilePool_Create_MinimumIPTarget(t *testing.T) {
+	targetConfig := &config.WarmPoolConfig{DesiredSize: 2, MaxDeviation: 1, MinimumIPTarget: 40}
+	warmPool := getMockPool(targetConfig, usedResources, []string{res3}, 50)
+
+	job := warmPool.ReconcilePool()
+
+	// minimum IP target(40) - used(2) = 38 warm, deviation = 38 - 1(warm) = 37
+	assert.Equal(t, &worker.WarmPoolJob{Operations: worker.OperationCreate, ResourceCount: 37}, job)
+	assert.Equal(t, 37, warmPool.pendingCreate)
+}
+
+// TestPool_ReconcilePool_Create_WarmENITarget tests the warm pool is grown to the resources of the warm ENIs
+func TestPool_ReconcilePool_Create_WarmENITarget(t *testing.T) {
+	targetConfig := &config.WarmPoolConfig{DesiredSize: 2, MaxDeviation: 1, WarmENITarget: 1}
+	warmPool := getMockPool(targetConfig, usedResources, []string{}, 50)
+	warmPool.SetResourcesPerENI(9)
+
+	job := warmPool.ReconcilePool()
+
+	assert.Equal(t, &worker.WarmPoolJob{Operations: worker.OperationCreate, ResourceCount: 9}, job)
+}
+
+// TestPool_getDesiredSize_WarmENITarget_Ranked tests the warm ENI target keeps whole ENIs without used resources on
+// top of the free slots of the ENIs with used or cooling resources
+func TestPool_getDesiredSize_WarmENITarget_Ranked(t *testing.T) {
+	targetConfig := &config.WarmPoolConfig{DesiredSize: 1, WarmENITarget: 1}
+
+	warmPool := getMockPool(targetConfig, usedResources, []string{}, 50)
+	warmPool.SetResourcesPerENI(3)
+	warmPool.SetResourceRanker(func(resourceID string) int {
+		return map[string]int{res1: 1, res2: 2, res3: 2}[resourceID]
+	})
+	// used(res1) on ENI 1 and used(res2) on ENI 2, 2 ENIs(6) - used(2) = 4 free slots + 1 warm ENI(3)
+	assert.Equal(t, 7, warmPool.getDesiredSize())
+
+	warmPool.SetResourceRanker(func(resourceID string) int { return 1 })
+	// used(res1, res2) on ENI 1, 1 ENI(3) - used(2) = 1 free slot + 1 warm ENI(3)
+	assert.Equal(t, 4, warmPool.getDesiredSize())
+
+	warmPool.coolDownQueue = []CoolDownResource{{ResourceID: res3}}
+	// cooling(res3) on ENI 1, 1 ENI(3) - used and cooling(3) = 0 free slot + 1 warm ENI(3)
+	assert.Equal(t, 3, warmPool.getDesiredSize())
+}
+
+// TestPool_ReconcilePool_TargetsBind tests the larger of the targets decides the warm pool size and the resources
+// beyond all the targets are deleted
+func TestPool_ReconcilePool_TargetsBind(t *testing.T) {
+	targetConfig := &config.WarmPoolConfig{DesiredSize: 1, MaxDeviation: 0, MinimumIPTarget: 5, WarmENITarget: 1}
+	warmPool := getMockPool(targetConfig, usedResources, []string{res3, res4, res5, res6, res7}, 50)
+	warmPool.SetResourcesPerENI(2)
+
+	// minimum IP target(5) - used(2) = 3 warm is larger than 1 warm ENI(2) and desired size(1)
+	assert.Equal(t, 3, warmPool.getDesiredSize())
+
+	job := warmPool.ReconcilePool()
+
+	assert.Equal(t, worker.NewWarmPoolDeleteJob("", []string{res7, res6}), job)
+}
+
+// TestPool_ReconcilePool_Idle_Shrink tests that the warm pool is shrunk to the idle desired size once no resource
+// was requested or freed for the idle TTL
+func TestPool_ReconcilePool_Idle_Shrink(t *testing.T) {
+	idleConfig := &config.WarmPoolConfig{DesiredSize: 3, MaxDeviation: 0, IdleTTL: time.Minute, IdleDesiredSize: 1}
+	warmPool := getMockPool(idleConfig, usedResources, []string{res3, res4, res5}, 50)
+
+	// The node is active, the full desired size is retained
+	warmPool.lastActive = time.Now()
+	job := warmPool.ReconcilePool()
+	assert.Equal(t, worker.OperationReconcileNotRequired, job.Operations)
+
+	warmPool.lastActive = time.Now().Add(-time.Minute)
+	job = warmPool.ReconcilePool()
+
+	assert.Equal(t, worker.NewWarmPoolDeleteJob("", []string{res5, res4}), job)
+	assert.Equal(t, []string{res3}, warmPool.warmResources)
+}
+
+// TestPool_ReconcilePool_Idle_PendingCreate tests that only the warm resources are deleted when the node becomes idle
+// while a create is pending, the pending create is deleted once the created resources are in the warm pool
+func TestPool_ReconcilePool_Idle_PendingCreate(t *testing.T) {
+	idleConfig := &config.WarmPoolConfig{DesiredSize: 10, MaxDeviation: 0, IdleTTL: time.Minute, IdleDesiredSize: 0}
+	warmPool := getMockPool(idleConfig, usedResources, []string{res3, res4}, 50)
+	warmPool.pendingCreate = 8
+	warmPool.lastActive = time.Now().Add(-time.Minute)
+
+	// deviation = 0(idle desired size) - 10(warm + pending create) = -10, but only 2 resources are warm
+	job := warmPool.ReconcilePool()
+	assert.Equal(t, worker.NewWarmPoolDeleteJob("", []string{res4, res3}), job)
+	assert.Empty(t, warmPool.warmResources)
+
+	job = warmPool.ReconcilePool()
+	assert.Equal(t, worker.OperationReconcileNotRequired, job.Operations)
+
+	// The created resources are deleted once added to the warm pool
+	warmPool.UpdatePool(&worker.WarmPoolJob{Operations: worker.OperationCreate, ResourceCount: 8,
+		Resources: []string{res5, res6, res7, "res-8", "res-9", "res-10", "res-11", "res-12"}}, true)
+	job = warmPool.ReconcilePool()
+	assert.Equal(t, worker.OperationDeleted, job.Operations)
+	assert.Equal(t, 8, job.ResourceCount)
+}
+
+// TestPool_ReconcilePool_Idle_MinimumIPTarget tests that the minimum IP target is honoured on an idle node
+func TestPool_ReconcilePool_Idle_MinimumIPTarget(t *testing.T) {
+	idleConfig := &config.WarmPoolConfig{DesiredSize: 3, MaxDeviation: 0, MinimumIPTarget: 4,
+		IdleTTL: time.Minute, IdleDesiredSize: 0}
+	warmPool := getMockPool(idleConfig, usedResources, []string{res3, res4, res5}, 50)
+	warmPool.lastActive = time.Now().Add(-time.Hour)
+
+	// minimum IP target(4) - used(2) = 2 warm is larger than the idle desired size(0)
+	assert.Equal(t, 2, warmPool.getDesiredSize())
+}
+
+// TestPool_AssignResource_Idle_Restore tests that the full target is restored on the next assignment once idle
+func TestPool_AssignResource_Idle_Restore(t *testing.T) {
+	idleConfig := &config.WarmPoolConfig{DesiredSize: 3, MaxDeviation: 0, IdleTTL: time.Minute, IdleDesiredSize: 0}
+	warmPool := getMockPool(idleConfig, map[string]string{}, []string{}, 50)
+	warmPool.lastActive = time.Now().Add(-time.Hour)
+	assert.Equal(t, 0, warmPool.getDesiredSize())
+
+	_, shouldReconcile, err := warmPool.AssignResource(pod1)
+	assert.Equal(t, ErrWarmPoolEmpty, err)
+	assert.True(t, shouldReconcile)
+
+	job := warmPool.ReconcilePool()
+	assert.Equal(t, worker.NewWarmPoolCreateJob("", 3), job)
+}
+
+// TestPool_FreeResource_Idle_Restore tests that freeing a resource marks the node as active
+func TestPool_FreeResource_Idle_Restore(t *testing.T) {
+	idleConfig := &config.WarmPoolConfig{DesiredSize: 3, MaxDeviation: 0, IdleTTL: time.Minute, IdleDesiredSize: 0}
+	warmPool := getMockPool(idleConfig, usedResources, []string{}, 50)
+	warmPool.lastActive = time.Now().Add(-time.Hour)
+
+	_, err := warmPool.FreeResource(pod1, res1)
+	assert.NoError(t, err)
+	assert.False(t, warmPool.isIdle())
 }
 
 // TestPool_ReconcilePool_Create_LimitByMaxCapacity tests when the warm pool deviates from max deviation and the deviation
@@ -311,8 +602,9 @@ func TestPool_ReconcilePool_Create_LimitByMaxCapacity(t *testing.T) {
 
 	job := warmPool.ReconcilePool()
 
-	// deviation = 2(desired WP) - 0(actual WP + pending create) = 2, (deviation)2 >= (max deviation)1 => true, so
-	// need to create (deviation)2 resources. But since remaining capacity is just 1, so we create 1 resource instead
+	// deviation = 3(desired WP + reserved) - 0(actual WP + pending create) = 3, (deviation)3 >= (max deviation)1 =>
+	// true, so need to create (deviation)3 resources. But since remaining capacity is just 1, so we create 1 resource
+	// instead
 	assert.Equal(t, &worker.WarmPoolJob{Operations: worker.OperationCreate, ResourceCount: 1}, job)
 	assert.Equal(t, warmPool.pendingCreate, 1)
 }
@@ -324,7 +616,8 @@ func TestPool_ReconcilePool_Delete_NotRequired(t *testing.T) {
 
 	job := warmPool.ReconcilePool()
 
-	// deviation = 2(desired WP) - 3(actual WP) = -1, (-deviation)1 > (max deviation)1 => false, so no need delete
+	// deviation = 3(desired WP + reserved) - 3(actual WP) = 0, (-deviation)0 > (max deviation)1 => false, so no need
+	// delete
 	assert.Equal(t, &worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired}, job)
 	assert.Equal(t, warmPool.pendingDelete, 0)
 }
@@ -332,16 +625,91 @@ func TestPool_ReconcilePool_Delete_NotRequired(t *testing.T) {
 // TestPool_ReconcilePool_Delete tests that if the warm pool is over the desired warm pool size and has exceed the max
 // deviation then we issue a return a delete job
 func TestPool_ReconcilePool_Delete(t *testing.T) {
-	warmPool := getMockPool(poolConfig, usedResources, []string{res3, res4, res5, res6}, 7)
+	warmPool := getMockPool(poolConfig, usedResources, []string{res3, res4, res5, res6, res7}, 7)
 
 	job := warmPool.ReconcilePool()
 
-	// deviation = 2(desired WP) - 3(actual WP) = -1, (-deviation)1 > (max deviation)1 => false, so no need delete
+	// deviation = 3(desired WP + reserved) - 5(actual WP) = -2, (-deviation)2 > (max deviation)1 => true, so delete
+	// (-deviation)2 resources
 	assert.Equal(t, &worker.WarmPoolJob{Operations: worker.OperationDeleted,
-		Resources: []string{res6, res5}, ResourceCount: 2}, job)
+		Resources: []string{res7, res6}, ResourceCount: 2}, job)
 	assert.Equal(t, 2, warmPool.pendingDelete)
 }
 
+// TestPool_ReconcilePool_Delete_PendingCreate tests that the warm resources are not deleted when the pending create
+// exceeds the minimum IP target after more resources were used, and only the warm resources beyond the desired
+// size are deleted
+func TestPool_ReconcilePool_Delete_PendingCreate(t *testing.T) {
+	targetConfig := &config.WarmPoolConfig{DesiredSize: 2, MaxDeviation: 1, MinimumIPTarget: 40}
+	used := map[string]string{}
+	for i := 0; i < 25; i++ {
+		used[fmt.Sprintf("default/pod-%d", i)] = fmt.Sprintf("used-%d", i)
+	}
+	warmPool := getMockPool(targetConfig, used, []string{}, 100)
+	// The create was submitted with 10 used resources, 15 more resources were used before it completed
+	warmPool.pendingCreate = 30
+
+	// minimum IP target(40) - used(25) = 15 warm, deviation = 15 - 30(pending create) = -15
+	job := warmPool.ReconcilePool()
+	assert.Equal(t, &worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired}, job)
+	assert.Equal(t, 0, warmPool.pendingDelete)
+
+	var warm []string
+	for i := 0; i < 20; i++ {
+		warm = append(warm, fmt.Sprintf("warm-%d", i))
+	}
+	warmPool.warmResources = warm
+	warmPool.pendingCreate = 10
+
+	// Only the 5 warm resources beyond the 15 desired are deleted, the pending create is deleted once created
+	job = warmPool.ReconcilePool()
+	assert.Equal(t, worker.NewWarmPoolDeleteJob("", []string{"warm-19", "warm-18", "warm-17", "warm-16",
+		"warm-15"}), job)
+	assert.Len(t, warmPool.warmResources, 15)
+	assert.Equal(t, 5, warmPool.pendingDelete)
+}
+
+// TestPool_SetResourceRanker tests the warm resources are ordered by rank and the lowest rank is assigned first
+func TestPool_SetResourceRanker(t *testing.T) {
+	warmPool := getMockPool(poolConfig, map[string]string{}, []string{res3, res4, res5}, 7)
+	warmPool.strategy = packingStrategy{}
+	rank := map[string]int{res3: 2, res4: 0, res5: 1, res6: 0}
+
+	warmPool.SetResourceRanker(func(resourceID string) int { return rank[resourceID] })
+	assert.Equal(t, []string{res4, res5, res3}, warmPool.warmResources)
+
+	warmPool.UpdatePool(&worker.WarmPoolJob{Operations: worker.OperationCreate, Resources: []string{res6},
+		ResourceCount: 1}, true)
+	assert.Equal(t, []string{res4, res6, res5, res3}, warmPool.warmResources)
+
+	resourceID, _, err := warmPool.AssignResource(pod1)
+	assert.NoError(t, err)
+	assert.Equal(t, res4, resourceID)
+}
+
+// TestPool_DrainResources tests the resources present in the warm pool are removed and returned in delete job
+func TestPool_DrainResources(t *testing.T) {
+	warmPool := getMockPool(poolConfig, usedResources, []string{res3, res4, res5}, 7)
+
+	job := warmPool.DrainResources([]string{res5, res3, res1})
+
+	assert.Equal(t, &worker.WarmPoolJob{Operations: worker.OperationDeleted, NodeName: warmPool.nodeName,
+		Resources: []string{res3, res5}, ResourceCount: 2}, job)
+	assert.Equal(t, []string{res4}, warmPool.warmResources)
+	assert.Equal(t, 2, warmPool.pendingDelete)
+}
+
+// TestPool_DrainResources_PendingCreate tests the resources are not drained if there are pending operations
+func TestPool_DrainResources_PendingCreate(t *testing.T) {
+	warmPool := getMockPool(poolConfig, usedResources, []string{res3, res4}, 7)
+	warmPool.pendingCreate = 1
+
+	job := warmPool.DrainResources([]string{res3})
+
+	assert.Equal(t, worker.OperationReconcileNotRequired, job.Operations)
+	assert.Equal(t, []string{res3, res4}, warmPool.warmResources)
+}
+
 func TestPool_Reconcile_ReSync(t *testing.T) {
 	warmPool := getMockPool(poolConfig, usedResources, []string{}, 4)
 
@@ -432,6 +800,18 @@ func TestPool_GetAssignedResource(t *testing.T) {
 	assert.Equal(t, resID, "")
 }
 
+func TestPool_GetAssignedResources(t *testing.T) {
+	warmPool := getMockPool(poolConfig, map[string]string{pod1: res1, pod1 + "/1": res2, pod2: res3}, nil, 7)
+
+	resIDs, found := warmPool.GetAssignedResources(pod1)
+	assert.True(t, found)
+	assert.Equal(t, []string{res1, res2}, resIDs)
+
+	resIDs, found = warmPool.GetAssignedResources(pod3)
+	assert.False(t, found)
+	assert.Empty(t, resIDs)
+}
+
 func TestPool_Introspect(t *testing.T) {
 	coolingResources := []CoolDownResource{{
 		ResourceID: res6,
@@ -445,3 +825,19 @@ func TestPool_Introspect(t *testing.T) {
 	assert.ElementsMatch(t, warmPool.warmResources, resp.WarmResources)
 	assert.ElementsMatch(t, warmPool.coolDownQueue, resp.CoolingResources)
 }
+
+// TestPool_Introspect_CoolingResourcesCopy tests the introspected cooling resources are not modified when the cool
+// down queue is processed afterwards
+func TestPool_Introspect_CoolingResourcesCopy(t *testing.T) {
+	warmPool := getMockPool(poolConfig, usedResources, warmPoolResources, 7)
+	warmPool.coolDownQueue = []CoolDownResource{
+		{ResourceID: res5, DeletionTimestamp: time.Now().Add(-config.CoolDownPeriod)},
+		{ResourceID: res6, DeletionTimestamp: time.Now()},
+	}
+
+	resp := warmPool.Introspect()
+	warmPool.ProcessCoolDownQueue()
+
+	assert.Equal(t, res5, resp.CoolingResources[0].ResourceID)
+	assert.Equal(t, res6, resp.CoolingResources[1].ResourceID)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License"). You may
+not use this file except in compliance with the License. A copy of the
+License is located at
+
+     http://aws.amazon.com/apache2.0/
+
+or in the "license" file accompanying this file. This file is distributed
+on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+express or implied. See the License for the specific language governing
+permissions and limitations under the License.
+*/
+
+package pool
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+)
+
+// AssignmentStrategy orders the warm resources of the pool. The first warm resource is assigned
+// to the next requester and the last warm resource is deleted first
+type AssignmentStrategy interface {
+	// Order orders the warm resources in place, it's called every time resources are added to
+	// the warm pool
+	Order(warmResources []string, info ResourceInfo)
+}
+
+// ResourceInfo provides the details of the resources used by the assignment strategy
+type ResourceInfo interface {
+	// Rank returns the rank of the resource, for IPv4 address it's the device index of the ENI
+	Rank(resourceID string) int
+	// LastFreed returns the time the resource was last freed by its owner, zero if never used
+	LastFreed(resourceID string) time.Time
+}
+
+// NewAssignmentStrategy returns the assignment strategy with the given name, the FIFO strategy
+// is returned if the name is empty
+func NewAssignmentStrategy(name string) (AssignmentStrategy, error) {
+	switch name {
+	case "", config.AssignmentStrategyFIFO:
+		return fifoStrategy{}, nil
+	case config.AssignmentStrategyLRU:
+		return lruStrategy{}, nil
+	case config.AssignmentStrategyPacking:
+		return packingStrategy{}, nil
+	case config.AssignmentStrategySpreading:
+		return spreadingStrategy{}, nil
+	}
+	return nil, fmt.Errorf("unsupported assignment strategy %s", name)
+}
+
+// fifoStrategy assigns the resources in the order they were added to the warm pool
+type fifoStrategy struct{}
+
+func (fifoStrategy) Order(_ []string, _ ResourceInfo) {}
+
+// lruStrategy assigns the resources that were never used first, followed by the resources that
+// were freed the earliest. This maximizes the time before a freed resource is reused
+type lruStrategy struct{}
+
+func (lruStrategy) Order(warmResources []string, info ResourceInfo) {
+	sort.SliceStable(warmResources, func(i, j int) bool {
+		return info.LastFreed(warmResources[i]).Before(info.LastFreed(warmResources[j]))
+	})
+}
+
+// packingStrategy assigns the resources with the lowest rank first and deletes the resources with
+// the highest rank first, so the resources are packed on the fewest ENIs
+type packingStrategy struct{}
+
+func (packingStrategy) Order(warmResources []string, info ResourceInfo) {
+	sort.SliceStable(warmResources, func(i, j int) bool {
+		return info.Rank(warmResources[i]) < info.Rank(warmResources[j])
+	})
+}
+
+// spreadingStrategy assigns the resources from each rank in turn, so the resources are spread
+// across all the ENIs
+type spreadingStrategy struct{}
+
+func (spreadingStrategy) Order(warmResources []string, info ResourceInfo) {
+	var ranks []int
+	resourcesPerRank := map[int][]string{}
+	for _, resource := range warmResources {
+		rank := info.Rank(resource)
+		if _, ok := resourcesPerRank[rank]; !ok {
+			ranks = append(ranks, rank)
+		}
+		resourcesPerRank[rank] = append(resourcesPerRank[rank], resource)
+	}
+	sort.Ints(ranks)
+
+	for index := 0; index < len(warmResources); {
+		for _, rank := range ranks {
+			if len(resourcesPerRank[rank]) == 0 {
+				continue
+			}
+			warmResources[index] = resourcesPerRank[rank][0]
+			resourcesPerRank[rank] = resourcesPerRank[rank][1:]
+			index++
+		}
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2020 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License"). You may
+not use this file except in compliance with the License. A copy of the
+License is located at
+
+     http://aws.amazon.com/apache2.0/
+
+or in the "license" file accompanying this file. This file is distributed
+on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+express or implied. See the License for the specific language governing
+permissions and limitations under the License.
+*/
+
+package pool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockResourceInfo struct {
+	rank      map[string]int
+	lastFreed map[string]time.Time
+}
+
+func (m mockResourceInfo) Rank(resourceID string) int {
+	return m.rank[resourceID]
+}
+
+func (m mockResourceInfo) LastFreed(resourceID string) time.Time {
+	return m.lastFreed[resourceID]
+}
+
+var (
+	now = time.Now()
+
+	resourceInfo = mockResourceInfo{
+		rank:      map[string]int{res1: 0, res2: 1, res3: 0, res4: 2, res5: 1},
+		lastFreed: map[string]time.Time{res1: now, res2: now.Add(-time.Minute), res4: now.Add(-time.Second)},
+	}
+)
+
+func TestNewAssignmentStrategy(t *testing.T) {
+	for name, expected := range map[string]AssignmentStrategy{
+		"":                                 fifoStrategy{},
+		config.AssignmentStrategyFIFO:      fifoStrategy{},
+		config.AssignmentStrategyLRU:       lruStrategy{},
+		config.AssignmentStrategyPacking:   packingStrategy{},
+		config.AssignmentStrategySpreading: spreadingStrategy{},
+	} {
+		strategy, err := NewAssignmentStrategy(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, strategy)
+	}
+
+	_, err := NewAssignmentStrategy("random")
+	assert.Error(t, err)
+}
+
+// TestFIFOStrategy_Order tests the resources are kept in the order they were added
+func TestFIFOStrategy_Order(t *testing.T) {
+	resources := []string{res1, res2, res3, res4, res5}
+	fifoStrategy{}.Order(resources, resourceInfo)
+	assert.Equal(t, []string{res1, res2, res3, res4, res5}, resources)
+}
+
+// TestLRUStrategy_Order tests the never used resources are first followed by the earliest freed resources
+func TestLRUStrategy_Order(t *testing.T) {
+	resources := []string{res1, res2, res3, res4, res5}
+	lruStrategy{}.Order(resources, resourceInfo)
+	assert.Equal(t, []string{res3, res5, res2, res4, res1}, resources)
+}
+
+// TestPackingStrategy_Order tests the resources are ordered by the rank
+func TestPackingStrategy_Order(t *testing.T) {
+	resources := []string{res1, res2, res3, res4, res5}
+	packingStrategy{}.Order(resources, resourceInfo)
+	assert.Equal(t, []string{res1, res3, res2, res5, res4}, resources)
+}
+
+// TestSpreadingStrategy_Order tests the resources are picked from each rank in turn
+func TestSpreadingStrategy_Order(t *testing.T) {
+	resources := []string{res1, res2, res3, res4, res5}
+	spreadingStrategy{}.Order(resources, resourceInfo)
+	assert.Equal(t, []string{res1, res2, res4, res3, res5}, resources)
+}
+
+// TestPool_ProcessCoolDownQueue_LRU tests the cooled down resources are assigned after the never used resources
+func TestPool_ProcessCoolDownQueue_LRU(t *testing.T) {
+	warmPool := getMockPool(poolConfig, map[string]string{}, []string{res1}, 7)
+	warmPool.strategy = lruStrategy{}
+	warmPool.coolDownQueue = []CoolDownResource{{ResourceID: res2, DeletionTimestamp: now.Add(-config.CoolDownPeriod)}}
+
+	warmPool.ProcessCoolDownQueue()
+	warmPool.UpdatePool(&worker.WarmPoolJob{Operations: worker.OperationCreate, Resources: []string{res3},
+		ResourceCount: 1}, true)
+
+	assert.Equal(t, []string{res1, res3, res2}, warmPool.warmResources)
+}
@@ -23,7 +23,6 @@ import (
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
-	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
@@ -31,9 +30,11 @@ import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
@@ -64,7 +65,8 @@ var (
 	ReasonBranchAllocationFailed    = "BranchAllocationFailed"
 	ReasonBranchENIAnnotationFailed = "BranchENIAnnotationFailed"
 
-	ReasonTrunkENICreationFailed = "TrunkENICreationFailed"
+	ReasonTrunkENICreationFailed  = "TrunkENICreationFailed"
+	ReasonBranchENIDeletionFailed = "BranchENIDeletionFailed"
 
 	reconcileRequeueRequest   = ctrl.Result{RequeueAfter: time.Minute * 30, Requeue: true}
 	deleteQueueRequeueRequest = ctrl.Result{RequeueAfter: time.Second * 30, Requeue: true}
@@ -76,6 +78,11 @@ var (
 	prometheusRegistered = false
 )
 
+const (
+	// ErrCodeInstanceNotFound is the EC2 error code returned when the instance doesn't exist anymore
+	ErrCodeInstanceNotFound = "InvalidInstanceID.NotFound"
+)
+
 var (
 	ErrTrunkExistInCache = fmt.Errorf("trunk eni already exist in cache")
 	ErrTrunkNotInCache   = fmt.Errorf("trunk eni not present in cache")
@@ -94,6 +101,10 @@ type branchENIProvider struct {
 	// apiWrapper
 	apiWrapper api.Wrapper
 	ctx        context.Context
+	// coolDownLock guards the following
+	coolDownLock sync.Mutex
+	// coolDownChecks is the set of nodes with a process cool down queue job submitted
+	coolDownChecks map[string]struct{}
 }
 
 // NewBranchENIProvider returns the Branch ENI Provider for all nodes across the cluster
@@ -103,11 +114,12 @@ func NewBranchENIProvider(logger logr.Logger, wrapper api.Wrapper,
 	trunk.PrometheusRegister()
 
 	return &branchENIProvider{
-		apiWrapper:    wrapper,
-		log:           logger,
-		workerPool:    worker,
-		trunkENICache: make(map[string]trunk.TrunkENI),
-		ctx:           ctx,
+		apiWrapper:     wrapper,
+		log:            logger,
+		workerPool:     worker,
+		trunkENICache:  make(map[string]trunk.TrunkENI),
+		coolDownChecks: make(map[string]struct{}),
+		ctx:            ctx,
 	}
 }
 
@@ -132,7 +144,12 @@ func timeSinceMs(start time.Time) float64 {
 func (b *branchENIProvider) InitResource(instance ec2.EC2Instance) error {
 	nodeName := instance.Name()
 	log := b.log.WithValues("node name", nodeName)
-	trunkENI := trunk.NewTrunkENI(log, instance, b.apiWrapper.EC2API)
+	var isAddressInUse func(address string) bool
+	if b.apiWrapper.EndpointAPI != nil {
+		// Delete the branch ENIs of deleted pods as soon as they are removed from all the endpoints
+		isAddressInUse = b.apiWrapper.EndpointAPI.IsAddressInUse
+	}
+	trunkENI := trunk.NewTrunkENI(log, instance, b.apiWrapper.EC2API, isAddressInUse)
 
 	// Initialize the Trunk ENI
 	start := time.Now()
@@ -188,14 +205,53 @@ func (b *branchENIProvider) InitResource(instance ec2.EC2Instance) error {
 // DeInitResources adds a an asynchronous delete job to the worker which will execute after a certain period.
 // This is done because we receive the Node Delete Event First and the Pods are evicted after the node no longer exists
 // leading to all the pod events to be ignored since the node has been de initialized and hence leaking branch ENs.
+// If the EC2 instance is already terminating or terminated, the pods can no longer use the branch ENIs so the resources
+// are cleaned up immediately without waiting for the pods to be evicted.
 func (b *branchENIProvider) DeInitResource(instance ec2.EC2Instance) error {
 	nodeName := instance.Name()
+	if b.apiWrapper.SubnetAPI != nil {
+		b.apiWrapper.SubnetAPI.Forget(nodeName)
+	}
+	if b.isInstanceTerminated(instance) {
+		b.log.Info("instance is terminating, will clean up resources immediately",
+			"node name", nodeName, "instance id", instance.InstanceID())
+		b.workerPool.SubmitJob(worker.NewOnDemandDeleteNodeJob(nodeName))
+		return nil
+	}
+
 	b.log.Info("will clean up resources later to allow pods to be evicted first",
 		"node name", nodeName, "cleanup after", NodeDeleteRequeueRequestDelay)
 	b.workerPool.SubmitJobAfter(worker.NewOnDemandDeleteNodeJob(nodeName), NodeDeleteRequeueRequestDelay)
 	return nil
 }
 
+// isInstanceTerminated returns true if the EC2 instance is shutting down, terminated or no longer exists. The instance
+// state is used instead of the taints applied by the termination handlers as the node object is usually deleted by the
+// time the resources are de initialized.
+func (b *branchENIProvider) isInstanceTerminated(instance ec2.EC2Instance) bool {
+	instanceID := instance.InstanceID()
+	instanceDetails, err := b.apiWrapper.EC2API.GetInstanceDetails(&instanceID)
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == ErrCodeInstanceNotFound {
+			return true
+		}
+		// Fall back to the delayed clean up
+		branchProviderOperationsErrCount.WithLabelValues("get_instance_state").Inc()
+		b.log.Error(err, "failed to get the instance state", "instance id", instanceID)
+		return false
+	}
+
+	if instanceDetails.State == nil || instanceDetails.State.Name == nil {
+		return false
+	}
+
+	switch *instanceDetails.State.Name {
+	case awsEC2.InstanceStateNameShuttingDown, awsEC2.InstanceStateNameTerminated:
+		return true
+	}
+	return false
+}
+
 // SubmitAsyncJob submits the job to the k8s worker queue and returns immediately without waiting for the job to
 // complete. Using the k8s worker queue features we can ensure that the same job is not submitted more than once.
 func (b *branchENIProvider) SubmitAsyncJob(job interface{}) {
@@ -217,6 +273,8 @@ func (b *branchENIProvider) ProcessAsyncJob(job interface{}) (ctrl.Result, error
 		return b.DeleteBranchUsedByPods(onDemandJob.NodeName, onDemandJob.UID)
 	case worker.OperationProcessDeleteQueue:
 		return b.ProcessDeleteQueue(onDemandJob.NodeName)
+	case worker.OperationProcessCoolDownQueue:
+		return b.ProcessCoolDownQueue(onDemandJob.NodeName)
 	case worker.OperationReconcileNode:
 		return b.ReconcileNode(onDemandJob.NodeName)
 	case worker.OperationDeleteNode:
@@ -233,6 +291,9 @@ func (b *branchENIProvider) DeleteNode(nodeName string) (ctrl.Result, error) {
 		return ctrl.Result{}, fmt.Errorf("failed to find node %s", nodeName)
 	}
 
+	// The queued jobs of the node would fail to find the trunk
+	b.workerPool.CancelNodeJobs(nodeName)
+
 	trunkENI.DeleteAllBranchENIs()
 	b.removeTrunkFromCache(nodeName)
 
@@ -241,13 +302,32 @@ func (b *branchENIProvider) DeleteNode(nodeName string) (ctrl.Result, error) {
 	return ctrl.Result{}, nil
 }
 
+// getBranchENICount returns the function counting the branch ENIs of the node, each holding an IP address of the
+// subnets of the node
+func (b *branchENIProvider) getBranchENICount(nodeName string) func() int {
+	return func() int {
+		trunkENI, found := b.getTrunkFromCache(nodeName)
+		if !found {
+			return 0
+		}
+		return trunkENI.GetBranchENICount()
+	}
+}
+
 // GetResourceCapacity returns the resource capacity for the given instance.
 func (b *branchENIProvider) UpdateResourceCapacity(instance ec2.EC2Instance) error {
 	instanceName := instance.Name()
 	instanceType := instance.Type()
-	capacity := vpc.Limits[instanceType].BranchInterface
+	var capacity int
+	if limits, found := instance.Limits(); found {
+		capacity = limits.BranchInterface
+	}
 
 	if capacity != 0 {
+		if b.apiWrapper.SubnetAPI != nil {
+			capacity = b.apiWrapper.SubnetAPI.LimitCapacity(instance, config.ResourceNamePodENI, capacity,
+				b.getBranchENICount(instanceName))
+		}
 		err := b.apiWrapper.K8sAPI.AdvertiseCapacityIfNotSet(instanceName, config.ResourceNamePodENI, capacity)
 		if err != nil {
 			branchProviderOperationsErrCount.WithLabelValues("advertise_capacity").Inc()
@@ -292,10 +372,113 @@ func (b *branchENIProvider) ProcessDeleteQueue(nodeName string) (ctrl.Result, er
 		log.Info("stopping the process delete queue job")
 		return ctrl.Result{}, nil
 	}
-	trunkENI.DeleteCooledDownENIs()
+	deadLetterENIs := trunkENI.DeleteCooledDownENIs()
+	if len(deadLetterENIs) > 0 {
+		b.broadcastDeadLetterEvent(nodeName, deadLetterENIs)
+	}
+	// Check the endpoints of the ENIs still cooling down again, in case they were added to the delete queue without
+	// submitting the process cool down queue job
+	if b.apiWrapper.EndpointAPI != nil && trunkENI.HasCoolingDownENIs() {
+		b.CheckCoolDownQueue(nodeName)
+	}
 	return deleteQueueRequeueRequest, nil
 }
 
+// CheckCoolDownQueue submits the job to process the delete queue of the node after the endpoint check interval, so
+// the branch ENIs freed on an idle node are not held for the cool down period once their endpoints are removed. At
+// most one job is submitted per node, the job is re-submitted until no ENI is cooling down
+func (b *branchENIProvider) CheckCoolDownQueue(nodeName string) {
+	if b.apiWrapper.EndpointAPI == nil || !b.setCoolDownCheck(nodeName) {
+		return
+	}
+	b.workerPool.SubmitJobAfter(worker.NewOnDemandProcessCoolDownQueueJob(nodeName),
+		config.EndpointCoolDownCheckInterval)
+}
+
+// ProcessCoolDownQueue deletes the branch ENIs that have cooled down, the job is re-submitted after the endpoint check
+// interval until no ENI is cooling down
+func (b *branchENIProvider) ProcessCoolDownQueue(nodeName string) (ctrl.Result, error) {
+	// ENIs freed from now on submit a new job if this job is not re-submitted
+	b.clearCoolDownCheck(nodeName)
+
+	trunkENI, isPresent := b.getTrunkFromCache(nodeName)
+	if !isPresent {
+		b.log.Info("stopping the process cool down queue job", "node", nodeName)
+		return ctrl.Result{}, nil
+	}
+	deadLetterENIs := trunkENI.DeleteCooledDownENIs()
+	if len(deadLetterENIs) > 0 {
+		b.broadcastDeadLetterEvent(nodeName, deadLetterENIs)
+	}
+	if trunkENI.HasCoolingDownENIs() && b.setCoolDownCheck(nodeName) {
+		return ctrl.Result{RequeueAfter: config.EndpointCoolDownCheckInterval, Requeue: true}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// setCoolDownCheck marks the node as having a process cool down queue job, returns false if it already has one
+func (b *branchENIProvider) setCoolDownCheck(nodeName string) bool {
+	b.coolDownLock.Lock()
+	defer b.coolDownLock.Unlock()
+
+	if _, ok := b.coolDownChecks[nodeName]; ok {
+		return false
+	}
+	b.coolDownChecks[nodeName] = struct{}{}
+	return true
+}
+
+// clearCoolDownCheck marks the node as not having a process cool down queue job
+func (b *branchENIProvider) clearCoolDownCheck(nodeName string) {
+	b.coolDownLock.Lock()
+	defer b.coolDownLock.Unlock()
+
+	delete(b.coolDownChecks, nodeName)
+}
+
+// broadcastDeadLetterEvent broadcasts an event on the node for the branch ENIs that were moved to the dead letter queue
+func (b *branchENIProvider) broadcastDeadLetterEvent(nodeName string, deadLetterENIs []trunk.ENIDetails) {
+	node, err := b.apiWrapper.K8sAPI.GetNode(nodeName)
+	if err != nil {
+		b.log.Error(err, "failed to get node for event advertisement", "node name", nodeName)
+		return
+	}
+
+	var eniIDs []string
+	for _, eni := range deadLetterENIs {
+		eniIDs = append(eniIDs, eni.ID)
+	}
+	b.apiWrapper.K8sAPI.BroadcastEvent(node, ReasonBranchENIDeletionFailed,
+		fmt.Sprintf("Failed to delete branch ENI/s %v after %d retries, will retry with backoff",
+			eniIDs, trunk.MaxDeleteRetries), v1.EventTypeWarning)
+}
+
+// RetryDeadLetterResource retries the deletion of the branch ENI present in the dead letter queue of the node
+func (b *branchENIProvider) RetryDeadLetterResource(nodeName string, eniID string) error {
+	trunkENI, isPresent := b.getTrunkFromCache(nodeName)
+	if !isPresent {
+		return provider.ErrDeadLetterResourceNotFound
+	}
+	return toDeadLetterError(trunkENI.RetryDeadLetterENI(eniID))
+}
+
+// ForgetDeadLetterResource removes the branch ENI deleted out of band from the dead letter queue of the node
+func (b *branchENIProvider) ForgetDeadLetterResource(nodeName string, eniID string) error {
+	trunkENI, isPresent := b.getTrunkFromCache(nodeName)
+	if !isPresent {
+		return provider.ErrDeadLetterResourceNotFound
+	}
+	return toDeadLetterError(trunkENI.ForgetDeadLetterENI(eniID))
+}
+
+// toDeadLetterError returns the error of the dead letter provider for the error returned by the trunk
+func toDeadLetterError(err error) error {
+	if err == trunk.ErrENINotInDeadLetterQueue {
+		return provider.ErrDeadLetterResourceNotFound
+	}
+	return err
+}
+
 // CreateAndAnnotateResources creates resource for the pod, the function can run concurrently for different pods without
 // any locking as long as caller guarantees this function is not called concurrently for same pods.
 func (b *branchENIProvider) CreateAndAnnotateResources(podNamespace string, podName string, resourceCount int) (ctrl.Result, error) {
@@ -306,7 +489,7 @@ func (b *branchENIProvider) CreateAndAnnotateResources(podNamespace string, podN
 		return ctrl.Result{}, err
 	}
 
-	if _, ok := pod.Annotations[config.ResourceNamePodENI]; ok {
+	if _, ok := pod.Annotations[config.ResourceNamePodENI]; ok && !needsPodENIReadinessCondition(pod) {
 		// Pod from cache already has annotation, skip the job
 		return ctrl.Result{}, nil
 	}
@@ -322,7 +505,9 @@ func (b *branchENIProvider) CreateAndAnnotateResources(podNamespace string, podN
 		// Pod doesn't have an annotation yet. Create Branch ENI and annotate the pod
 		b.log.Info("skipping pod event as the pod already has pod-eni allocated",
 			"namespace", pod.Namespace, "name", pod.Name)
-		return ctrl.Result{}, nil
+		// The readiness condition may not have been set if the earlier update failed after annotating the pod
+		return ctrl.Result{}, b.setPodENIReadinessCondition(pod, v1.ConditionTrue, ReasonResourceAllocated,
+			"branch ENI is associated with the trunk ENI")
 	}
 
 	securityGroups, err := b.apiWrapper.SGPAPI.GetMatchingSecurityGroupForPods(pod)
@@ -357,6 +542,10 @@ func (b *branchENIProvider) CreateAndAnnotateResources(podNamespace string, podN
 		}
 		b.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonBranchAllocationFailed,
 			fmt.Sprintf("failed to allocate branch ENI to pod: %v", err), v1.EventTypeWarning)
+		if condErr := b.setPodENIReadinessCondition(pod, v1.ConditionFalse, ReasonBranchAllocationFailed,
+			fmt.Sprintf("failed to allocate branch ENI to pod: %v", err)); condErr != nil {
+			log.Error(condErr, "failed to update pod-eni readiness condition")
+		}
 		return ctrl.Result{}, err
 	}
 
@@ -366,6 +555,7 @@ func (b *branchENIProvider) CreateAndAnnotateResources(podNamespace string, podN
 	jsonBytes, err := json.Marshal(branchENIs)
 	if err != nil {
 		trunkENI.PushENIsToFrontOfDeleteQueue(pod, branchENIs)
+		b.CheckCoolDownQueue(pod.Spec.NodeName)
 		b.log.Info("pushed the ENIs to the delete queue as failed to unmarshal ENI details", "ENI/s", branchENIs)
 		branchProviderOperationsErrCount.WithLabelValues("annotate_branch_eni").Inc()
 		return ctrl.Result{}, err
@@ -376,10 +566,15 @@ func (b *branchENIProvider) CreateAndAnnotateResources(podNamespace string, podN
 		config.ResourceNamePodENI, string(jsonBytes))
 	if err != nil {
 		trunkENI.PushENIsToFrontOfDeleteQueue(pod, branchENIs)
+		b.CheckCoolDownQueue(pod.Spec.NodeName)
 		b.log.Info("pushed the ENIs to the delete queue as failed to annotate the pod", "ENI/s", branchENIs)
 		b.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonBranchENIAnnotationFailed,
 			fmt.Sprintf("failed to annotate pod with branch ENI details: %v", err), v1.EventTypeWarning)
 		branchProviderOperationsErrCount.WithLabelValues("annotate_branch_eni").Inc()
+		if condErr := b.setPodENIReadinessCondition(pod, v1.ConditionFalse, ReasonBranchENIAnnotationFailed,
+			fmt.Sprintf("failed to annotate pod with branch ENI details: %v", err)); condErr != nil {
+			log.Error(condErr, "failed to update pod-eni readiness condition")
+		}
 		return ctrl.Result{}, err
 	}
 
@@ -392,7 +587,56 @@ func (b *branchENIProvider) CreateAndAnnotateResources(podNamespace string, podN
 
 	log.Info("created and annotated branch interface/s successfully", "branches", branchENIs)
 
-	return ctrl.Result{}, nil
+	// On failure, the job is retried and the condition is set again as the pod is already annotated
+	return ctrl.Result{}, b.setPodENIReadinessCondition(pod, v1.ConditionTrue, ReasonResourceAllocated,
+		"branch ENI is associated with the trunk ENI")
+}
+
+// setPodENIReadinessCondition sets the pod-eni readiness condition on the pod status if the pod has the
+// pod-eni readiness gate and the condition doesn't already have the given status
+func (b *branchENIProvider) setPodENIReadinessCondition(pod *v1.Pod, status v1.ConditionStatus,
+	reason string, message string) error {
+	if !hasPodENIReadinessGate(pod) || hasPodENIReadinessCondition(pod, status) {
+		return nil
+	}
+
+	err := b.apiWrapper.PodAPI.UpdatePodCondition(pod.Namespace, pod.Name, pod.UID, v1.PodCondition{
+		Type:               v1.PodConditionType(config.PodENIReadinessGate),
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+	if err != nil {
+		branchProviderOperationsErrCount.WithLabelValues("update_pod_condition").Inc()
+	}
+	return err
+}
+
+// needsPodENIReadinessCondition returns true if the pod has the pod-eni readiness gate and the condition was not set
+// to true yet, which happens if the condition update failed after annotating the pod
+func needsPodENIReadinessCondition(pod *v1.Pod) bool {
+	return hasPodENIReadinessGate(pod) && !hasPodENIReadinessCondition(pod, v1.ConditionTrue)
+}
+
+// hasPodENIReadinessGate returns true if the pod has the pod-eni readiness gate
+func hasPodENIReadinessGate(pod *v1.Pod) bool {
+	for _, gate := range pod.Spec.ReadinessGates {
+		if gate.ConditionType == v1.PodConditionType(config.PodENIReadinessGate) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPodENIReadinessCondition returns true if the pod-eni readiness condition of the pod has the given status
+func hasPodENIReadinessCondition(pod *v1.Pod, status v1.ConditionStatus) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodConditionType(config.PodENIReadinessGate) && condition.Status == status {
+			return true
+		}
+	}
+	return false
 }
 
 func (b *branchENIProvider) DeleteBranchUsedByPods(nodeName string, UID string) (ctrl.Result, error) {
@@ -405,6 +649,7 @@ func (b *branchENIProvider) DeleteBranchUsedByPods(nodeName string, UID string)
 	}
 
 	trunkENI.PushBranchENIsToCoolDownQueue(UID)
+	b.CheckCoolDownQueue(nodeName)
 
 	return ctrl.Result{}, nil
 }
@@ -459,13 +704,14 @@ func (b *branchENIProvider) GetPool(_ string) (pool.Pool, bool) {
 	return nil, false
 }
 
-// IsInstanceSupported returns true for linux node as pod eni is only supported for linux worker node
+// IsInstanceSupported returns true for linux node as pod eni is only supported for linux worker node. Linux nodes
+// managed only for the IPv4 resource don't have the trunk enabled and are not supported
 func (b *branchENIProvider) IsInstanceSupported(instance ec2.EC2Instance) bool {
-	limits, found := vpc.Limits[instance.Type()]
+	limits, found := instance.Limits()
 	if !found {
 		return false
 	}
-	if instance.Os() == config.OSLinux && limits.IsTrunkingCompatible {
+	if instance.Os() == config.OSLinux && limits.IsTrunkingCompatible && instance.IsTrunkEnabled() {
 		return true
 	}
 	return false
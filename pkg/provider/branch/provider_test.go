@@ -21,17 +21,25 @@ import (
 	"testing"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/endpoint"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s/pod"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider/branch/trunk"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/subnet"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/utils"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/worker"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s/pod"
+	pkgProvider "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -42,7 +50,8 @@ import (
 )
 
 var (
-	NodeName = "test-node"
+	NodeName   = "test-node"
+	InstanceID = "i-00000000000000000"
 
 	MockPodName1      = "pod_name"
 	MockPodNamespace1 = "pod_namespace"
@@ -105,17 +114,32 @@ func getProviderAndMockK8sWrapper(ctrl *gomock.Controller) (branchENIProvider, *
 
 func getProviderWithMockWorker(ctrl *gomock.Controller) (branchENIProvider, *mock_worker.MockWorker) {
 	mockWorker := mock_worker.NewMockWorker(ctrl)
+	return branchENIProvider{
+		log:            zap.New(zap.UseDevMode(true)).WithName("branch provider"),
+		workerPool:     mockWorker,
+		trunkENICache:  make(map[string]trunk.TrunkENI),
+		coolDownChecks: make(map[string]struct{}),
+	}, mockWorker
+}
+
+// getProviderWithMockWorkerAndHelper returns the mock provider along with the worker and the ec2 api helper
+func getProviderWithMockWorkerAndHelper(ctrl *gomock.Controller) (branchENIProvider, *mock_worker.MockWorker,
+	*mock_api.MockEC2APIHelper) {
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+	mockHelper := mock_api.NewMockEC2APIHelper(ctrl)
 	return branchENIProvider{
 		log:        zap.New(zap.UseDevMode(true)).WithName("branch provider"),
 		workerPool: mockWorker,
-	}, mockWorker
+		apiWrapper: api.Wrapper{EC2API: mockHelper},
+	}, mockWorker, mockHelper
 }
 
 func getProvider() branchENIProvider {
 	log := zap.New(zap.UseDevMode(true)).WithName("branch provider")
 	return branchENIProvider{
-		log:           log,
-		trunkENICache: make(map[string]trunk.TrunkENI),
+		log:            log,
+		trunkENICache:  make(map[string]trunk.TrunkENI),
+		coolDownChecks: make(map[string]struct{}),
 	}
 }
 
@@ -246,10 +270,13 @@ func TestBranchENIProvider_DeInitResources(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	provider, mockWorker := getProviderWithMockWorker(ctrl)
+	provider, mockWorker, mockHelper := getProviderWithMockWorkerAndHelper(ctrl)
 	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
 
 	mockInstance.EXPECT().Name().Return(NodeName)
+	mockInstance.EXPECT().InstanceID().Return(InstanceID)
+	mockHelper.EXPECT().GetInstanceDetails(&InstanceID).Return(&awsEC2.Instance{
+		State: &awsEC2.InstanceState{Name: aws.String(awsEC2.InstanceStateNameRunning)}}, nil)
 	mockWorker.EXPECT().SubmitJobAfter(worker.NewOnDemandDeleteNodeJob(NodeName), NodeDeleteRequeueRequestDelay)
 
 	err := provider.DeInitResource(mockInstance)
@@ -257,6 +284,83 @@ func TestBranchENIProvider_DeInitResources(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestBranchENIProvider_DeleteNode verifies that the queued jobs of the node are cancelled and the trunk is removed
+// from cache after deleting the branch ENIs
+func TestBranchENIProvider_DeleteNode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockWorker := getProviderWithMockWorker(ctrl)
+	fakeTrunk := mock_trunk.NewMockTrunkENI(ctrl)
+	provider.trunkENICache = map[string]trunk.TrunkENI{NodeName: fakeTrunk}
+
+	mockWorker.EXPECT().CancelNodeJobs(NodeName).Return(2)
+	fakeTrunk.EXPECT().DeleteAllBranchENIs()
+
+	_, err := provider.DeleteNode(NodeName)
+	assert.NoError(t, err)
+	_, ok := provider.trunkENICache[NodeName]
+	assert.False(t, ok)
+}
+
+// TestBranchENIProvider_DeInitResources_InstanceTerminating verifies that the delete job is submitted without any delay
+// if the instance is terminating or terminated
+func TestBranchENIProvider_DeInitResources_InstanceTerminating(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	for _, state := range []string{awsEC2.InstanceStateNameShuttingDown, awsEC2.InstanceStateNameTerminated} {
+		provider, mockWorker, mockHelper := getProviderWithMockWorkerAndHelper(ctrl)
+		mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+
+		mockInstance.EXPECT().Name().Return(NodeName)
+		mockInstance.EXPECT().InstanceID().Return(InstanceID).Times(2)
+		mockHelper.EXPECT().GetInstanceDetails(&InstanceID).Return(&awsEC2.Instance{
+			State: &awsEC2.InstanceState{Name: aws.String(state)}}, nil)
+		mockWorker.EXPECT().SubmitJob(worker.NewOnDemandDeleteNodeJob(NodeName))
+
+		err := provider.DeInitResource(mockInstance)
+		assert.NoError(t, err)
+	}
+}
+
+// TestBranchENIProvider_DeInitResources_InstanceNotFound verifies that the delete job is submitted without any delay
+// if the instance doesn't exist anymore
+func TestBranchENIProvider_DeInitResources_InstanceNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockWorker, mockHelper := getProviderWithMockWorkerAndHelper(ctrl)
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+
+	mockInstance.EXPECT().Name().Return(NodeName)
+	mockInstance.EXPECT().InstanceID().Return(InstanceID).Times(2)
+	mockHelper.EXPECT().GetInstanceDetails(&InstanceID).
+		Return(nil, awserr.New(ErrCodeInstanceNotFound, "instance not found", nil))
+	mockWorker.EXPECT().SubmitJob(worker.NewOnDemandDeleteNodeJob(NodeName))
+
+	err := provider.DeInitResource(mockInstance)
+	assert.NoError(t, err)
+}
+
+// TestBranchENIProvider_DeInitResources_DescribeError verifies that the delete job is delayed if the instance state
+// cannot be determined
+func TestBranchENIProvider_DeInitResources_DescribeError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockWorker, mockHelper := getProviderWithMockWorkerAndHelper(ctrl)
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+
+	mockInstance.EXPECT().Name().Return(NodeName)
+	mockInstance.EXPECT().InstanceID().Return(InstanceID)
+	mockHelper.EXPECT().GetInstanceDetails(&InstanceID).Return(nil, MockError)
+	mockWorker.EXPECT().SubmitJobAfter(worker.NewOnDemandDeleteNodeJob(NodeName), NodeDeleteRequeueRequestDelay)
+
+	err := provider.DeInitResource(mockInstance)
+	assert.NoError(t, err)
+}
+
 // TestBranchENIProvider_GetResourceCapacity tests that the correct capacity is returned for supported instance types
 func TestBranchENIProvider_GetResourceCapacity(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -267,6 +371,7 @@ func TestBranchENIProvider_GetResourceCapacity(t *testing.T) {
 
 	supportedInstanceType := "c5.xlarge"
 
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[supportedInstanceType], true)
 	mockInstance.EXPECT().Type().Return(supportedInstanceType)
 	mockInstance.EXPECT().Name().Return(NodeName)
 	mockK8sWrapper.EXPECT().AdvertiseCapacityIfNotSet(NodeName, config.ResourceNamePodENI,
@@ -276,6 +381,37 @@ func TestBranchENIProvider_GetResourceCapacity(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestBranchENIProvider_UpdateResourceCapacity_SubnetLimit tests the advertised capacity is limited by the subnet
+// monitor with the branch ENIs of the trunk of the node counted as held IPs
+func TestBranchENIProvider_UpdateResourceCapacity_SubnetLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockK8sWrapper := getProviderAndMockK8sWrapper(ctrl)
+	mockSubnetMonitor := mock_subnet.NewMockSubnetMonitor(ctrl)
+	provider.apiWrapper.SubnetAPI = mockSubnetMonitor
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	fakeTrunk := mock_trunk.NewMockTrunkENI(ctrl)
+	provider.trunkENICache[NodeName] = fakeTrunk
+
+	supportedInstanceType := "c5.xlarge"
+
+	mockInstance.EXPECT().Name().Return(NodeName)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[supportedInstanceType], true)
+	mockInstance.EXPECT().Type().Return(supportedInstanceType)
+	fakeTrunk.EXPECT().GetBranchENICount().Return(3)
+	mockSubnetMonitor.EXPECT().LimitCapacity(mockInstance, config.ResourceNamePodENI,
+		vpc.Limits[supportedInstanceType].BranchInterface, gomock.Any()).
+		DoAndReturn(func(_ interface{}, _ string, _ int, usage func() int) int {
+			assert.Equal(t, 3, usage())
+			return 5
+		})
+	mockK8sWrapper.EXPECT().AdvertiseCapacityIfNotSet(NodeName, config.ResourceNamePodENI, 5)
+
+	err := provider.UpdateResourceCapacity(mockInstance)
+	assert.NoError(t, err)
+}
+
 // TestBranchENIProvider_GetResourceCapacity_NotSupported tests that 0 is returned for non supported instance types
 func TestBranchENIProvider_GetResourceCapacity_NotSupported(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -287,6 +423,7 @@ func TestBranchENIProvider_GetResourceCapacity_NotSupported(t *testing.T) {
 	supportedInstanceType := "t3.medium"
 
 	mockInstance.EXPECT().Name().Return(NodeName)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[supportedInstanceType], true)
 	mockInstance.EXPECT().Type().Return(supportedInstanceType)
 
 	err := provider.UpdateResourceCapacity(mockInstance)
@@ -321,6 +458,142 @@ func TestBranchENIProvider_CreateAndAnnotateResources(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestBranchENIProvider_CreateAndAnnotateResources_ReadinessGate tests that the pod-eni readiness condition is set
+// to true after the pod is annotated if the pod has the readiness gate
+func TestBranchENIProvider_CreateAndAnnotateResources_ReadinessGate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockPodAPI, mockSGPAPI, mockK8sAPI := getProviderAndMocks(ctrl)
+
+	resCount := 1
+	expectedAnnotation, _ := json.Marshal(EniDetails)
+	fakeTrunk := mock_trunk.NewMockTrunkENI(ctrl)
+
+	provider.trunkENICache[NodeName] = fakeTrunk
+
+	podWithGate := MockPod1.DeepCopy()
+	podWithGate.Spec.ReadinessGates = []v1.PodReadinessGate{{ConditionType: config.PodENIReadinessGate}}
+
+	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(MockPod1, nil)
+	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(podWithGate, nil)
+	mockSGPAPI.EXPECT().GetMatchingSecurityGroupForPods(podWithGate).Return(SecurityGroups, nil)
+	mockK8sAPI.EXPECT().BroadcastEvent(podWithGate, ReasonSecurityGroupRequested, gomock.Any(), v1.EventTypeNormal)
+	fakeTrunk.EXPECT().CreateAndAssociateBranchENIs(podWithGate, SecurityGroups, resCount).Return(EniDetails, nil)
+	mockPodAPI.EXPECT().AnnotatePod(MockPodNamespace1, MockPodName1, MockPodUID1, config.ResourceNamePodENI,
+		string(expectedAnnotation)).Return(nil)
+	mockK8sAPI.EXPECT().BroadcastEvent(podWithGate, ReasonResourceAllocated, gomock.Any(), v1.EventTypeNormal)
+	mockPodAPI.EXPECT().UpdatePodCondition(MockPodNamespace1, MockPodName1, MockPodUID1, gomock.Any()).
+		DoAndReturn(func(_ string, _ string, _ types.UID, condition v1.PodCondition) error {
+			assert.Equal(t, v1.PodConditionType(config.PodENIReadinessGate), condition.Type)
+			assert.Equal(t, v1.ConditionTrue, condition.Status)
+			return nil
+		})
+
+	_, err := provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, resCount)
+
+	assert.NoError(t, err)
+}
+
+// TestBranchENIProvider_CreateAndAnnotateResources_ReadinessGate_CreateError tests that the pod-eni readiness
+// condition is set to false if the branch ENI allocation fails
+func TestBranchENIProvider_CreateAndAnnotateResources_ReadinessGate_CreateError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockPodAPI, mockSGPAPI, mockK8sAPI := getProviderAndMocks(ctrl)
+
+	resCount := 1
+	fakeTrunk := mock_trunk.NewMockTrunkENI(ctrl)
+
+	provider.trunkENICache[NodeName] = fakeTrunk
+
+	podWithGate := MockPod1.DeepCopy()
+	podWithGate.Spec.ReadinessGates = []v1.PodReadinessGate{{ConditionType: config.PodENIReadinessGate}}
+
+	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(MockPod1, nil)
+	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(podWithGate, nil)
+	mockSGPAPI.EXPECT().GetMatchingSecurityGroupForPods(podWithGate).Return(SecurityGroups, nil)
+	mockK8sAPI.EXPECT().BroadcastEvent(podWithGate, ReasonSecurityGroupRequested, gomock.Any(), v1.EventTypeNormal)
+	fakeTrunk.EXPECT().CreateAndAssociateBranchENIs(podWithGate, SecurityGroups, resCount).Return(nil, MockError)
+	mockK8sAPI.EXPECT().BroadcastEvent(podWithGate, ReasonBranchAllocationFailed, gomock.Any(), v1.EventTypeWarning)
+	mockPodAPI.EXPECT().UpdatePodCondition(MockPodNamespace1, MockPodName1, MockPodUID1, gomock.Any()).
+		DoAndReturn(func(_ string, _ string, _ types.UID, condition v1.PodCondition) error {
+			assert.Equal(t, v1.ConditionFalse, condition.Status)
+			assert.Equal(t, ReasonBranchAllocationFailed, condition.Reason)
+			return nil
+		})
+
+	_, err := provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, resCount)
+
+	assert.Equal(t, MockError, err)
+}
+
+// TestBranchENIProvider_CreateAndAnnotateResources_ReadinessGate_AlreadyAnnotated tests that the readiness condition
+// is set on an already annotated pod only if the condition is not already true
+func TestBranchENIProvider_CreateAndAnnotateResources_ReadinessGate_AlreadyAnnotated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockPodAPI, _, _ := getProviderAndMocks(ctrl)
+
+	podWithGate := MockPod1.DeepCopy()
+	podWithGate.Annotations[config.ResourceNamePodENI] = "EniDetails"
+	podWithGate.Spec.ReadinessGates = []v1.PodReadinessGate{{ConditionType: config.PodENIReadinessGate}}
+
+	podWithCondition := podWithGate.DeepCopy()
+	podWithCondition.Status.Conditions = []v1.PodCondition{
+		{Type: config.PodENIReadinessGate, Status: v1.ConditionTrue},
+	}
+
+	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(MockPod1, nil).Times(2)
+	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(podWithGate, nil)
+	mockPodAPI.EXPECT().UpdatePodCondition(MockPodNamespace1, MockPodName1, MockPodUID1, gomock.Any()).Return(MockError)
+
+	_, err := provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, 1)
+	assert.Equal(t, MockError, err)
+
+	// No update is required once the condition is true
+	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(podWithCondition, nil)
+
+	_, err = provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, 1)
+	assert.NoError(t, err)
+}
+
+// TestBranchENIProvider_CreateAndAnnotateResources_ReadinessGate_StrippedPod tests that the readiness gate and the
+// condition of the pods stripped down in the cache are seen by the provider
+func TestBranchENIProvider_CreateAndAnnotateResources_ReadinessGate_StrippedPod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockPodAPI, _, _ := getProviderAndMocks(ctrl)
+	converter := pod.PodConverter{}
+
+	podWithGate := MockPod1.DeepCopy()
+	podWithGate.Annotations[config.ResourceNamePodENI] = "EniDetails"
+	podWithGate.Spec.ReadinessGates = []v1.PodReadinessGate{{ConditionType: v1.ContainersReady},
+		{ConditionType: config.PodENIReadinessGate}}
+	podWithGate.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionFalse}}
+
+	podWithCondition := podWithGate.DeepCopy()
+	podWithCondition.Status.Conditions = append(podWithCondition.Status.Conditions,
+		v1.PodCondition{Type: config.PodENIReadinessGate, Status: v1.ConditionTrue})
+
+	// The condition is missing from the pod in the cache, it's set on the pod from the API server
+	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(converter.StripDownPod(podWithGate), nil)
+	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(podWithGate, nil)
+	mockPodAPI.EXPECT().UpdatePodCondition(MockPodNamespace1, MockPodName1, MockPodUID1, gomock.Any()).Return(nil)
+
+	_, err := provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, 1)
+	assert.NoError(t, err)
+
+	// The API server is not queried once the pod in the cache has the condition
+	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(converter.StripDownPod(podWithCondition), nil)
+
+	_, err = provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, 1)
+	assert.NoError(t, err)
+}
+
 func TestBranchENIProvider_CreateAndAnnotateResources_AlreadyAnnotated_Cache(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -342,6 +615,42 @@ func TestBranchENIProvider_CreateAndAnnotateResources_AlreadyAnnotated_Cache(t *
 	assert.NoError(t, err)
 }
 
+// TestBranchENIProvider_CreateAndAnnotateResources_AlreadyAnnotated_Cache_ConditionFailed tests that if the pod in
+// the cache is annotated but the readiness condition update had failed, the condition is set on the pod from the
+// API server
+func TestBranchENIProvider_CreateAndAnnotateResources_AlreadyAnnotated_Cache_ConditionFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockPodAPI, _, _ := getProviderAndMocks(ctrl)
+
+	podWithGate := MockPod1.DeepCopy()
+	podWithGate.Annotations[config.ResourceNamePodENI] = "EniDetails"
+	podWithGate.Spec.ReadinessGates = []v1.PodReadinessGate{{ConditionType: config.PodENIReadinessGate}}
+
+	podWithCondition := podWithGate.DeepCopy()
+	podWithCondition.Status.Conditions = []v1.PodCondition{
+		{Type: config.PodENIReadinessGate, Status: v1.ConditionTrue},
+	}
+
+	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(podWithGate, nil)
+	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(podWithGate, nil)
+	mockPodAPI.EXPECT().UpdatePodCondition(MockPodNamespace1, MockPodName1, MockPodUID1, gomock.Any()).
+		DoAndReturn(func(_ string, _ string, _ types.UID, condition v1.PodCondition) error {
+			assert.Equal(t, v1.ConditionTrue, condition.Status)
+			return nil
+		})
+
+	_, err := provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, 1)
+	assert.NoError(t, err)
+
+	// The API server is not queried once the pod in the cache has the condition
+	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(podWithCondition, nil)
+
+	_, err = provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, 1)
+	assert.NoError(t, err)
+}
+
 // TestBranchENIProvider_CreateAndAnnotateResources_AlreadyAnnotatedFromAPIServer tests that if the pod is already
 // annotated after getting the results from the API server no new ENIs will be created for it
 func TestBranchENIProvider_CreateAndAnnotateResources_AlreadyAnnotated_APIServer(t *testing.T) {
@@ -512,6 +821,147 @@ func TestBranchENIProvider_ProcessDeleteQueue(t *testing.T) {
 	assert.Equal(t, deleteQueueRequeueRequest, result)
 }
 
+// TestBranchENIProvider_ProcessDeleteQueue_DeadLetter tests that an event is broadcasted on the node when the ENIs
+// are moved to the dead letter queue
+func TestBranchENIProvider_ProcessDeleteQueue_DeadLetter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockK8sAPI := getProviderAndMockK8sWrapper(ctrl)
+
+	fakeTrunk1 := mock_trunk.NewMockTrunkENI(ctrl)
+	provider.trunkENICache[NodeName] = fakeTrunk1
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: NodeName}}
+
+	fakeTrunk1.EXPECT().DeleteCooledDownENIs().Return([]trunk.ENIDetails{*EniDetails[0]})
+	mockK8sAPI.EXPECT().GetNode(NodeName).Return(node, nil)
+	mockK8sAPI.EXPECT().BroadcastEvent(node, ReasonBranchENIDeletionFailed, gomock.Any(), v1.EventTypeWarning)
+
+	result, err := provider.ProcessDeleteQueue(NodeName)
+	assert.NoError(t, err)
+	assert.Equal(t, deleteQueueRequeueRequest, result)
+}
+
+// TestBranchENIProvider_ProcessDeleteQueue_EndpointCoolDown tests that the process cool down queue job is submitted
+// only while ENIs are cooling down when the endpoint tracker is set
+func TestBranchENIProvider_ProcessDeleteQueue_EndpointCoolDown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockWorker := getProviderWithMockWorker(ctrl)
+	provider.apiWrapper = api.Wrapper{EndpointAPI: mock_endpoint.NewMockEndpointTracker(ctrl)}
+
+	fakeTrunk1 := mock_trunk.NewMockTrunkENI(ctrl)
+	provider.trunkENICache[NodeName] = fakeTrunk1
+
+	fakeTrunk1.EXPECT().DeleteCooledDownENIs().Times(2)
+	gomock.InOrder(
+		fakeTrunk1.EXPECT().HasCoolingDownENIs().Return(true),
+		fakeTrunk1.EXPECT().HasCoolingDownENIs().Return(false),
+	)
+	mockWorker.EXPECT().SubmitJobAfter(worker.NewOnDemandProcessCoolDownQueueJob(NodeName),
+		config.EndpointCoolDownCheckInterval)
+
+	result, err := provider.ProcessDeleteQueue(NodeName)
+	assert.NoError(t, err)
+	assert.Equal(t, deleteQueueRequeueRequest, result)
+
+	result, err = provider.ProcessDeleteQueue(NodeName)
+	assert.NoError(t, err)
+	assert.Equal(t, deleteQueueRequeueRequest, result)
+}
+
+// TestBranchENIProvider_ProcessCoolDownQueue_IdleNode tests that the branch ENI freed on an idle node is checked after
+// the endpoint check interval instead of the cool down period, and the job is not re-submitted once the ENI is deleted
+func TestBranchENIProvider_ProcessCoolDownQueue_IdleNode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockWorker := getProviderWithMockWorker(ctrl)
+	provider.apiWrapper = api.Wrapper{EndpointAPI: mock_endpoint.NewMockEndpointTracker(ctrl)}
+
+	fakeTrunk1 := mock_trunk.NewMockTrunkENI(ctrl)
+	provider.trunkENICache[NodeName] = fakeTrunk1
+	coolDownJob := worker.NewOnDemandProcessCoolDownQueueJob(NodeName)
+
+	fakeTrunk1.EXPECT().PushBranchENIsToCoolDownQueue(PodUID1).Times(2)
+	mockWorker.EXPECT().SubmitJobAfter(coolDownJob, config.EndpointCoolDownCheckInterval)
+
+	// A single job is submitted for the ENIs freed before the job runs
+	_, err := provider.DeleteBranchUsedByPods(NodeName, PodUID1)
+	assert.NoError(t, err)
+	_, err = provider.DeleteBranchUsedByPods(NodeName, PodUID1)
+	assert.NoError(t, err)
+
+	fakeTrunk1.EXPECT().DeleteCooledDownENIs()
+	fakeTrunk1.EXPECT().HasCoolingDownENIs().Return(false)
+
+	result, err := provider.ProcessAsyncJob(coolDownJob)
+	assert.NoError(t, err)
+	assert.Equal(t, k8sCtrl.Result{}, result)
+	assert.NotContains(t, provider.coolDownChecks, NodeName)
+}
+
+// TestBranchENIProvider_ProcessCoolDownQueue_StillCooling tests that the job is re-submitted after the endpoint check
+// interval while ENIs are cooling down
+func TestBranchENIProvider_ProcessCoolDownQueue_StillCooling(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := getProvider()
+	fakeTrunk1 := mock_trunk.NewMockTrunkENI(ctrl)
+	provider.trunkENICache[NodeName] = fakeTrunk1
+
+	fakeTrunk1.EXPECT().DeleteCooledDownENIs()
+	fakeTrunk1.EXPECT().HasCoolingDownENIs().Return(true)
+
+	result, err := provider.ProcessCoolDownQueue(NodeName)
+	assert.NoError(t, err)
+	assert.Equal(t, k8sCtrl.Result{RequeueAfter: config.EndpointCoolDownCheckInterval, Requeue: true}, result)
+	assert.Contains(t, provider.coolDownChecks, NodeName)
+}
+
+// TestBranchENIProvider_RetryDeadLetterResource tests that the retry request is passed to the trunk of the node
+func TestBranchENIProvider_RetryDeadLetterResource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := getProvider()
+	fakeTrunk1 := mock_trunk.NewMockTrunkENI(ctrl)
+	provider.trunkENICache[NodeName] = fakeTrunk1
+
+	fakeTrunk1.EXPECT().RetryDeadLetterENI(EniDetails[0].ID).Return(MockError)
+
+	err := provider.RetryDeadLetterResource(NodeName, EniDetails[0].ID)
+	assert.Equal(t, MockError, err)
+
+	err = provider.RetryDeadLetterResource("unregistered-node", EniDetails[0].ID)
+	assert.Equal(t, pkgProvider.ErrDeadLetterResourceNotFound, err)
+}
+
+// TestBranchENIProvider_ForgetDeadLetterResource tests that the forget request is passed to the trunk of the node
+func TestBranchENIProvider_ForgetDeadLetterResource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := getProvider()
+	fakeTrunk1 := mock_trunk.NewMockTrunkENI(ctrl)
+	provider.trunkENICache[NodeName] = fakeTrunk1
+
+	fakeTrunk1.EXPECT().ForgetDeadLetterENI(EniDetails[0].ID).Return(nil)
+
+	err := provider.ForgetDeadLetterResource(NodeName, EniDetails[0].ID)
+	assert.NoError(t, err)
+
+	fakeTrunk1.EXPECT().ForgetDeadLetterENI(EniDetails[0].ID).Return(trunk.ErrENINotInDeadLetterQueue)
+
+	err = provider.ForgetDeadLetterResource(NodeName, EniDetails[0].ID)
+	assert.Equal(t, pkgProvider.ErrDeadLetterResourceNotFound, err)
+
+	err = provider.ForgetDeadLetterResource("unregistered-node", EniDetails[0].ID)
+	assert.Equal(t, pkgProvider.ErrDeadLetterResourceNotFound, err)
+}
+
 func TestBranchENIProvider_Introspect(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -22,7 +22,6 @@ import (
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
-	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -38,8 +37,19 @@ const (
 	MaxAllocatableVlanIds = 121
 	// CoolDownPeriod is the period to wait before deleting the branch ENI for propagation of ip tables rule for deleted pod
 	CoolDownPeriod = time.Second * 30
-	// MaxDeleteRetries is the maximum number of times the ENI will be retried before being removed from the delete queue
+	// MaxDeleteRetries is the maximum number of times the ENI will be retried before being moved to the dead letter queue
 	MaxDeleteRetries = 3
+	// DeadLetterInitialBackoff is the time to wait before retrying the deletion of an ENI in the dead letter queue for
+	// the first time, the backoff is doubled on every subsequent failure
+	DeadLetterInitialBackoff = time.Minute
+	// DeadLetterMaxBackoff is the maximum time to wait between two retries of an ENI in the dead letter queue
+	DeadLetterMaxBackoff = time.Hour
+	// MaxParallelBranchCreates is the maximum number of branch ENIs that are created and associated in parallel per trunk
+	// across all the pods on the node
+	MaxParallelBranchCreates = 4
+	// MaxParallelBranchDeletes is the maximum number of branch ENIs deleted in parallel when deleting all the branch
+	// ENIs of a trunk
+	MaxParallelBranchDeletes = 10
 )
 
 var (
@@ -51,6 +61,8 @@ var (
 var (
 	ErrCurrentlyAtMaxCapacity = fmt.Errorf("cannot create more branches at this point as used branches plus the " +
 		"delete queue is at max capacity")
+	ErrENINotInDeadLetterQueue = fmt.Errorf("eni not present in the dead letter queue")
+	ErrDeadLetterENIExists     = fmt.Errorf("eni in the dead letter queue still exists, retry its deletion instead")
 )
 
 var (
@@ -62,6 +74,13 @@ var (
 		[]string{"operation"},
 	)
 
+	branchENIDeadLetterCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "branch_eni_dead_letter_count",
+			Help: "The number of branch ENIs moved to the dead letter queue after exhausting all delete retries",
+		},
+	)
+
 	prometheusRegistered = false
 )
 
@@ -72,14 +91,24 @@ type TrunkENI interface {
 	CreateAndAssociateBranchENIs(pod *v1.Pod, securityGroups []string, eniCount int) ([]*ENIDetails, error)
 	// PushBranchENIsToCoolDownQueue pushes the branch interface belonging to the pod to the cool down queue
 	PushBranchENIsToCoolDownQueue(UID string)
-	// DeleteCooledDownENIs deletes the interfaces that have been sitting in the queue for cool down period
-	DeleteCooledDownENIs()
+	// DeleteCooledDownENIs deletes the interfaces that have been sitting in the queue for cool down period and retries
+	// the interfaces in the dead letter queue, it returns the interfaces that were newly moved to the dead letter queue
+	DeleteCooledDownENIs() []ENIDetails
+	// HasCoolingDownENIs returns true if any interface is waiting in the delete queue
+	HasCoolingDownENIs() bool
+	// GetBranchENICount returns the number of branch interfaces associated with the trunk, including the ones being
+	// deleted
+	GetBranchENICount() int
 	// Reconcile compares the cache state with the list of pods to identify events that were missed and clean up the dangling interfaces
 	Reconcile(pods []v1.Pod) error
 	// PushENIsToFrontOfDeleteQueue pushes the eni network interfaces to the front of the delete queue
 	PushENIsToFrontOfDeleteQueue(*v1.Pod, []*ENIDetails)
 	// DeleteAllBranchENIs deletes all the branch ENI associated with the trunk and also clears the cool down queue
 	DeleteAllBranchENIs()
+	// RetryDeadLetterENI retries the deletion of the interface in the dead letter queue immediately
+	RetryDeadLetterENI(eniID string) error
+	// ForgetDeadLetterENI removes the interface that was deleted out of band from the dead letter queue
+	ForgetDeadLetterENI(eniID string) error
 	// Introspect returns the state of the Trunk ENI
 	Introspect() IntrospectResponse
 }
@@ -102,6 +131,14 @@ type trunkENI struct {
 	uidToBranchENIMap map[string][]*ENIDetails
 	// deleteQueue is the queue of ENIs that are being cooled down before being deleted
 	deleteQueue []*ENIDetails
+	// deadLetterQueue is the list of ENIs that failed to delete after all the retries, these ENIs are retried
+	// with exponential backoff
+	deadLetterQueue []*ENIDetails
+	// createSemaphore limits the number of branch ENIs being created and associated in parallel on the trunk
+	createSemaphore chan struct{}
+	// isAddressInUse returns true if the address of the cooling down ENI may still receive traffic, the ENI is
+	// deleted before the cool down period once its address is no longer in use. Optional
+	isAddressInUse func(address string) bool
 }
 
 // PodENI is a json convertible structure that stores the Branch ENI details that can be
@@ -119,19 +156,39 @@ type ENIDetails struct {
 	SubnetCIDR string `json:"subnetCidr"`
 	// deletionTimeStamp is the time when the pod was marked deleted.
 	deletionTimeStamp time.Time
-	// deleteRetryCount is the
+	// deleteRetryCount is the number of times the deletion of the ENI failed
 	deleteRetryCount int
+	// deadLetterBackoff is the time to wait before the next retry when the ENI is in the dead letter queue
+	deadLetterBackoff time.Duration
+	// nextRetryTimeStamp is the time after which the ENI in the dead letter queue will be retried
+	nextRetryTimeStamp time.Time
+	// lastDeleteError is the error returned by the last attempt to delete the ENI
+	lastDeleteError error
+}
+
+// DeadLetterENI is the introspection view of a branch ENI present in the dead letter queue
+type DeadLetterENI struct {
+	ENIDetails
+	// RetryCount is the number of times the deletion of the ENI has failed
+	RetryCount int `json:"retryCount"`
+	// NextRetry is the time after which the deletion will be retried again
+	NextRetry time.Time `json:"nextRetry"`
+	// LastError is the error returned by the last attempt to delete the ENI
+	LastError string `json:"lastError"`
 }
 
 type IntrospectResponse struct {
-	TrunkENIID     string
-	InstanceID     string
-	PodToBranchENI map[string][]ENIDetails
-	DeleteQueue    []ENIDetails
+	TrunkENIID      string
+	InstanceID      string
+	PodToBranchENI  map[string][]ENIDetails
+	DeleteQueue     []ENIDetails
+	DeadLetterQueue []DeadLetterENI
 }
 
-// NewTrunkENI returns a new Trunk ENI interface.
-func NewTrunkENI(logger logr.Logger, instance ec2.EC2Instance, helper api.EC2APIHelper) TrunkENI {
+// NewTrunkENI returns a new Trunk ENI interface. If isAddressInUse is not nil the branch ENIs are deleted as soon as
+// their address is no longer in use, the cool down period remains the upper bound
+func NewTrunkENI(logger logr.Logger, instance ec2.EC2Instance, helper api.EC2APIHelper,
+	isAddressInUse func(address string) bool) TrunkENI {
 
 	availVlans := make([]bool, MaxAllocatableVlanIds)
 	// VlanID 0 cannot be assigned.
@@ -143,12 +200,14 @@ func NewTrunkENI(logger logr.Logger, instance ec2.EC2Instance, helper api.EC2API
 		ec2ApiHelper:      helper,
 		instance:          instance,
 		uidToBranchENIMap: make(map[string][]*ENIDetails),
+		createSemaphore:   make(chan struct{}, MaxParallelBranchCreates),
+		isAddressInUse:    isAddressInUse,
 	}
 }
 
 func PrometheusRegister() {
 	if !prometheusRegistered {
-		metrics.Registry.MustRegister(trunkENIOperationsErrCount)
+		metrics.Registry.MustRegister(trunkENIOperationsErrCount, branchENIDeadLetterCount)
 		prometheusRegistered = true
 	}
 }
@@ -180,15 +239,17 @@ func (t *trunkENI) InitTrunk(instance ec2.EC2Instance, podList []v1.Pod) error {
 
 	// Trunk interface doesn't exists, try to create a new trunk interface
 	if t.trunkENIId == "" {
-		freeIndex, err := instance.GetHighestUnusedDeviceIndex()
+		networkCardIndex := instance.TrunkNetworkCardIndex()
+		freeIndex, err := instance.GetHighestUnusedDeviceIndex(networkCardIndex)
 		if err != nil {
 			trunkENIOperationsErrCount.WithLabelValues("find_free_index").Inc()
 			log.Error(err, "failed to find free device index")
 			return err
 		}
 
-		trunk, err := t.ec2ApiHelper.CreateAndAttachNetworkInterface(&instanceID, aws.String(t.instance.SubnetID()),
-			t.instance.InstanceSecurityGroup(), nil, &freeIndex, &TrunkEniDescription, &InterfaceTypeTrunk, 0)
+		trunk, err := t.ec2ApiHelper.CreateAndAttachNetworkInterface(&instanceID, aws.String(t.instance.TrunkSubnetID()),
+			t.instance.TrunkSecurityGroup(), nil, &freeIndex, &networkCardIndex, &TrunkEniDescription, &InterfaceTypeTrunk,
+			0)
 		if err != nil {
 			trunkENIOperationsErrCount.WithLabelValues("create_trunk_eni").Inc()
 			log.Error(err, "failed to create trunk interface")
@@ -295,7 +356,10 @@ func (t *trunkENI) Reconcile(pods []v1.Pod) error {
 }
 
 // CreateAndAssociateBranchToTrunk creates a new branch network interface and associates the branch to the trunk
-// network interface. It returns a Json convertible structure which has all the required details of the branch ENI
+// network interface. It returns a Json convertible structure which has all the required details of the branch ENI.
+// The branch ENIs are created in parallel, bounded by MaxParallelBranchCreates per trunk. If any of the branch ENI
+// fails to be created or associated, all the branch ENIs created for the request are moved to the delete queue and
+// the vlan ids of the branch ENIs that were never created are released.
 func (t *trunkENI) CreateAndAssociateBranchENIs(pod *v1.Pod, securityGroups []string, eniCount int) ([]*ENIDetails, error) {
 	log := t.log.WithValues("request", "create", "pod namespace", pod.Namespace, "pod name", pod.Name)
 
@@ -314,55 +378,51 @@ func (t *trunkENI) CreateAndAssociateBranchENIs(pod *v1.Pod, securityGroups []st
 		securityGroups = t.instance.InstanceSecurityGroup()
 	}
 
-	var newENIs []*ENIDetails
-	var err error
-	var nwInterface *awsEC2.NetworkInterface
-	var vlanID int
+	// Assign all the Vlan IDs before creating the branch ENIs so concurrent requests never share a Vlan ID
+	vlanIDs, err := t.assignVlanIds(eniCount)
+	if err != nil {
+		trunkENIOperationsErrCount.WithLabelValues("assign_vlan_id").Inc()
+		log.Error(err, "failed to assign vlan ids")
+		return nil, err
+	}
 
-	for i := 0; i < eniCount; i++ {
-		// Assign VLAN
-		vlanID, err = t.assignVlanId()
-		if err != nil {
-			trunkENIOperationsErrCount.WithLabelValues("assign_vlan_id").Inc()
-			break
-		}
+	// The branch ENIs are created in the first subnet with free addresses
+	subnets := t.instance.CandidateSubnets()
 
-		// Vlan ID tag workaround, as describe trunk association is not supported with assumed role
-		tags := []*awsEC2.Tag{
-			{
-				Key:   aws.String(config.VLandIDTag),
-				Value: aws.String(strconv.Itoa(vlanID)),
-			},
-			{
-				Key:   aws.String(config.TrunkENIIDTag),
-				Value: &t.trunkENIId,
-			},
-		}
-		// Create Branch ENI
-		nwInterface, err = t.ec2ApiHelper.CreateNetworkInterface(&BranchEniDescription,
-			aws.String(t.instance.SubnetID()), securityGroups, tags, 0, nil)
-		if err != nil {
-			t.freeVlanId(vlanID)
-			break
-		}
+	newENIs := make([]*ENIDetails, eniCount)
+	errs := make([]error, eniCount)
 
-		newENI := &ENIDetails{ID: *nwInterface.NetworkInterfaceId, MACAdd: *nwInterface.MacAddress,
-			IPV4Addr: *nwInterface.PrivateIpAddress, SubnetCIDR: t.instance.SubnetCidrBlock(), VlanID: vlanID}
+	var wg sync.WaitGroup
+	for index, vlanID := range vlanIDs {
+		wg.Add(1)
+		go func(index int, vlanID int) {
+			defer wg.Done()
 
-		newENIs = append(newENIs, newENI)
+			t.createSemaphore <- struct{}{}
+			defer func() { <-t.createSemaphore }()
 
-		// Associate Branch to trunk
-		_, err = t.ec2ApiHelper.AssociateBranchToTrunk(&t.trunkENIId, nwInterface.NetworkInterfaceId, vlanID)
-		if err != nil {
-			trunkENIOperationsErrCount.WithLabelValues("associate_branch").Inc()
-			break
+			newENIs[index], errs[index] = t.createAndAssociateBranchENI(subnets, securityGroups, vlanID)
+		}(index, vlanID)
+	}
+	wg.Wait()
+
+	var createdENIs []*ENIDetails
+	for index, newENI := range newENIs {
+		if newENI != nil {
+			createdENIs = append(createdENIs, newENI)
+		} else if errs[index] != nil {
+			// The branch ENI was never created, so the vlan id can be reused right away
+			t.freeVlanId(vlanIDs[index])
+		}
+		if errs[index] != nil && err == nil {
+			err = errs[index]
 		}
 	}
 
 	if err != nil {
 		log.Error(err, "failed to create ENI, moving the ENI to delete list")
 		// Moving to delete list, because it has all the retrying logic in case of failure
-		t.PushENIsToFrontOfDeleteQueue(nil, newENIs)
+		t.PushENIsToFrontOfDeleteQueue(nil, createdENIs)
 		return nil, err
 	}
 
@@ -374,28 +434,90 @@ func (t *trunkENI) CreateAndAssociateBranchENIs(pod *v1.Pod, securityGroups []st
 	return newENIs, nil
 }
 
+// createAndAssociateBranchENI creates a branch ENI with the given vlan id and associates it with the trunk. The branch
+// ENI is created in the first of the subnets that has free addresses. If the branch ENI is created but fails to
+// associate, the branch ENI is returned along with the error.
+func (t *trunkENI) createAndAssociateBranchENI(subnets []ec2.Subnet, securityGroups []string,
+	vlanID int) (*ENIDetails, error) {
+	// Vlan ID tag workaround, as describe trunk association is not supported with assumed role
+	tags := []*awsEC2.Tag{
+		{
+			Key:   aws.String(config.VLandIDTag),
+			Value: aws.String(strconv.Itoa(vlanID)),
+		},
+		{
+			Key:   aws.String(config.TrunkENIIDTag),
+			Value: &t.trunkENIId,
+		},
+	}
+	// Create Branch ENI
+	var nwInterface *awsEC2.NetworkInterface
+	var subnet ec2.Subnet
+	var err error
+	for index := range subnets {
+		subnet = subnets[index]
+		nwInterface, err = t.ec2ApiHelper.CreateNetworkInterface(&BranchEniDescription,
+			aws.String(subnet.ID), securityGroups, tags, 0, nil)
+		if err != nil && api.IsInsufficientFreeAddressesError(err) && index+1 < len(subnets) {
+			t.log.Info("subnet has insufficient free addresses, creating the branch ENI in the next subnet",
+				"subnet", subnet.ID, "next subnet", subnets[index+1].ID)
+			continue
+		}
+		break
+	}
+	if err != nil {
+		trunkENIOperationsErrCount.WithLabelValues("create_branch").Inc()
+		return nil, err
+	}
+
+	newENI := &ENIDetails{ID: *nwInterface.NetworkInterfaceId, MACAdd: *nwInterface.MacAddress,
+		IPV4Addr: *nwInterface.PrivateIpAddress, SubnetCIDR: subnet.CIDRBlock, VlanID: vlanID}
+
+	// Associate Branch to trunk
+	_, err = t.ec2ApiHelper.AssociateBranchToTrunk(&t.trunkENIId, nwInterface.NetworkInterfaceId, vlanID)
+	if err != nil {
+		trunkENIOperationsErrCount.WithLabelValues("associate_branch").Inc()
+		return newENI, err
+	}
+
+	return newENI, nil
+}
+
 // DeleteAllBranchENIs deletes all the branch ENIs associated with the trunk and all the ENIs present in the cool down
-// queue, this is the last API call to the the Trunk ENI before it is removed from cache
+// and dead letter queue, this is the last API call to the the Trunk ENI before it is removed from cache. The ENIs are
+// deleted in parallel with at most MaxParallelBranchDeletes deletions in flight.
 func (t *trunkENI) DeleteAllBranchENIs() {
-	// Delete all the branch used by the pod on this trunk ENI
+	t.lock.RLock()
+	var branchENIs []*ENIDetails
+	// All the branch used by the pod on this trunk ENI
 	for _, podENIs := range t.uidToBranchENIMap {
-		for _, eni := range podENIs {
+		branchENIs = append(branchENIs, podENIs...)
+	}
+	// All the branch ENI present in the cool down queue and the dead letter queue
+	branchENIs = append(branchENIs, t.deleteQueue...)
+	branchENIs = append(branchENIs, t.deadLetterQueue...)
+	t.lock.RUnlock()
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, MaxParallelBranchDeletes)
+	for _, eni := range branchENIs {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(eni *ENIDetails) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
 			err := t.deleteENI(eni)
 			if err != nil {
 				// Just log, if the ENI still exists it can be removed by the dangling ENI cleaner routine
 				t.log.Error(err, "failed to delete eni", "eni id", eni.ID)
 			}
-		}
+		}(eni)
 	}
+	wg.Wait()
 
-	// Delete all the branch ENI present in the cool down queue
-	for _, eni := range t.deleteQueue {
-		err := t.deleteENI(eni)
-		if err != nil {
-			// Just log, if the ENI still exists it can be removed by the dangling ENI cleaner routine
-			t.log.Error(err, "failed to delete eni", "eni id", eni.ID)
-		}
-	}
+	t.log.Info("deleted all branch ENIs", "count", len(branchENIs))
 }
 
 // DeleteBranchNetworkInterface deletes the branch network interface and returns an error in case of failure to delete
@@ -423,16 +545,20 @@ func (t *trunkENI) PushBranchENIsToCoolDownQueue(UID string) {
 		branchENIs, "uid", UID)
 }
 
-func (t *trunkENI) DeleteCooledDownENIs() {
+// DeleteCooledDownENIs deletes the ENIs that have been cooled down. ENIs that fail to delete after MaxDeleteRetries are
+// moved to the dead letter queue and returned to the caller. The ENIs in the dead letter queue whose backoff has expired
+// are retried as well.
+func (t *trunkENI) DeleteCooledDownENIs() (deadLetterENIs []ENIDetails) {
+	var coolingENIs []*ENIDetails
 	for eni, hasENI := t.popENIFromDeleteQueue(); hasENI; eni, hasENI = t.popENIFromDeleteQueue() {
-		if eni.deletionTimeStamp.IsZero() ||
-			time.Now().After(eni.deletionTimeStamp.Add(CoolDownPeriod)) {
+		if t.isCooledDown(eni) {
 			err := t.deleteENI(eni)
 			if err != nil {
 				eni.deleteRetryCount++
 				if eni.deleteRetryCount >= MaxDeleteRetries {
-					t.log.Error(err, "forgetting eni as max retries exceeded", "eni", eni)
-					// TODO: free vlan id?
+					t.log.Error(err, "moving eni to dead letter queue as max retries exceeded", "eni", eni)
+					t.pushENIToDeadLetterQueue(eni, err)
+					deadLetterENIs = append(deadLetterENIs, *eni)
 					continue
 				}
 				t.log.Error(err, "failed to delete eni, will retry", "eni", eni)
@@ -441,25 +567,114 @@ func (t *trunkENI) DeleteCooledDownENIs() {
 			}
 			t.log.V(1).Info("deleted eni successfully", "eni", eni, "deletion time", time.Now(),
 				"pushed to queue time", eni.deletionTimeStamp)
-		} else {
+		} else if t.isAddressInUse == nil {
 			// Since the current item is not cooled down so the items added after it would not be cooled down either
 			t.PushENIsToFrontOfDeleteQueue(nil, []*ENIDetails{eni})
-			return
+			break
+		} else {
+			// The items added after it may no longer be in use, keep looking
+			coolingENIs = append(coolingENIs, eni)
 		}
 	}
+	if len(coolingENIs) > 0 {
+		t.PushENIsToFrontOfDeleteQueue(nil, coolingENIs)
+	}
+
+	t.retryDeadLetterENIs()
+
+	return deadLetterENIs
+}
+
+// HasCoolingDownENIs returns true if the delete queue is not empty
+func (t *trunkENI) HasCoolingDownENIs() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return len(t.deleteQueue) > 0
+}
+
+// isCooledDown returns true if the ENI has no deletion timestamp, the cool down period has passed since its
+// deletion or its address is no longer in use
+func (t *trunkENI) isCooledDown(eni *ENIDetails) bool {
+	return eni.deletionTimeStamp.IsZero() ||
+		time.Now().After(eni.deletionTimeStamp.Add(CoolDownPeriod)) ||
+		(t.isAddressInUse != nil && !t.isAddressInUse(eni.IPV4Addr))
+}
+
+// retryDeadLetterENIs retries the deletion of the ENIs in the dead letter queue whose backoff has expired
+func (t *trunkENI) retryDeadLetterENIs() {
+	for _, eni := range t.popRetryableDeadLetterENIs() {
+		err := t.deleteENI(eni)
+		if err != nil {
+			t.log.Error(err, "failed to delete eni from dead letter queue", "eni", eni,
+				"retry count", eni.deleteRetryCount+1)
+			t.pushBackToDeadLetterQueue(eni, err)
+			continue
+		}
+		t.log.Info("deleted eni from dead letter queue", "eni", eni)
+	}
+}
+
+// RetryDeadLetterENI retries the deletion of the ENI in the dead letter queue without waiting for the backoff to expire
+func (t *trunkENI) RetryDeadLetterENI(eniID string) error {
+	// The ENI is removed from the queue while it's deleted so it's not retried by the worker at the same time
+	eni, found := t.removeENIFromDeadLetterQueue(eniID)
+	if !found {
+		return ErrENINotInDeadLetterQueue
+	}
+
+	err := t.deleteENI(eni)
+	if err != nil {
+		t.pushBackToDeadLetterQueue(eni, err)
+		return err
+	}
+	t.log.Info("deleted eni from dead letter queue on retry request", "eni", eni)
+
+	return nil
+}
+
+// ForgetDeadLetterENI removes the ENI from the dead letter queue without deleting it. This should be used only when the
+// ENI has already been deleted out of band. The ENI is kept in the queue if it still exists, as it may still be
+// associated with the trunk and must keep its vlan id reserved, otherwise the vlan id of the ENI is released.
+func (t *trunkENI) ForgetDeadLetterENI(eniID string) error {
+	// The ENI is removed from the queue while it's described so it's not retried by the worker at the same time
+	eni, found := t.removeENIFromDeadLetterQueue(eniID)
+	if !found {
+		return ErrENINotInDeadLetterQueue
+	}
+
+	_, err := t.ec2ApiHelper.DescribeNetworkInterfaces([]*string{&eni.ID})
+	if err == nil {
+		err = ErrDeadLetterENIExists
+	}
+	if !api.IsNetworkInterfaceNotFoundError(err) {
+		t.returnToDeadLetterQueue(eni)
+		return err
+	}
+
+	if eni.VlanID != 0 {
+		t.freeVlanId(eni.VlanID)
+	}
+	t.log.Info("forgot eni deleted out of band from dead letter queue", "eni", eni)
+
+	return nil
 }
 
 // deleteENIs deletes the provided ENIs and frees up the Vlan assigned to then
 func (t *trunkENI) deleteENI(eniDetail *ENIDetails) (err error) {
 	// Delete Branch network interface first
 	err = t.ec2ApiHelper.DeleteNetworkInterface(&eniDetail.ID)
-	if err != nil {
+	if err != nil && api.IsNetworkInterfaceNotFoundError(err) {
+		// The ENI was already deleted out of band
+		t.log.Info("eni is already deleted", "eni details", eniDetail)
+		err = nil
+	} else if err != nil {
 		trunkENIOperationsErrCount.WithLabelValues("delete_branch").Inc()
 		return err
+	} else {
+		t.log.Info("deleted eni", "eni details", eniDetail)
 	}
 
-	t.log.Info("deleted eni", "eni details", eniDetail)
-
 	// Free vlan id used by the branch ENI
 	if eniDetail.VlanID != 0 {
 		t.freeVlanId(eniDetail.VlanID)
@@ -558,6 +773,76 @@ func (t *trunkENI) popENIFromDeleteQueue() (eni *ENIDetails, hasENI bool) {
 	return eni, hasENI
 }
 
+// pushENIToDeadLetterQueue pushes the ENI to the dead letter queue and sets the time for the next retry
+func (t *trunkENI) pushENIToDeadLetterQueue(eni *ENIDetails, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	eni.deadLetterBackoff = DeadLetterInitialBackoff
+	eni.nextRetryTimeStamp = time.Now().Add(eni.deadLetterBackoff)
+	eni.lastDeleteError = err
+	t.deadLetterQueue = append(t.deadLetterQueue, eni)
+
+	branchENIDeadLetterCount.Inc()
+}
+
+// pushBackToDeadLetterQueue pushes the ENI that failed to delete again back to the dead letter queue, doubling its
+// backoff up to DeadLetterMaxBackoff
+func (t *trunkENI) pushBackToDeadLetterQueue(eni *ENIDetails, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	eni.deleteRetryCount++
+	eni.deadLetterBackoff *= 2
+	if eni.deadLetterBackoff > DeadLetterMaxBackoff {
+		eni.deadLetterBackoff = DeadLetterMaxBackoff
+	}
+	eni.nextRetryTimeStamp = time.Now().Add(eni.deadLetterBackoff)
+	eni.lastDeleteError = err
+	t.deadLetterQueue = append(t.deadLetterQueue, eni)
+}
+
+// returnToDeadLetterQueue pushes the ENI back to the dead letter queue without changing its backoff
+func (t *trunkENI) returnToDeadLetterQueue(eni *ENIDetails) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.deadLetterQueue = append(t.deadLetterQueue, eni)
+}
+
+// popRetryableDeadLetterENIs removes and returns the ENIs from the dead letter queue whose backoff has expired, the
+// caller must push back the ENIs that fail to delete
+func (t *trunkENI) popRetryableDeadLetterENIs() (enis []*ENIDetails) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	var waitingENIs []*ENIDetails
+	for _, eni := range t.deadLetterQueue {
+		if now.After(eni.nextRetryTimeStamp) {
+			enis = append(enis, eni)
+		} else {
+			waitingENIs = append(waitingENIs, eni)
+		}
+	}
+	t.deadLetterQueue = waitingENIs
+	return enis
+}
+
+// removeENIFromDeadLetterQueue removes the ENI with the given ID from the dead letter queue
+func (t *trunkENI) removeENIFromDeadLetterQueue(eniID string) (*ENIDetails, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for index, eni := range t.deadLetterQueue {
+		if eni.ID == eniID {
+			t.deadLetterQueue = append(t.deadLetterQueue[:index], t.deadLetterQueue[index+1:]...)
+			return eni, true
+		}
+	}
+	return nil, false
+}
+
 // addBranchToCache adds the given branch to the cache if not already present
 func (t *trunkENI) addBranchToCache(UID string, branchENIs []*ENIDetails) {
 	t.lock.Lock()
@@ -580,18 +865,29 @@ func (t *trunkENI) getBranchFromCache(UID string) (branchENIs []*ENIDetails, isP
 	return
 }
 
-// assignVlanId assigns a free vlan id from the list of available vlan ids. In the future this can be changed to LL
-func (t *trunkENI) assignVlanId() (int, error) {
+// assignVlanIds assigns the given number of free vlan ids from the list of available vlan ids atomically, if there are
+// not enough free vlan ids then no vlan id is assigned
+func (t *trunkENI) assignVlanIds(count int) ([]int, error) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	var vlanIDs []int
 	for index, used := range t.usedVlanIds {
+		if len(vlanIDs) == count {
+			break
+		}
 		if !used {
-			t.usedVlanIds[index] = true
-			return index, nil
+			vlanIDs = append(vlanIDs, index)
 		}
 	}
-	return 0, fmt.Errorf("failed to find free vlan id in the available %d ids", len(t.usedVlanIds))
+	if len(vlanIDs) < count {
+		return nil, fmt.Errorf("failed to find %d free vlan ids in the available %d ids", count, len(t.usedVlanIds))
+	}
+
+	for _, vlanID := range vlanIDs {
+		t.usedVlanIds[vlanID] = true
+	}
+	return vlanIDs, nil
 }
 
 // markVlanAssigned marks a vlan Id as assigned if not used
@@ -631,15 +927,29 @@ func (t *trunkENI) canCreateMore() bool {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 
+	limits, found := t.instance.Limits()
+	if found && t.getBranchENICount() < limits.BranchInterface {
+		return true
+	}
+	return false
+}
+
+// GetBranchENICount returns the number of branch ENIs used by the pods, cooling down or in the dead letter queue
+func (t *trunkENI) GetBranchENICount() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.getBranchENICount()
+}
+
+// getBranchENICount returns the number of branch ENIs associated with the trunk, must be called with the lock held
+func (t *trunkENI) getBranchENICount() int {
 	var usedBranches int
 	for _, branches := range t.uidToBranchENIMap {
 		usedBranches += len(branches)
 	}
-
-	if usedBranches+len(t.deleteQueue) < vpc.Limits[t.instance.Type()].BranchInterface {
-		return true
-	}
-	return false
+	// ENIs in the dead letter queue are still associated with the trunk and count against the limit
+	return usedBranches + len(t.deleteQueue) + len(t.deadLetterQueue)
 }
 
 func (t *trunkENI) Introspect() IntrospectResponse {
@@ -661,5 +971,16 @@ func (t *trunkENI) Introspect() IntrospectResponse {
 	for _, eni := range t.deleteQueue {
 		response.DeleteQueue = append(response.DeleteQueue, *eni)
 	}
+	for _, eni := range t.deadLetterQueue {
+		deadLetterENI := DeadLetterENI{
+			ENIDetails: *eni,
+			RetryCount: eni.deleteRetryCount,
+			NextRetry:  eni.nextRetryTimeStamp,
+		}
+		if eni.lastDeleteError != nil {
+			deadLetterENI.LastError = eni.lastDeleteError.Error()
+		}
+		response.DeadLetterQueue = append(response.DeadLetterQueue, deadLetterENI)
+	}
 	return response
 }
@@ -16,15 +16,19 @@ package trunk
 import (
 	"fmt"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	awsEc2 "github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -40,6 +44,7 @@ var (
 	InstanceType          = "c5.xlarge"
 	SubnetId              = "subnet-00000000000000000"
 	SubnetCidrBlock       = "192.168.0.0/16"
+	CandidateSubnets      = []ec2.Subnet{{ID: SubnetId, CIDRBlock: SubnetCidrBlock}}
 	NodeName              = "test-node"
 	FakeInstance          = ec2.NewEC2Instance(NodeName, InstanceId, config.OSLinux)
 	InstanceSecurityGroup = []string{"sg-1", "sg-2"}
@@ -199,6 +204,12 @@ func getMockHelperInstanceAndTrunkObject(ctrl *gomock.Controller) (*trunkENI, *m
 	EniDetails2.deletionTimeStamp = time.Time{}
 	EniDetails1.deleteRetryCount = 0
 	EniDetails2.deleteRetryCount = 0
+	EniDetails1.deadLetterBackoff = 0
+	EniDetails2.deadLetterBackoff = 0
+	EniDetails1.nextRetryTimeStamp = time.Time{}
+	EniDetails2.nextRetryTimeStamp = time.Time{}
+	EniDetails1.lastDeleteError = nil
+	EniDetails2.lastDeleteError = nil
 
 	return &trunkENI, mockHelper, mockInstance
 }
@@ -209,46 +220,60 @@ func getMockTrunk() trunkENI {
 		log:               log,
 		usedVlanIds:       make([]bool, MaxAllocatableVlanIds),
 		uidToBranchENIMap: map[string][]*ENIDetails{},
+		createSemaphore:   make(chan struct{}, MaxParallelBranchCreates),
 	}
 }
 
 func TestNewTrunkENI(t *testing.T) {
-	trunkENI := NewTrunkENI(zap.New(), nil, nil)
+	trunkENI := NewTrunkENI(zap.New(), nil, nil, nil)
 	assert.NotNil(t, trunkENI)
 }
 
-// TestTrunkENI_assignVlanId tests that Vlan ids are assigned till the Max capacity is reached and after that assign
+// TestTrunkENI_assignVlanIds tests that Vlan ids are assigned till the Max capacity is reached and after that assign
 // call will return an error
-func TestTrunkENI_assignVlanId(t *testing.T) {
+func TestTrunkENI_assignVlanIds(t *testing.T) {
 	trunkENI := getMockTrunk()
 
 	for i := 0; i < MaxAllocatableVlanIds; i++ {
-		id, err := trunkENI.assignVlanId()
+		ids, err := trunkENI.assignVlanIds(1)
 		assert.NoError(t, err)
-		assert.Equal(t, i, id)
+		assert.Equal(t, []int{i}, ids)
 	}
 
 	// Try allocating one more Vlan Id after breaching max capacity
-	_, err := trunkENI.assignVlanId()
+	_, err := trunkENI.assignVlanIds(1)
 	assert.NotNil(t, err)
 }
 
+// TestTrunkENI_assignVlanIds_NotEnoughFree tests that no vlan id is assigned if the requested count cannot be satisfied
+func TestTrunkENI_assignVlanIds_NotEnoughFree(t *testing.T) {
+	trunkENI := getMockTrunk()
+
+	ids, err := trunkENI.assignVlanIds(MaxAllocatableVlanIds - 1)
+	assert.NoError(t, err)
+	assert.Equal(t, MaxAllocatableVlanIds-1, len(ids))
+
+	_, err = trunkENI.assignVlanIds(2)
+	assert.NotNil(t, err)
+	assert.False(t, trunkENI.usedVlanIds[MaxAllocatableVlanIds-1])
+}
+
 // TestTrunkENI_freeVlanId tests if a vlan id is freed it can be re assigned
 func TestTrunkENI_freeVlanId(t *testing.T) {
 	trunkENI := getMockTrunk()
 
 	// Assign single Vlan Id
-	id, err := trunkENI.assignVlanId()
+	ids, err := trunkENI.assignVlanIds(1)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, id)
+	assert.Equal(t, []int{0}, ids)
 
 	// Free the vlan Id
 	trunkENI.freeVlanId(0)
 
 	// Assign single Vlan Id again
-	id, err = trunkENI.assignVlanId()
+	ids, err = trunkENI.assignVlanIds(1)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, id)
+	assert.Equal(t, []int{0}, ids)
 }
 
 func TestTrunkENI_markVlanAssigned(t *testing.T) {
@@ -257,9 +282,9 @@ func TestTrunkENI_markVlanAssigned(t *testing.T) {
 	// Mark a Vlan as assigned
 	trunkENI.markVlanAssigned(0)
 
-	id, err := trunkENI.assignVlanId()
+	ids, err := trunkENI.assignVlanIds(1)
 	assert.NoError(t, err)
-	assert.Equal(t, 1, id)
+	assert.Equal(t, []int{1}, ids)
 }
 
 // TestTrunkENI_getBranchFromCache tests branch eni is returned when present in the cache
@@ -463,6 +488,27 @@ func TestTrunkENI_deleteENI_Fail(t *testing.T) {
 	assert.True(t, trunkENI.usedVlanIds[VlanId1])
 }
 
+// TestTrunkENI_GetBranchENICount tests that the branch ENIs used by the pods, cooling down and in the dead letter
+// queue are counted
+func TestTrunkENI_GetBranchENICount(t *testing.T) {
+	trunkENI := getMockTrunk()
+	assert.Equal(t, 0, trunkENI.GetBranchENICount())
+
+	trunkENI.uidToBranchENIMap[PodUID] = []*ENIDetails{EniDetails1, EniDetails2}
+	trunkENI.deleteQueue = []*ENIDetails{EniDetails1}
+	trunkENI.deadLetterQueue = []*ENIDetails{EniDetails2}
+	assert.Equal(t, 4, trunkENI.GetBranchENICount())
+}
+
+// TestTrunkENI_HasCoolingDownENIs tests that the trunk has cooling down ENIs while the delete queue is not empty
+func TestTrunkENI_HasCoolingDownENIs(t *testing.T) {
+	trunkENI := getMockTrunk()
+	assert.False(t, trunkENI.HasCoolingDownENIs())
+
+	trunkENI.deleteQueue = append(trunkENI.deleteQueue, EniDetails1)
+	assert.True(t, trunkENI.HasCoolingDownENIs())
+}
+
 // TestTrunkENI_DeleteCooledDownENIs_NotCooledDown tests that ENIs that have not cooled down are not deleted
 func TestTrunkENI_DeleteCooledDownENIs_NotCooledDown(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -519,6 +565,29 @@ func TestTrunkENI_DeleteCooledDownENIs_CooledDownResource(t *testing.T) {
 	assert.Equal(t, EniDetails2, trunkENI.deleteQueue[0])
 }
 
+// TestTrunkENI_DeleteCooledDownENIs_AddressNotInUse tests that the ENIs whose address is no longer in use are deleted
+// before the cool down period and the ENIs still in use remain in the delete queue
+func TestTrunkENI_DeleteCooledDownENIs_AddressNotInUse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, ec2APIHelper, _ := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.isAddressInUse = func(address string) bool {
+		return address == EniDetails1.IPV4Addr
+	}
+	EniDetails1.deletionTimeStamp = time.Now()
+	EniDetails2.deletionTimeStamp = time.Now()
+	trunkENI.usedVlanIds[VlanId1] = true
+	trunkENI.usedVlanIds[VlanId2] = true
+
+	trunkENI.deleteQueue = append(trunkENI.deleteQueue, EniDetails1, EniDetails2)
+
+	ec2APIHelper.EXPECT().DeleteNetworkInterface(&EniDetails2.ID).Return(nil)
+
+	trunkENI.DeleteCooledDownENIs()
+	assert.Equal(t, []*ENIDetails{EniDetails1}, trunkENI.deleteQueue)
+}
+
 // TestTrunkENI_DeleteCooledDownENIs_DeleteFailed tests that when delete fails item is requeued into the delete queue for
 // the retry count
 func TestTrunkENI_DeleteCooledDownENIs_DeleteFailed(t *testing.T) {
@@ -538,8 +607,170 @@ func TestTrunkENI_DeleteCooledDownENIs_DeleteFailed(t *testing.T) {
 		ec2APIHelper.EXPECT().DeleteNetworkInterface(&EniDetails2.ID).Return(nil),
 	)
 
-	trunkENI.DeleteCooledDownENIs()
+	deadLetterENIs := trunkENI.DeleteCooledDownENIs()
 	assert.Zero(t, len(trunkENI.deleteQueue))
+	assert.Equal(t, []*ENIDetails{EniDetails1}, trunkENI.deadLetterQueue)
+	assert.Equal(t, []ENIDetails{*EniDetails1}, deadLetterENIs)
+	assert.Equal(t, DeadLetterInitialBackoff, EniDetails1.deadLetterBackoff)
+	assert.Equal(t, MockError, EniDetails1.lastDeleteError)
+	assert.True(t, trunkENI.usedVlanIds[VlanId1])
+}
+
+// TestTrunkENI_DeleteCooledDownENIs_DeadLetterRetry tests that the ENIs in the dead letter queue are deleted once their
+// backoff expires and the ENIs whose backoff has not expired are not retried
+func TestTrunkENI_DeleteCooledDownENIs_DeadLetterRetry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, ec2APIHelper, _ := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.usedVlanIds[VlanId1] = true
+	trunkENI.usedVlanIds[VlanId2] = true
+
+	EniDetails1.nextRetryTimeStamp = time.Now().Add(-time.Second)
+	EniDetails2.nextRetryTimeStamp = time.Now().Add(time.Minute)
+	trunkENI.deadLetterQueue = []*ENIDetails{EniDetails1, EniDetails2}
+
+	ec2APIHelper.EXPECT().DeleteNetworkInterface(&EniDetails1.ID).Return(nil)
+
+	deadLetterENIs := trunkENI.DeleteCooledDownENIs()
+	assert.Empty(t, deadLetterENIs)
+	assert.Equal(t, []*ENIDetails{EniDetails2}, trunkENI.deadLetterQueue)
+	assert.False(t, trunkENI.usedVlanIds[VlanId1])
+}
+
+// TestTrunkENI_DeleteCooledDownENIs_DeadLetterBackoff tests that the backoff is doubled when the deletion of the ENI
+// in the dead letter queue fails and is capped to the max backoff
+func TestTrunkENI_DeleteCooledDownENIs_DeadLetterBackoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, ec2APIHelper, _ := getMockHelperInstanceAndTrunkObject(ctrl)
+
+	EniDetails1.deadLetterBackoff = DeadLetterInitialBackoff
+	EniDetails1.nextRetryTimeStamp = time.Now().Add(-time.Second)
+	trunkENI.deadLetterQueue = []*ENIDetails{EniDetails1}
+
+	ec2APIHelper.EXPECT().DeleteNetworkInterface(&EniDetails1.ID).Return(MockError).Times(2)
+
+	trunkENI.DeleteCooledDownENIs()
+	assert.Equal(t, []*ENIDetails{EniDetails1}, trunkENI.deadLetterQueue)
+	assert.Equal(t, DeadLetterInitialBackoff*2, EniDetails1.deadLetterBackoff)
+	assert.True(t, EniDetails1.nextRetryTimeStamp.After(time.Now()))
+	assert.Equal(t, 1, EniDetails1.deleteRetryCount)
+
+	EniDetails1.deadLetterBackoff = DeadLetterMaxBackoff
+	EniDetails1.nextRetryTimeStamp = time.Now().Add(-time.Second)
+
+	trunkENI.DeleteCooledDownENIs()
+	assert.Equal(t, DeadLetterMaxBackoff, EniDetails1.deadLetterBackoff)
+}
+
+// TestTrunkENI_RetryDeadLetterENI tests that the ENI is deleted immediately and removed from the dead letter queue
+func TestTrunkENI_RetryDeadLetterENI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, ec2APIHelper, _ := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.usedVlanIds[VlanId1] = true
+
+	EniDetails1.nextRetryTimeStamp = time.Now().Add(time.Hour)
+	trunkENI.deadLetterQueue = []*ENIDetails{EniDetails1}
+
+	ec2APIHelper.EXPECT().DeleteNetworkInterface(&EniDetails1.ID).Return(nil)
+
+	err := trunkENI.RetryDeadLetterENI(EniDetails1.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, trunkENI.deadLetterQueue)
+	assert.False(t, trunkENI.usedVlanIds[VlanId1])
+
+	err = trunkENI.RetryDeadLetterENI(EniDetails1.ID)
+	assert.Equal(t, ErrENINotInDeadLetterQueue, err)
+}
+
+// TestTrunkENI_RetryDeadLetterENI_Error tests that the ENI stays in the dead letter queue if the retry fails
+func TestTrunkENI_RetryDeadLetterENI_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, ec2APIHelper, _ := getMockHelperInstanceAndTrunkObject(ctrl)
+
+	EniDetails1.deadLetterBackoff = DeadLetterInitialBackoff
+	trunkENI.deadLetterQueue = []*ENIDetails{EniDetails1}
+
+	ec2APIHelper.EXPECT().DeleteNetworkInterface(&EniDetails1.ID).Return(MockError)
+
+	err := trunkENI.RetryDeadLetterENI(EniDetails1.ID)
+	assert.Equal(t, MockError, err)
+	assert.Equal(t, []*ENIDetails{EniDetails1}, trunkENI.deadLetterQueue)
+	assert.Equal(t, MockError, EniDetails1.lastDeleteError)
+}
+
+// TestTrunkENI_RetryDeadLetterENI_NotFound tests that the ENI already deleted out of band is removed from the dead
+// letter queue and its vlan id is freed
+func TestTrunkENI_RetryDeadLetterENI_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, ec2APIHelper, _ := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.usedVlanIds[VlanId1] = true
+	trunkENI.deadLetterQueue = []*ENIDetails{EniDetails1}
+
+	ec2APIHelper.EXPECT().DeleteNetworkInterface(&EniDetails1.ID).
+		Return(awserr.New(ec2API.ErrCodeNetworkInterfaceNotFound, "not found", nil))
+
+	err := trunkENI.RetryDeadLetterENI(EniDetails1.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, trunkENI.deadLetterQueue)
+	assert.False(t, trunkENI.usedVlanIds[VlanId1])
+}
+
+// TestTrunkENI_ForgetDeadLetterENI tests that the ENI deleted out of band is removed from the dead letter queue and
+// its vlan id is released
+func TestTrunkENI_ForgetDeadLetterENI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, ec2APIHelper, _ := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.usedVlanIds[VlanId1] = true
+	trunkENI.deadLetterQueue = []*ENIDetails{EniDetails1}
+
+	ec2APIHelper.EXPECT().DescribeNetworkInterfaces([]*string{&EniDetails1.ID}).
+		Return(nil, awserr.New(ec2API.ErrCodeNetworkInterfaceNotFound, "not found", nil))
+
+	err := trunkENI.ForgetDeadLetterENI(EniDetails1.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, trunkENI.deadLetterQueue)
+	assert.False(t, trunkENI.usedVlanIds[VlanId1])
+
+	err = trunkENI.ForgetDeadLetterENI(EniDetails1.ID)
+	assert.Equal(t, ErrENINotInDeadLetterQueue, err)
+}
+
+// TestTrunkENI_ForgetDeadLetterENI_Exists tests that the ENI is kept in the dead letter queue with its vlan id
+// reserved if it still exists or can't be described
+func TestTrunkENI_ForgetDeadLetterENI_Exists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, ec2APIHelper, _ := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.usedVlanIds[VlanId1] = true
+	trunkENI.deadLetterQueue = []*ENIDetails{EniDetails1}
+
+	gomock.InOrder(
+		ec2APIHelper.EXPECT().DescribeNetworkInterfaces([]*string{&EniDetails1.ID}).
+			Return([]*awsEc2.NetworkInterface{{NetworkInterfaceId: &EniDetails1.ID}}, nil),
+		ec2APIHelper.EXPECT().DescribeNetworkInterfaces([]*string{&EniDetails1.ID}).Return(nil, MockError),
+	)
+
+	err := trunkENI.ForgetDeadLetterENI(EniDetails1.ID)
+	assert.Equal(t, ErrDeadLetterENIExists, err)
+	assert.Equal(t, []*ENIDetails{EniDetails1}, trunkENI.deadLetterQueue)
+	assert.True(t, trunkENI.usedVlanIds[VlanId1])
+
+	err = trunkENI.ForgetDeadLetterENI(EniDetails1.ID)
+	assert.Equal(t, MockError, err)
+	assert.Equal(t, []*ENIDetails{EniDetails1}, trunkENI.deadLetterQueue)
+	assert.True(t, trunkENI.usedVlanIds[VlanId1])
 }
 
 // TestTrunkENI_PushBranchENIsToCoolDownQueue tests that ENIs are pushed to the delete queue if the pod is being deleted
@@ -595,14 +826,16 @@ func TestTrunkENI_InitTrunk_TrunkNotExists(t *testing.T) {
 
 	trunkENI, mockEC2APIHelper, mockInstance := getMockHelperInstanceAndTrunkObject(ctrl)
 	freeIndex := int64(2)
+	networkCardIndex := int64(1)
 
 	mockInstance.EXPECT().InstanceID().Return(InstanceId)
-	mockInstance.EXPECT().InstanceSecurityGroup().Return(SecurityGroups)
+	mockInstance.EXPECT().TrunkSecurityGroup().Return(SecurityGroups)
 	mockEC2APIHelper.EXPECT().GetInstanceNetworkInterface(&InstanceId).Return([]*awsEc2.InstanceNetworkInterface{}, nil)
-	mockInstance.EXPECT().GetHighestUnusedDeviceIndex().Return(freeIndex, nil)
-	mockInstance.EXPECT().SubnetID().Return(SubnetId)
+	mockInstance.EXPECT().TrunkNetworkCardIndex().Return(networkCardIndex)
+	mockInstance.EXPECT().GetHighestUnusedDeviceIndex(networkCardIndex).Return(freeIndex, nil)
+	mockInstance.EXPECT().TrunkSubnetID().Return(SubnetId)
 	mockEC2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&InstanceId, &SubnetId, SecurityGroups, nil,
-		&freeIndex, &TrunkEniDescription, &InterfaceTypeTrunk, 0).Return(trunkInterface, nil)
+		&freeIndex, &networkCardIndex, &TrunkEniDescription, &InterfaceTypeTrunk, 0).Return(trunkInterface, nil)
 
 	err := trunkENI.InitTrunk(mockInstance, []v1.Pod{*MockPod2})
 
@@ -651,7 +884,8 @@ func TestTrunkENI_InitTrunk_GetFreeIndexFail(t *testing.T) {
 
 	mockInstance.EXPECT().InstanceID().Return(InstanceId)
 	mockEC2APIHelper.EXPECT().GetInstanceNetworkInterface(&InstanceId).Return([]*awsEc2.InstanceNetworkInterface{}, nil)
-	mockInstance.EXPECT().GetHighestUnusedDeviceIndex().Return(int64(0), MockError)
+	mockInstance.EXPECT().TrunkNetworkCardIndex().Return(int64(0))
+	mockInstance.EXPECT().GetHighestUnusedDeviceIndex(int64(0)).Return(int64(0), MockError)
 
 	err := trunkENI.InitTrunk(mockInstance, []v1.Pod{*MockPod2})
 
@@ -730,6 +964,26 @@ func TestTrunkENI_DeleteAllBranchENIs(t *testing.T) {
 	trunkENI.DeleteAllBranchENIs()
 }
 
+// TestTrunkENI_DeleteAllBranchENIs_DeadLetterQueue tests that the ENIs in the dead letter queue are deleted and a
+// failure to delete one ENI doesn't stop the deletion of the other ENIs
+func TestTrunkENI_DeleteAllBranchENIs_DeadLetterQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, mockEC2APIHelper, _ := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.usedVlanIds[VlanId1] = true
+	trunkENI.usedVlanIds[VlanId2] = true
+	trunkENI.uidToBranchENIMap[PodUID] = branchENIs1
+	trunkENI.deadLetterQueue = branchENIs2
+
+	mockEC2APIHelper.EXPECT().DeleteNetworkInterface(&Branch1Id).Return(MockError)
+	mockEC2APIHelper.EXPECT().DeleteNetworkInterface(&Branch2Id).Return(nil)
+
+	trunkENI.DeleteAllBranchENIs()
+	assert.True(t, trunkENI.usedVlanIds[VlanId1])
+	assert.False(t, trunkENI.usedVlanIds[VlanId2])
+}
+
 // TestTrunkENI_CreateAndAssociateBranchENIs test branch is created and associated with the trunk and valid eni details
 // are returned
 func TestTrunkENI_CreateAndAssociateBranchENIs(t *testing.T) {
@@ -739,9 +993,8 @@ func TestTrunkENI_CreateAndAssociateBranchENIs(t *testing.T) {
 	trunkENI, mockEC2APIHelper, mockInstance := getMockHelperInstanceAndTrunkObject(ctrl)
 	trunkENI.trunkENIId = trunkId
 
-	mockInstance.EXPECT().Type().Return(InstanceType)
-	mockInstance.EXPECT().SubnetID().Return(SubnetId).Times(2)
-	mockInstance.EXPECT().SubnetCidrBlock().Return(SubnetCidrBlock).Times(2)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[InstanceType], true)
+	mockInstance.EXPECT().CandidateSubnets().Return(CandidateSubnets)
 
 	mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &SubnetId, SecurityGroups,
 		vlan1Tag, 0, nil).Return(BranchInterface1, nil)
@@ -771,9 +1024,8 @@ func TestTrunkENI_CreateAndAssociateBranchENIs_InstanceSecurityGroup(t *testing.
 	trunkENI, mockEC2APIHelper, mockInstance := getMockHelperInstanceAndTrunkObject(ctrl)
 	trunkENI.trunkENIId = trunkId
 
-	mockInstance.EXPECT().Type().Return(InstanceType)
-	mockInstance.EXPECT().SubnetID().Return(SubnetId).Times(2)
-	mockInstance.EXPECT().SubnetCidrBlock().Return(SubnetCidrBlock).Times(2)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[InstanceType], true)
+	mockInstance.EXPECT().CandidateSubnets().Return(CandidateSubnets)
 	mockInstance.EXPECT().InstanceSecurityGroup().Return(InstanceSecurityGroup)
 
 	mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &SubnetId, InstanceSecurityGroup,
@@ -804,22 +1056,25 @@ func TestTrunkENI_CreateAndAssociateBranchENIs_ErrorAssociate(t *testing.T) {
 	trunkENI, mockEC2APIHelper, mockInstance := getMockHelperInstanceAndTrunkObject(ctrl)
 	trunkENI.trunkENIId = trunkId
 
-	mockInstance.EXPECT().Type().Return(InstanceType)
-	mockInstance.EXPECT().SubnetID().Return(SubnetId).Times(2)
-	mockInstance.EXPECT().SubnetCidrBlock().Return(SubnetCidrBlock).Times(2)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[InstanceType], true)
+	mockInstance.EXPECT().CandidateSubnets().Return(CandidateSubnets)
 
-	gomock.InOrder(
-		mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &SubnetId, SecurityGroups,
-			vlan1Tag, 0, nil).Return(BranchInterface1, nil),
-		mockEC2APIHelper.EXPECT().AssociateBranchToTrunk(&trunkId, &Branch1Id, VlanId1).Return(nil, nil),
-		mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &SubnetId, SecurityGroups,
-			vlan2Tag, 0, nil).Return(BranchInterface2, nil),
-		mockEC2APIHelper.EXPECT().AssociateBranchToTrunk(&trunkId, &Branch2Id, VlanId2).Return(nil, MockError),
-	)
+	// Branch ENIs are created in parallel
+	mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &SubnetId, SecurityGroups,
+		vlan1Tag, 0, nil).Return(BranchInterface1, nil)
+	mockEC2APIHelper.EXPECT().AssociateBranchToTrunk(&trunkId, &Branch1Id, VlanId1).Return(nil, nil)
+	mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &SubnetId, SecurityGroups,
+		vlan2Tag, 0, nil).Return(BranchInterface2, nil)
+	mockEC2APIHelper.EXPECT().AssociateBranchToTrunk(&trunkId, &Branch2Id, VlanId2).Return(nil, MockError)
 
 	_, err := trunkENI.CreateAndAssociateBranchENIs(MockPod2, SecurityGroups, 2)
 	assert.Error(t, MockError, err)
 	assert.Equal(t, []*ENIDetails{EniDetails1, EniDetails2}, trunkENI.deleteQueue)
+	// Vlan IDs are held till the branch ENIs are deleted
+	assert.True(t, trunkENI.usedVlanIds[VlanId1])
+	assert.True(t, trunkENI.usedVlanIds[VlanId2])
+	_, isPresent := trunkENI.uidToBranchENIMap[PodUID2]
+	assert.False(t, isPresent)
 }
 
 // TestTrunkENI_CreateAndAssociateBranchENIs_ErrorCreate tests if error is returned on associate then the created interfaces
@@ -831,21 +1086,112 @@ func TestTrunkENI_CreateAndAssociateBranchENIs_ErrorCreate(t *testing.T) {
 	trunkENI, mockEC2APIHelper, mockInstance := getMockHelperInstanceAndTrunkObject(ctrl)
 	trunkENI.trunkENIId = trunkId
 
-	mockInstance.EXPECT().Type().Return(InstanceType)
-	mockInstance.EXPECT().SubnetID().Return(SubnetId).Times(2)
-	mockInstance.EXPECT().SubnetCidrBlock().Return(SubnetCidrBlock).Times(1)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[InstanceType], true)
+	mockInstance.EXPECT().CandidateSubnets().Return(CandidateSubnets)
 
-	gomock.InOrder(
-		mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &SubnetId, SecurityGroups, vlan1Tag,
-			0, nil).Return(BranchInterface1, nil),
-		mockEC2APIHelper.EXPECT().AssociateBranchToTrunk(&trunkId, &Branch1Id, VlanId1).Return(nil, nil),
-		mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &SubnetId, SecurityGroups, vlan2Tag,
-			0, nil).Return(nil, MockError),
-	)
+	// Branch ENIs are created in parallel
+	mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &SubnetId, SecurityGroups, vlan1Tag,
+		0, nil).Return(BranchInterface1, nil)
+	mockEC2APIHelper.EXPECT().AssociateBranchToTrunk(&trunkId, &Branch1Id, VlanId1).Return(nil, nil)
+	mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &SubnetId, SecurityGroups, vlan2Tag,
+		0, nil).Return(nil, MockError)
 
 	_, err := trunkENI.CreateAndAssociateBranchENIs(MockPod2, SecurityGroups, 2)
 	assert.Error(t, MockError, err)
 	assert.Equal(t, []*ENIDetails{EniDetails1}, trunkENI.deleteQueue)
+	// Vlan ID of the branch that was never created is released
+	assert.True(t, trunkENI.usedVlanIds[VlanId1])
+	assert.False(t, trunkENI.usedVlanIds[VlanId2])
+}
+
+// TestTrunkENI_CreateAndAssociateBranchENIs_FallbackSubnet tests that the branch is created in the fallback subnet if
+// the current subnet has insufficient free addresses and the returned details carry the fallback subnet CIDR
+func TestTrunkENI_CreateAndAssociateBranchENIs_FallbackSubnet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, mockEC2APIHelper, mockInstance := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.trunkENIId = trunkId
+
+	fallbackSubnetID := "subnet-00000000000000001"
+	fallbackSubnetCIDR := "192.169.0.0/20"
+	insufficientAddressesErr := awserr.New(ec2API.ErrCodeInsufficientFreeAddressesInSubnet, "", nil)
+
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[InstanceType], true)
+	mockInstance.EXPECT().CandidateSubnets().Return(append(CandidateSubnets,
+		ec2.Subnet{ID: fallbackSubnetID, CIDRBlock: fallbackSubnetCIDR}))
+
+	gomock.InOrder(
+		mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &SubnetId, SecurityGroups,
+			vlan1Tag, 0, nil).Return(nil, insufficientAddressesErr),
+		mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &fallbackSubnetID, SecurityGroups,
+			vlan1Tag, 0, nil).Return(BranchInterface1, nil),
+	)
+	mockEC2APIHelper.EXPECT().AssociateBranchToTrunk(&trunkId, &Branch1Id, VlanId1).Return(nil, nil)
+
+	eniDetails, err := trunkENI.CreateAndAssociateBranchENIs(MockPod2, SecurityGroups, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fallbackSubnetCIDR, eniDetails[0].SubnetCIDR)
+	assert.Equal(t, Branch1Id, eniDetails[0].ID)
+}
+
+// TestTrunkENI_CreateAndAssociateBranchENIs_ErrorAssignVlan tests that no branch is created if there are not enough
+// vlan ids for the request
+func TestTrunkENI_CreateAndAssociateBranchENIs_ErrorAssignVlan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, _, mockInstance := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.trunkENIId = trunkId
+	for i := 1; i < MaxAllocatableVlanIds-1; i++ {
+		trunkENI.usedVlanIds[i] = true
+	}
+
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[InstanceType], true)
+
+	_, err := trunkENI.CreateAndAssociateBranchENIs(MockPod2, SecurityGroups, 2)
+	assert.NotNil(t, err)
+	assert.False(t, trunkENI.usedVlanIds[MaxAllocatableVlanIds-1])
+	assert.Empty(t, trunkENI.deleteQueue)
+}
+
+// TestTrunkENI_CreateAndAssociateBranchENIs_Concurrent tests that concurrent requests on the same trunk get unique
+// vlan ids
+func TestTrunkENI_CreateAndAssociateBranchENIs_Concurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, mockEC2APIHelper, mockInstance := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.trunkENIId = trunkId
+
+	podCount, eniCount := 5, 3
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[InstanceType], true).Times(podCount)
+	mockInstance.EXPECT().CandidateSubnets().Return(CandidateSubnets).Times(podCount)
+	mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, &SubnetId, SecurityGroups, gomock.Any(),
+		0, nil).Return(BranchInterface1, nil).Times(podCount * eniCount)
+	mockEC2APIHelper.EXPECT().AssociateBranchToTrunk(&trunkId, &Branch1Id, gomock.Any()).Return(nil, nil).
+		Times(podCount * eniCount)
+
+	var wg sync.WaitGroup
+	for i := 0; i < podCount; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{UID: types.UID(strconv.Itoa(i))}}
+			_, err := trunkENI.CreateAndAssociateBranchENIs(pod, SecurityGroups, eniCount)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	vlanIDs := map[int]struct{}{}
+	for _, enis := range trunkENI.uidToBranchENIMap {
+		for _, eni := range enis {
+			vlanIDs[eni.VlanID] = struct{}{}
+		}
+	}
+	assert.Equal(t, podCount*eniCount, len(vlanIDs))
 }
 
 func TestTrunkENI_Introspect(t *testing.T) {
@@ -864,3 +1210,27 @@ func TestTrunkENI_Introspect(t *testing.T) {
 		PodToBranchENI: map[string][]ENIDetails{PodUID: {*EniDetails1}}},
 	)
 }
+
+// TestTrunkENI_Introspect_DeadLetterQueue tests that the ENIs in the dead letter queue are returned with the retry details
+func TestTrunkENI_Introspect_DeadLetterQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, _, mockInstance := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.trunkENIId = trunkId
+
+	nextRetry := time.Now()
+	EniDetails1.deleteRetryCount = MaxDeleteRetries
+	EniDetails1.nextRetryTimeStamp = nextRetry
+	EniDetails1.lastDeleteError = MockError
+	trunkENI.deadLetterQueue = []*ENIDetails{EniDetails1}
+
+	mockInstance.EXPECT().InstanceID().Return(InstanceId)
+	response := trunkENI.Introspect()
+	assert.Equal(t, []DeadLetterENI{{
+		ENIDetails: *EniDetails1,
+		RetryCount: MaxDeleteRetries,
+		NextRetry:  nextRetry,
+		LastError:  MockError.Error(),
+	}}, response.DeadLetterQueue)
+}
@@ -15,14 +15,17 @@ package eni
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
-	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 
 	"github.com/aws/aws-sdk-go/aws"
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/go-logr/logr"
 )
 
@@ -45,12 +48,22 @@ type eniManager struct {
 type eni struct {
 	eniID             string
 	remainingCapacity int
+	// subnetMask is the mask of the subnet in which the ENI was created
+	subnetMask string
+	// deviceIndex is the index at which the ENI is attached to the instance
+	deviceIndex int64
+	// networkCardIndex is the index of the network card the ENI is attached to
+	networkCardIndex int64
+	// attachmentID is used to detach the ENI before deleting it
+	attachmentID string
 }
 
 type ENIManager interface {
 	InitResources(ec2APIHelper api.EC2APIHelper) ([]string, error)
 	CreateIPV4Address(required int, ec2APIHelper api.EC2APIHelper, log logr.Logger) ([]string, error)
 	DeleteIPV4Address(ipList []string, ec2APIHelper api.EC2APIHelper, log logr.Logger) ([]string, error)
+	GetIPRank(ip string) int
+	GetCompactableIPs(warmIPs []string) []string
 }
 
 // NewENIManager returns a new ENI Manager
@@ -69,18 +82,30 @@ func (e *eniManager) InitResources(ec2APIHelper api.EC2APIHelper) ([]string, err
 		return nil, err
 	}
 
-	limits, found := vpc.Limits[e.instance.Type()]
+	limits, found := e.instance.Limits()
 	if !found {
 		return nil, fmt.Errorf("unsupported instance type")
 	}
 
 	ipLimit := limits.IPv4PerInterface
+	candidateSubnets := e.instance.CandidateSubnets()
+	isLinux := e.instance.Os() == config.OSLinux
 	var availIPs []string
 	for _, nwInterface := range nwInterfaces {
+		// On Linux nodes the VPC CNI manages its own ENIs, only the ENIs created by the controller are used
+		if isLinux && !isCreatedByController(nwInterface) {
+			continue
+		}
 		if nwInterface.PrivateIpAddresses != nil {
 			eni := &eni{
 				remainingCapacity: ipLimit,
 				eniID:             *nwInterface.NetworkInterfaceId,
+				subnetMask:        e.getSubnetMask(candidateSubnets, aws.StringValue(nwInterface.SubnetId)),
+			}
+			if nwInterface.Attachment != nil {
+				eni.deviceIndex = aws.Int64Value(nwInterface.Attachment.DeviceIndex)
+				eni.networkCardIndex = aws.Int64Value(nwInterface.Attachment.NetworkCardIndex)
+				eni.attachmentID = aws.StringValue(nwInterface.Attachment.AttachmentId)
 			}
 			for _, ip := range nwInterface.PrivateIpAddresses {
 				if *ip.Primary != true {
@@ -92,10 +117,16 @@ func (e *eniManager) InitResources(ec2APIHelper api.EC2APIHelper) ([]string, err
 			e.attachedENIs = append(e.attachedENIs, eni)
 		}
 	}
+	e.sortENIsByDeviceIndex()
 
 	return e.addSubnetMaskToIPSlice(availIPs), nil
 }
 
+// isCreatedByController returns true if the network interface was created by the controller for IPv4 addresses
+func isCreatedByController(nwInterface *awsEC2.InstanceNetworkInterface) bool {
+	return aws.StringValue(nwInterface.Description) == api.CreateENIDescriptionPrefix+ENIDescription
+}
+
 // CreateIPV4Address creates IPv4 address and returns the list of assigned IPs along with the error if not all the required
 // IPs were assigned
 func (e *eniManager) CreateIPV4Address(required int, ec2APIHelper api.EC2APIHelper, log logr.Logger) ([]string, error) {
@@ -120,9 +151,14 @@ func (e *eniManager) CreateIPV4Address(required int, ec2APIHelper api.EC2APIHelp
 			}
 			// Assign the IPv4 Addresses from this ENI
 			assignedIPs, err := ec2APIHelper.AssignIPv4AddressesAndWaitTillReady(e.attachedENIs[index].eniID, canAssign)
-			if err != nil && len(assignedIPs) == 0 {
-				// Return the list of IPs that were actually created along with the error
-				return assignedIPs, err
+			if err != nil && len(assignedIPs) == 0 && api.IsInsufficientFreeAddressesError(err) {
+				// The ENI's subnet is exhausted, try the remaining ENIs or create a new ENI in a fallback subnet
+				log.Info("subnet of the ENI has insufficient free addresses", "eni", e.attachedENIs[index].eniID)
+				continue
+			} else if err != nil && len(assignedIPs) == 0 {
+				// Return the list of IPs that were actually created on the previous ENIs along with the error, so
+				// they are added to the warm pool instead of being leaked
+				return assignedIPv4Address, err
 			} else if err != nil {
 				// Just log and continue processing the assigned IPs
 				log.Error(err, "failed to assign all the requested IPs",
@@ -144,35 +180,85 @@ func (e *eniManager) CreateIPV4Address(required int, ec2APIHelper api.EC2APIHelp
 	}
 
 	// List of secondary IPs supported minus the primary IP
-	ipLimit := vpc.Limits[e.instance.Type()].IPv4PerInterface - 1
-	eniLimit := vpc.Limits[e.instance.Type()].Interface
+	limits, found := e.instance.Limits()
+	if !found {
+		return e.addSubnetMaskToIPSlice(assignedIPv4Address), fmt.Errorf("unsupported instance type")
+	}
+	ipLimit := limits.IPv4PerInterface - 1
+	eniLimit := limits.Interface
 
 	// If the existing ENIs could not assign the required IPs, loop till the new ENIs can assign the required
-	// number of IPv4 Addresses
+	// number of IPv4 Addresses. The new ENIs are created in the first candidate subnet with free addresses
+	var candidateSubnets []ec2.Subnet
+	var tags []*awsEC2.Tag
+	subnetIndex := 0
+	isLinux := false
+	refreshedOnConflict := false
 	for len(assignedIPv4Address) < required &&
 		len(e.attachedENIs) < eniLimit {
 
-		deviceIndex, err := e.instance.GetHighestUnusedDeviceIndex()
+		if candidateSubnets == nil {
+			candidateSubnets = e.instance.CandidateSubnets()
+			// The VPC CNI attaches ENIs to Linux nodes independently, refresh the used device indexes so the
+			// new ENIs are not attached at an index taken by the VPC CNI
+			if isLinux = e.instance.Os() == config.OSLinux; isLinux {
+				if err := e.instance.RefreshDeviceIndexes(ec2APIHelper); err != nil {
+					return assignedIPv4Address, err
+				}
+				// The VPC CNI must not assign the IPs of the new ENIs to its pods
+				tags = []*awsEC2.Tag{{Key: aws.String(config.NoManageTagKey),
+					Value: aws.String(config.NoManageTagValue)}}
+			}
+		}
+
+		networkCardIndex := e.instance.ENINetworkCardIndex()
+		deviceIndex, err := e.instance.GetHighestUnusedDeviceIndex(networkCardIndex)
 		if err != nil {
-			// TODO: Refresh device index for linux nodes only
 			return assignedIPv4Address, err
 		}
 		want := required - len(assignedIPv4Address)
 		if want > ipLimit {
 			want = ipLimit
 		}
+		subnet := candidateSubnets[subnetIndex]
 		nwInterface, err := ec2APIHelper.CreateAndAttachNetworkInterface(aws.String(e.instance.InstanceID()),
-			aws.String(e.instance.SubnetID()), e.instance.InstanceSecurityGroup(), nil, aws.Int64(deviceIndex),
-			&ENIDescription, nil, want)
+			aws.String(subnet.ID), e.instance.InstanceSecurityGroup(), tags, aws.Int64(deviceIndex),
+			aws.Int64(networkCardIndex), &ENIDescription, nil, want)
 		if err != nil {
+			if api.IsInsufficientFreeAddressesError(err) && subnetIndex+1 < len(candidateSubnets) {
+				e.instance.FreeDeviceIndex(networkCardIndex, deviceIndex)
+				subnetIndex++
+				log.Info("subnet has insufficient free addresses, creating the ENI in the next subnet",
+					"subnet", subnet.ID, "next subnet", candidateSubnets[subnetIndex].ID)
+				continue
+			}
+			if api.IsDeviceIndexConflictError(err) && isLinux && !refreshedOnConflict {
+				// The VPC CNI attached an ENI since the device indexes were refreshed, refresh them again
+				// and retry once with the next free index
+				log.Info("device index is used by another ENI, refreshing the device indexes",
+					"device index", deviceIndex, "network card index", networkCardIndex)
+				e.instance.FreeDeviceIndex(networkCardIndex, deviceIndex)
+				if err := e.instance.RefreshDeviceIndexes(ec2APIHelper); err != nil {
+					return assignedIPv4Address, err
+				}
+				refreshedOnConflict = true
+				continue
+			}
 			// TODO: Check if any clean up is required here for linux nodes only?
 			return assignedIPv4Address, err
 		}
 		eni := &eni{
 			remainingCapacity: ipLimit - want,
 			eniID:             *nwInterface.NetworkInterfaceId,
+			subnetMask:        e.getSubnetMask(candidateSubnets, subnet.ID),
+			deviceIndex:       deviceIndex,
+			networkCardIndex:  networkCardIndex,
+		}
+		if nwInterface.Attachment != nil {
+			eni.attachmentID = aws.StringValue(nwInterface.Attachment.AttachmentId)
 		}
 		e.attachedENIs = append(e.attachedENIs, eni)
+		e.sortENIsByDeviceIndex()
 		for _, assignedIP := range nwInterface.PrivateIpAddresses {
 			if !*assignedIP.Primary {
 				assignedIPv4Address = append(assignedIPv4Address, *assignedIP.PrivateIpAddress)
@@ -221,7 +307,11 @@ func (e *eniManager) DeleteIPV4Address(ipList []string, ec2APIHelper api.EC2APIH
 		log.Info("deleted secondary IPv4 address", "eni", eni.eniID, "IPv4 addresses", ips)
 	}
 
-	ipLimit := vpc.Limits[e.instance.Type()].IPv4PerInterface - 1
+	limits, found := e.instance.Limits()
+	if !found {
+		return failedToUnAssign, fmt.Errorf("unsupported instance type")
+	}
+	ipLimit := limits.IPv4PerInterface - 1
 	primaryENIID := e.instance.PrimaryNetworkInterfaceID()
 
 	// Clean up ENIs that just have the primary network interface attached to them
@@ -229,13 +319,22 @@ func (e *eniManager) DeleteIPV4Address(ipList []string, ec2APIHelper api.EC2APIH
 	for _, eni := range e.attachedENIs {
 		// ENI doesn't have any secondary IP attached to it and is not the primary network interface
 		if eni.remainingCapacity == ipLimit && primaryENIID != eni.eniID {
-			err := ec2APIHelper.DeleteNetworkInterface(&eni.eniID)
+			var err error
+			if eni.attachmentID != "" {
+				err = ec2APIHelper.DetachAndDeleteNetworkInterface(&eni.attachmentID, &eni.eniID)
+			} else {
+				err = ec2APIHelper.DeleteNetworkInterface(&eni.eniID)
+			}
 			if err != nil {
 				errors = append(errors, err)
 				e.attachedENIs[i] = eni
 				i++
 				continue
 			}
+			// Return the device index so it can be used by new ENIs
+			if eni.deviceIndex > 0 {
+				e.instance.FreeDeviceIndex(eni.networkCardIndex, eni.deviceIndex)
+			}
 			log.Info("deleted ENI successfully as it has no secondary IP attached",
 				"id", eni.eniID)
 		} else {
@@ -252,6 +351,74 @@ func (e *eniManager) DeleteIPV4Address(ipList []string, ec2APIHelper api.EC2APIH
 	return nil, nil
 }
 
+// GetIPRank returns the device index of the ENI the IP belongs to. IPs on lower index ENIs are assigned
+// first and IPs on higher index ENIs are deleted first, so that the sparse ENIs can be released
+func (e *eniManager) GetIPRank(ip string) int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	eni, found := e.ipToENIMap[strings.Split(ip, "/")[0]]
+	if !found {
+		// Rank the unknown IPs after the IPs of all the ENIs
+		if limits, found := e.instance.Limits(); found {
+			return limits.Interface
+		}
+		return math.MaxInt32
+	}
+	return int(eni.deviceIndex)
+}
+
+// GetCompactableIPs returns the warm IPs of the highest index secondary ENI on which all the IPs are warm,
+// provided the other ENIs can hold the same number of IPs. Deleting these IPs releases the ENI and the
+// warm pool is re-created on the lower index ENIs, as new IPs are assigned from the lowest index ENI first
+func (e *eniManager) GetCompactableIPs(warmIPs []string) []string {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	limits, found := e.instance.Limits()
+	if !found {
+		return nil
+	}
+	ipLimit := limits.IPv4PerInterface - 1
+	primaryENIID := e.instance.PrimaryNetworkInterfaceID()
+
+	warmIPsPerENI := map[*eni][]string{}
+	for _, ip := range warmIPs {
+		if eni, found := e.ipToENIMap[strings.Split(ip, "/")[0]]; found {
+			warmIPsPerENI[eni] = append(warmIPsPerENI[eni], ip)
+		}
+	}
+
+	totalRemainingCapacity := 0
+	for _, eni := range e.attachedENIs {
+		totalRemainingCapacity += eni.remainingCapacity
+	}
+
+	for index := len(e.attachedENIs) - 1; index >= 0; index-- {
+		eni := e.attachedENIs[index]
+		assignedIPs := ipLimit - eni.remainingCapacity
+		// Skip the ENIs which have IPs used by pods or in cool down
+		if eni.eniID == primaryENIID || assignedIPs == 0 ||
+			len(warmIPsPerENI[eni]) != assignedIPs {
+			continue
+		}
+		// The warm IPs can only be moved if the other ENIs have the capacity
+		if totalRemainingCapacity-eni.remainingCapacity < assignedIPs {
+			return nil
+		}
+		return warmIPsPerENI[eni]
+	}
+
+	return nil
+}
+
+// sortENIsByDeviceIndex sorts the ENIs so that IPs are assigned from the lowest index ENIs first
+func (e *eniManager) sortENIsByDeviceIndex() {
+	sort.SliceStable(e.attachedENIs, func(i, j int) bool {
+		return e.attachedENIs[i].deviceIndex < e.attachedENIs[j].deviceIndex
+	})
+}
+
 // groupIPsPerENI groups the IPs to delete per ENI
 func (e *eniManager) groupIPsPerENI(deleteList []string) map[*eni][]string {
 	toDelete := map[*eni][]string{}
@@ -265,13 +432,31 @@ func (e *eniManager) groupIPsPerENI(deleteList []string) map[*eni][]string {
 	return toDelete
 }
 
+// addSubnetMaskToIPSlice adds the mask of the subnet of the ENI the IP belongs to
 func (e *eniManager) addSubnetMaskToIPSlice(ipAddresses []string) []string {
 	for i := 0; i < len(ipAddresses); i++ {
-		ipAddresses[i] = ipAddresses[i] + "/" + e.instance.SubnetMask()
+		var subnetMask string
+		if eni, found := e.ipToENIMap[ipAddresses[i]]; found && eni.subnetMask != "" {
+			subnetMask = eni.subnetMask
+		} else {
+			subnetMask = e.instance.SubnetMask()
+		}
+		ipAddresses[i] = ipAddresses[i] + "/" + subnetMask
 	}
 	return ipAddresses
 }
 
+// getSubnetMask returns the mask of the candidate subnet with the given ID, if the subnet is not a candidate
+// an empty mask is returned and the mask of the instance subnet is used instead
+func (e *eniManager) getSubnetMask(candidateSubnets []ec2.Subnet, subnetID string) string {
+	for _, subnet := range candidateSubnets {
+		if subnet.ID == subnetID && strings.Contains(subnet.CIDRBlock, "/") {
+			return strings.Split(subnet.CIDRBlock, "/")[1]
+		}
+	}
+	return ""
+}
+
 func (e *eniManager) stripSubnetMaskFromIPSlice(ipAddresses []string) []string {
 	for i := 0; i < len(ipAddresses); i++ {
 		ipAddresses[i] = strings.Split(ipAddresses[i], "/")[0]
@@ -20,7 +20,13 @@ import (
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	ec2Instance "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -38,6 +44,17 @@ var (
 	subnetMask = "16"
 	instanceSG = []string{"sg-1"}
 
+	fallbackSubnetID   = "subnet-0000000002"
+	fallbackSubnetMask = "20"
+
+	candidateSubnets = []ec2Instance.Subnet{{ID: subnetID, CIDRBlock: "192.168.0.0/" + subnetMask}}
+	fallbackSubnets  = []ec2Instance.Subnet{{ID: subnetID, CIDRBlock: "192.168.0.0/" + subnetMask},
+		{ID: fallbackSubnetID, CIDRBlock: "192.169.0.0/" + fallbackSubnetMask}}
+
+	insufficientAddressesError = awserr.New(ec2API.ErrCodeInsufficientFreeAddressesInSubnet, "", nil)
+
+	noManageTags = []*ec2.Tag{{Key: aws.String(config.NoManageTagKey), Value: aws.String(config.NoManageTagValue)}}
+
 	ip1         = "192.168.1.0"
 	ip1WithMask = ip1 + "/" + subnetMask
 	ip2         = "192.168.1.1"
@@ -119,9 +136,11 @@ func TestEni_InitResources(t *testing.T) {
 
 	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
 
-	mockInstance.EXPECT().Type().Return(instanceType)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
 	mockInstance.EXPECT().InstanceID().Return(instanceID)
 	mockInstance.EXPECT().SubnetMask().Return(subnetMask).Times(3)
+	mockInstance.EXPECT().CandidateSubnets().Return(candidateSubnets)
+	mockInstance.EXPECT().Os().Return(config.OSWindows)
 
 	mockEc2APIHelper.EXPECT().GetInstanceNetworkInterface(&instanceID).Return(nwInterfaces, nil)
 
@@ -170,7 +189,7 @@ func TestEniManager_CreateIPV4Address_FromSingleENI(t *testing.T) {
 
 	mockEc2APIHelper.EXPECT().AssignIPv4AddressesAndWaitTillReady(eniID1, 2).Return([]string{ip1, ip2}, nil)
 	mockInstance.EXPECT().Name().Return(instanceName)
-	mockInstance.EXPECT().Type().Return(instanceType).Times(2)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
 	mockInstance.EXPECT().SubnetMask().Return(subnetMask).Times(2)
 
 	ips, err := manager.CreateIPV4Address(2, mockEc2APIHelper, log)
@@ -195,7 +214,7 @@ func TestEniManager_CreateIPV4Address_FromMultipleENI(t *testing.T) {
 		mockEc2APIHelper.EXPECT().AssignIPv4AddressesAndWaitTillReady(eniID2, 1).Return([]string{ip2}, nil),
 	)
 
-	mockInstance.EXPECT().Type().Return(instanceType).Times(2)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
 	mockInstance.EXPECT().Name().Return(instanceName)
 	mockInstance.EXPECT().SubnetMask().Return(subnetMask).Times(2)
 
@@ -212,6 +231,33 @@ func TestEniManager_CreateIPV4Address_FromMultipleENI(t *testing.T) {
 	assert.Equal(t, manager.attachedENIs[1], manager.ipToENIMap[ip2])
 }
 
+// TestEniManager_CreateIPV4Address_ExistingENIFail tests that if the assignment fails on an existing ENI, the IPs
+// assigned on the previous ENIs are returned along with the error
+func TestEniManager_CreateIPV4Address_ExistingENIFail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+
+	manager.attachedENIs = []*eni{createENIDetails(eniID1, 1),
+		createENIDetails(eniID2, 3)}
+
+	gomock.InOrder(
+		mockEc2APIHelper.EXPECT().AssignIPv4AddressesAndWaitTillReady(eniID1, 1).Return([]string{ip1}, nil),
+		mockEc2APIHelper.EXPECT().AssignIPv4AddressesAndWaitTillReady(eniID2, 2).Return(nil, mockError),
+	)
+
+	mockInstance.EXPECT().Name().Return(instanceName)
+
+	ips, err := manager.CreateIPV4Address(3, mockEc2APIHelper, log)
+
+	assert.Equal(t, mockError, err)
+	assert.Equal(t, []string{ip1}, ips)
+	assert.Equal(t, 0, manager.attachedENIs[0].remainingCapacity)
+	assert.Equal(t, 3, manager.attachedENIs[1].remainingCapacity)
+	assert.Equal(t, map[string]*eni{ip1: manager.attachedENIs[0]}, manager.ipToENIMap)
+}
+
 // TestEniManager_CreateIPV4Address_FromNewENI tests if existing ENIs cannot supply new IP, IPs are allocated from a new
 // ENI
 func TestEniManager_CreateIPV4Address_FromNewENI(t *testing.T) {
@@ -224,24 +270,29 @@ func TestEniManager_CreateIPV4Address_FromNewENI(t *testing.T) {
 	manager.attachedENIs = []*eni{existingENI}
 
 	mockInstance.EXPECT().Name().Return(instanceName)
-	mockInstance.EXPECT().Type().Return(instanceType).Times(2)
-	mockInstance.EXPECT().GetHighestUnusedDeviceIndex().Return(int64(3), nil).Times(2)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().ENINetworkCardIndex().Return(int64(0)).Times(2)
+	mockInstance.EXPECT().GetHighestUnusedDeviceIndex(int64(0)).Return(int64(3), nil).Times(2)
 	mockInstance.EXPECT().InstanceID().Return(instanceID).Times(2)
-	mockInstance.EXPECT().SubnetID().Return(subnetID).Times(2)
-	mockInstance.EXPECT().SubnetMask().Return(subnetMask).Times(4)
+	mockInstance.EXPECT().CandidateSubnets().Return(candidateSubnets)
+	mockInstance.EXPECT().Os().Return(config.OSWindows)
 	mockInstance.EXPECT().InstanceSecurityGroup().Return(instanceSG).Times(2)
 
 	gomock.InOrder(
 		mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &subnetID, instanceSG, nil, aws.Int64(3),
-			&ENIDescription, nil, 3).Return(networkInterface1, nil),
+			aws.Int64(0), &ENIDescription, nil, 3).Return(networkInterface1, nil),
 		mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &subnetID, instanceSG, nil, aws.Int64(3),
-			&ENIDescription, nil, 1).Return(networkInterface2, nil),
+			aws.Int64(0), &ENIDescription, nil, 1).Return(networkInterface2, nil),
 	)
 
 	ips, err := manager.CreateIPV4Address(4, mockEc2APIHelper, log)
 
 	expectedNewENI1 := createENIDetails(*networkInterface1.NetworkInterfaceId, 0)
+	expectedNewENI1.subnetMask = subnetMask
+	expectedNewENI1.deviceIndex = 3
 	expectedNewENI2 := createENIDetails(*networkInterface2.NetworkInterfaceId, 2)
+	expectedNewENI2.subnetMask = subnetMask
+	expectedNewENI2.deviceIndex = 3
 
 	assert.NoError(t, err)
 	assert.Equal(t, []string{ip2WithMask, ip3WithMask, ip4WithMask, ip6WithMask}, ips)
@@ -261,22 +312,26 @@ func TestEniManager_CreateIPV4Address_InBetweenENIFail(t *testing.T) {
 	manager.attachedENIs = []*eni{existingENI}
 
 	mockInstance.EXPECT().Name().Return(instanceName)
-	mockInstance.EXPECT().Type().Return(instanceType).Times(2)
-	mockInstance.EXPECT().GetHighestUnusedDeviceIndex().Return(int64(3), nil).Times(2)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().ENINetworkCardIndex().Return(int64(0)).Times(2)
+	mockInstance.EXPECT().GetHighestUnusedDeviceIndex(int64(0)).Return(int64(3), nil).Times(2)
 	mockInstance.EXPECT().InstanceID().Return(instanceID).Times(2)
-	mockInstance.EXPECT().SubnetID().Return(subnetID).Times(2)
+	mockInstance.EXPECT().CandidateSubnets().Return(candidateSubnets)
+	mockInstance.EXPECT().Os().Return(config.OSWindows)
 	mockInstance.EXPECT().InstanceSecurityGroup().Return(instanceSG).Times(2)
 
 	gomock.InOrder(
 		mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &subnetID, instanceSG, nil, aws.Int64(3),
-			&ENIDescription, nil, 3).Return(networkInterface1, nil),
+			aws.Int64(0), &ENIDescription, nil, 3).Return(networkInterface1, nil),
 		mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &subnetID, instanceSG, nil, aws.Int64(3),
-			&ENIDescription, nil, 1).Return(nil, mockError),
+			aws.Int64(0), &ENIDescription, nil, 1).Return(nil, mockError),
 	)
 
 	ips, err := manager.CreateIPV4Address(4, mockEc2APIHelper, log)
 
 	expectedNewENI1 := createENIDetails(*networkInterface1.NetworkInterfaceId, 0)
+	expectedNewENI1.subnetMask = subnetMask
+	expectedNewENI1.deviceIndex = 3
 
 	assert.Error(t, mockError, err)
 	assert.Equal(t, []string{ip2, ip3, ip4}, ips)
@@ -284,6 +339,78 @@ func TestEniManager_CreateIPV4Address_InBetweenENIFail(t *testing.T) {
 	assert.Equal(t, map[string]*eni{ip2: expectedNewENI1, ip3: expectedNewENI1, ip4: expectedNewENI1}, manager.ipToENIMap)
 }
 
+// TestEniManager_CreateIPV4Address_FallbackSubnet tests that the new ENI is created in the fallback subnet when the
+// current subnet has insufficient free addresses and the IPs carry the fallback subnet mask
+func TestEniManager_CreateIPV4Address_FallbackSubnet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+
+	mockInstance.EXPECT().Name().Return(instanceName)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().ENINetworkCardIndex().Return(int64(0)).Times(2)
+	mockInstance.EXPECT().GetHighestUnusedDeviceIndex(int64(0)).Return(int64(3), nil).Times(2)
+	mockInstance.EXPECT().FreeDeviceIndex(int64(0), int64(3))
+	mockInstance.EXPECT().InstanceID().Return(instanceID).Times(2)
+	mockInstance.EXPECT().CandidateSubnets().Return(fallbackSubnets)
+	mockInstance.EXPECT().Os().Return(config.OSWindows)
+	mockInstance.EXPECT().InstanceSecurityGroup().Return(instanceSG).Times(2)
+
+	gomock.InOrder(
+		mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &subnetID, instanceSG, nil, aws.Int64(3),
+			aws.Int64(0), &ENIDescription, nil, 1).Return(nil, insufficientAddressesError),
+		mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &fallbackSubnetID, instanceSG, nil,
+			aws.Int64(3), aws.Int64(0), &ENIDescription, nil, 1).Return(networkInterface2, nil),
+	)
+
+	ips, err := manager.CreateIPV4Address(1, mockEc2APIHelper, log)
+
+	expectedNewENI := createENIDetails(*networkInterface2.NetworkInterfaceId, 2)
+	expectedNewENI.subnetMask = fallbackSubnetMask
+	expectedNewENI.deviceIndex = 3
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ip6 + "/" + fallbackSubnetMask}, ips)
+	assert.Equal(t, []*eni{expectedNewENI}, manager.attachedENIs)
+}
+
+// TestEniManager_CreateIPV4Address_AllSubnetsExhausted tests that the error is returned when the last candidate
+// subnet has insufficient free addresses
+func TestEniManager_CreateIPV4Address_AllSubnetsExhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+
+	existingENI := createENIDetails(eniID1, 1)
+	manager.attachedENIs = []*eni{existingENI}
+
+	mockInstance.EXPECT().Name().Return(instanceName)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().ENINetworkCardIndex().Return(int64(0)).Times(2)
+	mockInstance.EXPECT().GetHighestUnusedDeviceIndex(int64(0)).Return(int64(3), nil).Times(2)
+	mockInstance.EXPECT().FreeDeviceIndex(int64(0), int64(3))
+	mockInstance.EXPECT().InstanceID().Return(instanceID).Times(2)
+	mockInstance.EXPECT().CandidateSubnets().Return(fallbackSubnets)
+	mockInstance.EXPECT().Os().Return(config.OSWindows)
+	mockInstance.EXPECT().InstanceSecurityGroup().Return(instanceSG).Times(2)
+
+	mockEc2APIHelper.EXPECT().AssignIPv4AddressesAndWaitTillReady(eniID1, 1).Return(nil, insufficientAddressesError)
+	gomock.InOrder(
+		mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &subnetID, instanceSG, nil, aws.Int64(3),
+			aws.Int64(0), &ENIDescription, nil, 1).Return(nil, insufficientAddressesError),
+		mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &fallbackSubnetID, instanceSG, nil,
+			aws.Int64(3), aws.Int64(0), &ENIDescription, nil, 1).Return(nil, insufficientAddressesError),
+	)
+
+	ips, err := manager.CreateIPV4Address(1, mockEc2APIHelper, log)
+
+	assert.Equal(t, insufficientAddressesError, err)
+	assert.Empty(t, ips)
+	assert.Equal(t, []*eni{existingENI}, manager.attachedENIs)
+}
+
 // TestEniManager_DeleteIPV4Address tests ips are un assigned and network interface without any secondary IP is deleted
 func TestEniManager_DeleteIPV4Address(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -298,7 +425,7 @@ func TestEniManager_DeleteIPV4Address(t *testing.T) {
 	manager.attachedENIs = []*eni{eniDetails1, eniDetails2}
 
 	mockInstance.EXPECT().Name().Return(instanceName)
-	mockInstance.EXPECT().Type().Return(instanceType).Times(1)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true).Times(1)
 	mockInstance.EXPECT().PrimaryNetworkInterfaceID().Return(eniID1)
 	// Unassign the IPs from interface 1 and 2
 	mockEc2APIHelper.EXPECT().UnassignPrivateIpAddresses(eniID1, []string{ip1}).Return(nil)
@@ -329,7 +456,7 @@ func TestEniManager_DeleteIPV4Address_SomeFail(t *testing.T) {
 	manager.attachedENIs = []*eni{eniDetails1, eniDetails2}
 
 	mockInstance.EXPECT().Name().Return(instanceName)
-	mockInstance.EXPECT().Type().Return(instanceType).Times(1)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true).Times(1)
 	mockInstance.EXPECT().PrimaryNetworkInterfaceID().Return(eniID1)
 	// Unassign the IPs from interface 1 and 2
 	mockEc2APIHelper.EXPECT().UnassignPrivateIpAddresses(eniID1, []string{ip1}).Return(mockError)
@@ -365,4 +492,350 @@ func TestEniManager_groupIPsPerENI(t *testing.T) {
 	assert.Equal(t, map[*eni][]string{eni1: {ip1, ip3}, eni2: {ip4}}, groupedIP)
 }
 
+// TestEni_InitResources_SortByDeviceIndex tests the ENIs are ordered by the device index and the attachment is loaded
+func TestEni_InitResources_SortByDeviceIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+
+	attachmentID := "attach-000000000000001"
+	interfaces := []*ec2.InstanceNetworkInterface{
+		{
+			NetworkInterfaceId: &eniID2,
+			Attachment:         &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(2), AttachmentId: &attachmentID},
+			PrivateIpAddresses: []*ec2.InstancePrivateIpAddress{{PrivateIpAddress: &ip3, Primary: aws.Bool(false)}},
+		},
+		{
+			NetworkInterfaceId: &eniID1,
+			Attachment:         &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(0)},
+			PrivateIpAddresses: []*ec2.InstancePrivateIpAddress{{PrivateIpAddress: &ip1, Primary: aws.Bool(true)}},
+		},
+	}
+
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().InstanceID().Return(instanceID)
+	mockInstance.EXPECT().SubnetMask().Return(subnetMask)
+	mockInstance.EXPECT().CandidateSubnets().Return(candidateSubnets)
+	mockInstance.EXPECT().Os().Return(config.OSWindows)
+	mockEc2APIHelper.EXPECT().GetInstanceNetworkInterface(&instanceID).Return(interfaces, nil)
+
+	_, err := manager.InitResources(mockEc2APIHelper)
+
+	assert.NoError(t, err)
+	assert.Equal(t, eniID1, manager.attachedENIs[0].eniID)
+	assert.Equal(t, eniID2, manager.attachedENIs[1].eniID)
+	assert.Equal(t, int64(2), manager.attachedENIs[1].deviceIndex)
+	assert.Equal(t, attachmentID, manager.attachedENIs[1].attachmentID)
+}
+
+// TestEniManager_DeleteIPV4Address_DetachENI tests the ENI is detached before deletion and the device index is freed
+func TestEniManager_DeleteIPV4Address_DetachENI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+
+	attachmentID := "attach-000000000000002"
+	eniDetails1 := createENIDetails(eniID1, 1)
+	eniDetails2 := &eni{eniID: eniID2, remainingCapacity: 2, deviceIndex: 2, attachmentID: attachmentID}
+
+	manager.ipToENIMap = map[string]*eni{ip1: eniDetails1, ip2: eniDetails1, ip3: eniDetails2}
+	manager.attachedENIs = []*eni{eniDetails1, eniDetails2}
+
+	mockInstance.EXPECT().Name().Return(instanceName)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().PrimaryNetworkInterfaceID().Return(eniID1)
+	mockInstance.EXPECT().FreeDeviceIndex(int64(0), int64(2))
+	mockEc2APIHelper.EXPECT().UnassignPrivateIpAddresses(eniID2, []string{ip3}).Return(nil)
+	mockEc2APIHelper.EXPECT().DetachAndDeleteNetworkInterface(&attachmentID, &eniID2).Return(nil)
+
+	failedToDelete, err := manager.DeleteIPV4Address([]string{ip3 + "/" + subnetMask}, mockEc2APIHelper, log)
+
+	assert.NoError(t, err)
+	assert.Empty(t, failedToDelete)
+	assert.Equal(t, []*eni{eniDetails1}, manager.attachedENIs)
+}
+
+// TestEniManager_GetIPRank tests the rank of the IP is the device index of its ENI
+func TestEniManager_GetIPRank(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, _ := getMockManager(ctrl)
+	manager.ipToENIMap = map[string]*eni{ip1: {eniID: eniID1}, ip3: {eniID: eniID2, deviceIndex: 2}}
+
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+
+	assert.Equal(t, 0, manager.GetIPRank(ip1WithMask))
+	assert.Equal(t, 2, manager.GetIPRank(ip3WithMask))
+	// Unknown IPs are ranked after the IPs of all the ENIs
+	assert.Equal(t, 3, manager.GetIPRank(ip4WithMask))
+}
+
+// TestEniManager_GetCompactableIPs tests the warm IPs of the highest index ENI are returned if the other ENIs
+// can hold them
+func TestEniManager_GetCompactableIPs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, _ := getMockManager(ctrl)
+
+	eniDetails1 := &eni{eniID: eniID1, remainingCapacity: 1}
+	eniDetails2 := &eni{eniID: eniID2, remainingCapacity: 2, deviceIndex: 1}
+	manager.attachedENIs = []*eni{eniDetails1, eniDetails2}
+	manager.ipToENIMap = map[string]*eni{ip1: eniDetails1, ip2: eniDetails1, ip3: eniDetails2}
+
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().PrimaryNetworkInterfaceID().Return(eniID1)
+
+	ips := manager.GetCompactableIPs([]string{ip2WithMask, ip3WithMask})
+	assert.Equal(t, []string{ip3WithMask}, ips)
+}
+
+// TestEniManager_GetCompactableIPs_UnsupportedInstanceType tests no IPs are returned if the limits of the instance
+// type are not known
+func TestEniManager_GetCompactableIPs_UnsupportedInstanceType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, _ := getMockManager(ctrl)
+
+	eniDetails1 := &eni{eniID: eniID1, remainingCapacity: 1}
+	eniDetails2 := &eni{eniID: eniID2, remainingCapacity: 2, deviceIndex: 1}
+	manager.attachedENIs = []*eni{eniDetails1, eniDetails2}
+	manager.ipToENIMap = map[string]*eni{ip1: eniDetails1, ip2: eniDetails1, ip3: eniDetails2}
+
+	mockInstance.EXPECT().Limits().Return(nil, false)
+
+	ips := manager.GetCompactableIPs([]string{ip2WithMask, ip3WithMask})
+	assert.Empty(t, ips)
+}
+
+// TestEniManager_GetCompactableIPs_IPInUse tests the ENI is not drained if any of its IPs is not warm
+func TestEniManager_GetCompactableIPs_IPInUse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, _ := getMockManager(ctrl)
+
+	eniDetails1 := &eni{eniID: eniID1, remainingCapacity: 2}
+	eniDetails2 := &eni{eniID: eniID2, remainingCapacity: 1, deviceIndex: 1}
+	manager.attachedENIs = []*eni{eniDetails1, eniDetails2}
+	manager.ipToENIMap = map[string]*eni{ip1: eniDetails1, ip3: eniDetails2, ip4: eniDetails2}
+
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().PrimaryNetworkInterfaceID().Return(eniID1)
+
+	ips := manager.GetCompactableIPs([]string{ip1WithMask, ip3WithMask})
+	assert.Empty(t, ips)
+}
+
+// TestEniManager_GetCompactableIPs_NoCapacity tests the ENI is not drained if the other ENIs can't hold its IPs
+func TestEniManager_GetCompactableIPs_NoCapacity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, _ := getMockManager(ctrl)
+
+	eniDetails1 := &eni{eniID: eniID1, remainingCapacity: 1}
+	eniDetails2 := &eni{eniID: eniID2, remainingCapacity: 1, deviceIndex: 1}
+	manager.attachedENIs = []*eni{eniDetails1, eniDetails2}
+	manager.ipToENIMap = map[string]*eni{ip1: eniDetails1, ip2: eniDetails1, ip3: eniDetails2, ip4: eniDetails2}
+
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().PrimaryNetworkInterfaceID().Return(eniID1)
+
+	ips := manager.GetCompactableIPs([]string{ip3WithMask, ip4WithMask})
+	assert.Empty(t, ips)
+}
+
 //TODO: Add more test cases
+
+// TestEni_InitResources_Linux tests only the ENIs created by the controller are used on Linux nodes
+func TestEni_InitResources_Linux(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+
+	controllerDescription := ec2API.CreateENIDescriptionPrefix + ENIDescription
+	linuxInterfaces := []*ec2.InstanceNetworkInterface{
+		{
+			NetworkInterfaceId: &eniID1,
+			Description:        aws.String("aws-K8S-" + instanceID),
+			PrivateIpAddresses: []*ec2.InstancePrivateIpAddress{{PrivateIpAddress: &ip1, Primary: aws.Bool(false)}},
+		},
+		{
+			NetworkInterfaceId: &eniID2,
+			Description:        &controllerDescription,
+			PrivateIpAddresses: []*ec2.InstancePrivateIpAddress{{PrivateIpAddress: &ip3, Primary: aws.Bool(false)}},
+		},
+	}
+
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().InstanceID().Return(instanceID)
+	mockInstance.EXPECT().SubnetMask().Return(subnetMask)
+	mockInstance.EXPECT().CandidateSubnets().Return(candidateSubnets)
+	mockInstance.EXPECT().Os().Return(config.OSLinux)
+
+	mockEc2APIHelper.EXPECT().GetInstanceNetworkInterface(&instanceID).Return(linuxInterfaces, nil)
+
+	allIPs, err := manager.InitResources(mockEc2APIHelper)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ip3WithMask}, allIPs)
+	assert.Len(t, manager.attachedENIs, 1)
+	assert.Equal(t, eniID2, manager.attachedENIs[0].eniID)
+}
+
+// TestEniManager_CreateIPV4Address_Linux tests the device indexes are refreshed before creating new ENI on Linux nodes
+func TestEniManager_CreateIPV4Address_Linux(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+
+	mockInstance.EXPECT().Name().Return(instanceName)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().CandidateSubnets().Return(candidateSubnets)
+	mockInstance.EXPECT().Os().Return(config.OSLinux)
+	mockInstance.EXPECT().InstanceID().Return(instanceID)
+	mockInstance.EXPECT().InstanceSecurityGroup().Return(instanceSG)
+	mockInstance.EXPECT().SubnetMask().Return(subnetMask).AnyTimes()
+
+	gomock.InOrder(
+		mockInstance.EXPECT().RefreshDeviceIndexes(mockEc2APIHelper).Return(nil),
+		mockInstance.EXPECT().ENINetworkCardIndex().Return(int64(0)),
+		mockInstance.EXPECT().GetHighestUnusedDeviceIndex(int64(0)).Return(int64(2), nil),
+		mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &subnetID, instanceSG, noManageTags,
+			aws.Int64(2), aws.Int64(0), &ENIDescription, nil, 1).Return(networkInterface2, nil),
+	)
+
+	ips, err := manager.CreateIPV4Address(1, mockEc2APIHelper, log)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ip6WithMask}, ips)
+}
+
+// TestEniManager_CreateIPV4Address_Linux_DeviceIndexConflict tests the device indexes are refreshed again and the
+// ENI is created at the next free index if the VPC CNI attached an ENI at the same index in the meantime
+func TestEniManager_CreateIPV4Address_Linux_DeviceIndexConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+	conflictErr := awserr.New(ec2API.ErrCodeInvalidParameterValue, "Instance already has an interface attached "+
+		"at device index '2'.", nil)
+
+	mockInstance.EXPECT().Name().Return(instanceName)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().CandidateSubnets().Return(candidateSubnets)
+	mockInstance.EXPECT().Os().Return(config.OSLinux)
+	mockInstance.EXPECT().InstanceID().Return(instanceID).Times(2)
+	mockInstance.EXPECT().InstanceSecurityGroup().Return(instanceSG).Times(2)
+	mockInstance.EXPECT().SubnetMask().Return(subnetMask).AnyTimes()
+
+	gomock.InOrder(
+		mockInstance.EXPECT().RefreshDeviceIndexes(mockEc2APIHelper).Return(nil),
+		mockInstance.EXPECT().ENINetworkCardIndex().Return(int64(0)),
+		mockInstance.EXPECT().GetHighestUnusedDeviceIndex(int64(0)).Return(int64(2), nil),
+		mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &subnetID, instanceSG, noManageTags,
+			aws.Int64(2), aws.Int64(0), &ENIDescription, nil, 1).Return(nil, conflictErr),
+		mockInstance.EXPECT().FreeDeviceIndex(int64(0), int64(2)),
+		mockInstance.EXPECT().RefreshDeviceIndexes(mockEc2APIHelper).Return(nil),
+		mockInstance.EXPECT().ENINetworkCardIndex().Return(int64(0)),
+		mockInstance.EXPECT().GetHighestUnusedDeviceIndex(int64(0)).Return(int64(3), nil),
+		mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &subnetID, instanceSG, noManageTags,
+			aws.Int64(3), aws.Int64(0), &ENIDescription, nil, 1).Return(networkInterface2, nil),
+	)
+
+	ips, err := manager.CreateIPV4Address(1, mockEc2APIHelper, log)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ip6WithMask}, ips)
+	assert.Equal(t, int64(3), manager.attachedENIs[0].deviceIndex)
+}
+
+// TestEniManager_CreateIPV4Address_Linux_AttachmentLimitExceeded tests the attach is retried only once after
+// refreshing the device indexes
+func TestEniManager_CreateIPV4Address_Linux_AttachmentLimitExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+	limitErr := awserr.New(ec2API.ErrCodeAttachmentLimitExceeded, "Interface count exceeds the limit", nil)
+
+	mockInstance.EXPECT().Name().Return(instanceName)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().CandidateSubnets().Return(candidateSubnets)
+	mockInstance.EXPECT().Os().Return(config.OSLinux)
+	mockInstance.EXPECT().InstanceID().Return(instanceID).Times(2)
+	mockInstance.EXPECT().InstanceSecurityGroup().Return(instanceSG).Times(2)
+	mockInstance.EXPECT().RefreshDeviceIndexes(mockEc2APIHelper).Return(nil).Times(2)
+	mockInstance.EXPECT().ENINetworkCardIndex().Return(int64(0)).Times(2)
+	mockInstance.EXPECT().GetHighestUnusedDeviceIndex(int64(0)).Return(int64(2), nil).Times(2)
+	mockInstance.EXPECT().FreeDeviceIndex(int64(0), int64(2))
+	mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &subnetID, instanceSG, noManageTags,
+		aws.Int64(2), aws.Int64(0), &ENIDescription, nil, 1).Return(nil, limitErr).Times(2)
+
+	_, err := manager.CreateIPV4Address(1, mockEc2APIHelper, log)
+
+	assert.Equal(t, limitErr, err)
+}
+
+// TestEniManager_CreateIPV4Address_NetworkCard tests the new ENI is attached to the network card chosen by the
+// instance and its device index is freed on the same network card once deleted
+func TestEniManager_CreateIPV4Address_NetworkCard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+
+	mockInstance.EXPECT().Name().Return(instanceName).AnyTimes()
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true).Times(2)
+	mockInstance.EXPECT().CandidateSubnets().Return(candidateSubnets)
+	mockInstance.EXPECT().Os().Return(config.OSWindows)
+	mockInstance.EXPECT().InstanceID().Return(instanceID)
+	mockInstance.EXPECT().InstanceSecurityGroup().Return(instanceSG)
+	mockInstance.EXPECT().SubnetMask().Return(subnetMask).AnyTimes()
+
+	gomock.InOrder(
+		mockInstance.EXPECT().ENINetworkCardIndex().Return(int64(1)),
+		mockInstance.EXPECT().GetHighestUnusedDeviceIndex(int64(1)).Return(int64(2), nil),
+		mockEc2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &subnetID, instanceSG, nil, aws.Int64(2),
+			aws.Int64(1), &ENIDescription, nil, 1).Return(networkInterface2, nil),
+	)
+
+	_, err := manager.CreateIPV4Address(1, mockEc2APIHelper, log)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), manager.attachedENIs[0].networkCardIndex)
+
+	mockEc2APIHelper.EXPECT().UnassignPrivateIpAddresses(eniID2, []string{ip6}).Return(nil)
+	mockEc2APIHelper.EXPECT().DeleteNetworkInterface(&eniID2).Return(nil)
+	mockInstance.EXPECT().PrimaryNetworkInterfaceID().Return(eniID1)
+	mockInstance.EXPECT().FreeDeviceIndex(int64(1), int64(2))
+
+	failedToDelete, err := manager.DeleteIPV4Address([]string{ip6}, mockEc2APIHelper, log)
+	assert.NoError(t, err)
+	assert.Empty(t, failedToDelete)
+	assert.Empty(t, manager.attachedENIs)
+}
+
+// TestEniManager_CreateIPV4Address_Linux_RefreshFails tests no ENI is created if the device indexes can't be refreshed
+func TestEniManager_CreateIPV4Address_Linux_RefreshFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+
+	mockInstance.EXPECT().Name().Return(instanceName)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().CandidateSubnets().Return(candidateSubnets)
+	mockInstance.EXPECT().Os().Return(config.OSLinux)
+	mockInstance.EXPECT().RefreshDeviceIndexes(mockEc2APIHelper).Return(mockError)
+
+	_, err := manager.CreateIPV4Address(1, mockEc2APIHelper, log)
+
+	assert.Error(t, err)
+}
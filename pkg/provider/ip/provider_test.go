@@ -14,350 +14,325 @@
 package ip
 
 import (
-	"fmt"
-	"reflect"
+	"context"
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s/pod"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/pool"
-	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider/ip/eni"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/subnet"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/worker"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	ec2Instance "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api/fake"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/warm"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 
+	"github.com/aws/aws-sdk-go/aws"
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 var (
 	nodeName     = "node-1"
 	instanceType = "t3.medium"
+	instanceID   = "i-00000000000000000"
+	vpcID        = "vpc-00000000000000000"
+	subnetID     = "subnet-00000000000000000"
+	clusterName  = "cluster-name"
 
 	ip1 = "192.168.1.1"
 	ip2 = "192.168.1.2"
 	ip3 = "192.168.1.3"
 )
 
-// TestIpv4Provider_difference tests difference removes the difference between an array and a set
-func TestIpv4Provider_difference(t *testing.T) {
-	allIPs := []string{ip1, ip2, ip3}
-	usedIPSet := map[string]struct{}{ip3: {}, ip2: {}}
-
-	unusedIPs := difference(allIPs, usedIPSet)
-	assert.Equal(t, []string{ip1}, unusedIPs)
-}
-
-// TestIpv4Provider_no_difference tests that an empty slice is returned if there is no difference
-func TestIpv4Provider_no_difference(t *testing.T) {
-	allIPs := []string{ip1, ip2}
-	usedIPSet := map[string]struct{}{ip1: {}, ip2: {}}
-
-	unusedIPs := difference(allIPs, usedIPSet)
-	assert.Empty(t, unusedIPs)
-}
-
 // TestNewIPv4Provider_getCapacity tests capacity of different os type
 func TestNewIPv4Provider_getCapacity(t *testing.T) {
-	capacityLinux := getCapacity(instanceType, config.OSLinux)
-	capacityWindows := getCapacity(instanceType, config.OSWindows)
-	capacityUnknown := getCapacity("x.large", "linux")
+	capacityLinux := getCapacity(vpc.Limits[instanceType], config.OSLinux)
+	capacityWindows := getCapacity(vpc.Limits[instanceType], config.OSWindows)
+	capacityUnknown := getCapacity(nil, "linux")
 
 	assert.Zero(t, capacityUnknown)
 	// IP(6) - 1(Primary) = 5
 	assert.Equal(t, 5, capacityWindows)
-	// (IP(6) - 1(Primary)) * 3(ENI) = 15
-	assert.Equal(t, 15, capacityLinux)
-}
-
-// TestNewIPv4Provider_deleteInstanceProviderAndPool tests that the ResourcePoolAndProvider for given node is removed from
-// cache after calling the API
-func TestNewIPv4Provider_deleteInstanceProviderAndPool(t *testing.T) {
-	ipProvider := getMockIpProvider()
-	ipProvider.instanceProviderAndPool[nodeName] = ResourceProviderAndPool{}
-	ipProvider.deleteInstanceProviderAndPool(nodeName)
-	assert.NotContains(t, ipProvider.instanceProviderAndPool, nodeName)
+	// (IP(6) - 1(Primary)) * (3(ENI) - 1(Primary ENI)) = 10
+	assert.Equal(t, 10, capacityLinux)
 }
 
-// TestNewIPv4Provider_getInstanceProviderAndPool tests if the resource pool and provider is present in cache it's returned
-func TestNewIPv4Provider_getInstanceProviderAndPool(t *testing.T) {
-	ipProvider := getMockIpProvider()
-	resourcePoolAndProvider := ResourceProviderAndPool{}
-	ipProvider.instanceProviderAndPool[nodeName] = resourcePoolAndProvider
-	result, found := ipProvider.getInstanceProviderAndPool(nodeName)
-
-	assert.True(t, found)
-	assert.Equal(t, resourcePoolAndProvider, result)
-}
-
-// TestIpv4Provider_putInstanceProviderAndPool tests put stores teh resource pool and provider into the cache
-func TestIpv4Provider_putInstanceProviderAndPool(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockPool := mock_pool.NewMockPool(ctrl)
-	mockManager := mock_eni.NewMockENIManager(ctrl)
-
-	ipProvider := getMockIpProvider()
-	ipProvider.putInstanceProviderAndPool(nodeName, mockPool, mockManager)
-
-	assert.Equal(t, ResourceProviderAndPool{resourcePool: mockPool, eniManager: mockManager}, ipProvider.instanceProviderAndPool[nodeName])
-}
-
-// TestIpv4Provider_updatePoolAndReconcileIfRequired_NoFurtherReconcile tests pool is updated and reconciliation is not
-// performed again
-func TestIpv4Provider_updatePoolAndReconcileIfRequired_NoFurtherReconcile(t *testing.T) {
+// TestIPv4Provider_UpdateResourceCapacity tests the resource capacity is updated by calling the k8s wrapper
+func TestIPv4Provider_UpdateResourceCapacity(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockWorker := mock_worker.NewMockWorker(ctrl)
-	mockPool := mock_pool.NewMockPool(ctrl)
-	provider := ipv4Provider{workerPool: mockWorker}
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
 
-	job := &worker.WarmPoolJob{Operations: worker.OperationCreate}
+	ipv4Provider := ipv4Provider{apiWrapper: api.Wrapper{K8sAPI: mockK8sWrapper}, log: zap.New(zap.UseDevMode(true)).WithName("ip provider")}
 
-	mockPool.EXPECT().UpdatePool(job, true).Return(false)
+	mockInstance.EXPECT().Name().Return(nodeName).Times(2)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().Type().Return(instanceType)
+	mockInstance.EXPECT().Os().Return(config.OSWindows)
+	mockK8sWrapper.EXPECT().AdvertiseCapacityIfNotSet(nodeName, config.ResourceNameIPAddress, 5).Return(nil)
 
-	provider.updatePoolAndReconcileIfRequired(mockPool, job, true)
+	err := ipv4Provider.UpdateResourceCapacity(mockInstance)
+	assert.NoError(t, err)
 }
 
-// TestIpv4Provider_updatePoolAndReconcileIfRequired_ReconcileRequired tests pool is updated and reconciliation is
-// performed again and the job submitted to the worker
-func TestIpv4Provider_updatePoolAndReconcileIfRequired_ReconcileRequired(t *testing.T) {
+// TestIPv4Provider_UpdateResourceCapacity_UnsupportedInstanceType tests an error is returned and no capacity is
+// advertised if the limits of the instance type are not known
+func TestIPv4Provider_UpdateResourceCapacity_UnsupportedInstanceType(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockWorker := mock_worker.NewMockWorker(ctrl)
-	mockPool := mock_pool.NewMockPool(ctrl)
-	provider := ipv4Provider{workerPool: mockWorker}
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
 
-	job := &worker.WarmPoolJob{Operations: worker.OperationCreate}
+	ipv4Provider := ipv4Provider{apiWrapper: api.Wrapper{K8sAPI: mockK8sWrapper}, log: zap.New(zap.UseDevMode(true)).WithName("ip provider")}
 
-	mockPool.EXPECT().UpdatePool(job, true).Return(true)
-	mockPool.EXPECT().ReconcilePool().Return(job)
-	mockWorker.EXPECT().SubmitJob(job)
+	mockInstance.EXPECT().Name().Return(nodeName)
+	mockInstance.EXPECT().Limits().Return(nil, false)
+	mockInstance.EXPECT().Type().Return(instanceType)
+	mockInstance.EXPECT().Os().Return(config.OSWindows)
 
-	provider.updatePoolAndReconcileIfRequired(mockPool, job, true)
+	err := ipv4Provider.UpdateResourceCapacity(mockInstance)
+	assert.Error(t, err)
 }
 
-// TestIpv4Provider_DeletePrivateIPv4AndUpdatePool tests job with empty resources is passed back if some of the resource
-// fail to delete
-func TestIpv4Provider_DeletePrivateIPv4AndUpdatePool(t *testing.T) {
+// TestIPv4Provider_UpdateResourceCapacity_SubnetLimit tests the advertised capacity is limited by the subnet monitor
+func TestIPv4Provider_UpdateResourceCapacity_SubnetLimit(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ipv4Provider := getMockIpProvider()
-	mockPool := mock_pool.NewMockPool(ctrl)
-	mockManager := mock_eni.NewMockENIManager(ctrl)
-	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager)
-	resourcesToDelete := []string{ip1, ip2}
-
-	deleteJob := &worker.WarmPoolJob{
-		Operations:    worker.OperationDeleted,
-		Resources:     resourcesToDelete,
-		ResourceCount: 2,
-		NodeName:      nodeName,
-	}
-
-	mockManager.EXPECT().DeleteIPV4Address(resourcesToDelete, nil, gomock.Any()).Return([]string{}, nil)
-	mockPool.EXPECT().UpdatePool(&worker.WarmPoolJob{
-		Operations:    worker.OperationDeleted,
-		Resources:     []string{},
-		ResourceCount: 2,
-		NodeName:      nodeName,
-	}, true).Return(false)
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
+	mockSubnetMonitor := mock_subnet.NewMockSubnetMonitor(ctrl)
 
-	ipv4Provider.DeletePrivateIPv4AndUpdatePool(deleteJob)
-}
+	mockPool := mock_pool.NewMockPool(ctrl)
+	mockWorker := mock_worker.NewMockWorker(ctrl)
 
-// TestIpv4Provider_DeletePrivateIPv4AndUpdatePool_SomeResourceFail tests if some resource fail to delete those resources
-// are passed back inside the job to the resource pool
-func TestIpv4Provider_DeletePrivateIPv4AndUpdatePool_SomeResourceFail(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
+	ipv4Provider := getMockIpProvider(api.Wrapper{K8sAPI: mockK8sWrapper, SubnetAPI: mockSubnetMonitor}, mockWorker)
 
-	ipv4Provider := getMockIpProvider()
-	mockPool := mock_pool.NewMockPool(ctrl)
-	mockManager := mock_eni.NewMockENIManager(ctrl)
-	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager)
-	resourcesToDelete := []string{ip1, ip2}
-	failedResources := []string{ip2}
-
-	deleteJob := worker.WarmPoolJob{
-		Operations:    worker.OperationDeleted,
-		Resources:     resourcesToDelete,
-		ResourceCount: 2,
-		NodeName:      nodeName,
-	}
+	mockPool.EXPECT().ReconcilePool().Return(&worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired})
+	mockWorker.EXPECT().SubmitJob(gomock.Any())
+	ipv4Provider.AddInstance(nodeName, nil, mockPool)
 
-	mockManager.EXPECT().DeleteIPV4Address(resourcesToDelete, nil, gomock.Any()).Return(failedResources, nil)
-	mockPool.EXPECT().UpdatePool(&worker.WarmPoolJob{
-		Operations:    worker.OperationDeleted,
-		Resources:     failedResources,
-		ResourceCount: 2,
-		NodeName:      nodeName,
-	}, true).Return(false)
+	mockInstance.EXPECT().Name().Return(nodeName).Times(2)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().Type().Return(instanceType)
+	mockInstance.EXPECT().Os().Return(config.OSWindows)
+	mockPool.EXPECT().Introspect().Return(pool.IntrospectResponse{
+		UsedResources:    map[string]string{"pod-1": "192.168.1.1"},
+		WarmResources:    []string{"192.168.1.2", "192.168.1.3"},
+		CoolingResources: []pool.CoolDownResource{{ResourceID: "192.168.1.4"}},
+	})
+	mockSubnetMonitor.EXPECT().LimitCapacity(mockInstance, config.ResourceNameIPAddress, 5, gomock.Any()).
+		DoAndReturn(func(_ ec2Instance.EC2Instance, _ string, _ int, usage func() int) int {
+			// The IPs used, warm and cooling down are held by the node
+			assert.Equal(t, 4, usage())
+			return 2
+		})
+	mockK8sWrapper.EXPECT().AdvertiseCapacityIfNotSet(nodeName, config.ResourceNameIPAddress, 2).Return(nil)
 
-	ipv4Provider.DeletePrivateIPv4AndUpdatePool(&deleteJob)
+	err := ipv4Provider.UpdateResourceCapacity(mockInstance)
+	assert.NoError(t, err)
 }
 
-// TestIPv4Provider_CreatePrivateIPv4AndUpdatePool tests if resources are created then the job object is updated
-// with the resources and the pool is updated
-func TestIPv4Provider_CreatePrivateIPv4AndUpdatePool(t *testing.T) {
+// TestIPv4Provider_InitResource_MultipleIPs tests all the IPs of a pod assigned more than one IP are used after the
+// controller restarts, so they are not assigned to other pods and the pod can free all its IPs
+func TestIPv4Provider_InitResource_MultipleIPs(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ipv4Provider := getMockIpProvider()
-	mockPool := mock_pool.NewMockPool(ctrl)
-	mockManager := mock_eni.NewMockENIManager(ctrl)
-	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager)
-	createdResources := []string{ip1, ip2}
-
-	createJob := &worker.WarmPoolJob{
-		Operations:    worker.OperationCreate,
-		Resources:     []string{},
-		ResourceCount: 2,
-		NodeName:      nodeName,
-	}
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	mockEC2APIHelper := mock_api.NewMockEC2APIHelper(ctrl)
+	mockPodAPI := mock_pod.NewMockPodClientAPIWrapper(ctrl)
+	mockWorker := mock_worker.NewMockWorker(ctrl)
 
-	mockManager.EXPECT().CreateIPV4Address(2, nil, gomock.Any()).Return(createdResources, nil)
-	mockPool.EXPECT().UpdatePool(&worker.WarmPoolJob{
-		Operations:    worker.OperationCreate,
-		Resources:     createdResources,
-		ResourceCount: 2,
-		NodeName:      nodeName,
-	}, true).Return(false)
+	ipv4Provider := getMockIpProvider(api.Wrapper{EC2API: mockEC2APIHelper, PodAPI: mockPodAPI}, mockWorker)
+
+	mockInstance.EXPECT().Name().Return(nodeName).AnyTimes()
+	mockInstance.EXPECT().InstanceID().Return("i-00000000000000000").AnyTimes()
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true).AnyTimes()
+	mockInstance.EXPECT().Type().Return(instanceType).AnyTimes()
+	mockInstance.EXPECT().Os().Return(config.OSWindows).AnyTimes()
+	mockInstance.EXPECT().CandidateSubnets().Return(nil)
+	mockInstance.EXPECT().SubnetMask().Return("20").AnyTimes()
+
+	mockEC2APIHelper.EXPECT().GetInstanceNetworkInterface(aws.String("i-00000000000000000")).Return(
+		[]*awsEC2.InstanceNetworkInterface{{
+			NetworkInterfaceId: aws.String("eni-1"),
+			Attachment:         &awsEC2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(0)},
+			PrivateIpAddresses: []*awsEC2.InstancePrivateIpAddress{
+				{PrivateIpAddress: aws.String("192.168.1.0"), Primary: aws.Bool(true)},
+				{PrivateIpAddress: aws.String(ip1), Primary: aws.Bool(false)},
+				{PrivateIpAddress: aws.String(ip2), Primary: aws.Bool(false)},
+				{PrivateIpAddress: aws.String(ip3), Primary: aws.Bool(false)},
+			},
+		}}, nil)
+	mockPodAPI.EXPECT().GetRunningPodsOnNode(nodeName).Return([]v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{
+			UID: "uid-1",
+			Annotations: map[string]string{
+				config.ResourceNameIPAddress:   ip1 + "/20",
+				config.IPv4AddressesAnnotation: `["` + ip1 + `/20","` + ip2 + `/20"]`,
+			},
+		},
+	}}, nil)
+	mockWorker.EXPECT().SubmitJob(gomock.Any())
+
+	err := ipv4Provider.InitResource(mockInstance)
+	assert.NoError(t, err)
 
-	ipv4Provider.CreatePrivateIPv4AndUpdatePool(createJob)
-}
+	resourcePool, found := ipv4Provider.GetPool(nodeName)
+	assert.True(t, found)
+	assert.Equal(t, []string{ip3 + "/20"}, resourcePool.Introspect().WarmResources)
 
-// TestIPv4Provider_CreatePrivateIPv4AndUpdatePool_Fail tests that if some of the create fails then the pool is
-// updated with the created resource and success status as false
-func TestIPv4Provider_CreatePrivateIPv4AndUpdatePool_Fail(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
+	_, err = resourcePool.FreeResources("uid-1", []string{ip1 + "/20", ip2 + "/20"})
+	assert.NoError(t, err)
+}
 
-	ipv4Provider := getMockIpProvider()
-	mockPool := mock_pool.NewMockPool(ctrl)
-	mockManager := mock_eni.NewMockENIManager(ctrl)
-	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager)
-	createdResources := []string{ip1, ip2}
-
-	createJob := &worker.WarmPoolJob{
-		Operations:    worker.OperationCreate,
-		Resources:     []string{},
-		ResourceCount: 2,
-		NodeName:      nodeName,
+func getMockIpProvider(apiWrapper api.Wrapper, workerPool worker.Worker) *ipv4Provider {
+	log := zap.New(zap.UseDevMode(true)).WithName("ip provider")
+	return &ipv4Provider{
+		Provider: warm.NewProvider(log, apiWrapper, workerPool, config.ResourceConfig{
+			Name:           config.ResourceNameIPAddress,
+			WarmPoolConfig: &config.WarmPoolConfig{DesiredSize: 1},
+		}),
+		log:        log,
+		apiWrapper: apiWrapper,
 	}
-
-	mockManager.EXPECT().CreateIPV4Address(2, nil, gomock.Any()).Return(createdResources, fmt.Errorf("failed"))
-	mockPool.EXPECT().UpdatePool(&worker.WarmPoolJob{
-		Operations:    worker.OperationCreate,
-		Resources:     createdResources,
-		ResourceCount: 2,
-		NodeName:      nodeName,
-	}, false).Return(false)
-
-	ipv4Provider.CreatePrivateIPv4AndUpdatePool(createJob)
 }
 
-func TestIpv4Provider_ReSyncPool(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
+// cleanerManager is the manager the ENI cleaner is added to, the cleaner is started by the test
+type cleanerManager struct {
+	manager.Manager
+}
 
-	ipv4Provider := getMockIpProvider()
-	mockPool := mock_pool.NewMockPool(ctrl)
-	mockManager := mock_eni.NewMockENIManager(ctrl)
-	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager)
-	resources := []string{ip1, ip2}
+func (m *cleanerManager) Add(_ manager.Runnable) error {
+	return nil
+}
 
-	reSyncJob := &worker.WarmPoolJob{
-		Operations: worker.OperationReSyncPool,
-		NodeName:   nodeName,
+// popJob removes and returns the first submitted warm pool job with the operation
+func popJob(t *testing.T, jobs *[]*worker.WarmPoolJob, operation worker.Operations) *worker.WarmPoolJob {
+	for i, job := range *jobs {
+		if job.Operations == operation {
+			*jobs = append((*jobs)[:i], (*jobs)[i+1:]...)
+			return job
+		}
 	}
-
-	// When error occurs, pool should not be re-synced
-	mockManager.EXPECT().InitResources(ipv4Provider.apiWrapper.EC2API).Return(nil, fmt.Errorf(""))
-	ipv4Provider.ReSyncPool(reSyncJob)
-
-	// When no error occurs, pool should be re-synced
-	mockManager.EXPECT().InitResources(ipv4Provider.apiWrapper.EC2API).Return(resources, nil)
-	mockPool.EXPECT().ReSync(resources)
-	ipv4Provider.ReSyncPool(reSyncJob)
+	t.Fatalf("no %s job submitted", operation)
+	return nil
 }
 
-// TestIPv4Provider_SubmitAsyncJob tests that the job is submitted to the worker on calling SubmitAsyncJob
-func TestIPv4Provider_SubmitAsyncJob(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockWorker := mock_worker.NewMockWorker(ctrl)
-	ipv4Provider := ipv4Provider{workerPool: mockWorker}
-
-	job := worker.NewWarmPoolDeleteJob(nodeName, nil)
-
-	mockWorker.EXPECT().SubmitJob(job)
-
-	ipv4Provider.SubmitAsyncJob(job)
+// getSecondaryENI returns the network interface attached by the controller, at a non zero device index
+func getSecondaryENI(t *testing.T, nwInterfaces []*awsEC2.InstanceNetworkInterface) *awsEC2.InstanceNetworkInterface {
+	for _, nwInterface := range nwInterfaces {
+		if aws.Int64Value(nwInterface.Attachment.DeviceIndex) != 0 {
+			return nwInterface
+		}
+	}
+	t.Fatal("no secondary network interface attached")
+	return nil
 }
 
-// TestIPv4Provider_UpdateResourceCapacity tests the resource capacity is updated by calling the k8s wrapper
-func TestIPv4Provider_UpdateResourceCapacity(t *testing.T) {
+// TestIPv4Provider_EndToEnd tests the IPv4 addresses of a Linux node are created on a new ENI, assigned and freed,
+// the ENI cleaner deletes the leaked ENI but not the attached ENI, and the ENI is deleted with its last address
+// against the EC2 simulator
+func TestIPv4Provider_EndToEnd(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
-	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
+	coolDownPeriod, cleanUpInterval := config.CoolDownPeriod, config.ENICleanUpInterval
+	defer func() {
+		config.CoolDownPeriod, config.ENICleanUpInterval = coolDownPeriod, cleanUpInterval
+	}()
+	config.CoolDownPeriod, config.ENICleanUpInterval = 0, time.Millisecond
 
-	ipv4Provider := ipv4Provider{apiWrapper: api.Wrapper{K8sAPI: mockK8sWrapper}, log: zap.New(zap.UseDevMode(true)).WithName("ip provider")}
+	ec2Fake := fake.NewEC2()
+	assert.NoError(t, ec2Fake.AddVPC(vpcID, "192.168.0.0/16"))
+	assert.NoError(t, ec2Fake.AddSubnet(subnetID, vpcID, "us-west-2a", "192.168.0.0/24", ""))
+	assert.NoError(t, ec2Fake.AddInstance(fake.Instance{ID: instanceID, InstanceType: instanceType,
+		SubnetID: subnetID}))
+	ec2APIHelper := ec2API.NewEC2APIHelper(ec2Fake, clusterName)
 
-	mockInstance.EXPECT().Name().Return(nodeName).Times(2)
-	mockInstance.EXPECT().Type().Return(instanceType)
-	mockInstance.EXPECT().Os().Return(config.OSWindows)
-	mockK8sWrapper.EXPECT().AdvertiseCapacityIfNotSet(nodeName, config.ResourceNameIPAddress, 5).Return(nil)
-
-	err := ipv4Provider.UpdateResourceCapacity(mockInstance)
-	assert.NoError(t, err)
-}
+	instance := ec2Instance.NewEC2Instance(nodeName, instanceID, config.OSLinux)
+	assert.NoError(t, instance.LoadDetails(ec2APIHelper))
 
-func TestIpv4Provider_GetPool(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	ipv4Provider := getMockIpProvider()
-	mockPool := mock_pool.NewMockPool(ctrl)
-	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, nil)
-
-	pool, found := ipv4Provider.GetPool(nodeName)
+	mockPodAPI := mock_pod.NewMockPodClientAPIWrapper(ctrl)
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+	var jobs []*worker.WarmPoolJob
+	mockWorker.EXPECT().SubmitJob(gomock.Any()).Do(func(job interface{}) {
+		jobs = append(jobs, job.(*worker.WarmPoolJob))
+	}).AnyTimes()
+	mockPodAPI.EXPECT().GetRunningPodsOnNode(nodeName).Return(nil, nil)
+
+	ipv4Provider := getMockIpProvider(api.Wrapper{EC2API: ec2APIHelper, PodAPI: mockPodAPI}, mockWorker)
+	assert.NoError(t, ipv4Provider.InitResource(instance))
+	resourcePool, found := ipv4Provider.GetPool(nodeName)
 	assert.True(t, found)
-	assert.Equal(t, mockPool, pool)
-}
-
-func TestIpv4Provider_Introspect(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	ipv4Provider := getMockIpProvider()
-	mockPool := mock_pool.NewMockPool(ctrl)
-	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, nil)
-	expectedResp := pool.IntrospectResponse{}
 
-	mockPool.EXPECT().Introspect().Return(expectedResp)
-	resp := ipv4Provider.Introspect()
-	assert.True(t, reflect.DeepEqual(resp, map[string]pool.IntrospectResponse{nodeName: expectedResp}))
+	// The primary ENI is left to the VPC CNI, the warm pool is filled on a new ENI
+	_, err := ipv4Provider.ProcessAsyncJob(popJob(t, &jobs, worker.OperationCreate))
+	assert.NoError(t, err)
+	nwInterfaces, err := ec2APIHelper.GetInstanceNetworkInterface(&instanceID)
+	assert.NoError(t, err)
+	assert.Len(t, nwInterfaces, 2)
+	eni := getSecondaryENI(t, nwInterfaces)
+	eniID := aws.StringValue(eni.NetworkInterfaceId)
 
-	mockPool.EXPECT().Introspect().Return(expectedResp)
-	resp = ipv4Provider.IntrospectNode(nodeName)
-	assert.Equal(t, resp, expectedResp)
+	ip, shouldReconcile, err := resourcePool.AssignResource("pod-1")
+	assert.NoError(t, err)
+	assert.True(t, shouldReconcile)
+	_, err = ipv4Provider.ProcessAsyncJob(resourcePool.ReconcilePool())
+	assert.NoError(t, err)
+	nwInterfaces, err = ec2APIHelper.GetInstanceNetworkInterface(&instanceID)
+	assert.NoError(t, err)
+	assert.Len(t, getSecondaryENI(t, nwInterfaces).PrivateIpAddresses, 3)
 
-	resp = ipv4Provider.IntrospectNode("unregistered-node")
-	assert.Equal(t, resp, struct{}{})
-}
+	// The leaked ENI is deleted after two clean up cycles, the attached ENI is kept
+	leakedENI, err := ec2APIHelper.CreateNetworkInterface(aws.String("leaked"), &subnetID, nil, nil, 0, nil)
+	assert.NoError(t, err)
+	cleaner := &ec2API.ENICleaner{EC2Wrapper: ec2Fake, ClusterName: clusterName, Log: zap.New(zap.UseDevMode(true))}
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, cleaner.SetupWithManager(ctx, &cleanerManager{}))
+	done := make(chan error)
+	go func() {
+		done <- cleaner.Start(ctx)
+	}()
+	assert.Eventually(t, func() bool {
+		_, err := ec2Fake.DescribeNetworkInterfaces(&awsEC2.DescribeNetworkInterfacesInput{
+			NetworkInterfaceIds: []*string{leakedENI.NetworkInterfaceId}})
+		return err != nil
+	}, time.Second, time.Millisecond)
+	cancel()
+	assert.NoError(t, <-done)
+	_, err = ec2Fake.DescribeNetworkInterfaces(&awsEC2.DescribeNetworkInterfacesInput{
+		NetworkInterfaceIds: []*string{&eniID}})
+	assert.NoError(t, err)
 
-func getMockIpProvider() ipv4Provider {
-	return ipv4Provider{instanceProviderAndPool: map[string]ResourceProviderAndPool{},
-		log: zap.New(zap.UseDevMode(true)).WithName("ip provider")}
+	// The ENI is deleted once all its addresses are freed and deleted
+	_, err = resourcePool.FreeResource("pod-1", ip)
+	assert.NoError(t, err)
+	resourcePool.ProcessCoolDownQueue()
+	_, err = ipv4Provider.ProcessAsyncJob(resourcePool.DrainResources(resourcePool.Introspect().WarmResources))
+	assert.NoError(t, err)
+	nwInterfaces, err = ec2APIHelper.GetInstanceNetworkInterface(&instanceID)
+	assert.NoError(t, err)
+	assert.Len(t, nwInterfaces, 1)
+	_, err = ec2Fake.DescribeNetworkInterfaces(&awsEC2.DescribeNetworkInterfacesInput{
+		NetworkInterfaceIds: []*string{&eniID}})
+	assert.Error(t, err)
 }
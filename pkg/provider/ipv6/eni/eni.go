@@ -0,0 +1,195 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eni
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/go-logr/logr"
+)
+
+const (
+	// defaultSubnetV6Mask is the mask of the IPv6 CIDR block of the VPC subnets
+	defaultSubnetV6Mask = "64"
+)
+
+type eniManager struct {
+	// instance is the pointer to the instance details
+	instance ec2.EC2Instance
+	// usePrefixes assigns /80 IPv6 prefixes instead of IPv6 addresses
+	usePrefixes bool
+	// lock to prevent multiple routines concurrently accessing the eni for same node
+	lock sync.Mutex // lock guards the following resources
+	// remainingCapacity is the number of IPv6 addresses or prefixes that can still be assigned to the primary ENI
+	remainingCapacity int
+}
+
+type ENIManager interface {
+	InitResources(ec2APIHelper api.EC2APIHelper) ([]string, error)
+	CreateIPv6Address(required int, ec2APIHelper api.EC2APIHelper, log logr.Logger) ([]string, error)
+	DeleteIPv6Address(addresses []string, ec2APIHelper api.EC2APIHelper, log logr.Logger) ([]string, error)
+}
+
+// NewENIManager returns a new ENI Manager that assigns the IPv6 addresses or prefixes to the primary network
+// interface of the instance
+func NewENIManager(instance ec2.EC2Instance, usePrefixes bool) *eniManager {
+	return &eniManager{
+		instance:    instance,
+		usePrefixes: usePrefixes,
+	}
+}
+
+// InitResources loads the list of IPv6 addresses or prefixes assigned to the primary network interface
+func (e *eniManager) InitResources(ec2APIHelper api.EC2APIHelper) ([]string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	nwInterfaces, err := ec2APIHelper.GetInstanceNetworkInterface(aws.String(e.instance.InstanceID()))
+	if err != nil {
+		return nil, err
+	}
+
+	limits, found := e.instance.Limits()
+	if !found {
+		return nil, fmt.Errorf("unsupported instance type")
+	}
+
+	var addresses []string
+	primaryENIID := e.instance.PrimaryNetworkInterfaceID()
+	primaryIPv6Address := e.instance.PrimaryIPv6Address()
+	for _, nwInterface := range nwInterfaces {
+		if aws.StringValue(nwInterface.NetworkInterfaceId) != primaryENIID {
+			continue
+		}
+		if e.usePrefixes {
+			for _, prefix := range nwInterface.Ipv6Prefixes {
+				addresses = append(addresses, aws.StringValue(prefix.Ipv6Prefix))
+			}
+		} else {
+			for _, address := range nwInterface.Ipv6Addresses {
+				// The IPv6 address of the instance is not available to the pods
+				if aws.StringValue(address.Ipv6Address) != primaryIPv6Address {
+					addresses = append(addresses, aws.StringValue(address.Ipv6Address))
+				}
+			}
+		}
+	}
+	e.remainingCapacity = getCapacity(limits) - len(addresses)
+
+	return e.addSubnetMaskToAddressSlice(addresses), nil
+}
+
+// CreateIPv6Address assigns IPv6 addresses or prefixes to the primary network interface and returns the list of
+// assigned addresses along with the error if not all the required addresses were assigned
+func (e *eniManager) CreateIPv6Address(required int, ec2APIHelper api.EC2APIHelper, log logr.Logger) ([]string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	log = log.WithValues("node name", e.instance.Name())
+
+	canAssign := required
+	if e.remainingCapacity < canAssign {
+		canAssign = e.remainingCapacity
+	}
+
+	var assignedAddresses []string
+	var err error
+	if canAssign > 0 {
+		assignedAddresses, err = ec2APIHelper.AssignIPv6AddressesAndWaitTillReady(
+			e.instance.PrimaryNetworkInterfaceID(), canAssign, e.usePrefixes)
+		if err != nil && len(assignedAddresses) == 0 {
+			return nil, err
+		} else if err != nil {
+			// Just log and continue processing the assigned addresses
+			log.Error(err, "failed to assign all the requested IPv6 addresses",
+				"requested", canAssign, "got", len(assignedAddresses))
+		}
+		e.remainingCapacity -= len(assignedAddresses)
+		log.Info("assigned IPv6 addresses", "addresses", assignedAddresses,
+			"eni", e.instance.PrimaryNetworkInterfaceID(), "use prefixes", e.usePrefixes)
+	}
+
+	if len(assignedAddresses) < required {
+		err = fmt.Errorf("not able to create the desired number of IPv6 addresses, required %d, created %d",
+			required, len(assignedAddresses))
+	}
+
+	return e.addSubnetMaskToAddressSlice(assignedAddresses), err
+}
+
+// DeleteIPv6Address unassigns the list of IPv6 addresses or prefixes and returns the list of addresses that failed
+// to unassign along with the error
+func (e *eniManager) DeleteIPv6Address(addresses []string, ec2APIHelper api.EC2APIHelper,
+	log logr.Logger) ([]string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	log = log.WithValues("node name", e.instance.Name())
+
+	toUnassign := e.stripSubnetMaskFromAddressSlice(addresses)
+	err := ec2APIHelper.UnassignIPv6Addresses(e.instance.PrimaryNetworkInterfaceID(), toUnassign, e.usePrefixes)
+	if err != nil {
+		log.Info("failed to unassign IPv6 addresses", "eni", e.instance.PrimaryNetworkInterfaceID(),
+			"addresses", toUnassign)
+		return addresses, err
+	}
+	e.remainingCapacity += len(addresses)
+
+	log.Info("unassigned IPv6 addresses", "eni", e.instance.PrimaryNetworkInterfaceID(),
+		"addresses", toUnassign)
+
+	return nil, nil
+}
+
+// addSubnetMaskToAddressSlice adds the mask of the subnet IPv6 CIDR block to the addresses, the prefixes
+// already have the prefix length
+func (e *eniManager) addSubnetMaskToAddressSlice(addresses []string) []string {
+	if e.usePrefixes {
+		return addresses
+	}
+	subnetMask := e.instance.SubnetV6Mask()
+	if subnetMask == "" {
+		subnetMask = defaultSubnetV6Mask
+	}
+	for i := 0; i < len(addresses); i++ {
+		addresses[i] = addresses[i] + "/" + subnetMask
+	}
+	return addresses
+}
+
+// stripSubnetMaskFromAddressSlice returns the addresses without the subnet mask, the prefixes are returned
+// as it is
+func (e *eniManager) stripSubnetMaskFromAddressSlice(addresses []string) []string {
+	if e.usePrefixes {
+		return addresses
+	}
+	stripped := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		stripped = append(stripped, strings.Split(address, "/")[0])
+	}
+	return stripped
+}
+
+// getCapacity returns the number of IPv6 addresses or prefixes that can be assigned to the primary network
+// interface. The IPv6 addresses and prefixes per interface are limited to the IPv4 addresses per interface
+func getCapacity(limits *vpc.VPCLimits) int {
+	return limits.IPv4PerInterface - 1
+}
@@ -0,0 +1,210 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eni
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	instanceID   = "i-000000000000001"
+	instanceName = "ip-192-0-2-1.us-west-2.compute.internal"
+	instanceType = "t3.small"
+
+	primaryENIID   = "eni-000000000000001"
+	secondaryENIID = "eni-000000000000002"
+
+	subnetV6Mask = "64"
+
+	nodeIPv6Address = "2600::1"
+	ipv6Address1    = "2600::2"
+	ipv6Address2    = "2600::3"
+	ipv6Address3    = "2600::4"
+	prefix1         = "2600:0:0:1::/80"
+	prefix2         = "2600:0:0:2::/80"
+
+	nwInterfaces = []*ec2.InstanceNetworkInterface{
+		{
+			NetworkInterfaceId: &primaryENIID,
+			Ipv6Addresses: []*ec2.InstanceIpv6Address{
+				{Ipv6Address: &nodeIPv6Address},
+				{Ipv6Address: &ipv6Address1},
+			},
+			Ipv6Prefixes: []*ec2.InstanceIpv6Prefix{
+				{Ipv6Prefix: &prefix1},
+			},
+		},
+		{
+			NetworkInterfaceId: &secondaryENIID,
+			Ipv6Addresses: []*ec2.InstanceIpv6Address{
+				{Ipv6Address: &ipv6Address2},
+			},
+		},
+	}
+
+	mockError = fmt.Errorf("mock-error")
+
+	log = zap.New(zap.UseDevMode(true)).WithName("ipv6 eni manager")
+)
+
+func getMockManager(ctrl *gomock.Controller, usePrefixes bool) (*eniManager, *mock_ec2.MockEC2Instance,
+	*mock_api.MockEC2APIHelper) {
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	mockEC2APIHelper := mock_api.NewMockEC2APIHelper(ctrl)
+
+	mockInstance.EXPECT().InstanceID().Return(instanceID).AnyTimes()
+	mockInstance.EXPECT().Name().Return(instanceName).AnyTimes()
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true).AnyTimes()
+	mockInstance.EXPECT().PrimaryNetworkInterfaceID().Return(primaryENIID).AnyTimes()
+	mockInstance.EXPECT().PrimaryIPv6Address().Return(nodeIPv6Address).AnyTimes()
+	mockInstance.EXPECT().SubnetV6Mask().Return(subnetV6Mask).AnyTimes()
+
+	return NewENIManager(mockInstance, usePrefixes), mockInstance, mockEC2APIHelper
+}
+
+// TestEniManager_InitResources tests that the IPv6 addresses of the primary network interface are loaded except
+// the IPv6 address of the instance
+func TestEniManager_InitResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _, mockEC2APIHelper := getMockManager(ctrl, false)
+
+	mockEC2APIHelper.EXPECT().GetInstanceNetworkInterface(&instanceID).Return(nwInterfaces, nil)
+
+	addresses, err := manager.InitResources(mockEC2APIHelper)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ipv6Address1 + "/" + subnetV6Mask}, addresses)
+	assert.Equal(t, 2, manager.remainingCapacity)
+}
+
+// TestEniManager_InitResources_Prefixes tests that the IPv6 prefixes of the primary network interface are loaded
+func TestEniManager_InitResources_Prefixes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _, mockEC2APIHelper := getMockManager(ctrl, true)
+
+	mockEC2APIHelper.EXPECT().GetInstanceNetworkInterface(&instanceID).Return(nwInterfaces, nil)
+
+	prefixes, err := manager.InitResources(mockEC2APIHelper)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{prefix1}, prefixes)
+	assert.Equal(t, 2, manager.remainingCapacity)
+}
+
+// TestEniManager_InitResources_Error tests that the error is returned if the network interfaces can't be loaded
+func TestEniManager_InitResources_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _, mockEC2APIHelper := getMockManager(ctrl, false)
+
+	mockEC2APIHelper.EXPECT().GetInstanceNetworkInterface(&instanceID).Return(nil, mockError)
+
+	_, err := manager.InitResources(mockEC2APIHelper)
+	assert.Equal(t, mockError, err)
+}
+
+// TestEniManager_CreateIPv6Address tests that the IPv6 addresses are assigned to the primary network interface
+// up to the remaining capacity
+func TestEniManager_CreateIPv6Address(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _, mockEC2APIHelper := getMockManager(ctrl, false)
+	manager.remainingCapacity = 2
+
+	mockEC2APIHelper.EXPECT().AssignIPv6AddressesAndWaitTillReady(primaryENIID, 2, false).
+		Return([]string{ipv6Address2, ipv6Address3}, nil)
+
+	addresses, err := manager.CreateIPv6Address(3, mockEC2APIHelper, log)
+	assert.Error(t, err)
+	assert.Equal(t, []string{ipv6Address2 + "/" + subnetV6Mask, ipv6Address3 + "/" + subnetV6Mask}, addresses)
+	assert.Equal(t, 0, manager.remainingCapacity)
+}
+
+// TestEniManager_CreateIPv6Address_Prefixes tests that the prefixes are returned without adding the subnet mask
+func TestEniManager_CreateIPv6Address_Prefixes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _, mockEC2APIHelper := getMockManager(ctrl, true)
+	manager.remainingCapacity = 2
+
+	mockEC2APIHelper.EXPECT().AssignIPv6AddressesAndWaitTillReady(primaryENIID, 1, true).
+		Return([]string{prefix2}, nil)
+
+	prefixes, err := manager.CreateIPv6Address(1, mockEC2APIHelper, log)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{prefix2}, prefixes)
+	assert.Equal(t, 1, manager.remainingCapacity)
+}
+
+// TestEniManager_CreateIPv6Address_Error tests that the error is returned if no address could be assigned
+func TestEniManager_CreateIPv6Address_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _, mockEC2APIHelper := getMockManager(ctrl, false)
+	manager.remainingCapacity = 2
+
+	mockEC2APIHelper.EXPECT().AssignIPv6AddressesAndWaitTillReady(primaryENIID, 1, false).
+		Return(nil, mockError)
+
+	addresses, err := manager.CreateIPv6Address(1, mockEC2APIHelper, log)
+	assert.Equal(t, mockError, err)
+	assert.Empty(t, addresses)
+	assert.Equal(t, 2, manager.remainingCapacity)
+}
+
+// TestEniManager_DeleteIPv6Address tests that the addresses are unassigned without the subnet mask
+func TestEniManager_DeleteIPv6Address(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _, mockEC2APIHelper := getMockManager(ctrl, false)
+
+	mockEC2APIHelper.EXPECT().UnassignIPv6Addresses(primaryENIID, []string{ipv6Address1}, false).Return(nil)
+
+	failed, err := manager.DeleteIPv6Address([]string{ipv6Address1 + "/" + subnetV6Mask}, mockEC2APIHelper, log)
+	assert.NoError(t, err)
+	assert.Empty(t, failed)
+	assert.Equal(t, 1, manager.remainingCapacity)
+}
+
+// TestEniManager_DeleteIPv6Address_Error tests that the addresses are returned as failed if the unassign call fails
+func TestEniManager_DeleteIPv6Address_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, _, mockEC2APIHelper := getMockManager(ctrl, true)
+
+	mockEC2APIHelper.EXPECT().UnassignIPv6Addresses(primaryENIID, []string{prefix1}, true).Return(mockError)
+
+	failed, err := manager.DeleteIPv6Address([]string{prefix1}, mockEC2APIHelper, log)
+	assert.Equal(t, mockError, err)
+	assert.Equal(t, []string{prefix1}, failed)
+	assert.Equal(t, 0, manager.remainingCapacity)
+}
@@ -0,0 +1,131 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipv6
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/ipv6/eni"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/warm"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	"github.com/go-logr/logr"
+)
+
+type ipv6Provider struct {
+	// Provider implements the warm pool workflow for the IPv6 addresses or prefixes
+	*warm.Provider
+	// log is the logger initialized with ipv6 provider details
+	log logr.Logger
+	// apiWrapper wraps all clients used by the controller
+	apiWrapper api.Wrapper
+	// usePrefixes allocates a /80 IPv6 prefix instead of an IPv6 address for each resource
+	usePrefixes bool
+}
+
+// ipv6ResourceManager creates and deletes the IPv6 addresses or prefixes of the instance's primary network interface
+// for the warm pool
+type ipv6ResourceManager struct {
+	eni.ENIManager
+}
+
+func (m ipv6ResourceManager) CreateResources(required int, ec2APIHelper ec2API.EC2APIHelper,
+	log logr.Logger) ([]string, error) {
+	return m.CreateIPv6Address(required, ec2APIHelper, log)
+}
+
+func (m ipv6ResourceManager) DeleteResources(addresses []string, ec2APIHelper ec2API.EC2APIHelper,
+	log logr.Logger) ([]string, error) {
+	return m.DeleteIPv6Address(addresses, ec2APIHelper, log)
+}
+
+func NewIPv6Provider(log logr.Logger, apiWrapper api.Wrapper,
+	workerPool worker.Worker, resourceConfig config.ResourceConfig) provider.ResourceProvider {
+	return &ipv6Provider{
+		Provider:    warm.NewProvider(log, apiWrapper, workerPool, resourceConfig),
+		log:         log,
+		apiWrapper:  apiWrapper,
+		usePrefixes: resourceConfig.UsePrefixes,
+	}
+}
+
+func (p *ipv6Provider) InitResource(instance ec2.EC2Instance) error {
+	nodeName := instance.Name()
+
+	resourceManager := ipv6ResourceManager{ENIManager: eni.NewENIManager(instance, p.usePrefixes)}
+	limits, found := instance.Limits()
+	if !found {
+		return fmt.Errorf("unsupported instance type %s", instance.Type())
+	}
+	nodeCapacity := getCapacity(limits)
+	resourcePool, err := p.LoadPool(instance, resourceManager, nodeCapacity)
+	if err != nil {
+		return err
+	}
+
+	// All the IPv6 addresses are assigned to the primary network interface
+	resourcePool.SetResourcesPerENI(nodeCapacity)
+	if p.apiWrapper.EndpointAPI != nil {
+		// Release the freed addresses as soon as they are removed from all the endpoints, a prefix is
+		// released once none of its addresses is present in the endpoints
+		if p.usePrefixes {
+			resourcePool.SetInUseChecker(p.apiWrapper.EndpointAPI.IsPrefixInUse)
+		} else {
+			resourcePool.SetInUseChecker(p.apiWrapper.EndpointAPI.IsAddressInUse)
+		}
+	}
+
+	p.log.Info("initialized the resource provider for resource IPv6",
+		"capacity", nodeCapacity, "node name", nodeName, "instance type",
+		instance.Type(), "instance ID", instance.InstanceID(), "use prefixes", p.usePrefixes)
+
+	p.AddInstance(nodeName, resourceManager, resourcePool)
+	return nil
+}
+
+// UpdateResourceCapacity updates the resource capacity based on the type of instance
+func (p *ipv6Provider) UpdateResourceCapacity(instance ec2.EC2Instance) error {
+	instanceType := instance.Type()
+	instanceName := instance.Name()
+
+	limits, found := instance.Limits()
+	if !found {
+		return fmt.Errorf("unsupported instance type %s", instanceType)
+	}
+	capacity := getCapacity(limits)
+
+	err := p.apiWrapper.K8sAPI.AdvertiseCapacityIfNotSet(instanceName, config.ResourceNameIPv6Address, capacity)
+	if err != nil {
+		return err
+	}
+	p.log.V(1).Info("advertised capacity",
+		"instance", instanceName, "instance type", instanceType, "capacity", capacity)
+
+	return nil
+}
+
+// getCapacity returns the number of IPv6 addresses or prefixes that can be assigned to the primary network
+// interface of the instance type, the capacity is 0 if the limits are unknown
+func getCapacity(limits *vpc.VPCLimits) int {
+	if limits == nil {
+		return 0
+	}
+	return limits.IPv4PerInterface - 1
+}
@@ -0,0 +1,154 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipv6
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s/pod"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/worker"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/warm"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	nodeName     = "node-1"
+	instanceType = "t3.medium"
+	instanceID   = "i-00000000000000000"
+	primaryENIID = "eni-00000000000000000"
+
+	address1 = "2600::1"
+	address2 = "2600::2"
+	address3 = "2600::3"
+)
+
+func getMockIPv6Provider(apiWrapper api.Wrapper, workerPool worker.Worker) *ipv6Provider {
+	log := zap.New(zap.UseDevMode(true)).WithName("ipv6 provider")
+	return &ipv6Provider{
+		Provider: warm.NewProvider(log, apiWrapper, workerPool, config.ResourceConfig{
+			Name:           config.ResourceNameIPv6Address,
+			WarmPoolConfig: &config.WarmPoolConfig{DesiredSize: 1},
+		}),
+		log:        log,
+		apiWrapper: apiWrapper,
+	}
+}
+
+// TestIPv6Provider_getCapacity tests the capacity is the number of secondary addresses of the primary interface
+func TestIPv6Provider_getCapacity(t *testing.T) {
+	assert.Equal(t, 5, getCapacity(vpc.Limits[instanceType]))
+	assert.Equal(t, 0, getCapacity(nil))
+}
+
+// TestIPv6Provider_UpdateResourceCapacity tests the IPv6 resource capacity is advertised on the node
+func TestIPv6Provider_UpdateResourceCapacity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
+	ipv6Provider := getMockIPv6Provider(api.Wrapper{K8sAPI: mockK8sWrapper}, nil)
+
+	mockInstance.EXPECT().Name().Return(nodeName)
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true)
+	mockInstance.EXPECT().Type().Return(instanceType)
+	mockK8sWrapper.EXPECT().AdvertiseCapacityIfNotSet(nodeName, config.ResourceNameIPv6Address, 5).Return(nil)
+
+	err := ipv6Provider.UpdateResourceCapacity(mockInstance)
+	assert.NoError(t, err)
+}
+
+// TestIPv6Provider_UpdateResourceCapacity_UnsupportedInstanceType tests an error is returned and no capacity is
+// advertised if the limits of the instance type are not known
+func TestIPv6Provider_UpdateResourceCapacity_UnsupportedInstanceType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
+	ipv6Provider := getMockIPv6Provider(api.Wrapper{K8sAPI: mockK8sWrapper}, nil)
+
+	mockInstance.EXPECT().Name().Return(nodeName)
+	mockInstance.EXPECT().Limits().Return(nil, false)
+	mockInstance.EXPECT().Type().Return(instanceType)
+
+	err := ipv6Provider.UpdateResourceCapacity(mockInstance)
+	assert.Error(t, err)
+}
+
+// TestIPv6Provider_InitResource_MultipleAddresses tests all the addresses of a pod assigned more than one address are
+// used after the controller restarts, so they are not assigned to other pods and the pod can free all its addresses
+func TestIPv6Provider_InitResource_MultipleAddresses(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	mockEC2APIHelper := mock_api.NewMockEC2APIHelper(ctrl)
+	mockPodAPI := mock_pod.NewMockPodClientAPIWrapper(ctrl)
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+
+	ipv6Provider := getMockIPv6Provider(api.Wrapper{EC2API: mockEC2APIHelper, PodAPI: mockPodAPI}, mockWorker)
+
+	mockInstance.EXPECT().Name().Return(nodeName).AnyTimes()
+	mockInstance.EXPECT().InstanceID().Return(instanceID).AnyTimes()
+	mockInstance.EXPECT().Limits().Return(vpc.Limits[instanceType], true).AnyTimes()
+	mockInstance.EXPECT().Type().Return(instanceType).AnyTimes()
+	mockInstance.EXPECT().PrimaryNetworkInterfaceID().Return(primaryENIID)
+	mockInstance.EXPECT().PrimaryIPv6Address().Return("")
+	mockInstance.EXPECT().SubnetV6Mask().Return("80")
+
+	mockEC2APIHelper.EXPECT().GetInstanceNetworkInterface(aws.String(instanceID)).Return(
+		[]*awsEC2.InstanceNetworkInterface{{
+			NetworkInterfaceId: aws.String(primaryENIID),
+			Ipv6Addresses: []*awsEC2.InstanceIpv6Address{
+				{Ipv6Address: aws.String(address1)},
+				{Ipv6Address: aws.String(address2)},
+				{Ipv6Address: aws.String(address3)},
+			},
+		}}, nil)
+	mockPodAPI.EXPECT().GetRunningPodsOnNode(nodeName).Return([]v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{
+			UID: "uid-1",
+			Annotations: map[string]string{
+				config.ResourceNameIPv6Address: address1 + "/80",
+				config.IPv6AddressesAnnotation: `["` + address1 + `/80","` + address3 + `/80"]`,
+			},
+		},
+	}}, nil)
+	mockWorker.EXPECT().SubmitJob(gomock.Any()).AnyTimes()
+
+	err := ipv6Provider.InitResource(mockInstance)
+	assert.NoError(t, err)
+
+	resourcePool, found := ipv6Provider.GetPool(nodeName)
+	assert.True(t, found)
+	assert.Equal(t, []string{address2 + "/80"}, resourcePool.Introspect().WarmResources)
+
+	_, err = resourcePool.FreeResources("uid-1", []string{address1 + "/80", address3 + "/80"})
+	assert.NoError(t, err)
+}
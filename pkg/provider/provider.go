@@ -14,6 +14,8 @@
 package provider
 
 import (
+	"fmt"
+
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -40,3 +42,23 @@ type ResourceProvider interface {
 	// IntrospectNode allows introspection of a node for the given resource
 	IntrospectNode(node string) interface{}
 }
+
+// ErrDeadLetterResourceNotFound is returned by the DeadLetterProvider if the node is not managed by the provider or
+// the resource is not present in the dead letter queue of the node
+var ErrDeadLetterResourceNotFound = fmt.Errorf("resource not found in the dead letter queue")
+
+// DeadLetterProvider is implemented by the providers that move the resources which failed to be deleted to a dead
+// letter queue, it allows an operator to retry or forget such resources
+type DeadLetterProvider interface {
+	// RetryDeadLetterResource retries the deletion of the resource in the dead letter queue of the node
+	RetryDeadLetterResource(nodeName string, resourceID string) error
+	// ForgetDeadLetterResource removes the resource deleted out of band from the dead letter queue of the node
+	ForgetDeadLetterResource(nodeName string, resourceID string) error
+}
+
+// CoolDownChecker is implemented by the providers that check the endpoints of the resources cooling down on a node,
+// it allows the resources freed on a node to be released without waiting for the next periodic check
+type CoolDownChecker interface {
+	// CheckCoolDownQueue schedules a check of the cool down queue of the node
+	CheckCoolDownQueue(nodeName string)
+}
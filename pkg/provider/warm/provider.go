@@ -0,0 +1,432 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package warm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// ResourceManager creates and deletes the resources of a single instance for its warm pool
+type ResourceManager interface {
+	// InitResources returns all the resources present on the instance
+	InitResources(ec2APIHelper ec2API.EC2APIHelper) ([]string, error)
+	// CreateResources creates the required number of resources and returns the created resources along with the
+	// error if not all the resources were created
+	CreateResources(required int, ec2APIHelper ec2API.EC2APIHelper, log logr.Logger) ([]string, error)
+	// DeleteResources deletes the resources and returns the resources that failed to delete along with the error
+	DeleteResources(resources []string, ec2APIHelper ec2API.EC2APIHelper, log logr.Logger) ([]string, error)
+}
+
+// Compactor is optionally implemented by the resource managers that can release the ENIs sparsely used by the warm
+// resources
+type Compactor interface {
+	// GetCompactableIPs returns the warm resources to delete so that their ENI can be deleted
+	GetCompactableIPs(warmResources []string) []string
+}
+
+// Provider implements the warm pool workflow shared by the providers of the resources assigned to the pods from a
+// warm pool per node
+type Provider struct {
+	// log is the logger initialized with the provider details
+	log logr.Logger
+	// apiWrapper wraps all clients used by the controller
+	apiWrapper api.Wrapper
+	// workerPool with worker routine to execute asynchronous job on the provider
+	workerPool worker.Worker
+	// config is the warm pool configuration for the resource
+	config *config.WarmPoolConfig
+	// resourceName is the name of the resource annotated on the pods
+	resourceName string
+	// supportedOS is the set of operating systems of the nodes managed for the resource
+	supportedOS map[string]bool
+	// lock to allow multiple routines to access the cache concurrently
+	lock sync.RWMutex // guards the following
+	// instanceProviderAndPool stores the resource manager and the resource pool per instance
+	instanceProviderAndPool map[string]ResourceProviderAndPool
+	// coolDownChecks is the set of nodes with a process cool down queue job submitted
+	coolDownChecks map[string]struct{}
+}
+
+// ResourceProviderAndPool contains the instance's resource manager and the resource pool
+type ResourceProviderAndPool struct {
+	resourceManager ResourceManager
+	resourcePool    pool.Pool
+}
+
+// NewProvider returns the warm pool provider for the given resource
+func NewProvider(log logr.Logger, apiWrapper api.Wrapper, workerPool worker.Worker,
+	resourceConfig config.ResourceConfig) *Provider {
+	return &Provider{
+		instanceProviderAndPool: make(map[string]ResourceProviderAndPool),
+		coolDownChecks:          make(map[string]struct{}),
+		config:                  resourceConfig.WarmPoolConfig,
+		resourceName:            resourceConfig.Name,
+		supportedOS:             resourceConfig.SupportedOS,
+		log:                     log,
+		apiWrapper:              apiWrapper,
+		workerPool:              workerPool,
+	}
+}
+
+// LoadPool returns the resource pool of the instance with the resources annotated on the running pods of the node as
+// used and all the other resources present on the instance as warm
+func (p *Provider) LoadPool(instance ec2.EC2Instance, manager ResourceManager, capacity int) (pool.Pool, error) {
+	nodeName := instance.Name()
+
+	presentResources, err := manager.InitResources(p.apiWrapper.EC2API)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := p.apiWrapper.PodAPI.GetRunningPodsOnNode(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	podToResourceMap := map[string][]string{}
+	usedResourceSet := map[string]struct{}{}
+	for _, pod := range pods {
+		resources, present := GetResourcesFromAnnotation(pod.Annotations, p.resourceName)
+		if !present {
+			continue
+		}
+		podToResourceMap[string(pod.UID)] = resources
+		for _, resource := range resources {
+			usedResourceSet[resource] = struct{}{}
+		}
+	}
+
+	warmResources := difference(presentResources, usedResourceSet)
+
+	return pool.NewResourcePool(p.log.WithName("resource pool").WithValues("node name", nodeName),
+		p.config, podToResourceMap, warmResources, nodeName, capacity), nil
+}
+
+// AddInstance stores the instance's resource manager and pool, and starts reconciling the warm pool of the instance
+func (p *Provider) AddInstance(nodeName string, manager ResourceManager, resourcePool pool.Pool) {
+	p.putInstanceProviderAndPool(nodeName, resourcePool, manager)
+
+	// Reconcile pool after starting up and submit the async job
+	job := resourcePool.ReconcilePool()
+	if job.Operations != worker.OperationReconcileNotRequired {
+		p.SubmitAsyncJob(job)
+	}
+
+	// Submit the async job to periodically process the delete queue
+	p.SubmitAsyncJob(worker.NewWarmProcessDeleteQueueJob(nodeName))
+}
+
+func (p *Provider) DeInitResource(instance ec2.EC2Instance) error {
+	nodeName := instance.Name()
+	p.deleteInstanceProviderAndPool(nodeName)
+	// The queued warm pool jobs of the node would fail to find the pool
+	p.workerPool.CancelNodeJobs(nodeName)
+	return nil
+}
+
+func (p *Provider) ProcessDeleteQueue(job *worker.WarmPoolJob) (ctrl.Result, error) {
+	nodeName := job.NodeName
+	resourceProviderAndPool, isPresent := p.getInstanceProviderAndPool(nodeName)
+	if !isPresent {
+		p.log.Info("forgetting the delete queue processing job", "node", nodeName)
+		return ctrl.Result{}, nil
+	}
+	// TODO: For efficiency run only when required in next release
+	coolingDown := resourceProviderAndPool.resourcePool.ProcessCoolDownQueue()
+
+	// After the cool down queue is processed check if we need to do reconciliation
+	job = resourceProviderAndPool.resourcePool.ReconcilePool()
+	if job.Operations != worker.OperationReconcileNotRequired {
+		p.SubmitAsyncJob(job)
+	} else if compactor, ok := resourceProviderAndPool.resourceManager.(Compactor); ok && p.shouldCompact() {
+		// The pool is at the desired state, drain the warm resources from a sparse ENI so the ENI can be deleted
+		// and the warm resources are re-created on the lower index ENIs
+		warmResources := resourceProviderAndPool.resourcePool.Introspect().WarmResources
+		if resources := compactor.GetCompactableIPs(warmResources); len(resources) > 0 {
+			job = resourceProviderAndPool.resourcePool.DrainResources(resources)
+			if job.Operations != worker.OperationReconcileNotRequired {
+				p.SubmitAsyncJob(job)
+			}
+		}
+	}
+
+	// Check the endpoints of the resources still cooling down again, in case they were added to the cool down queue
+	// without submitting the process cool down queue job
+	if coolingDown {
+		p.CheckCoolDownQueue(nodeName)
+	}
+
+	// Re submit the job to execute after cool down period has ended
+	return ctrl.Result{Requeue: true, RequeueAfter: config.CoolDownPeriod}, nil
+}
+
+// CheckCoolDownQueue submits the job to process the cool down queue of the node after the endpoint check interval,
+// so the resources freed on an idle node are not held for the cool down period once their endpoints are removed. At
+// most one job is submitted per node, the job is re-submitted until no resource is cooling down
+func (p *Provider) CheckCoolDownQueue(nodeName string) {
+	if p.apiWrapper.EndpointAPI == nil || !p.setCoolDownCheck(nodeName) {
+		return
+	}
+	p.workerPool.SubmitJobAfter(worker.NewWarmProcessCoolDownQueueJob(nodeName), config.EndpointCoolDownCheckInterval)
+}
+
+// ProcessCoolDownQueue moves the resources that have cooled down to the warm pool, the job is re-submitted after the
+// endpoint check interval until no resource is cooling down
+func (p *Provider) ProcessCoolDownQueue(job *worker.WarmPoolJob) (ctrl.Result, error) {
+	// Resources freed from now on submit a new job if this job is not re-submitted
+	p.clearCoolDownCheck(job.NodeName)
+
+	resourceProviderAndPool, isPresent := p.getInstanceProviderAndPool(job.NodeName)
+	if !isPresent {
+		p.log.Info("forgetting the cool down queue processing job", "node", job.NodeName)
+		return ctrl.Result{}, nil
+	}
+	coolingDown := resourceProviderAndPool.resourcePool.ProcessCoolDownQueue()
+
+	// The cooled down resources may be in excess of the warm pool size
+	reconcileJob := resourceProviderAndPool.resourcePool.ReconcilePool()
+	if reconcileJob.Operations != worker.OperationReconcileNotRequired {
+		p.SubmitAsyncJob(reconcileJob)
+	}
+
+	if coolingDown && p.setCoolDownCheck(job.NodeName) {
+		return ctrl.Result{Requeue: true, RequeueAfter: config.EndpointCoolDownCheckInterval}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// setCoolDownCheck marks the node as having a process cool down queue job, returns false if it already has one
+func (p *Provider) setCoolDownCheck(nodeName string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.coolDownChecks[nodeName]; ok {
+		return false
+	}
+	p.coolDownChecks[nodeName] = struct{}{}
+	return true
+}
+
+// clearCoolDownCheck marks the node as not having a process cool down queue job
+func (p *Provider) clearCoolDownCheck(nodeName string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.coolDownChecks, nodeName)
+}
+
+// shouldCompact returns true if the warm resources of the sparse ENIs should be drained. The resources are assigned
+// from the lowest index ENI first only with the packing strategy, with the other strategies the drained resources
+// would be spread over the ENIs again. Spare ENIs are retained if the warm ENI target is set
+func (p *Provider) shouldCompact() bool {
+	return p.config.AssignmentStrategy == config.AssignmentStrategyPacking && p.config.WarmENITarget == 0
+}
+
+// SubmitAsyncJob submits an asynchronous job to the worker pool
+func (p *Provider) SubmitAsyncJob(job interface{}) {
+	p.workerPool.SubmitJob(job)
+}
+
+// ProcessAsyncJob processes the job, the function should be called using the worker pool in order to be processed
+// asynchronously
+func (p *Provider) ProcessAsyncJob(job interface{}) (ctrl.Result, error) {
+	warmPoolJob, isValid := job.(*worker.WarmPoolJob)
+	if !isValid {
+		return ctrl.Result{}, fmt.Errorf("invalid job type")
+	}
+
+	switch warmPoolJob.Operations {
+	case worker.OperationCreate:
+		p.CreateAndUpdatePool(warmPoolJob)
+	case worker.OperationDeleted:
+		p.DeleteAndUpdatePool(warmPoolJob)
+	case worker.OperationReSyncPool:
+		p.ReSyncPool(warmPoolJob)
+	case worker.OperationProcessDeleteQueue:
+		return p.ProcessDeleteQueue(warmPoolJob)
+	case worker.OperationProcessCoolDownQueue:
+		return p.ProcessCoolDownQueue(warmPoolJob)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// CreateAndUpdatePool executes the Create workflow by creating the desired number of resources provided in the warm
+// pool job
+func (p *Provider) CreateAndUpdatePool(job *worker.WarmPoolJob) {
+	instanceResource, found := p.getInstanceProviderAndPool(job.NodeName)
+	if !found {
+		p.log.Error(fmt.Errorf("cannot find the instance provider and pool form the cache"), "node", job.NodeName)
+		return
+	}
+	didSucceed := true
+	resources, err := instanceResource.resourceManager.CreateResources(job.ResourceCount, p.apiWrapper.EC2API, p.log)
+	if err != nil {
+		p.log.Error(err, "failed to create all/some of the resources", "created resources", resources)
+		didSucceed = false
+	}
+	job.Resources = resources
+	p.updatePoolAndReconcileIfRequired(instanceResource.resourcePool, job, didSucceed)
+}
+
+func (p *Provider) ReSyncPool(job *worker.WarmPoolJob) {
+	providerAndPool, found := p.getInstanceProviderAndPool(job.NodeName)
+	if !found {
+		p.log.Error(fmt.Errorf("instance provider not found"), "node is not initialized",
+			"name", job.NodeName)
+		return
+	}
+
+	resources, err := providerAndPool.resourceManager.InitResources(p.apiWrapper.EC2API)
+	if err != nil {
+		p.log.Error(err, "failed to get init resources for the node",
+			"name", job.NodeName)
+		return
+	}
+
+	providerAndPool.resourcePool.ReSync(resources)
+}
+
+// DeleteAndUpdatePool executes the Delete workflow for the list of resources provided in the warm pool job
+func (p *Provider) DeleteAndUpdatePool(job *worker.WarmPoolJob) {
+	instanceResource, found := p.getInstanceProviderAndPool(job.NodeName)
+	if !found {
+		p.log.Error(fmt.Errorf("cannot find the instance provider and pool form the cache"), "node", job.NodeName)
+		return
+	}
+	didSucceed := true
+	failedResources, err := instanceResource.resourceManager.DeleteResources(job.Resources, p.apiWrapper.EC2API, p.log)
+	if err != nil {
+		p.log.Error(err, "failed to delete all/some of the resources", "failed resources", failedResources)
+		didSucceed = false
+	}
+	job.Resources = failedResources
+	p.updatePoolAndReconcileIfRequired(instanceResource.resourcePool, job, didSucceed)
+}
+
+// updatePoolAndReconcileIfRequired updates the resource pool and reconcile again and submit a new job if required
+func (p *Provider) updatePoolAndReconcileIfRequired(resourcePool pool.Pool, job *worker.WarmPoolJob, didSucceed bool) {
+	// Update the pool to add the created/failed resource to the warm pool and decrement the pending count
+	shouldReconcile := resourcePool.UpdatePool(job, didSucceed)
+
+	if shouldReconcile {
+		job := resourcePool.ReconcilePool()
+		if job.Operations != worker.OperationReconcileNotRequired {
+			p.SubmitAsyncJob(job)
+		}
+	}
+}
+
+// putInstanceProviderAndPool stores the node's resource manager and pool to the cache
+func (p *Provider) putInstanceProviderAndPool(nodeName string, resourcePool pool.Pool, manager ResourceManager) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.instanceProviderAndPool[nodeName] = ResourceProviderAndPool{
+		resourceManager: manager,
+		resourcePool:    resourcePool,
+	}
+}
+
+// getInstanceProviderAndPool returns the node's resource manager and pool from the cache
+func (p *Provider) getInstanceProviderAndPool(nodeName string) (ResourceProviderAndPool, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	resource, found := p.instanceProviderAndPool[nodeName]
+	return resource, found
+}
+
+// deleteInstanceProviderAndPool deletes the node's resource manager and pool from the cache
+func (p *Provider) deleteInstanceProviderAndPool(nodeName string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.instanceProviderAndPool, nodeName)
+}
+
+// GetResourcesFromAnnotation returns the resources annotated on the pod in the order of assignment, the list of all
+// the resources is annotated if the pod was assigned more than one resource
+func GetResourcesFromAnnotation(annotations map[string]string, resourceName string) ([]string, bool) {
+	if resources, present := annotations[config.ResourcesAnnotation[resourceName]]; present {
+		var resIDs []string
+		if err := json.Unmarshal([]byte(resources), &resIDs); err == nil && len(resIDs) > 0 {
+			return resIDs, true
+		}
+	}
+	resourceID, present := annotations[resourceName]
+	if !present {
+		return nil, false
+	}
+	return []string{resourceID}, true
+}
+
+// difference returns the difference between the slice and the map in the argument
+func difference(allResources []string, usedResourceSet map[string]struct{}) []string {
+	var notUsed []string
+	for _, resource := range allResources {
+		if _, found := usedResourceSet[resource]; !found {
+			notUsed = append(notUsed, resource)
+		}
+	}
+	return notUsed
+}
+
+// GetPool returns the warm pool of the node
+func (p *Provider) GetPool(nodeName string) (pool.Pool, bool) {
+	providerAndPool, exists := p.getInstanceProviderAndPool(nodeName)
+	if !exists {
+		return nil, false
+	}
+	return providerAndPool.resourcePool, true
+}
+
+// IsInstanceSupported returns true if the resource is enabled for the node's operating system
+func (p *Provider) IsInstanceSupported(instance ec2.EC2Instance) bool {
+	return p.supportedOS[instance.Os()]
+}
+
+func (p *Provider) Introspect() interface{} {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	response := make(map[string]pool.IntrospectResponse)
+	for nodeName, resource := range p.instanceProviderAndPool {
+		response[nodeName] = resource.resourcePool.Introspect()
+	}
+	return response
+}
+
+func (p *Provider) IntrospectNode(nodeName string) interface{} {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	resource, found := p.instanceProviderAndPool[nodeName]
+	if !found {
+		return struct{}{}
+	}
+	return resource.resourcePool.Introspect()
+}
@@ -0,0 +1,662 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package warm
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/endpoint"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s/pod"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider/warm"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/worker"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	nodeName = "node-1"
+
+	ip1 = "192.168.1.1"
+	ip2 = "192.168.1.2"
+	ip3 = "192.168.1.3"
+
+	address1 = "2600::1/80"
+	address2 = "2600::2/80"
+	address3 = "2600::3/80"
+)
+
+// compactingManager is a resource manager that can release the sparse ENIs
+type compactingManager struct {
+	*mock_warm.MockResourceManager
+	compactableIPs []string
+}
+
+func (m compactingManager) GetCompactableIPs(_ []string) []string {
+	return m.compactableIPs
+}
+
+func getMockProvider() *Provider {
+	return &Provider{
+		instanceProviderAndPool: map[string]ResourceProviderAndPool{},
+		coolDownChecks:          map[string]struct{}{},
+		config:                  &config.WarmPoolConfig{},
+		log:                     zap.New(zap.UseDevMode(true)).WithName("warm provider"),
+	}
+}
+
+// TestProvider_difference tests difference removes the difference between an array and a set
+func TestProvider_difference(t *testing.T) {
+	allIPs := []string{ip1, ip2, ip3}
+	usedIPSet := map[string]struct{}{ip3: {}, ip2: {}}
+
+	unusedIPs := difference(allIPs, usedIPSet)
+	assert.Equal(t, []string{ip1}, unusedIPs)
+}
+
+// TestProvider_no_difference tests that an empty slice is returned if there is no difference
+func TestProvider_no_difference(t *testing.T) {
+	allIPs := []string{ip1, ip2}
+	usedIPSet := map[string]struct{}{ip1: {}, ip2: {}}
+
+	unusedIPs := difference(allIPs, usedIPSet)
+	assert.Empty(t, unusedIPs)
+}
+
+// TestGetResourcesFromAnnotation tests the list of resources is returned if annotated, else the single resource
+func TestGetResourcesFromAnnotation(t *testing.T) {
+	resources, present := GetResourcesFromAnnotation(map[string]string{
+		config.ResourceNameIPAddress:   ip1,
+		config.IPv4AddressesAnnotation: `["` + ip1 + `","` + ip2 + `"]`,
+	}, config.ResourceNameIPAddress)
+	assert.True(t, present)
+	assert.Equal(t, []string{ip1, ip2}, resources)
+
+	resources, present = GetResourcesFromAnnotation(map[string]string{
+		config.ResourceNameIPAddress:   ip1,
+		config.IPv4AddressesAnnotation: "[",
+	}, config.ResourceNameIPAddress)
+	assert.True(t, present)
+	assert.Equal(t, []string{ip1}, resources)
+
+	_, present = GetResourcesFromAnnotation(map[string]string{config.ResourceNameIPAddress: ip1},
+		config.ResourceNameIPv6Address)
+	assert.False(t, present)
+}
+
+// TestProvider_LoadPool tests all the resources of the pods assigned more than one resource are used after the
+// controller restarts, so they are not assigned to other pods and the pods can free all their resources
+func TestProvider_LoadPool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	mockManager := mock_warm.NewMockResourceManager(ctrl)
+	mockPodAPI := mock_pod.NewMockPodClientAPIWrapper(ctrl)
+
+	provider := getMockProvider()
+	provider.resourceName = config.ResourceNameIPv6Address
+	provider.apiWrapper = api.Wrapper{PodAPI: mockPodAPI}
+
+	mockInstance.EXPECT().Name().Return(nodeName)
+	mockManager.EXPECT().InitResources(nil).Return([]string{address1, address2, address3}, nil)
+	mockPodAPI.EXPECT().GetRunningPodsOnNode(nodeName).Return([]v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{
+			UID: "uid-1",
+			Annotations: map[string]string{
+				config.ResourceNameIPv6Address: address1,
+				config.IPv6AddressesAnnotation: `["` + address1 + `","` + address3 + `"]`,
+			},
+		},
+	}}, nil)
+
+	resourcePool, err := provider.LoadPool(mockInstance, mockManager, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{address2}, resourcePool.Introspect().WarmResources)
+
+	_, err = resourcePool.FreeResources("uid-1", []string{address1, address3})
+	assert.NoError(t, err)
+}
+
+// TestProvider_LoadPool_Error tests the error to load the resources of the instance is returned
+func TestProvider_LoadPool_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	mockManager := mock_warm.NewMockResourceManager(ctrl)
+
+	provider := getMockProvider()
+
+	mockInstance.EXPECT().Name().Return(nodeName)
+	mockManager.EXPECT().InitResources(nil).Return(nil, fmt.Errorf("failed"))
+
+	_, err := provider.LoadPool(mockInstance, mockManager, 5)
+	assert.Error(t, err)
+}
+
+// TestProvider_AddInstance tests the instance is stored, the pool reconciled and the delete queue processed
+func TestProvider_AddInstance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mock_pool.NewMockPool(ctrl)
+	mockManager := mock_warm.NewMockResourceManager(ctrl)
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+
+	provider := getMockProvider()
+	provider.workerPool = mockWorker
+	createJob := worker.NewWarmPoolCreateJob(nodeName, 2)
+
+	mockPool.EXPECT().ReconcilePool().Return(createJob)
+	mockWorker.EXPECT().SubmitJob(createJob)
+	mockWorker.EXPECT().SubmitJob(worker.NewWarmProcessDeleteQueueJob(nodeName))
+
+	provider.AddInstance(nodeName, mockManager, mockPool)
+
+	assert.Equal(t, ResourceProviderAndPool{resourcePool: mockPool, resourceManager: mockManager},
+		provider.instanceProviderAndPool[nodeName])
+}
+
+// TestProvider_IsInstanceSupported tests only the nodes with the supported operating system are supported
+func TestProvider_IsInstanceSupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	provider := Provider{supportedOS: map[string]bool{config.OSWindows: true, config.OSLinux: false}}
+
+	mockInstance.EXPECT().Os().Return(config.OSWindows)
+	assert.True(t, provider.IsInstanceSupported(mockInstance))
+
+	mockInstance.EXPECT().Os().Return(config.OSLinux)
+	assert.False(t, provider.IsInstanceSupported(mockInstance))
+}
+
+// TestProvider_deleteInstanceProviderAndPool tests that the ResourcePoolAndProvider for given node is removed from
+// cache after calling the API
+func TestProvider_deleteInstanceProviderAndPool(t *testing.T) {
+	provider := getMockProvider()
+	provider.instanceProviderAndPool[nodeName] = ResourceProviderAndPool{}
+	provider.deleteInstanceProviderAndPool(nodeName)
+	assert.NotContains(t, provider.instanceProviderAndPool, nodeName)
+}
+
+// TestProvider_getInstanceProviderAndPool tests if the resource pool and provider is present in cache it's returned
+func TestProvider_getInstanceProviderAndPool(t *testing.T) {
+	provider := getMockProvider()
+	resourcePoolAndProvider := ResourceProviderAndPool{}
+	provider.instanceProviderAndPool[nodeName] = resourcePoolAndProvider
+	result, found := provider.getInstanceProviderAndPool(nodeName)
+
+	assert.True(t, found)
+	assert.Equal(t, resourcePoolAndProvider, result)
+}
+
+// TestProvider_putInstanceProviderAndPool tests put stores teh resource pool and provider into the cache
+func TestProvider_putInstanceProviderAndPool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mock_pool.NewMockPool(ctrl)
+	mockManager := mock_warm.NewMockResourceManager(ctrl)
+
+	provider := getMockProvider()
+	provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager)
+
+	assert.Equal(t, ResourceProviderAndPool{resourcePool: mockPool, resourceManager: mockManager},
+		provider.instanceProviderAndPool[nodeName])
+}
+
+// TestProvider_updatePoolAndReconcileIfRequired_NoFurtherReconcile tests pool is updated and reconciliation is not
+// performed again
+func TestProvider_updatePoolAndReconcileIfRequired_NoFurtherReconcile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+	mockPool := mock_pool.NewMockPool(ctrl)
+	provider := Provider{workerPool: mockWorker}
+
+	job := &worker.WarmPoolJob{Operations: worker.OperationCreate}
+
+	mockPool.EXPECT().UpdatePool(job, true).Return(false)
+
+	provider.updatePoolAndReconcileIfRequired(mockPool, job, true)
+}
+
+// TestProvider_updatePoolAndReconcileIfRequired_ReconcileRequired tests pool is updated and reconciliation is
+// performed again and the job submitted to the worker
+func TestProvider_updatePoolAndReconcileIfRequired_ReconcileRequired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+	mockPool := mock_pool.NewMockPool(ctrl)
+	provider := Provider{workerPool: mockWorker}
+
+	job := &worker.WarmPoolJob{Operations: worker.OperationCreate}
+
+	mockPool.EXPECT().UpdatePool(job, true).Return(true)
+	mockPool.EXPECT().ReconcilePool().Return(job)
+	mockWorker.EXPECT().SubmitJob(job)
+
+	provider.updatePoolAndReconcileIfRequired(mockPool, job, true)
+}
+
+// TestProvider_DeleteAndUpdatePool tests job with empty resources is passed back if all the resources are deleted
+func TestProvider_DeleteAndUpdatePool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := getMockProvider()
+	mockPool := mock_pool.NewMockPool(ctrl)
+	mockManager := mock_warm.NewMockResourceManager(ctrl)
+	provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager)
+	resourcesToDelete := []string{ip1, ip2}
+
+	deleteJob := &worker.WarmPoolJob{
+		Operations:    worker.OperationDeleted,
+		Resources:     resourcesToDelete,
+		ResourceCount: 2,
+		NodeName:      nodeName,
+	}
+
+	mockManager.EXPECT().DeleteResources(resourcesToDelete, nil, gomock.Any()).Return([]string{}, nil)
+	mockPool.EXPECT().UpdatePool(&worker.WarmPoolJob{
+		Operations:    worker.OperationDeleted,
+		Resources:     []string{},
+		ResourceCount: 2,
+		NodeName:      nodeName,
+	}, true).Return(false)
+
+	provider.DeleteAndUpdatePool(deleteJob)
+}
+
+// TestProvider_DeleteAndUpdatePool_SomeResourceFail tests if some resource fail to delete those resources are passed
+// back inside the job to the resource pool
+func TestProvider_DeleteAndUpdatePool_SomeResourceFail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := getMockProvider()
+	mockPool := mock_pool.NewMockPool(ctrl)
+	mockManager := mock_warm.NewMockResourceManager(ctrl)
+	provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager)
+	resourcesToDelete := []string{ip1, ip2}
+	failedResources := []string{ip2}
+
+	deleteJob := worker.WarmPoolJob{
+		Operations:    worker.OperationDeleted,
+		Resources:     resourcesToDelete,
+		ResourceCount: 2,
+		NodeName:      nodeName,
+	}
+
+	mockManager.EXPECT().DeleteResources(resourcesToDelete, nil, gomock.Any()).
+		Return(failedResources, fmt.Errorf("failed"))
+	mockPool.EXPECT().UpdatePool(&worker.WarmPoolJob{
+		Operations:    worker.OperationDeleted,
+		Resources:     failedResources,
+		ResourceCount: 2,
+		NodeName:      nodeName,
+	}, false).Return(false)
+
+	provider.DeleteAndUpdatePool(&deleteJob)
+}
+
+// TestProvider_CreateAndUpdatePool tests if resources are created then the job object is updated with the resources
+// and the pool is updated
+func TestProvider_CreateAndUpdatePool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := getMockProvider()
+	mockPool := mock_pool.NewMockPool(ctrl)
+	mockManager := mock_warm.NewMockResourceManager(ctrl)
+	provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager)
+	createdResources := []string{ip1, ip2}
+
+	createJob := &worker.WarmPoolJob{
+		Operations:    worker.OperationCreate,
+		Resources:     []string{},
+		ResourceCount: 2,
+		NodeName:      nodeName,
+	}
+
+	mockManager.EXPECT().CreateResources(2, nil, gomock.Any()).Return(createdResources, nil)
+	mockPool.EXPECT().UpdatePool(&worker.WarmPoolJob{
+		Operations:    worker.OperationCreate,
+		Resources:     createdResources,
+		ResourceCount: 2,
+		NodeName:      nodeName,
+	}, true).Return(false)
+
+	provider.CreateAndUpdatePool(createJob)
+}
+
+// TestProvider_CreateAndUpdatePool_Fail tests that if some of the create fails then the pool is updated with the
+// created resource and success status as false
+func TestProvider_CreateAndUpdatePool_Fail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := getMockProvider()
+	mockPool := mock_pool.NewMockPool(ctrl)
+	mockManager := mock_warm.NewMockResourceManager(ctrl)
+	provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager)
+	createdResources := []string{ip1, ip2}
+
+	createJob := &worker.WarmPoolJob{
+		Operations:    worker.OperationCreate,
+		Resources:     []string{},
+		ResourceCount: 2,
+		NodeName:      nodeName,
+	}
+
+	mockManager.EXPECT().CreateResources(2, nil, gomock.Any()).Return(createdResources, fmt.Errorf("failed"))
+	mockPool.EXPECT().UpdatePool(&worker.WarmPoolJob{
+		Operations:    worker.OperationCreate,
+		Resources:     createdResources,
+		ResourceCount: 2,
+		NodeName:      nodeName,
+	}, false).Return(false)
+
+	provider.CreateAndUpdatePool(createJob)
+}
+
+// TestProvider_ReSyncPool tests the pool is re-synced with the resources of the instance
+func TestProvider_ReSyncPool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := getMockProvider()
+	mockPool := mock_pool.NewMockPool(ctrl)
+	mockManager := mock_warm.NewMockResourceManager(ctrl)
+	provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager)
+	resources := []string{ip1, ip2}
+
+	reSyncJob := &worker.WarmPoolJob{
+		Operations: worker.OperationReSyncPool,
+		NodeName:   nodeName,
+	}
+
+	// When error occurs, pool should not be re-synced
+	mockManager.EXPECT().InitResources(nil).Return(nil, fmt.Errorf(""))
+	provider.ReSyncPool(reSyncJob)
+
+	// When no error occurs, pool should be re-synced
+	mockManager.EXPECT().InitResources(nil).Return(resources, nil)
+	mockPool.EXPECT().ReSync(resources)
+	provider.ReSyncPool(reSyncJob)
+}
+
+// TestProvider_SubmitAsyncJob tests that the job is submitted to the worker on calling SubmitAsyncJob
+func TestProvider_SubmitAsyncJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+	provider := Provider{workerPool: mockWorker}
+
+	job := worker.NewWarmPoolDeleteJob(nodeName, nil)
+
+	mockWorker.EXPECT().SubmitJob(job)
+
+	provider.SubmitAsyncJob(job)
+}
+
+// TestProvider_ProcessDeleteQueue_EndpointCoolDown tests the process cool down queue job is submitted if resources
+// are still cooling down and the endpoint tracker is set
+func TestProvider_ProcessDeleteQueue_EndpointCoolDown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mock_pool.NewMockPool(ctrl)
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+	mockEndpointTracker := mock_endpoint.NewMockEndpointTracker(ctrl)
+	provider := getMockProvider()
+	provider.workerPool = mockWorker
+	provider.apiWrapper = api.Wrapper{EndpointAPI: mockEndpointTracker}
+	provider.putInstanceProviderAndPool(nodeName, mockPool, nil)
+
+	mockPool.EXPECT().ProcessCoolDownQueue().Return(true)
+	mockPool.EXPECT().ReconcilePool().Return(&worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired})
+	mockWorker.EXPECT().SubmitJobAfter(worker.NewWarmProcessCoolDownQueueJob(nodeName),
+		config.EndpointCoolDownCheckInterval)
+
+	result, err := provider.ProcessDeleteQueue(worker.NewWarmProcessDeleteQueueJob(nodeName))
+	assert.NoError(t, err)
+	assert.Equal(t, config.CoolDownPeriod, result.RequeueAfter)
+}
+
+// TestProvider_ProcessDeleteQueue_EndpointCoolDown_Empty tests the process cool down queue job is not submitted
+// when no resource is cooling down, even if the endpoint tracker is set
+func TestProvider_ProcessDeleteQueue_EndpointCoolDown_Empty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mock_pool.NewMockPool(ctrl)
+	mockEndpointTracker := mock_endpoint.NewMockEndpointTracker(ctrl)
+	provider := getMockProvider()
+	provider.apiWrapper = api.Wrapper{EndpointAPI: mockEndpointTracker}
+	provider.putInstanceProviderAndPool(nodeName, mockPool, nil)
+
+	mockPool.EXPECT().ProcessCoolDownQueue().Return(false)
+	mockPool.EXPECT().ReconcilePool().Return(&worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired})
+
+	result, err := provider.ProcessDeleteQueue(worker.NewWarmProcessDeleteQueueJob(nodeName))
+	assert.NoError(t, err)
+	assert.Equal(t, config.CoolDownPeriod, result.RequeueAfter)
+}
+
+// TestProvider_CheckCoolDownQueue tests a single process cool down queue job is submitted per node after the endpoint
+// check interval
+func TestProvider_CheckCoolDownQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+	mockEndpointTracker := mock_endpoint.NewMockEndpointTracker(ctrl)
+	provider := getMockProvider()
+	provider.workerPool = mockWorker
+	provider.apiWrapper = api.Wrapper{EndpointAPI: mockEndpointTracker}
+
+	mockWorker.EXPECT().SubmitJobAfter(worker.NewWarmProcessCoolDownQueueJob(nodeName),
+		config.EndpointCoolDownCheckInterval)
+
+	provider.CheckCoolDownQueue(nodeName)
+	provider.CheckCoolDownQueue(nodeName)
+}
+
+// TestProvider_CheckCoolDownQueue_NoEndpointTracker tests no job is submitted if the endpoint tracker is not set, as
+// the resources cool down for the whole cool down period
+func TestProvider_CheckCoolDownQueue_NoEndpointTracker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := getMockProvider()
+	provider.workerPool = mock_worker.NewMockWorker(ctrl)
+
+	provider.CheckCoolDownQueue(nodeName)
+}
+
+// TestProvider_ProcessCoolDownQueue_IdleNode tests the resource freed on an idle node is moved back to the warm pool
+// once its endpoints are removed, without waiting for the cool down period, and the job is not re-submitted
+func TestProvider_ProcessCoolDownQueue_IdleNode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+	mockEndpointTracker := mock_endpoint.NewMockEndpointTracker(ctrl)
+	provider := getMockProvider()
+	provider.workerPool = mockWorker
+	provider.apiWrapper = api.Wrapper{EndpointAPI: mockEndpointTracker}
+	provider.config = &config.WarmPoolConfig{DesiredSize: 1}
+
+	resourcePool := pool.NewResourcePool(provider.log, provider.config, map[string][]string{"uid-1": {ip1}},
+		nil, nodeName, 5)
+	resourcePool.SetInUseChecker(mockEndpointTracker.IsAddressInUse)
+	provider.putInstanceProviderAndPool(nodeName, resourcePool, nil)
+
+	var coolDownJob interface{}
+	mockWorker.EXPECT().SubmitJobAfter(gomock.Any(), config.EndpointCoolDownCheckInterval).Do(
+		func(job interface{}, _ interface{}) { coolDownJob = job })
+
+	_, err := resourcePool.FreeResource("uid-1", ip1)
+	assert.NoError(t, err)
+	provider.CheckCoolDownQueue(nodeName)
+	assert.Equal(t, []pool.CoolDownResource{{ResourceID: ip1}},
+		clearDeletionTimestamp(resourcePool.Introspect().CoolingResources))
+
+	mockEndpointTracker.EXPECT().IsAddressInUse(ip1).Return(false)
+
+	result, err := provider.ProcessAsyncJob(coolDownJob)
+	assert.NoError(t, err)
+	assert.False(t, result.Requeue)
+	assert.Empty(t, resourcePool.Introspect().CoolingResources)
+	assert.Equal(t, []string{ip1}, resourcePool.Introspect().WarmResources)
+}
+
+// TestProvider_ProcessCoolDownQueue_StillCooling tests the job is re-submitted after the endpoint check interval if
+// resources are still cooling down
+func TestProvider_ProcessCoolDownQueue_StillCooling(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mock_pool.NewMockPool(ctrl)
+	provider := getMockProvider()
+	provider.putInstanceProviderAndPool(nodeName, mockPool, nil)
+
+	mockPool.EXPECT().ProcessCoolDownQueue().Return(true)
+	mockPool.EXPECT().ReconcilePool().Return(&worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired})
+
+	result, err := provider.ProcessCoolDownQueue(worker.NewWarmProcessCoolDownQueueJob(nodeName))
+	assert.NoError(t, err)
+	assert.Equal(t, config.EndpointCoolDownCheckInterval, result.RequeueAfter)
+	assert.Contains(t, provider.coolDownChecks, nodeName)
+}
+
+// TestProvider_ProcessCoolDownQueue_NodeNotFound tests the job is not re-submitted once the node is de initialized
+func TestProvider_ProcessCoolDownQueue_NodeNotFound(t *testing.T) {
+	provider := getMockProvider()
+	provider.coolDownChecks[nodeName] = struct{}{}
+
+	result, err := provider.ProcessCoolDownQueue(worker.NewWarmProcessCoolDownQueueJob(nodeName))
+	assert.NoError(t, err)
+	assert.False(t, result.Requeue)
+	assert.NotContains(t, provider.coolDownChecks, nodeName)
+}
+
+// clearDeletionTimestamp returns the cooling resources without their deletion timestamp
+func clearDeletionTimestamp(resources []pool.CoolDownResource) []pool.CoolDownResource {
+	for i := range resources {
+		resources[i].DeletionTimestamp = time.Time{}
+	}
+	return resources
+}
+
+// TestProvider_ProcessDeleteQueue_Compact tests the warm resources of a sparse ENI are drained once the pool is at
+// the desired state, if the resource manager can compact the ENIs and the packing strategy is used
+func TestProvider_ProcessDeleteQueue_Compact(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mock_pool.NewMockPool(ctrl)
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+	provider := getMockProvider()
+	provider.workerPool = mockWorker
+	provider.config.AssignmentStrategy = config.AssignmentStrategyPacking
+	manager := compactingManager{MockResourceManager: mock_warm.NewMockResourceManager(ctrl),
+		compactableIPs: []string{ip3}}
+	provider.putInstanceProviderAndPool(nodeName, mockPool, manager)
+	drainJob := worker.NewWarmPoolDeleteJob(nodeName, []string{ip3})
+
+	mockPool.EXPECT().ProcessCoolDownQueue().Return(false)
+	mockPool.EXPECT().ReconcilePool().Return(&worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired})
+	mockPool.EXPECT().Introspect().Return(pool.IntrospectResponse{WarmResources: []string{ip1, ip3}})
+	mockPool.EXPECT().DrainResources([]string{ip3}).Return(drainJob)
+	mockWorker.EXPECT().SubmitJob(drainJob)
+
+	result, err := provider.ProcessDeleteQueue(worker.NewWarmProcessDeleteQueueJob(nodeName))
+	assert.NoError(t, err)
+	assert.Equal(t, config.CoolDownPeriod, result.RequeueAfter)
+}
+
+// TestProvider_ProcessDeleteQueue_Compact_NotPacking tests the warm resources are not drained if the packing strategy
+// is not used, as the re-created resources would not be assigned from the lower index ENIs first
+func TestProvider_ProcessDeleteQueue_Compact_NotPacking(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mock_pool.NewMockPool(ctrl)
+	provider := getMockProvider()
+	provider.config.AssignmentStrategy = config.AssignmentStrategyFIFO
+	manager := compactingManager{MockResourceManager: mock_warm.NewMockResourceManager(ctrl),
+		compactableIPs: []string{ip3}}
+	provider.putInstanceProviderAndPool(nodeName, mockPool, manager)
+
+	mockPool.EXPECT().ProcessCoolDownQueue().Return(false)
+	mockPool.EXPECT().ReconcilePool().Return(&worker.WarmPoolJob{Operations: worker.OperationReconcileNotRequired})
+
+	result, err := provider.ProcessDeleteQueue(worker.NewWarmProcessDeleteQueueJob(nodeName))
+	assert.NoError(t, err)
+	assert.Equal(t, config.CoolDownPeriod, result.RequeueAfter)
+}
+
+func TestProvider_GetPool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := getMockProvider()
+	mockPool := mock_pool.NewMockPool(ctrl)
+	provider.putInstanceProviderAndPool(nodeName, mockPool, nil)
+
+	pool, found := provider.GetPool(nodeName)
+	assert.True(t, found)
+	assert.Equal(t, mockPool, pool)
+}
+
+func TestProvider_Introspect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := getMockProvider()
+	mockPool := mock_pool.NewMockPool(ctrl)
+	provider.putInstanceProviderAndPool(nodeName, mockPool, nil)
+	expectedResp := pool.IntrospectResponse{}
+
+	mockPool.EXPECT().Introspect().Return(expectedResp)
+	resp := provider.Introspect()
+	assert.True(t, reflect.DeepEqual(resp, map[string]pool.IntrospectResponse{nodeName: expectedResp}))
+
+	mockPool.EXPECT().Introspect().Return(expectedResp)
+	resp = provider.IntrospectNode(nodeName)
+	assert.Equal(t, resp, expectedResp)
+
+	resp = provider.IntrospectNode("unregistered-node")
+	assert.Equal(t, resp, struct{}{})
+}
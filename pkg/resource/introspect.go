@@ -18,6 +18,9 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
 	"github.com/go-logr/logr"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
@@ -25,12 +28,26 @@ import (
 const (
 	GetNodeResourcesPath = "/node/"
 	GetAllResourcesPath  = "/resources"
+
+	// RetryDeadLetterResourcePath retries the deletion of a resource in the dead letter queue, the request must be
+	// a POST with the node and id query parameters. Served only on the DeadLetterBindAddress
+	RetryDeadLetterResourcePath = "/dead-letter/retry"
+	// ForgetDeadLetterResourcePath removes a resource deleted out of band from the dead letter queue, the request
+	// must be a POST with the node and id query parameters. Served only on the DeadLetterBindAddress
+	ForgetDeadLetterResourcePath = "/dead-letter/forget"
+
+	// GetWorkerJobsPath returns the pending, in flight and retrying jobs of the workers of each resource, the
+	// optional node query parameter returns only the jobs of the node
+	GetWorkerJobsPath = "/jobs"
 )
 
 type IntrospectHandler struct {
-	Log             logr.Logger
-	BindAddress     string
-	ResourceManager ResourceManager
+	Log         logr.Logger
+	BindAddress string
+	// DeadLetterBindAddress is the address serving the requests modifying the dead letter queues, the requests
+	// are not authenticated so it must be bound to localhost. Optional, the requests are not served if empty
+	DeadLetterBindAddress string
+	ResourceManager       ResourceManager
 }
 
 // StartENICleaner starts the ENI Cleaner routine that cleans up dangling ENIs created by the controller
@@ -40,6 +57,11 @@ func (i *IntrospectHandler) Start(_ context.Context) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc(GetAllResourcesPath, i.ResourceHandler)
 	mux.HandleFunc(GetNodeResourcesPath, i.NodeResourceHandler)
+	mux.HandleFunc(GetWorkerJobsPath, i.WorkerJobsHandler)
+
+	if i.DeadLetterBindAddress != "" {
+		go i.startDeadLetterAPI()
+	}
 
 	// Should this be a fatal error?
 	err := http.ListenAndServe(i.BindAddress, mux)
@@ -49,6 +71,19 @@ func (i *IntrospectHandler) Start(_ context.Context) error {
 	return err
 }
 
+// startDeadLetterAPI serves the requests to retry or forget the resources in the dead letter queues
+func (i *IntrospectHandler) startDeadLetterAPI() {
+	i.Log.Info("starting dead letter API", "address", i.DeadLetterBindAddress)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(RetryDeadLetterResourcePath, i.RetryDeadLetterHandler)
+	mux.HandleFunc(ForgetDeadLetterResourcePath, i.ForgetDeadLetterHandler)
+
+	if err := http.ListenAndServe(i.DeadLetterBindAddress, mux); err != nil {
+		i.Log.Error(err, "failed to run dead letter API")
+	}
+}
+
 // ResourceHandler returns all the nodes associated with the resource
 func (i *IntrospectHandler) ResourceHandler(w http.ResponseWriter, _ *http.Request) {
 	response := make(map[string]interface{})
@@ -93,6 +128,91 @@ func (i *IntrospectHandler) NodeResourceHandler(w http.ResponseWriter, r *http.R
 	w.Write(jsonData)
 }
 
+// WorkerJobsHandler returns the jobs of the workers of each resource
+func (i *IntrospectHandler) WorkerJobsHandler(w http.ResponseWriter, r *http.Request) {
+	nodeName := r.URL.Query().Get("node")
+
+	response := make(map[string][]worker.JobInfo)
+	for resourceName, resourceWorker := range i.ResourceManager.GetResourceWorkers() {
+		jobs := []worker.JobInfo{}
+		for _, job := range resourceWorker.Introspect() {
+			if nodeName == "" || job.NodeName == nodeName {
+				jobs = append(jobs, job)
+			}
+		}
+		response[resourceName] = jobs
+	}
+
+	jsonData, err := json.MarshalIndent(response, "", "\t")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// RetryDeadLetterHandler retries the deletion of the resource present in the dead letter queue of the node
+func (i *IntrospectHandler) RetryDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	i.handleDeadLetterRequest(w, r, func(p provider.DeadLetterProvider, nodeName, id string) error {
+		return p.RetryDeadLetterResource(nodeName, id)
+	})
+}
+
+// ForgetDeadLetterHandler removes the resource from the dead letter queue of the node
+func (i *IntrospectHandler) ForgetDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	i.handleDeadLetterRequest(w, r, func(p provider.DeadLetterProvider, nodeName, id string) error {
+		return p.ForgetDeadLetterResource(nodeName, id)
+	})
+}
+
+// handleDeadLetterRequest validates the request and executes the operation on the providers that support a dead
+// letter queue. The request succeeds if any of the provider executes the operation successfully and fails with not
+// found only if none of the providers has the resource.
+func (i *IntrospectHandler) handleDeadLetterRequest(w http.ResponseWriter, r *http.Request,
+	operation func(p provider.DeadLetterProvider, nodeName, id string) error) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	nodeName := r.URL.Query().Get("node")
+	id := r.URL.Query().Get("id")
+	if nodeName == "" || id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("node and id query parameters are required"))
+		return
+	}
+
+	for resourceName, resourceProvider := range i.ResourceManager.GetResourceProviders() {
+		deadLetterProvider, ok := resourceProvider.(provider.DeadLetterProvider)
+		if !ok {
+			continue
+		}
+		err := operation(deadLetterProvider, nodeName, id)
+		if err == provider.ErrDeadLetterResourceNotFound {
+			continue
+		}
+		if err != nil {
+			i.Log.Error(err, "failed to execute dead letter request", "path", r.URL.Path, "resource",
+				resourceName, "node", nodeName, "id", id)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		i.Log.Info("executed dead letter request", "path", r.URL.Path, "resource", resourceName,
+			"node", nodeName, "id", id)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(provider.ErrDeadLetterResourceNotFound.Error()))
+}
+
 func (i *IntrospectHandler) SetupWithManager(mgr ctrl.Manager) error {
 	return mgr.Add(i)
 }
@@ -15,17 +15,21 @@ package resource
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/resource"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/worker"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
 var (
@@ -47,6 +51,7 @@ func NewMockIntrospectHandler(ctrl *gomock.Controller) MockIntrospect {
 		mockManager:  mockManager,
 		mockProvider: mock_provider.NewMockResourceProvider(ctrl),
 		handler: IntrospectHandler{
+			Log:             zap.New(zap.UseDevMode(true)),
 			ResourceManager: mockManager,
 		},
 		response: map[string]string{resourceName: response},
@@ -90,6 +95,37 @@ func TestIntrospectHandler_ResourceHandler(t *testing.T) {
 	VerifyResponse(t, rr, mock.response)
 }
 
+// TestIntrospectHandler_WorkerJobsHandler tests the jobs of the workers are filtered by the node
+func TestIntrospectHandler_WorkerJobsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMockIntrospectHandler(ctrl)
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+
+	req, err := http.NewRequest("GET", GetWorkerJobsPath+"?node="+nodeName, nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	nodeJob := worker.JobInfo{Job: "job-1", NodeName: nodeName, Status: worker.JobStatusRetrying, RetryCount: 2,
+		LastError: "error"}
+	mock.mockManager.EXPECT().GetResourceWorkers().Return(map[string]worker.Worker{resourceName: mockWorker})
+	mockWorker.EXPECT().Introspect().Return([]worker.JobInfo{
+		nodeJob, {Job: "job-2", NodeName: "other-node", Status: worker.JobStatusPending},
+	})
+
+	mock.handler.WorkerJobsHandler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	got := map[string][]worker.JobInfo{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Len(t, got[resourceName], 1)
+	assert.Equal(t, nodeJob.Job, got[resourceName][0].Job)
+	assert.Equal(t, nodeJob.Status, got[resourceName][0].Status)
+	assert.Equal(t, nodeJob.RetryCount, got[resourceName][0].RetryCount)
+	assert.Equal(t, nodeJob.LastError, got[resourceName][0].LastError)
+}
+
 func VerifyResponse(t *testing.T, rr *httptest.ResponseRecorder, response map[string]string) {
 	got := &map[string]string{}
 	err := json.Unmarshal(rr.Body.Bytes(), got)
@@ -97,3 +133,99 @@ func VerifyResponse(t *testing.T, rr *httptest.ResponseRecorder, response map[st
 	assert.Equal(t, rr.Code, http.StatusOK)
 	assert.Equal(t, response, *got)
 }
+
+// mockDeadLetterProvider is a resource provider that supports the dead letter queue
+type mockDeadLetterProvider struct {
+	*mock_provider.MockResourceProvider
+	err       error
+	retried   []string
+	forgotten []string
+}
+
+func (m *mockDeadLetterProvider) RetryDeadLetterResource(nodeName string, resourceID string) error {
+	m.retried = append(m.retried, nodeName+"/"+resourceID)
+	return m.err
+}
+
+func (m *mockDeadLetterProvider) ForgetDeadLetterResource(nodeName string, resourceID string) error {
+	m.forgotten = append(m.forgotten, nodeName+"/"+resourceID)
+	return m.err
+}
+
+func TestIntrospectHandler_RetryDeadLetterHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMockIntrospectHandler(ctrl)
+	deadLetterProvider := &mockDeadLetterProvider{MockResourceProvider: mock.mockProvider}
+
+	req, err := http.NewRequest(http.MethodPost, RetryDeadLetterResourcePath+"?node="+nodeName+"&id=eni-1", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	mock.mockManager.EXPECT().GetResourceProviders().
+		Return(map[string]provider.ResourceProvider{resourceName: deadLetterProvider})
+
+	mock.handler.RetryDeadLetterHandler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{nodeName + "/eni-1"}, deadLetterProvider.retried)
+}
+
+func TestIntrospectHandler_ForgetDeadLetterHandler_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMockIntrospectHandler(ctrl)
+	deadLetterProvider := &mockDeadLetterProvider{MockResourceProvider: mock.mockProvider,
+		err: provider.ErrDeadLetterResourceNotFound}
+
+	req, err := http.NewRequest(http.MethodPost, ForgetDeadLetterResourcePath+"?node="+nodeName+"&id=eni-1", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	mock.mockManager.EXPECT().GetResourceProviders().
+		Return(map[string]provider.ResourceProvider{resourceName: deadLetterProvider})
+
+	mock.handler.ForgetDeadLetterHandler(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, []string{nodeName + "/eni-1"}, deadLetterProvider.forgotten)
+}
+
+func TestIntrospectHandler_RetryDeadLetterHandler_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMockIntrospectHandler(ctrl)
+	deadLetterProvider := &mockDeadLetterProvider{MockResourceProvider: mock.mockProvider,
+		err: fmt.Errorf("throttled")}
+
+	req, err := http.NewRequest(http.MethodPost, RetryDeadLetterResourcePath+"?node="+nodeName+"&id=eni-1", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	mock.mockManager.EXPECT().GetResourceProviders().
+		Return(map[string]provider.ResourceProvider{resourceName: deadLetterProvider})
+
+	mock.handler.RetryDeadLetterHandler(rr, req)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "throttled", rr.Body.String())
+}
+
+func TestIntrospectHandler_DeadLetterHandler_InvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMockIntrospectHandler(ctrl)
+
+	req, err := http.NewRequest(http.MethodGet, RetryDeadLetterResourcePath+"?node="+nodeName+"&id=eni-1", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	mock.handler.RetryDeadLetterHandler(rr, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	req, err = http.NewRequest(http.MethodPost, RetryDeadLetterResourcePath+"?node="+nodeName, nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	mock.handler.RetryDeadLetterHandler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
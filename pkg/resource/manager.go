@@ -20,9 +20,11 @@ import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/handler"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/ip"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/ipv6"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -35,35 +37,59 @@ type Manager struct {
 type Resource struct {
 	handler.Handler
 	provider.ResourceProvider
+	// Worker is the worker pool processing the asynchronous jobs of the resource
+	Worker worker.Worker
 }
 
 type ResourceManager interface {
 	GetResourceProviders() map[string]provider.ResourceProvider
 	GetResourceHandler(resourceName string) (handler.Handler, bool)
+	GetResourceWorkers() map[string]worker.Worker
 }
 
-func NewResourceManager(ctx context.Context, resourceNames []string, wrapper api.Wrapper) (ResourceManager, error) {
-	// Load that static configuration of the resource
-	resourceConfig := config.LoadResourceConfig()
+func NewResourceManager(ctx context.Context, resourceNames []string, resourceConfigs map[string]config.ResourceConfig,
+	wrapper api.Wrapper) (ResourceManager, error) {
 
 	resources := make(map[string]Resource)
 
 	// For each supported resource, initialize the resource provider and handler
 	for _, resourceName := range resourceNames {
 
-		resourceConfig, ok := resourceConfig[resourceName]
+		resourceConfig, ok := resourceConfigs[resourceName]
 		if !ok {
 			return nil, fmt.Errorf("failed to find resource configuration %s", resourceName)
 		}
 
+		if warmPoolConfig := resourceConfig.WarmPoolConfig; warmPoolConfig != nil {
+			if _, err := pool.NewAssignmentStrategy(warmPoolConfig.AssignmentStrategy); err != nil {
+				return nil, fmt.Errorf("invalid warm pool configuration for resource %s: %v", resourceName, err)
+			}
+			if warmPoolConfig.MinimumIPTarget < 0 || warmPoolConfig.WarmENITarget < 0 {
+				return nil, fmt.Errorf("invalid warm pool configuration for resource %s: minimum ip target %d "+
+					"and warm eni target %d must not be negative", resourceName, warmPoolConfig.MinimumIPTarget,
+					warmPoolConfig.WarmENITarget)
+			}
+			if warmPoolConfig.IdleTTL < 0 || warmPoolConfig.IdleDesiredSize < 0 {
+				return nil, fmt.Errorf("invalid warm pool configuration for resource %s: idle ttl %s "+
+					"and idle desired size %d must not be negative", resourceName, warmPoolConfig.IdleTTL,
+					warmPoolConfig.IdleDesiredSize)
+			}
+			if warmPoolConfig.ReservedSize < 0 {
+				return nil, fmt.Errorf("invalid warm pool configuration for resource %s: reserved size %d "+
+					"must not be negative", resourceName, warmPoolConfig.ReservedSize)
+			}
+		}
+
 		ctrl.Log.Info("initializing resource", "resource name",
 			resourceName, "resource count", resourceConfig.WorkerCount)
 
-		workers := worker.NewDefaultWorkerPool(
-			resourceConfig.Name,
-			resourceConfig.WorkerCount,
-			config.WorkQueueDefaultMaxRetries,
-			ctrl.Log.WithName(fmt.Sprintf("%s-%s", resourceName, "worker")), ctx)
+		workerLog := ctrl.Log.WithName(fmt.Sprintf("%s-%s", resourceName, "worker"))
+		workers := worker.NewWorkerPool(resourceConfig.Name, worker.PoolConfig{
+			MinWorkerCount:     resourceConfig.WorkerCount,
+			MaxWorkerCount:     resourceConfig.MaxWorkerCount,
+			MaxRequeue:         config.WorkQueueDefaultMaxRetries,
+			MaxNodeConcurrency: resourceConfig.MaxNodeConcurrency,
+		}, workerLog, ctx)
 
 		var resourceHandler handler.Handler
 		var resourceProvider provider.ResourceProvider
@@ -73,6 +99,11 @@ func NewResourceManager(ctx context.Context, resourceNames []string, wrapper api
 				wrapper, workers, resourceConfig)
 			resourceHandler = handler.NewWarmResourceHandler(ctrl.Log.WithName(resourceName), wrapper,
 				resourceName, resourceProvider, ctx)
+		} else if resourceName == config.ResourceNameIPv6Address {
+			resourceProvider = ipv6.NewIPv6Provider(ctrl.Log.WithName("ipv6 provider"),
+				wrapper, workers, resourceConfig)
+			resourceHandler = handler.NewWarmResourceHandler(ctrl.Log.WithName(resourceName), wrapper,
+				resourceName, resourceProvider, ctx)
 		} else if resourceName == config.ResourceNamePodENI {
 			resourceProvider = branch.NewBranchENIProvider(ctrl.Log.WithName("branch eni provider"),
 				wrapper, workers, resourceConfig, ctx)
@@ -90,6 +121,7 @@ func NewResourceManager(ctx context.Context, resourceNames []string, wrapper api
 		resources[resourceName] = Resource{
 			Handler:          resourceHandler,
 			ResourceProvider: resourceProvider,
+			Worker:           workers,
 		}
 
 		ctrl.Log.Info("successfully initialized resource handler and provider",
@@ -116,3 +148,11 @@ func (m *Manager) GetResourceHandler(resourceName string) (handler.Handler, bool
 	}
 	return resource.Handler, found
 }
+
+func (m *Manager) GetResourceWorkers() map[string]worker.Worker {
+	workers := make(map[string]worker.Worker)
+	for resourceName, resource := range m.resource {
+		workers[resourceName] = resource.Worker
+	}
+	return workers
+}
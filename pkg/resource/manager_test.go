@@ -41,9 +41,9 @@ func Test_NewResourceManager(t *testing.T) {
 	defer ctrl.Finish()
 
 	mock := NewMock(ctrl)
-	resources := []string{config.ResourceNamePodENI, config.ResourceNameIPAddress}
+	resources := []string{config.ResourceNamePodENI, config.ResourceNameIPAddress, config.ResourceNameIPv6Address}
 
-	manger, err := NewResourceManager(context.TODO(), resources, mock.Wrapper)
+	manger, err := NewResourceManager(context.TODO(), resources, config.LoadResourceConfig(), mock.Wrapper)
 	assert.NoError(t, err)
 
 	_, ok := manger.GetResourceHandler(config.ResourceNamePodENI)
@@ -52,6 +52,57 @@ func Test_NewResourceManager(t *testing.T) {
 	_, ok = manger.GetResourceHandler(config.ResourceNameIPAddress)
 	assert.True(t, ok)
 
+	_, ok = manger.GetResourceHandler(config.ResourceNameIPv6Address)
+	assert.True(t, ok)
+
 	providers := manger.GetResourceProviders()
-	assert.Equal(t, len(providers), 2)
+	assert.Equal(t, len(providers), 3)
+}
+
+func Test_NewResourceManager_InvalidAssignmentStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl)
+	resourceConfig := config.LoadResourceConfig()
+	resourceConfig[config.ResourceNameIPAddress].WarmPoolConfig.AssignmentStrategy = "random"
+
+	_, err := NewResourceManager(context.TODO(), []string{config.ResourceNameIPAddress}, resourceConfig, mock.Wrapper)
+	assert.Error(t, err)
+}
+
+func Test_NewResourceManager_NegativeWarmENITarget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl)
+	resourceConfig := config.LoadResourceConfig()
+	resourceConfig[config.ResourceNameIPAddress].WarmPoolConfig.WarmENITarget = -1
+
+	_, err := NewResourceManager(context.TODO(), []string{config.ResourceNameIPAddress}, resourceConfig, mock.Wrapper)
+	assert.Error(t, err)
+}
+
+func Test_NewResourceManager_NegativeIdleDesiredSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl)
+	resourceConfig := config.LoadResourceConfig()
+	resourceConfig[config.ResourceNameIPAddress].WarmPoolConfig.IdleDesiredSize = -1
+
+	_, err := NewResourceManager(context.TODO(), []string{config.ResourceNameIPAddress}, resourceConfig, mock.Wrapper)
+	assert.Error(t, err)
+}
+
+func Test_NewResourceManager_NegativeReservedSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl)
+	resourceConfig := config.LoadResourceConfig()
+	resourceConfig[config.ResourceNameIPAddress].WarmPoolConfig.ReservedSize = -1
+
+	_, err := NewResourceManager(context.TODO(), []string{config.ResourceNameIPAddress}, resourceConfig, mock.Wrapper)
+	assert.Error(t, err)
 }
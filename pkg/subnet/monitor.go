@@ -0,0 +1,321 @@
+/*
+Copyright 2020 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License"). You may
+not use this file except in compliance with the License. A copy of the
+License is located at
+
+     http://aws.amazon.com/apache2.0/
+
+or in the "license" file accompanying this file. This file is distributed
+on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+express or implied. See the License for the specific language governing
+permissions and limitations under the License.
+*/
+
+package subnet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	ReasonSubnetLowOnIPAddresses = "SubnetLowOnIPAddresses"
+)
+
+var (
+	subnetAvailableIPAddressCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "subnet_available_ip_address_count",
+			Help: "The number of available IPv4 addresses in the subnet used by the managed nodes",
+		},
+		[]string{"subnet_id"},
+	)
+
+	subnetMonitorErrCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "subnet_monitor_err_count",
+			Help: "The number of errors encountered while refreshing the subnet available IP address count",
+		},
+	)
+
+	prometheusRegistered = false
+)
+
+func prometheusRegister() {
+	if !prometheusRegistered {
+		metrics.Registry.MustRegister(
+			subnetAvailableIPAddressCount,
+			subnetMonitorErrCount)
+
+		prometheusRegistered = true
+	}
+}
+
+// SubnetMonitor tracks the number of free IPv4 addresses in the subnets used by the managed
+// nodes and limits the capacity advertised for a node to the addresses it already holds plus
+// its share of the addresses left in its subnets
+type SubnetMonitor interface {
+	// LimitCapacity returns the capacity of the resource that can be advertised on the instance
+	// and remembers the instance so its capacity can be re-advertised on subnet updates. The
+	// usage returns the number of IP addresses of the subnets held by the resource on the node
+	LimitCapacity(instance ec2.EC2Instance, resourceName string, capacity int, usage func() int) int
+	// Forget stops tracking the capacity of the node
+	Forget(nodeName string)
+}
+
+// Monitor periodically describes the subnets of the managed nodes, exports the available IP
+// address count as metrics and broadcasts events on nodes with subnets running out of addresses
+type Monitor struct {
+	EC2API api.EC2APIHelper
+	K8sAPI k8s.K8sWrapper
+	Log    logr.Logger
+	// Interval between two consecutive refresh of the subnets
+	Interval time.Duration
+	// Threshold is the available IP address count below which events are broadcasted on the node
+	Threshold int
+	// EnableCapacityLimit limits the advertised capacity of a resource to the IP addresses it holds on the node plus
+	// its share of the available IP addresses, the available IP addresses of a subnet are shared evenly between the
+	// resources of the tracked nodes using the subnet
+	EnableCapacityLimit bool
+
+	lock sync.RWMutex // guards the following
+	// availableIPs is the last seen available IP address count per subnet
+	availableIPs map[string]int
+	// lowSubnets are the subnets that are currently below the threshold
+	lowSubnets map[string]struct{}
+	// nodes is the tracked instance and its resources per node
+	nodes map[string]*trackedNode
+}
+
+type trackedNode struct {
+	instance ec2.EC2Instance
+	// resources are the resources of the node drawing IP addresses from its subnets
+	resources map[string]*trackedResource
+}
+
+type trackedResource struct {
+	// capacity is the capacity of the resource before applying the subnet limit
+	capacity int
+	// usage returns the number of IP addresses held by the resource on the node
+	usage func() int
+}
+
+func (m *Monitor) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	m.availableIPs = make(map[string]int)
+	m.lowSubnets = make(map[string]struct{})
+	m.nodes = make(map[string]*trackedNode)
+
+	prometheusRegister()
+
+	return mgr.Add(m)
+}
+
+// Start starts the routine that refreshes the subnets after fixed intervals till shut down
+func (m *Monitor) Start(ctx context.Context) error {
+	m.Log.Info("starting subnet monitor", "interval", m.Interval, "threshold", m.Threshold,
+		"capacity limit enabled", m.EnableCapacityLimit)
+
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	m.refreshSubnets()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			m.refreshSubnets()
+		}
+	}
+}
+
+// LimitCapacity returns the minimum of the capacity and the IP addresses held by the resource on the
+// node plus its share of the IP addresses available across the subnets the instance can create
+// network interfaces in. If the subnets have not been described yet or the limit is disabled, the
+// capacity is returned as is
+func (m *Monitor) LimitCapacity(instance ec2.EC2Instance, resourceName string, capacity int, usage func() int) int {
+	// The usage is read without the lock held as it takes the locks of the resource provider
+	used := 0
+	if m.EnableCapacityLimit {
+		used = usage()
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	nodeName := instance.Name()
+	node, ok := m.nodes[nodeName]
+	if !ok || node.instance != instance {
+		node = &trackedNode{instance: instance, resources: make(map[string]*trackedResource)}
+		m.nodes[nodeName] = node
+	}
+	node.resources[resourceName] = &trackedResource{capacity: capacity, usage: usage}
+
+	if !m.EnableCapacityLimit {
+		return capacity
+	}
+	share, found := m.getAvailableShare(instance, m.countResourcesPerSubnet())
+	return limitCapacity(capacity, used, share, found)
+}
+
+// Forget stops tracking the node
+func (m *Monitor) Forget(nodeName string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.nodes, nodeName)
+}
+
+// limitCapacity returns the minimum of the capacity and the IP addresses used by the resource plus its share of the
+// available IP addresses, the capacity is not limited if none of the subnets was described yet
+func limitCapacity(capacity int, used int, share int, found bool) int {
+	if found && used+share < capacity {
+		return used + share
+	}
+	return capacity
+}
+
+// getAvailableShare returns the share of a resource of the instance of the available IP addresses of each candidate
+// subnet, the available IP addresses of a subnet are split evenly between the resources of the nodes using it. It
+// returns false if none of the subnets was described yet, must be called with the lock held
+func (m *Monitor) getAvailableShare(instance ec2.EC2Instance, resourcesPerSubnet map[string]int) (int, bool) {
+	available, found := 0, false
+	for _, subnet := range instance.CandidateSubnets() {
+		if count, ok := m.availableIPs[subnet.ID]; ok {
+			if resources := resourcesPerSubnet[subnet.ID]; resources > 1 {
+				count /= resources
+			}
+			available += count
+			found = true
+		}
+	}
+	return available, found
+}
+
+// countResourcesPerSubnet returns the number of resources of the tracked nodes that draw IP addresses from each
+// subnet, must be called with the lock held
+func (m *Monitor) countResourcesPerSubnet() map[string]int {
+	resourcesPerSubnet := map[string]int{}
+	for _, node := range m.nodes {
+		for _, subnet := range node.instance.CandidateSubnets() {
+			resourcesPerSubnet[subnet.ID] += len(node.resources)
+		}
+	}
+	return resourcesPerSubnet
+}
+
+// refreshSubnets describes all the subnets used by the tracked nodes, updates the metrics,
+// broadcasts events on nodes with subnets that crossed the threshold and re-advertises the
+// capacity of the nodes if the capacity limit is enabled
+func (m *Monitor) refreshSubnets() {
+	m.lock.Lock()
+	resourcesPerSubnet := m.countResourcesPerSubnet()
+	// Stop exporting the subnets that are no longer used by any node
+	for subnetID := range m.availableIPs {
+		if _, ok := resourcesPerSubnet[subnetID]; !ok {
+			delete(m.availableIPs, subnetID)
+			delete(m.lowSubnets, subnetID)
+			subnetAvailableIPAddressCount.DeleteLabelValues(subnetID)
+		}
+	}
+	m.lock.Unlock()
+
+	if len(resourcesPerSubnet) == 0 {
+		return
+	}
+
+	var ids []*string
+	for id := range resourcesPerSubnet {
+		ids = append(ids, aws.String(id))
+	}
+
+	subnets, err := m.EC2API.GetSubnets(ids)
+	if err != nil {
+		subnetMonitorErrCount.Inc()
+		m.Log.Error(err, "failed to describe subnets, will retry")
+		return
+	}
+
+	m.lock.Lock()
+	resourcesPerSubnet = m.countResourcesPerSubnet()
+	var crossedSubnets []string
+	for _, subnet := range subnets {
+		if subnet.SubnetId == nil || subnet.AvailableIpAddressCount == nil {
+			continue
+		}
+		subnetID, count := *subnet.SubnetId, int(*subnet.AvailableIpAddressCount)
+		m.availableIPs[subnetID] = count
+		subnetAvailableIPAddressCount.WithLabelValues(subnetID).Set(float64(count))
+
+		_, wasLow := m.lowSubnets[subnetID]
+		if count < m.Threshold && !wasLow {
+			m.lowSubnets[subnetID] = struct{}{}
+			crossedSubnets = append(crossedSubnets, subnetID)
+		} else if count >= m.Threshold && wasLow {
+			delete(m.lowSubnets, subnetID)
+		}
+	}
+
+	type nodeCapacity struct {
+		resourceName string
+		resource     *trackedResource
+		share        int
+		found        bool
+	}
+	nodesToNotify := map[string][]string{}
+	capacityToAdvertise := map[string][]nodeCapacity{}
+	for nodeName, node := range m.nodes {
+		for _, subnet := range node.instance.CandidateSubnets() {
+			for _, crossed := range crossedSubnets {
+				if subnet.ID == crossed {
+					nodesToNotify[nodeName] = append(nodesToNotify[nodeName], crossed)
+				}
+			}
+		}
+		if m.EnableCapacityLimit {
+			share, found := m.getAvailableShare(node.instance, resourcesPerSubnet)
+			for resourceName, resource := range node.resources {
+				capacityToAdvertise[nodeName] = append(capacityToAdvertise[nodeName],
+					nodeCapacity{resourceName: resourceName, resource: resource, share: share, found: found})
+			}
+		}
+	}
+	m.lock.Unlock()
+
+	for nodeName, subnetIDs := range nodesToNotify {
+		node, err := m.K8sAPI.GetNode(nodeName)
+		if err != nil {
+			m.Log.Error(err, "failed to get node to broadcast event", "node", nodeName)
+			continue
+		}
+		m.K8sAPI.BroadcastEvent(node, ReasonSubnetLowOnIPAddresses,
+			fmt.Sprintf("Subnets %v have less than %d available IP addresses", subnetIDs, m.Threshold),
+			v1.EventTypeWarning)
+	}
+
+	for nodeName, capacities := range capacityToAdvertise {
+		for _, c := range capacities {
+			// The usage is read without the lock held as it takes the locks of the resource provider
+			capacity := limitCapacity(c.resource.capacity, c.resource.usage(), c.share, c.found)
+			if err := m.K8sAPI.AdvertiseCapacityIfNotSet(nodeName, c.resourceName, capacity); err != nil {
+				m.Log.Error(err, "failed to advertise capacity", "node", nodeName,
+					"resource", c.resourceName, "capacity", capacity)
+			}
+		}
+	}
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2020 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License"). You may
+not use this file except in compliance with the License. A copy of the
+License is located at
+
+     http://aws.amazon.com/apache2.0/
+
+or in the "license" file accompanying this file. This file is distributed
+on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+express or implied. See the License for the specific language governing
+permissions and limitations under the License.
+*/
+
+package subnet
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	mock_ec2 "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
+	mock_api "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	nodeName = "node-name"
+
+	subnetID         = "subnet-000000000000000"
+	fallbackSubnetID = "subnet-000000000000001"
+
+	node = &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+)
+
+type Mock struct {
+	EC2API   *mock_api.MockEC2APIHelper
+	K8sAPI   *mock_k8s.MockK8sWrapper
+	Instance *mock_ec2.MockEC2Instance
+}
+
+func getMockMonitor(ctrl *gomock.Controller, enableCapacityLimit bool) (*Monitor, Mock) {
+	mock := Mock{
+		EC2API:   mock_api.NewMockEC2APIHelper(ctrl),
+		K8sAPI:   mock_k8s.NewMockK8sWrapper(ctrl),
+		Instance: mock_ec2.NewMockEC2Instance(ctrl),
+	}
+	return &Monitor{
+		EC2API:              mock.EC2API,
+		K8sAPI:              mock.K8sAPI,
+		Log:                 zap.New(zap.UseDevMode(true)),
+		Threshold:           10,
+		EnableCapacityLimit: enableCapacityLimit,
+		availableIPs:        map[string]int{},
+		lowSubnets:          map[string]struct{}{},
+		nodes:               map[string]*trackedNode{},
+	}, mock
+}
+
+// getUsage returns the usage function of a resource holding the given number of IP addresses
+func getUsage(used int) func() int {
+	return func() int { return used }
+}
+
+func getSubnet(id string, available int64) *awsEC2.Subnet {
+	return &awsEC2.Subnet{SubnetId: aws.String(id), AvailableIpAddressCount: aws.Int64(available)}
+}
+
+// TestMonitor_LimitCapacity_NotRefreshed tests the capacity is not limited before the subnets are described
+func TestMonitor_LimitCapacity_NotRefreshed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, mock := getMockMonitor(ctrl, true)
+
+	mock.Instance.EXPECT().Name().Return(nodeName)
+	mock.Instance.EXPECT().CandidateSubnets().Return([]ec2.Subnet{{ID: subnetID}}).Times(2)
+
+	capacity := monitor.LimitCapacity(mock.Instance, config.ResourceNamePodENI, 50, getUsage(0))
+	assert.Equal(t, 50, capacity)
+	assert.Equal(t, 50, monitor.nodes[nodeName].resources[config.ResourceNamePodENI].capacity)
+}
+
+// TestMonitor_LimitCapacity tests the capacity is limited to the available IPs across the candidate subnets
+func TestMonitor_LimitCapacity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, mock := getMockMonitor(ctrl, true)
+	monitor.availableIPs = map[string]int{subnetID: 5, fallbackSubnetID: 10}
+
+	mock.Instance.EXPECT().Name().Return(nodeName)
+	mock.Instance.EXPECT().CandidateSubnets().Return([]ec2.Subnet{{ID: subnetID}, {ID: fallbackSubnetID}}).Times(2)
+
+	capacity := monitor.LimitCapacity(mock.Instance, config.ResourceNamePodENI, 50, getUsage(0))
+	assert.Equal(t, 15, capacity)
+}
+
+// TestMonitor_LimitCapacity_Used tests the IPs already held by the resource on the node are added to the available
+// IPs, so the capacity never falls below the resources already in use
+func TestMonitor_LimitCapacity_Used(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, mock := getMockMonitor(ctrl, true)
+	monitor.availableIPs = map[string]int{subnetID: 5}
+
+	mock.Instance.EXPECT().Name().Return(nodeName)
+	mock.Instance.EXPECT().CandidateSubnets().Return([]ec2.Subnet{{ID: subnetID}}).Times(2)
+
+	capacity := monitor.LimitCapacity(mock.Instance, config.ResourceNamePodENI, 50, getUsage(30))
+	assert.Equal(t, 35, capacity)
+}
+
+// TestMonitor_LimitCapacity_SharedSubnet tests the available IPs of a subnet are shared between the nodes using it
+func TestMonitor_LimitCapacity_SharedSubnet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, mock := getMockMonitor(ctrl, true)
+	monitor.availableIPs = map[string]int{subnetID: 20, fallbackSubnetID: 10}
+	otherInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	monitor.nodes["other-node"] = &trackedNode{instance: otherInstance, resources: map[string]*trackedResource{
+		config.ResourceNamePodENI: {capacity: 50, usage: getUsage(0)}}}
+
+	otherInstance.EXPECT().CandidateSubnets().Return([]ec2.Subnet{{ID: subnetID}})
+	mock.Instance.EXPECT().Name().Return(nodeName)
+	mock.Instance.EXPECT().CandidateSubnets().Return([]ec2.Subnet{{ID: subnetID}, {ID: fallbackSubnetID}}).Times(2)
+
+	// Half of the shared subnet and all of the subnet used only by the node
+	capacity := monitor.LimitCapacity(mock.Instance, config.ResourceNamePodENI, 50, getUsage(0))
+	assert.Equal(t, 20, capacity)
+}
+
+// TestMonitor_LimitCapacity_SharedResources tests the available IPs of a subnet are shared between the resources of
+// the node drawing IPs from it
+func TestMonitor_LimitCapacity_SharedResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, mock := getMockMonitor(ctrl, true)
+	monitor.availableIPs = map[string]int{subnetID: 20}
+
+	mock.Instance.EXPECT().Name().Return(nodeName).Times(2)
+	mock.Instance.EXPECT().CandidateSubnets().Return([]ec2.Subnet{{ID: subnetID}}).AnyTimes()
+
+	capacity := monitor.LimitCapacity(mock.Instance, config.ResourceNamePodENI, 50, getUsage(2))
+	assert.Equal(t, 22, capacity)
+
+	// Half of the subnet once both the pod-eni and the IPv4 resources draw IPs from it
+	capacity = monitor.LimitCapacity(mock.Instance, config.ResourceNameIPAddress, 50, getUsage(4))
+	assert.Equal(t, 14, capacity)
+}
+
+// TestMonitor_LimitCapacity_Disabled tests the capacity is not limited if the limit is disabled
+func TestMonitor_LimitCapacity_Disabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, mock := getMockMonitor(ctrl, false)
+	monitor.availableIPs = map[string]int{subnetID: 5}
+
+	mock.Instance.EXPECT().Name().Return(nodeName)
+
+	capacity := monitor.LimitCapacity(mock.Instance, config.ResourceNamePodENI, 50, func() int {
+		assert.Fail(t, "usage must not be read if the limit is disabled")
+		return 0
+	})
+	assert.Equal(t, 50, capacity)
+}
+
+// TestMonitor_Forget tests the node is no longer tracked after forgetting it
+func TestMonitor_Forget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, mock := getMockMonitor(ctrl, false)
+	monitor.nodes[nodeName] = &trackedNode{instance: mock.Instance}
+
+	monitor.Forget(nodeName)
+	assert.NotContains(t, monitor.nodes, nodeName)
+}
+
+// TestMonitor_refreshSubnets_BelowThreshold tests the event is broadcasted once when the subnet crosses the
+// threshold and the capacity is re-advertised with the limit on each refresh
+func TestMonitor_refreshSubnets_BelowThreshold(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, mock := getMockMonitor(ctrl, true)
+	monitor.nodes[nodeName] = &trackedNode{instance: mock.Instance,
+		resources: map[string]*trackedResource{config.ResourceNamePodENI: {capacity: 50, usage: getUsage(0)}}}
+
+	mock.Instance.EXPECT().CandidateSubnets().Return([]ec2.Subnet{{ID: subnetID}}).AnyTimes()
+	mock.EC2API.EXPECT().GetSubnets([]*string{&subnetID}).Return([]*awsEC2.Subnet{getSubnet(subnetID, 4)}, nil).Times(2)
+	mock.K8sAPI.EXPECT().GetNode(nodeName).Return(node, nil)
+	mock.K8sAPI.EXPECT().BroadcastEvent(node, ReasonSubnetLowOnIPAddresses,
+		fmt.Sprintf("Subnets %v have less than %d available IP addresses", []string{subnetID}, 10),
+		v1.EventTypeWarning)
+	mock.K8sAPI.EXPECT().AdvertiseCapacityIfNotSet(nodeName, config.ResourceNamePodENI, 4).Return(nil).Times(2)
+
+	monitor.refreshSubnets()
+	monitor.refreshSubnets()
+
+	assert.Equal(t, 4, monitor.availableIPs[subnetID])
+	assert.Contains(t, monitor.lowSubnets, subnetID)
+}
+
+// TestMonitor_refreshSubnets_Used tests the capacity of each resource is re-advertised with the IPs it holds on the
+// node plus its share of the available IPs of the subnet
+func TestMonitor_refreshSubnets_Used(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, mock := getMockMonitor(ctrl, true)
+	monitor.nodes[nodeName] = &trackedNode{instance: mock.Instance,
+		resources: map[string]*trackedResource{
+			config.ResourceNamePodENI:    {capacity: 50, usage: getUsage(10)},
+			config.ResourceNameIPAddress: {capacity: 50, usage: getUsage(2)},
+		}}
+
+	mock.Instance.EXPECT().CandidateSubnets().Return([]ec2.Subnet{{ID: subnetID}}).AnyTimes()
+	mock.EC2API.EXPECT().GetSubnets([]*string{&subnetID}).Return([]*awsEC2.Subnet{getSubnet(subnetID, 40)}, nil)
+	mock.K8sAPI.EXPECT().AdvertiseCapacityIfNotSet(nodeName, config.ResourceNamePodENI, 30).Return(nil)
+	mock.K8sAPI.EXPECT().AdvertiseCapacityIfNotSet(nodeName, config.ResourceNameIPAddress, 22).Return(nil)
+
+	monitor.refreshSubnets()
+}
+
+// TestMonitor_refreshSubnets_Recovered tests the subnet is no longer marked low once it has enough addresses and
+// the original capacity is advertised back
+func TestMonitor_refreshSubnets_Recovered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, mock := getMockMonitor(ctrl, true)
+	monitor.lowSubnets[subnetID] = struct{}{}
+	monitor.nodes[nodeName] = &trackedNode{instance: mock.Instance,
+		resources: map[string]*trackedResource{config.ResourceNamePodENI: {capacity: 50, usage: getUsage(0)}}}
+
+	mock.Instance.EXPECT().CandidateSubnets().Return([]ec2.Subnet{{ID: subnetID}}).AnyTimes()
+	mock.EC2API.EXPECT().GetSubnets([]*string{&subnetID}).Return([]*awsEC2.Subnet{getSubnet(subnetID, 200)}, nil)
+	mock.K8sAPI.EXPECT().AdvertiseCapacityIfNotSet(nodeName, config.ResourceNamePodENI, 50).Return(nil)
+
+	monitor.refreshSubnets()
+
+	assert.NotContains(t, monitor.lowSubnets, subnetID)
+}
+
+// TestMonitor_refreshSubnets_Error tests the last seen count is retained if describing the subnets fails
+func TestMonitor_refreshSubnets_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, mock := getMockMonitor(ctrl, true)
+	monitor.availableIPs[subnetID] = 20
+	monitor.nodes[nodeName] = &trackedNode{instance: mock.Instance}
+
+	mock.Instance.EXPECT().CandidateSubnets().Return([]ec2.Subnet{{ID: subnetID}})
+	mock.EC2API.EXPECT().GetSubnets([]*string{&subnetID}).Return(nil, fmt.Errorf("mock error"))
+
+	monitor.refreshSubnets()
+
+	assert.Equal(t, 20, monitor.availableIPs[subnetID])
+}
+
+// TestMonitor_refreshSubnets_UnusedSubnet tests the subnets no longer used by any node are no longer tracked
+func TestMonitor_refreshSubnets_UnusedSubnet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, _ := getMockMonitor(ctrl, true)
+	monitor.availableIPs[subnetID] = 4
+	monitor.lowSubnets[subnetID] = struct{}{}
+	subnetAvailableIPAddressCount.WithLabelValues(subnetID).Set(4)
+
+	monitor.refreshSubnets()
+
+	assert.NotContains(t, monitor.availableIPs, subnetID)
+	assert.NotContains(t, monitor.lowSubnets, subnetID)
+	assert.False(t, subnetAvailableIPAddressCount.DeleteLabelValues(subnetID))
+}
+
+// TestMonitor_Start tests the monitor stops once the context is done
+func TestMonitor_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor, _ := getMockMonitor(ctrl, true)
+	monitor.Interval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- monitor.Start(ctx)
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("monitor didn't stop after the context was done")
+	}
+}
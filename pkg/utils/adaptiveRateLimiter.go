@@ -0,0 +1,171 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AdaptiveRateLimiterConfig is the configuration of the AIMD rate limiter
+type AdaptiveRateLimiterConfig struct {
+	// InitialQPS is the rate the limiter starts with, bounded by the min and the max qps
+	InitialQPS float64
+	// MinQPS and MaxQPS bound the rate of the limiter
+	MinQPS float64
+	MaxQPS float64
+	// Burst is the maximum number of requests made at once
+	Burst int
+	// IncreaseStep is the rate added on each successful request
+	IncreaseStep float64
+	// DecreaseFactor multiplies the rate on throttling, between 0 and 1
+	DecreaseFactor float64
+	// DecreaseInterval is the minimum time between two decreases, so the requests in flight when the throttling
+	// starts decrease the rate only once
+	DecreaseInterval time.Duration
+	// PriorityShares are the shares of the rate reserved to each priority class, from the highest to the lowest
+	// priority. The rate is not split when empty
+	PriorityShares []float64
+}
+
+// AdaptiveRateLimiter is a token bucket whose rate is increased additively on success and decreased
+// multiplicatively on throttling, so the client uses the spare quota and backs off when the quota is
+// consumed by other clients
+type AdaptiveRateLimiter struct {
+	config  AdaptiveRateLimiterConfig
+	limiter *PriorityRateLimiter
+	// onRateChange is called with the new rate each time the rate changes
+	onRateChange func(qps float64)
+	// now is replaced in the unit tests
+	now func() time.Time
+
+	lock         sync.Mutex // guards the following
+	qps          float64
+	lastDecrease time.Time
+}
+
+// NewAdaptiveRateLimiter returns the AIMD rate limiter, onRateChange is optional and is called with the
+// initial rate and then on each rate change
+func NewAdaptiveRateLimiter(config AdaptiveRateLimiterConfig,
+	onRateChange func(qps float64)) (*AdaptiveRateLimiter, error) {
+	if config.MinQPS <= 0 || config.MaxQPS < config.MinQPS {
+		return nil, fmt.Errorf("expected 0 < min qps <= max qps, got min %v and max %v",
+			config.MinQPS, config.MaxQPS)
+	}
+	if config.InitialQPS < config.MinQPS {
+		config.InitialQPS = config.MinQPS
+	}
+	if config.InitialQPS > config.MaxQPS {
+		config.InitialQPS = config.MaxQPS
+	}
+	if config.Burst < 1 {
+		return nil, fmt.Errorf("burst expected >0, got %d", config.Burst)
+	}
+	if config.IncreaseStep < 0 || config.DecreaseFactor <= 0 || config.DecreaseFactor > 1 {
+		return nil, fmt.Errorf("expected increase step >= 0 and 0 < decrease factor <= 1, got %v and %v",
+			config.IncreaseStep, config.DecreaseFactor)
+	}
+	limiter, err := NewPriorityRateLimiter(config.InitialQPS, config.Burst, config.PriorityShares)
+	if err != nil {
+		return nil, err
+	}
+	if onRateChange == nil {
+		onRateChange = func(float64) {}
+	}
+	onRateChange(config.InitialQPS)
+
+	return &AdaptiveRateLimiter{
+		config:       config,
+		limiter:      limiter,
+		onRateChange: onRateChange,
+		now:          time.Now,
+		qps:          config.InitialQPS,
+	}, nil
+}
+
+// QPS returns the current rate of the limiter
+func (a *AdaptiveRateLimiter) QPS() float64 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.qps
+}
+
+// OnSuccess increases the rate by the increase step up to the max qps
+func (a *AdaptiveRateLimiter) OnSuccess() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.qps >= a.config.MaxQPS || a.config.IncreaseStep == 0 {
+		return
+	}
+	a.setQPS(a.qps + a.config.IncreaseStep)
+}
+
+// OnThrottle decreases the rate by the decrease factor down to the min qps, unless the rate was already
+// decreased within the decrease interval
+func (a *AdaptiveRateLimiter) OnThrottle() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	now := a.now()
+	if !a.lastDecrease.IsZero() && now.Sub(a.lastDecrease) < a.config.DecreaseInterval {
+		return
+	}
+	a.lastDecrease = now
+	a.setQPS(a.qps * a.config.DecreaseFactor)
+}
+
+// setQPS updates the rate of the token bucket bounded by the min and the max qps, must be called with the
+// lock held
+func (a *AdaptiveRateLimiter) setQPS(qps float64) {
+	if qps > a.config.MaxQPS {
+		qps = a.config.MaxQPS
+	}
+	if qps < a.config.MinQPS {
+		qps = a.config.MinQPS
+	}
+	if qps == a.qps {
+		return
+	}
+	a.qps = qps
+	a.limiter.SetQPS(qps)
+	a.onRateChange(qps)
+}
+
+// NewAdaptiveRateLimitedClient returns a new HTTP client waiting on the adaptive rate limiter before each request
+// with the priority carried by the request context. The caller is responsible for reporting the outcome of the
+// requests to the limiter
+func NewAdaptiveRateLimitedClient(limiter *AdaptiveRateLimiter) *http.Client {
+	return &http.Client{
+		Transport: &priorityRateLimitedRoundTripper{
+			rt: http.DefaultTransport,
+			rl: limiter.limiter,
+		},
+	}
+}
+
+type priorityRateLimitedRoundTripper struct {
+	rt http.RoundTripper
+	rl *PriorityRateLimiter
+}
+
+func (pr *priorityRateLimitedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := pr.rl.Wait(req.Context(), PriorityFromContext(req.Context())); err != nil {
+		return nil, err
+	}
+	return pr.rt.RoundTrip(req)
+}
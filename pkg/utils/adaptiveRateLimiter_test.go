@@ -0,0 +1,99 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+var adaptiveConfig = AdaptiveRateLimiterConfig{
+	InitialQPS:       4,
+	MinQPS:           1,
+	MaxQPS:           5,
+	Burst:            2,
+	IncreaseStep:     0.5,
+	DecreaseFactor:   0.5,
+	DecreaseInterval: time.Second,
+}
+
+// TestAdaptiveRateLimiter_OnSuccess tests the rate is increased additively up to the max qps
+func TestAdaptiveRateLimiter_OnSuccess(t *testing.T) {
+	var rates []float64
+	limiter, err := NewAdaptiveRateLimiter(adaptiveConfig, func(qps float64) { rates = append(rates, qps) })
+	assert.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		limiter.OnSuccess()
+	}
+
+	assert.Equal(t, float64(5), limiter.QPS())
+	assert.Equal(t, rate.Limit(5), limiter.limiter.classes[0].limiter.Limit())
+	assert.Equal(t, []float64{4, 4.5, 5}, rates)
+}
+
+// TestAdaptiveRateLimiter_OnThrottle tests the rate is decreased multiplicatively down to the min qps and only
+// once within the decrease interval
+func TestAdaptiveRateLimiter_OnThrottle(t *testing.T) {
+	limiter, err := NewAdaptiveRateLimiter(adaptiveConfig, nil)
+	assert.NoError(t, err)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	limiter.OnThrottle()
+	assert.Equal(t, float64(2), limiter.QPS())
+
+	// The throttled calls in flight don't decrease the rate again
+	limiter.OnThrottle()
+	assert.Equal(t, float64(2), limiter.QPS())
+
+	now = now.Add(time.Second)
+	limiter.OnThrottle()
+	assert.Equal(t, float64(1), limiter.QPS())
+
+	now = now.Add(time.Second)
+	limiter.OnThrottle()
+	assert.Equal(t, float64(1), limiter.QPS())
+	assert.Equal(t, rate.Limit(1), limiter.limiter.classes[0].limiter.Limit())
+}
+
+// TestNewAdaptiveRateLimiter_InitialQPS tests the initial rate is bounded by the min and the max qps
+func TestNewAdaptiveRateLimiter_InitialQPS(t *testing.T) {
+	config := adaptiveConfig
+	config.InitialQPS = 10
+
+	limiter, err := NewAdaptiveRateLimiter(config, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(5), limiter.QPS())
+}
+
+// TestNewAdaptiveRateLimiter_InvalidConfig tests the invalid configurations are rejected
+func TestNewAdaptiveRateLimiter_InvalidConfig(t *testing.T) {
+	invalidMin := adaptiveConfig
+	invalidMin.MinQPS = 0
+	invalidMax := adaptiveConfig
+	invalidMax.MaxQPS = 0.5
+	invalidBurst := adaptiveConfig
+	invalidBurst.Burst = 0
+	invalidFactor := adaptiveConfig
+	invalidFactor.DecreaseFactor = 1.5
+
+	for _, config := range []AdaptiveRateLimiterConfig{invalidMin, invalidMax, invalidBurst, invalidFactor} {
+		_, err := NewAdaptiveRateLimiter(config, nil)
+		assert.Error(t, err)
+	}
+}
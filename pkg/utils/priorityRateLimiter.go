@@ -0,0 +1,150 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// borrowPollInterval is the maximum time a request waits for the token of its class before checking again if
+// it can borrow the token of an idle class
+const borrowPollInterval = time.Millisecond * 50
+
+type priorityKey struct{}
+
+// ContextWithPriority returns the context carrying the priority class of the requests made with it, 0 being the
+// highest priority
+func ContextWithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the priority class carried by the context, or -1 if the context has none
+func PriorityFromContext(ctx context.Context) int {
+	if priority, ok := ctx.Value(priorityKey{}).(int); ok {
+		return priority
+	}
+	return -1
+}
+
+// PriorityRateLimiter splits the rate between priority classes. Each class has a token bucket with its reserved
+// share of the rate and the requests of a class borrow the tokens of the classes without waiting requests
+type PriorityRateLimiter struct {
+	lock    sync.Mutex // guards the waiting count of the classes
+	classes []*priorityClass
+}
+
+type priorityClass struct {
+	share   float64
+	limiter *rate.Limiter
+	// waiting is the number of requests of the class waiting for a token
+	waiting int
+}
+
+// NewPriorityRateLimiter returns the rate limiter splitting the rate and the burst between the classes by their
+// share. The shares are ordered from the highest to the lowest priority and are normalized to add up to 1
+func NewPriorityRateLimiter(qps float64, burst int, shares []float64) (*PriorityRateLimiter, error) {
+	if len(shares) == 0 {
+		shares = []float64{1}
+	}
+	total := float64(0)
+	for _, share := range shares {
+		if share <= 0 {
+			return nil, fmt.Errorf("priority shares expected >0, got %v", shares)
+		}
+		total += share
+	}
+
+	p := &PriorityRateLimiter{}
+	for _, share := range shares {
+		share = share / total
+		classBurst := int(math.Round(share * float64(burst)))
+		if classBurst < 1 {
+			classBurst = 1
+		}
+		p.classes = append(p.classes, &priorityClass{
+			share:   share,
+			limiter: rate.NewLimiter(rate.Limit(share*qps), classBurst),
+		})
+	}
+	return p, nil
+}
+
+// SetQPS splits the new rate between the classes
+func (p *PriorityRateLimiter) SetQPS(qps float64) {
+	for _, class := range p.classes {
+		class.limiter.SetLimit(rate.Limit(class.share * qps))
+	}
+}
+
+// Wait blocks until the request of the priority class gets a token or the context is done. Priorities out of the
+// range of the classes are treated as the lowest priority
+func (p *PriorityRateLimiter) Wait(ctx context.Context, priority int) error {
+	class := p.classes[len(p.classes)-1]
+	if priority >= 0 && priority < len(p.classes) {
+		class = p.classes[priority]
+	}
+
+	p.lock.Lock()
+	class.waiting++
+	p.lock.Unlock()
+	defer func() {
+		p.lock.Lock()
+		class.waiting--
+		p.lock.Unlock()
+	}()
+
+	for {
+		if p.tryAcquire(class) {
+			return nil
+		}
+		// Wait for the next token of the class, checking regularly if another class became idle
+		reservation := class.limiter.Reserve()
+		delay := reservation.Delay()
+		reservation.Cancel()
+		if delay > borrowPollInterval {
+			delay = borrowPollInterval
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// tryAcquire takes a token from the class or from a class without waiting requests, returns false if no token
+// is available
+func (p *PriorityRateLimiter) tryAcquire(class *priorityClass) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if class.limiter.Allow() {
+		return true
+	}
+	for _, other := range p.classes {
+		if other != class && other.waiting == 0 && other.limiter.Allow() {
+			return true
+		}
+	}
+	return false
+}
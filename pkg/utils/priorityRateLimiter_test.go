@@ -0,0 +1,83 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+// TestNewPriorityRateLimiter_Shares tests the rate and the burst are split between the classes by their share
+func TestNewPriorityRateLimiter_Shares(t *testing.T) {
+	limiter, err := NewPriorityRateLimiter(10, 10, []float64{3, 1, 1})
+	assert.NoError(t, err)
+
+	assert.Equal(t, rate.Limit(6), limiter.classes[0].limiter.Limit())
+	assert.Equal(t, 6, limiter.classes[0].limiter.Burst())
+	assert.Equal(t, rate.Limit(2), limiter.classes[2].limiter.Limit())
+	assert.Equal(t, 2, limiter.classes[2].limiter.Burst())
+
+	limiter.SetQPS(5)
+	assert.Equal(t, rate.Limit(3), limiter.classes[0].limiter.Limit())
+	assert.Equal(t, rate.Limit(1), limiter.classes[2].limiter.Limit())
+
+	_, err = NewPriorityRateLimiter(10, 10, []float64{1, 0})
+	assert.Error(t, err)
+}
+
+// TestPriorityRateLimiter_Borrow tests a class borrows the tokens of the idle classes
+func TestPriorityRateLimiter_Borrow(t *testing.T) {
+	limiter, err := NewPriorityRateLimiter(0.001, 2, []float64{1, 1})
+	assert.NoError(t, err)
+
+	low := limiter.classes[1]
+	assert.True(t, limiter.tryAcquire(low))
+	assert.True(t, limiter.tryAcquire(low))
+	assert.False(t, limiter.tryAcquire(low))
+}
+
+// TestPriorityRateLimiter_Reserved tests a class doesn't borrow the tokens reserved to the classes with waiting
+// requests
+func TestPriorityRateLimiter_Reserved(t *testing.T) {
+	limiter, err := NewPriorityRateLimiter(0.001, 2, []float64{1, 1})
+	assert.NoError(t, err)
+
+	limiter.classes[0].waiting = 1
+	assert.NoError(t, limiter.Wait(context.Background(), 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, limiter.Wait(ctx, 1))
+	assert.Equal(t, 0, limiter.classes[1].waiting)
+
+	// The token of the high priority class is still available to its requests
+	assert.True(t, limiter.tryAcquire(limiter.classes[0]))
+}
+
+// TestPriorityRateLimiter_UnknownPriority tests the requests without a priority are in the lowest class
+func TestPriorityRateLimiter_UnknownPriority(t *testing.T) {
+	limiter, err := NewPriorityRateLimiter(0.001, 2, []float64{1, 1})
+	assert.NoError(t, err)
+
+	assert.Equal(t, -1, PriorityFromContext(context.Background()))
+	assert.Equal(t, 1, PriorityFromContext(ContextWithPriority(context.Background(), 1)))
+
+	limiter.classes[0].waiting = 1
+	assert.NoError(t, limiter.Wait(context.Background(), PriorityFromContext(context.Background())))
+	assert.False(t, limiter.tryAcquire(limiter.classes[1]))
+}
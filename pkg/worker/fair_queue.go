@@ -0,0 +1,220 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// fairQueue is a rate limiting queue with a FIFO queue per node. The nodes are served in round-robin and each
+// node has at most maxNodeConcurrency jobs processed at the same time, so the jobs of a busy node don't delay the
+// jobs of the other nodes. Like the k8s work queue, a job is queued at most once and is never processed
+// concurrently with itself
+type fairQueue struct {
+	rateLimiter        workqueue.RateLimiter
+	maxNodeConcurrency int
+
+	lock sync.Mutex
+	cond *sync.Cond
+	// nodes are the nodes with queued jobs, in the round-robin order
+	nodes []string
+	// queues are the queued jobs of each node
+	queues map[string][]interface{}
+	// processing is the number of jobs of each node being processed
+	processing map[string]int
+	// dirty are the jobs queued or added while being processed
+	dirty map[interface{}]struct{}
+	// active are the jobs being processed
+	active       map[interface{}]struct{}
+	shuttingDown bool
+}
+
+// newFairQueue returns the fair queue with the given maximum number of jobs processed concurrently per node
+func newFairQueue(rateLimiter workqueue.RateLimiter, maxNodeConcurrency int) *fairQueue {
+	q := &fairQueue{
+		rateLimiter:        rateLimiter,
+		maxNodeConcurrency: maxNodeConcurrency,
+		queues:             map[string][]interface{}{},
+		processing:         map[string]int{},
+		dirty:              map[interface{}]struct{}{},
+		active:             map[interface{}]struct{}{},
+	}
+	q.cond = sync.NewCond(&q.lock)
+	return q
+}
+
+// jobNodeName returns the name of the node of the job, jobs without a node share the same queue
+func jobNodeName(job interface{}) string {
+	switch j := job.(type) {
+	case OnDemandJob:
+		return j.NodeName
+	case *OnDemandJob:
+		return j.NodeName
+	case WarmPoolJob:
+		return j.NodeName
+	case *WarmPoolJob:
+		return j.NodeName
+	}
+	return ""
+}
+
+func (q *fairQueue) Add(item interface{}) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.shuttingDown {
+		return
+	}
+	if _, ok := q.dirty[item]; ok {
+		return
+	}
+	q.dirty[item] = struct{}{}
+	// The job is queued again once processed
+	if _, ok := q.active[item]; ok {
+		return
+	}
+	q.push(item)
+}
+
+// push adds the job at the end of its node's queue, must be called with the lock held
+func (q *fairQueue) push(item interface{}) {
+	nodeName := jobNodeName(item)
+	if _, ok := q.queues[nodeName]; !ok {
+		q.nodes = append(q.nodes, nodeName)
+	}
+	q.queues[nodeName] = append(q.queues[nodeName], item)
+	q.cond.Signal()
+}
+
+func (q *fairQueue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	length := 0
+	for _, queue := range q.queues {
+		length += len(queue)
+	}
+	return length
+}
+
+// Get blocks until a node under its concurrency cap has a queued job and returns the first job of the next
+// such node in the round-robin order
+func (q *fairQueue) Get() (item interface{}, shutdown bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for {
+		if item, ok := q.pop(); ok {
+			return item, false
+		}
+		if q.shuttingDown {
+			return nil, true
+		}
+		q.cond.Wait()
+	}
+}
+
+// pop returns the first job of the first node under its concurrency cap and moves the node to the end of the
+// round-robin order, must be called with the lock held
+func (q *fairQueue) pop() (interface{}, bool) {
+	for i, nodeName := range q.nodes {
+		if q.maxNodeConcurrency > 0 && q.processing[nodeName] >= q.maxNodeConcurrency {
+			continue
+		}
+		queue := q.queues[nodeName]
+		item := queue[0]
+		q.nodes = append(q.nodes[:i:i], q.nodes[i+1:]...)
+		if len(queue) > 1 {
+			q.queues[nodeName] = queue[1:]
+			q.nodes = append(q.nodes, nodeName)
+		} else {
+			delete(q.queues, nodeName)
+		}
+
+		q.processing[nodeName]++
+		q.active[item] = struct{}{}
+		delete(q.dirty, item)
+		return item, true
+	}
+	return nil, false
+}
+
+func (q *fairQueue) Done(item interface{}) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	nodeName := jobNodeName(item)
+	delete(q.active, item)
+	if q.processing[nodeName]--; q.processing[nodeName] <= 0 {
+		delete(q.processing, nodeName)
+	}
+	if _, ok := q.dirty[item]; ok {
+		q.push(item)
+	}
+	// The node may be under its concurrency cap again
+	q.cond.Broadcast()
+}
+
+func (q *fairQueue) ShutDown() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+// ShutDownWithDrain shuts down the queue and waits for the jobs being processed to be done
+func (q *fairQueue) ShutDownWithDrain() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.shuttingDown = true
+	q.cond.Broadcast()
+	for len(q.active) > 0 {
+		q.cond.Wait()
+	}
+}
+
+func (q *fairQueue) ShuttingDown() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.shuttingDown
+}
+
+func (q *fairQueue) AddAfter(item interface{}, duration time.Duration) {
+	if q.ShuttingDown() {
+		return
+	}
+	if duration <= 0 {
+		q.Add(item)
+		return
+	}
+	time.AfterFunc(duration, func() { q.Add(item) })
+}
+
+func (q *fairQueue) AddRateLimited(item interface{}) {
+	q.AddAfter(item, q.rateLimiter.When(item))
+}
+
+func (q *fairQueue) Forget(item interface{}) {
+	q.rateLimiter.Forget(item)
+}
+
+func (q *fairQueue) NumRequeues(item interface{}) int {
+	return q.rateLimiter.NumRequeues(item)
+}
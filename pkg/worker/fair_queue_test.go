@@ -0,0 +1,151 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func getFairQueue(maxNodeConcurrency int) *fairQueue {
+	return newFairQueue(workqueue.DefaultControllerRateLimiter(), maxNodeConcurrency)
+}
+
+func createJob(nodeName string, podName string) OnDemandJob {
+	return NewOnDemandCreateJob(nodeName, "default", podName, 1)
+}
+
+// TestFairQueue_RoundRobin tests the jobs of the nodes are returned in round-robin
+func TestFairQueue_RoundRobin(t *testing.T) {
+	q := getFairQueue(0)
+	q.Add(createJob("node-a", "a1"))
+	q.Add(createJob("node-a", "a2"))
+	q.Add(createJob("node-a", "a3"))
+	q.Add(createJob("node-b", "b1"))
+	q.Add(NewWarmPoolCreateJob("node-c", 1))
+	assert.Equal(t, 5, q.Len())
+
+	var podNames []string
+	for i := 0; i < 5; i++ {
+		job, shutdown := q.Get()
+		assert.False(t, shutdown)
+		if onDemandJob, ok := job.(OnDemandJob); ok {
+			podNames = append(podNames, onDemandJob.PodName)
+		} else {
+			podNames = append(podNames, job.(*WarmPoolJob).NodeName)
+		}
+		q.Done(job)
+	}
+
+	assert.Equal(t, []string{"a1", "b1", "node-c", "a2", "a3"}, podNames)
+}
+
+// TestFairQueue_MaxNodeConcurrency tests a node at its concurrency cap doesn't get more jobs until a job is done
+func TestFairQueue_MaxNodeConcurrency(t *testing.T) {
+	q := getFairQueue(1)
+	a1, a2, b1 := createJob("node-a", "a1"), createJob("node-a", "a2"), createJob("node-b", "b1")
+	q.Add(a1)
+	q.Add(a2)
+	q.Add(b1)
+
+	job, _ := q.Get()
+	assert.Equal(t, a1, job)
+	job, _ = q.Get()
+	assert.Equal(t, b1, job)
+
+	jobs := make(chan interface{})
+	go func() {
+		job, _ := q.Get()
+		jobs <- job
+	}()
+	select {
+	case <-jobs:
+		assert.Fail(t, "expected the node at its concurrency cap to get no job")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	q.Done(a1)
+	select {
+	case job := <-jobs:
+		assert.Equal(t, a2, job)
+	case <-time.After(time.Second):
+		assert.Fail(t, "expected the next job of the node once a job is done")
+	}
+}
+
+// TestFairQueue_Deduplicate tests a job is queued once and a job added while processed is queued once done
+func TestFairQueue_Deduplicate(t *testing.T) {
+	q := getFairQueue(0)
+	job := createJob("node-a", "a1")
+	q.Add(job)
+	q.Add(job)
+	assert.Equal(t, 1, q.Len())
+
+	processing, _ := q.Get()
+	q.Add(job)
+	assert.Equal(t, 0, q.Len())
+
+	q.Done(processing)
+	assert.Equal(t, 1, q.Len())
+}
+
+// TestFairQueue_ShutDown tests the queued jobs are returned before the shut down
+func TestFairQueue_ShutDown(t *testing.T) {
+	q := getFairQueue(0)
+	q.Add(createJob("node-a", "a1"))
+	q.ShutDown()
+	q.Add(createJob("node-a", "a2"))
+
+	job, shutdown := q.Get()
+	assert.False(t, shutdown)
+	assert.Equal(t, createJob("node-a", "a1"), job)
+	q.Done(job)
+
+	_, shutdown = q.Get()
+	assert.True(t, shutdown)
+}
+
+// TestFairWorkerPool_SubmitJob tests the jobs submitted to the fair worker pool are executed
+func TestFairWorkerPool_SubmitJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := NewFairWorkerPool(resourceName, 2, maxRequeue, 1, zap.New(zap.UseDevMode(true)), ctx)
+
+	var lock sync.Mutex
+	var podNames []string
+	var wg sync.WaitGroup
+	wg.Add(3)
+	assert.NoError(t, w.StartWorkerPool(func(job interface{}) (ctrl.Result, error) {
+		lock.Lock()
+		podNames = append(podNames, job.(OnDemandJob).PodName)
+		lock.Unlock()
+		wg.Done()
+		return ctrl.Result{}, nil
+	}))
+
+	w.SubmitJob(createJob("node-a", "a1"))
+	w.SubmitJob(createJob("node-a", "a2"))
+	w.SubmitJobAfter(createJob("node-b", "b1"), time.Millisecond*10)
+	wg.Wait()
+
+	assert.ElementsMatch(t, []string{"a1", "a2", "b1"}, podNames)
+}
@@ -0,0 +1,159 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package worker
+
+import (
+	"sort"
+	"time"
+)
+
+// JobStatus is the status of a job submitted to the worker
+type JobStatus string
+
+const (
+	// JobStatusPending represents a job waiting in the queue or waiting for its submission delay
+	JobStatusPending JobStatus = "Pending"
+	// JobStatusInFlight represents a job being processed by a worker routine
+	JobStatusInFlight JobStatus = "InFlight"
+	// JobStatusRetrying represents a job that failed and is waiting for its next retry
+	JobStatusRetrying JobStatus = "Retrying"
+)
+
+// JobInfo is the introspection view of a job submitted to the worker
+type JobInfo struct {
+	// Job is the submitted job
+	Job interface{} `json:"job"`
+	// NodeName is the name of the node of the job, empty for jobs without a node
+	NodeName string `json:"nodeName,omitempty"`
+	// Status is the status of the job
+	Status JobStatus `json:"status"`
+	// RetryCount is the number of times the job has failed and was retried
+	RetryCount int `json:"retryCount"`
+	// LastError is the error returned by the last failed attempt of the job
+	LastError string `json:"lastError,omitempty"`
+	// SubmittedAt is the time the job was first submitted
+	SubmittedAt time.Time `json:"submittedAt"`
+
+	// resubmitted is set when the job is submitted again while in flight, the job is processed again once done
+	resubmitted bool
+}
+
+// Introspect returns the pending, in flight and retrying jobs in the order they were submitted
+func (w *worker) Introspect() []JobInfo {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	jobs := make([]JobInfo, 0, len(w.jobs))
+	for _, info := range w.jobs {
+		jobs = append(jobs, *info)
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].SubmittedAt.Before(jobs[j].SubmittedAt)
+	})
+	return jobs
+}
+
+// CancelNodeJobs cancels the queued jobs of the node and returns the number of jobs cancelled. The jobs in flight
+// are not interrupted, the cancelled jobs are dropped when they reach the head of the queue
+func (w *worker) CancelNodeJobs(nodeName string) int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	cancelled := 0
+	for job, info := range w.jobs {
+		if info.NodeName != nodeName {
+			continue
+		}
+		if info.Status == JobStatusInFlight {
+			// Only the resubmission of the job in flight is queued
+			if !info.resubmitted {
+				continue
+			}
+			info.resubmitted = false
+		} else {
+			delete(w.jobs, job)
+		}
+		w.cancelled[job] = struct{}{}
+		cancelled++
+	}
+	if cancelled > 0 {
+		w.Log.Info("cancelled the queued jobs of the node", "node name", nodeName, "jobs", cancelled)
+	}
+	return cancelled
+}
+
+// trackSubmittedJob records the job as pending unless it is already queued or in flight
+func (w *worker) trackSubmittedJob(job interface{}) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	// The job is submitted again so it must not be dropped
+	delete(w.cancelled, job)
+
+	if info, ok := w.jobs[job]; ok {
+		if info.Status == JobStatusInFlight {
+			info.resubmitted = true
+		}
+		return
+	}
+	w.jobs[job] = &JobInfo{
+		Job:         job,
+		NodeName:    jobNodeName(job),
+		Status:      JobStatusPending,
+		SubmittedAt: time.Now(),
+	}
+}
+
+// startJob records the job as in flight, returns false if the job was cancelled and must be dropped
+func (w *worker) startJob(job interface{}) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if _, ok := w.cancelled[job]; ok {
+		delete(w.cancelled, job)
+		return false
+	}
+	info, ok := w.jobs[job]
+	if !ok {
+		info = &JobInfo{Job: job, NodeName: jobNodeName(job), SubmittedAt: time.Now()}
+		w.jobs[job] = info
+	}
+	info.Status = JobStatusInFlight
+	return true
+}
+
+// finishJob records the outcome of the job, the job is no longer tracked once it completes or is not retried
+// anymore unless it was submitted again while in flight
+func (w *worker) finishJob(job interface{}, status JobStatus, retryCount int, err error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	info, ok := w.jobs[job]
+	if !ok {
+		return
+	}
+	if err != nil {
+		info.LastError = err.Error()
+	}
+	info.RetryCount = retryCount
+	if status == "" {
+		if !info.resubmitted {
+			delete(w.jobs, job)
+			return
+		}
+		status = JobStatusPending
+	}
+	info.Status = status
+	info.resubmitted = false
+}
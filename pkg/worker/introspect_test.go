@@ -0,0 +1,126 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package worker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// getIntrospectWorker returns the worker processing the jobs with the worker function only when the test calls
+// processNextItem
+func getIntrospectWorker(ctx context.Context, workerFunc func(interface{}) (ctrl.Result, error)) *worker {
+	w := NewWorkerPool(resourceName, PoolConfig{MinWorkerCount: 1, MaxRequeue: maxRequeue},
+		zap.New(zap.UseDevMode(true)), ctx).(*worker)
+	w.workerFunc = workerFunc
+	return w
+}
+
+// TestWorker_Introspect tests the pending and retrying jobs are returned with the retry count and the last error,
+// and the completed jobs are no longer returned
+func TestWorker_Introspect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	failingJob, otherJob := createJob("node-a", "a1"), createJob("node-b", "b1")
+	w := getIntrospectWorker(ctx, func(job interface{}) (ctrl.Result, error) {
+		if job == failingJob {
+			return ctrl.Result{}, fmt.Errorf("trunk not found")
+		}
+		return ctrl.Result{}, nil
+	})
+
+	w.SubmitJob(failingJob)
+	w.SubmitJob(otherJob)
+	jobs := w.Introspect()
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, failingJob, jobs[0].Job)
+	assert.Equal(t, "node-a", jobs[0].NodeName)
+	assert.Equal(t, JobStatusPending, jobs[0].Status)
+
+	assert.True(t, w.processNextItem())
+	jobs = w.Introspect()
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, JobStatusRetrying, jobs[0].Status)
+	assert.Equal(t, 1, jobs[0].RetryCount)
+	assert.Equal(t, "trunk not found", jobs[0].LastError)
+
+	assert.True(t, w.processNextItem())
+	jobs = w.Introspect()
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, failingJob, jobs[0].Job)
+}
+
+// TestWorker_CancelNodeJobs tests the queued and delayed jobs of the node are dropped without being processed
+func TestWorker_CancelNodeJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var processed []interface{}
+	w := getIntrospectWorker(ctx, func(job interface{}) (ctrl.Result, error) {
+		processed = append(processed, job)
+		return ctrl.Result{}, nil
+	})
+
+	w.SubmitJob(createJob("node-a", "a1"))
+	w.SubmitJob(createJob("node-b", "b1"))
+	w.SubmitJobAfter(NewWarmPoolReSyncJob("node-a"), time.Millisecond*10)
+
+	assert.Equal(t, 2, w.CancelNodeJobs("node-a"))
+	jobs := w.Introspect()
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, "node-b", jobs[0].NodeName)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, w.processNextItem())
+	}
+	assert.Equal(t, []interface{}{createJob("node-b", "b1")}, processed)
+	assert.Empty(t, w.Introspect())
+	assert.Empty(t, w.cancelled)
+}
+
+// TestWorker_CancelNodeJobs_Resubmitted tests the job submitted again while in flight stays pending once processed
+// and its resubmission is cancelled
+func TestWorker_CancelNodeJobs_Resubmitted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	job := createJob("node-a", "a1")
+	invoked := 0
+	var w *worker
+	w = getIntrospectWorker(ctx, func(job interface{}) (ctrl.Result, error) {
+		invoked++
+		// Job in flight doesn't get cancelled
+		assert.Equal(t, 0, w.CancelNodeJobs("node-a"))
+		w.SubmitJob(job)
+		return ctrl.Result{}, nil
+	})
+
+	w.SubmitJob(job)
+	assert.True(t, w.processNextItem())
+	jobs := w.Introspect()
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, JobStatusPending, jobs[0].Status)
+
+	assert.Equal(t, 1, w.CancelNodeJobs("node-a"))
+	assert.True(t, w.processNextItem())
+	assert.Equal(t, 1, invoked)
+	assert.Empty(t, w.Introspect())
+}
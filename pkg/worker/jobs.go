@@ -34,6 +34,9 @@ const (
 	OperationReSyncPool Operations = "ReSyncPool"
 	// OperationDeleteNode represents the job to delete the node
 	OperationDeleteNode Operations = "NodeDelete"
+	// OperationProcessCoolDownQueue represents the job checking the resources cooling down on a node until the cool
+	// down queue is empty
+	OperationProcessCoolDownQueue Operations = "ProcessCoolDownQueue"
 )
 
 // OnDemandJob represents the job that will be executed by the respective worker
@@ -53,9 +56,10 @@ type OnDemandJob struct {
 }
 
 // NewOnDemandDeleteJob returns an on demand job for operation Create or Update
-func NewOnDemandCreateJob(podNamespace string, podName string, requestCount int) OnDemandJob {
+func NewOnDemandCreateJob(nodeName string, podNamespace string, podName string, requestCount int) OnDemandJob {
 	return OnDemandJob{
 		Operation:    OperationCreate,
+		NodeName:     nodeName,
 		PodNamespace: podNamespace,
 		PodName:      podName,
 		RequestCount: requestCount,
@@ -87,6 +91,14 @@ func NewOnDemandProcessDeleteQueueJob(nodeName string) OnDemandJob {
 	}
 }
 
+// NewOnDemandProcessCoolDownQueueJob returns a process cool down queue job
+func NewOnDemandProcessCoolDownQueueJob(nodeName string) OnDemandJob {
+	return OnDemandJob{
+		Operation: OperationProcessCoolDownQueue,
+		NodeName:  nodeName,
+	}
+}
+
 // NewOnDemandDeleteNodeJob returns a delete node job
 func NewOnDemandDeleteNodeJob(nodeName string) OnDemandJob {
 	return OnDemandJob{
@@ -139,3 +151,11 @@ func NewWarmProcessDeleteQueueJob(nodeName string) *WarmPoolJob {
 		NodeName:   nodeName,
 	}
 }
+
+// NewWarmProcessCoolDownQueueJob returns a process cool down queue job
+func NewWarmProcessCoolDownQueueJob(nodeName string) *WarmPoolJob {
+	return &WarmPoolJob{
+		Operations: OperationProcessCoolDownQueue,
+		NodeName:   nodeName,
+	}
+}
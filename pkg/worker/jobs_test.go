@@ -31,9 +31,10 @@ var (
 
 // TestNewOnDemandCreateJob tests the fields of Create Job
 func TestNewOnDemandCreateJob(t *testing.T) {
-	onDemandJob := NewOnDemandCreateJob(podNamespace, podName, reqCount)
+	onDemandJob := NewOnDemandCreateJob(nodeName, podNamespace, podName, reqCount)
 
 	assert.Equal(t, OperationCreate, onDemandJob.Operation)
+	assert.Equal(t, nodeName, onDemandJob.NodeName)
 	assert.Equal(t, podName, onDemandJob.PodName)
 	assert.Equal(t, podNamespace, onDemandJob.PodNamespace)
 	assert.Equal(t, reqCount, onDemandJob.RequestCount)
@@ -61,6 +62,13 @@ func TestNewOnDemandProcessDeleteQueueJob(t *testing.T) {
 	assert.Equal(t, nodeName, onDemandJob.NodeName)
 }
 
+func TestNewOnDemandProcessCoolDownQueueJob(t *testing.T) {
+	onDemandJob := NewOnDemandProcessCoolDownQueueJob(nodeName)
+
+	assert.Equal(t, OperationProcessCoolDownQueue, onDemandJob.Operation)
+	assert.Equal(t, nodeName, onDemandJob.NodeName)
+}
+
 func TestNewWarmPoolCreateJob(t *testing.T) {
 	warmPoolJob := NewWarmPoolCreateJob(nodeName, 2)
 
@@ -85,3 +93,10 @@ func TestNewWarmPoolReSyncJob(t *testing.T) {
 	assert.Equal(t, OperationReSyncPool, WarmPoolJob.Operations)
 	assert.Equal(t, nodeName, WarmPoolJob.NodeName)
 }
+
+func TestNewWarmProcessCoolDownQueueJob(t *testing.T) {
+	warmPoolJob := NewWarmProcessCoolDownQueueJob(nodeName)
+
+	assert.Equal(t, OperationProcessCoolDownQueue, warmPoolJob.Operations)
+	assert.Equal(t, nodeName, warmPoolJob.NodeName)
+}
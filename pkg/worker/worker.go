@@ -16,6 +16,8 @@ package worker
 import (
 	"context"
 	"errors"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -51,6 +53,41 @@ var (
 			Help: "The number of jobs that failed to complete after retries",
 		}, []string{"resource"},
 	)
+
+	jobsCancelledCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jobs_cancelled_count",
+			Help: "The number of queued jobs dropped as their node was deleted",
+		}, []string{"resource"},
+	)
+
+	workerQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "worker_queue_depth",
+			Help: "The number of jobs waiting in the queue of the worker pool",
+		}, []string{"resource"},
+	)
+
+	workerJobsInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "worker_jobs_in_flight",
+			Help: "The number of jobs being processed by the worker routines",
+		}, []string{"resource"},
+	)
+
+	workerRoutines = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "worker_routines",
+			Help: "The number of worker routines running in the worker pool",
+		}, []string{"resource"},
+	)
+)
+
+const (
+	// defaultScaleInterval is the interval at which the number of worker routines is adjusted to the queue
+	defaultScaleInterval = time.Second
+	// latencyWeight is the weight of the latest job in the average job latency
+	latencyWeight = 0.2
 )
 
 // Errors
@@ -62,6 +99,24 @@ type Worker interface {
 	StartWorkerPool(func(interface{}) (ctrl.Result, error)) error
 	SubmitJob(job interface{})
 	SubmitJobAfter(job interface{}, submitAfter time.Duration)
+	// Introspect returns the pending, in flight and retrying jobs
+	Introspect() []JobInfo
+	// CancelNodeJobs cancels the queued jobs of the node and returns the number of jobs cancelled
+	CancelNodeJobs(nodeName string) int
+}
+
+// PoolConfig is the configuration of a worker pool
+type PoolConfig struct {
+	// MinWorkerCount is the number of worker routines the pool starts with and scales down to
+	MinWorkerCount int
+	// MaxWorkerCount is the number of worker routines the pool scales up to when the jobs queue up. Optional,
+	// defaults to the min worker count
+	MaxWorkerCount int
+	// MaxRequeue is the number of times to retry a job in case of failure
+	MaxRequeue int
+	// MaxNodeConcurrency is the maximum number of jobs of a node processed at the same time. Optional, when set
+	// the jobs of the nodes are processed in round-robin instead of in the order they were submitted
+	MaxNodeConcurrency int
 }
 
 type worker struct {
@@ -73,29 +128,81 @@ type worker struct {
 	workerFunc func(interface{}) (ctrl.Result, error)
 	// maxRetries is the number of times to retry item in case of failure
 	maxRetriesOnErr int
+	// minWorkerCount represents the number of workers the pool starts with and scales down to
+	minWorkerCount int
 	// maxWorkerCount represents the maximum number of workers that will be started
 	maxWorkerCount int
+	// scaleInterval is the interval at which the number of workers is adjusted to the queue
+	scaleInterval time.Duration
 	// ctx is the background context to close the chanel on termination signal
 	ctx context.Context
 	// Log is the structured logger set to log with resource name
 	Log logr.Logger
 	// queue is the k8s rate limiting queue to store the submitted jobs
 	queue workqueue.RateLimitingInterface
+
+	lock sync.Mutex // guards the following
+	// routines is the number of running worker routines
+	routines int
+	// targetRoutines is the number of worker routines the pool is scaling to, the routines above the target
+	// exit after their current job
+	targetRoutines int
+	// inFlight is the number of jobs being processed
+	inFlight int
+	// avgJobLatency is the moving average of the time taken by the worker function
+	avgJobLatency time.Duration
+	// jobs are the submitted jobs that are pending, in flight or retrying
+	jobs map[interface{}]*JobInfo
+	// cancelled are the queued jobs to drop when they reach the head of the queue
+	cancelled map[interface{}]struct{}
 }
 
 // NewDefaultWorkerPool returns a new worker pool for a give resource type with the given configuration
 func NewDefaultWorkerPool(resourceName string, workerCount int, maxRequeue int,
 	logger logr.Logger, ctx context.Context) Worker {
+	return NewWorkerPool(resourceName, PoolConfig{
+		MinWorkerCount: workerCount,
+		MaxRequeue:     maxRequeue,
+	}, logger, ctx)
+}
+
+// NewFairWorkerPool returns a new worker pool for a given resource type that serves the jobs of the nodes in
+// round-robin, with at most maxNodeConcurrency jobs of a node processed at the same time
+func NewFairWorkerPool(resourceName string, workerCount int, maxRequeue int, maxNodeConcurrency int,
+	logger logr.Logger, ctx context.Context) Worker {
+	return NewWorkerPool(resourceName, PoolConfig{
+		MinWorkerCount:     workerCount,
+		MaxRequeue:         maxRequeue,
+		MaxNodeConcurrency: maxNodeConcurrency,
+	}, logger, ctx)
+}
 
+// NewWorkerPool returns a new worker pool for a given resource type that scales the number of worker routines
+// between the min and the max worker count based on the queued jobs and the job latency
+func NewWorkerPool(resourceName string, config PoolConfig, logger logr.Logger, ctx context.Context) Worker {
 	prometheusRegister()
 
+	var queue workqueue.RateLimitingInterface
+	if config.MaxNodeConcurrency > 0 {
+		queue = newFairQueue(workqueue.DefaultControllerRateLimiter(), config.MaxNodeConcurrency)
+	} else {
+		queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	}
+	if config.MaxWorkerCount < config.MinWorkerCount {
+		config.MaxWorkerCount = config.MinWorkerCount
+	}
+
 	return &worker{
 		resourceName:    resourceName,
-		maxRetriesOnErr: maxRequeue,
-		maxWorkerCount:  workerCount,
+		maxRetriesOnErr: config.MaxRequeue,
+		minWorkerCount:  config.MinWorkerCount,
+		maxWorkerCount:  config.MaxWorkerCount,
+		scaleInterval:   defaultScaleInterval,
 		Log:             logger,
-		queue:           workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		queue:           queue,
 		ctx:             ctx,
+		jobs:            map[interface{}]*JobInfo{},
+		cancelled:       map[interface{}]struct{}{},
 	}
 }
 
@@ -105,7 +212,11 @@ func prometheusRegister() {
 		metrics.Registry.MustRegister(
 			jobsSubmittedCount,
 			jobsCompletedCount,
-			jobsFailedCount)
+			jobsFailedCount,
+			jobsCancelledCount,
+			workerQueueDepth,
+			workerJobsInFlight,
+			workerRoutines)
 
 		prometheusRegistered = true
 	}
@@ -117,19 +228,135 @@ func (w *worker) SetWorkerFunc(workerFunc func(interface{}) (ctrl.Result, error)
 
 // SubmitJob adds the job to the rate limited queue
 func (w *worker) SubmitJob(job interface{}) {
+	w.trackSubmittedJob(job)
 	w.queue.Add(job)
 	jobsSubmittedCount.WithLabelValues(w.resourceName).Inc()
 }
 
 // SubmitJobAfter submits the job to the work queue after the given time period
 func (w *worker) SubmitJobAfter(job interface{}, submitAfter time.Duration) {
+	w.trackSubmittedJob(job)
 	w.queue.AddAfter(job, submitAfter)
 	jobsSubmittedCount.WithLabelValues(w.resourceName).Inc()
 }
 
-// runWorker runs a worker that listens on new item on the worker queue
+// runWorker runs a worker that listens on new item on the worker queue until the queue is shut down or the pool
+// scales down
 func (w *worker) runWorker() {
 	for w.processNextItem() {
+		if w.exitOnScaleDown() {
+			return
+		}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.routines--
+	workerRoutines.WithLabelValues(w.resourceName).Set(float64(w.routines))
+}
+
+// exitOnScaleDown returns true if the worker routine must exit as the pool has more routines than its target
+func (w *worker) exitOnScaleDown() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.routines <= w.targetRoutines {
+		return false
+	}
+	w.routines--
+	workerRoutines.WithLabelValues(w.resourceName).Set(float64(w.routines))
+	return true
+}
+
+// startRoutines starts the worker routines up to the target, must be called with the lock held
+func (w *worker) startRoutines() {
+	for ; w.routines < w.targetRoutines; w.routines++ {
+		go w.runWorker()
+	}
+	workerRoutines.WithLabelValues(w.resourceName).Set(float64(w.routines))
+}
+
+// desiredRoutines returns the number of routines to process the jobs in flight and to drain the queued jobs
+// within a scale interval at the average job latency, bounded by the min and the max worker count. Must be
+// called with the lock held
+func (w *worker) desiredRoutines(queueDepth int) int {
+	desired := w.inFlight
+	if queueDepth > 0 {
+		// Number of jobs a routine completes within the interval, unknown until the first job completes
+		jobsPerRoutine := float64(1)
+		if w.avgJobLatency > 0 && w.avgJobLatency < w.scaleInterval {
+			jobsPerRoutine = float64(w.scaleInterval) / float64(w.avgJobLatency)
+		}
+		desired += int(math.Ceil(float64(queueDepth) / jobsPerRoutine))
+	}
+	if desired < w.minWorkerCount {
+		desired = w.minWorkerCount
+	}
+	if desired > w.maxWorkerCount {
+		desired = w.maxWorkerCount
+	}
+	return desired
+}
+
+// scale starts the routines needed to drain the queue and scales down by one routine at a time when the
+// routines are idle, so a short lull doesn't stop the routines of an ongoing burst
+func (w *worker) scale() {
+	queueDepth := w.queue.Len()
+	workerQueueDepth.WithLabelValues(w.resourceName).Set(float64(queueDepth))
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	desired := w.desiredRoutines(queueDepth)
+	if desired > w.targetRoutines {
+		w.Log.V(1).Info("scaling up worker routines", "queue depth", queueDepth, "in flight", w.inFlight,
+			"average job latency", w.avgJobLatency, "routines", desired)
+		w.targetRoutines = desired
+		w.startRoutines()
+	} else if desired < w.targetRoutines {
+		w.targetRoutines--
+		w.Log.V(1).Info("scaling down worker routines", "queue depth", queueDepth, "in flight", w.inFlight,
+			"routines", w.targetRoutines)
+	}
+}
+
+// runScaler adjusts the number of routines to the queue at each scale interval until the context is done
+func (w *worker) runScaler() {
+	ticker := time.NewTicker(w.scaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.scale()
+		}
+	}
+}
+
+// trackJob counts the job in flight and returns the function to call once the job is processed
+func (w *worker) trackJob() func() {
+	start := time.Now()
+
+	w.lock.Lock()
+	w.inFlight++
+	workerJobsInFlight.WithLabelValues(w.resourceName).Set(float64(w.inFlight))
+	w.lock.Unlock()
+
+	return func() {
+		latency := time.Since(start)
+
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		w.inFlight--
+		workerJobsInFlight.WithLabelValues(w.resourceName).Set(float64(w.inFlight))
+		if w.avgJobLatency == 0 {
+			w.avgJobLatency = latency
+		} else {
+			w.avgJobLatency = time.Duration(latencyWeight*float64(latency) +
+				(1-latencyWeight)*float64(w.avgJobLatency))
+		}
 	}
 }
 
@@ -144,24 +371,39 @@ func (w *worker) processNextItem() (cont bool) {
 
 	cont = true
 
-	if result, err := w.workerFunc(job); err != nil {
+	if !w.startJob(job) {
+		log.V(1).Info("dropped cancelled job")
+		w.queue.Forget(job)
+		jobsCancelledCount.WithLabelValues(w.resourceName).Inc()
+		return
+	}
+
+	done := w.trackJob()
+	result, err := w.workerFunc(job)
+	done()
+
+	if err != nil {
 		if w.queue.NumRequeues(job) >= w.maxRetriesOnErr {
 			log.Error(err, "exceeded maximum retries", "max retries", w.maxRetriesOnErr)
+			w.finishJob(job, "", w.queue.NumRequeues(job), err)
 			w.queue.Forget(job)
 			jobsFailedCount.WithLabelValues(w.resourceName).Inc()
 			return
 		}
 		log.Error(err, "re-queuing job", "retry count", w.queue.NumRequeues(job))
+		w.finishJob(job, JobStatusRetrying, w.queue.NumRequeues(job)+1, err)
 		w.queue.AddRateLimited(job)
 		return
 	} else if result.Requeue {
 		log.V(1).Info("timed retry", "retry after", result.RequeueAfter)
+		w.finishJob(job, JobStatusPending, w.queue.NumRequeues(job), nil)
 		w.queue.AddAfter(job, result.RequeueAfter)
 		return
 	}
 
 	log.V(1).Info("completed job successfully")
 
+	w.finishJob(job, "", w.queue.NumRequeues(job), nil)
 	w.queue.Forget(job)
 	jobsCompletedCount.WithLabelValues(w.resourceName).Inc()
 
@@ -183,12 +425,17 @@ func (w *worker) StartWorkerPool(workerFunc func(interface{}) (ctrl.Result, erro
 		w.Log.Info("shut down the queue after receiving termination signal")
 	}()
 
-	w.Log.Info("starting worker routines", "worker count", w.maxWorkerCount)
+	w.Log.Info("starting worker routines", "min worker count", w.minWorkerCount,
+		"max worker count", w.maxWorkerCount)
 
-	// Start a new go routine to listen on the chanel and allocate jobs to go routines
-	for workerCount := 1; workerCount <= w.maxWorkerCount; workerCount++ {
-		go w.runWorker()
-	}
+	// Start the minimum go routines to listen on the chanel and allocate jobs to go routines, the scaler starts
+	// more routines when the jobs queue up
+	w.lock.Lock()
+	w.targetRoutines = w.minWorkerCount
+	w.startRoutines()
+	w.lock.Unlock()
+
+	go w.runScaler()
 
 	return nil
 }
@@ -100,3 +100,84 @@ func TestWorker_SubmitJob_RequeueOnError(t *testing.T) {
 	// expected invocation = max requeue + the first invocation
 	assert.Equal(t, maxRequeue+1, invoked)
 }
+
+// getRoutines returns the number of running and target routines of the worker pool
+func getRoutines(w *worker) (int, int, int) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.routines, w.targetRoutines, w.inFlight
+}
+
+// TestWorker_DesiredRoutines tests the routines needed to drain the queue within the scale interval are bounded by
+// the min and the max worker count
+func TestWorker_DesiredRoutines(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewWorkerPool(resourceName, PoolConfig{MinWorkerCount: 1, MaxWorkerCount: 5, MaxRequeue: maxRequeue},
+		zap.New(zap.UseDevMode(true)), ctx).(*worker)
+
+	// No job, scale down to the min
+	assert.Equal(t, 1, w.desiredRoutines(0))
+	// Job latency unknown, one routine per queued job
+	assert.Equal(t, 3, w.desiredRoutines(3))
+
+	// Each routine completes 4 jobs per interval
+	w.avgJobLatency = w.scaleInterval / 4
+	w.inFlight = 1
+	assert.Equal(t, 3, w.desiredRoutines(8))
+	assert.Equal(t, 5, w.desiredRoutines(100))
+}
+
+// TestWorker_Scale tests the routines are scaled up when the jobs queue up and are scaled down one at a time once
+// the queue is drained
+func TestWorker_Scale(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewWorkerPool(resourceName, PoolConfig{MinWorkerCount: 1, MaxWorkerCount: 3, MaxRequeue: maxRequeue},
+		zap.New(zap.UseDevMode(true)), ctx).(*worker)
+	// The test drives the scaling
+	w.scaleInterval = time.Hour
+
+	release := make(chan struct{})
+	assert.NoError(t, w.StartWorkerPool(func(job interface{}) (ctrl.Result, error) {
+		<-release
+		return ctrl.Result{}, nil
+	}))
+
+	jobs := make([]int, 7)
+	for i := 0; i < 5; i++ {
+		w.SubmitJob(&jobs[i])
+	}
+	assert.Eventually(t, func() bool {
+		_, _, inFlight := getRoutines(w)
+		return inFlight == 1
+	}, time.Second, time.Millisecond*10)
+
+	w.scale()
+	assert.Eventually(t, func() bool {
+		routines, target, inFlight := getRoutines(w)
+		return routines == 3 && target == 3 && inFlight == 3
+	}, time.Second, time.Millisecond*10)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		_, _, inFlight := getRoutines(w)
+		return w.queue.Len() == 0 && inFlight == 0
+	}, time.Second, time.Millisecond*10)
+
+	w.scale()
+	_, target, _ := getRoutines(w)
+	assert.Equal(t, 2, target)
+	w.scale()
+	w.scale()
+	_, target, _ = getRoutines(w)
+	assert.Equal(t, 1, target)
+
+	// The routines above the target exit after their next job
+	w.SubmitJob(&jobs[5])
+	w.SubmitJob(&jobs[6])
+	assert.Eventually(t, func() bool {
+		routines, _, _ := getRoutines(w)
+		return routines == 1
+	}, time.Second, time.Millisecond*10)
+}
@@ -14,6 +14,8 @@ mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/handler/moc
 mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/provider/mock_provider.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider ResourceProvider
 mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/provider/branch/trunk/mock_trunk.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk TrunkENI
 mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/provider/ip/eni/mock_eni.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/ip/eni ENIManager
+mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/provider/ipv6/eni/mock_eni.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/ipv6/eni ENIManager
+mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/provider/warm/mock_provider.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/warm ResourceManager
 # package node mocks
 mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/node/manager/mock_manager.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node/manager Manager
 mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/node/mock_node.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node Node
@@ -25,3 +27,7 @@ mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/pool/mock_p
 mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/resource/mock_resources.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/resource ResourceManager
 # package condition maocks
 mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/condition/mock_condtion.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition Conditions
+# package subnet mocks
+mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/subnet/mock_monitor.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/subnet SubnetMonitor
+# package endpoint mocks
+mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/endpoint/mock_tracker.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/endpoint EndpointTracker
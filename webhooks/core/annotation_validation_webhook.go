@@ -35,6 +35,10 @@ type AnnotationValidator struct {
 	decoder   *admission.Decoder
 	Condition condition.Conditions
 	Log       logr.Logger
+	// EnableLinuxIPv4 validates the IPv4 annotations when the controller manages IPv4 addresses on Linux nodes
+	EnableLinuxIPv4 bool
+	// EnableWindowsIPv6 validates the IPv6 annotations when the controller manages IPv6 addresses on Windows nodes
+	EnableWindowsIPv6 bool
 }
 
 // We are allowing multiple usernames to annotate the Windows/SGP Pod, eventually we will
@@ -126,10 +130,15 @@ func (a *AnnotationValidator) handleUpdate(req admission.Request) admission.Resp
 func (a *AnnotationValidator) getAnnotationKeysToBeValidated() []string {
 	// Pod ENI annotation is validated by default
 	annotationsToValidate := []string{config.ResourceNamePodENI}
-	if a.Condition.IsWindowsIPAMEnabled() {
+	if a.EnableLinuxIPv4 || a.Condition.IsWindowsIPAMEnabled() {
 		// Windows IPv4 Annotation is validated if feature is enabled, as the older controller could
 		// be installed on Customer Data Plane and new controller should not block it's annotations
-		annotationsToValidate = append(annotationsToValidate, config.ResourceNameIPAddress)
+		annotationsToValidate = append(annotationsToValidate, config.ResourceNameIPAddress,
+			config.IPv4AddressesAnnotation)
+	}
+	if a.EnableWindowsIPv6 {
+		annotationsToValidate = append(annotationsToValidate, config.ResourceNameIPv6Address,
+			config.IPv6AddressesAnnotation)
 	}
 	return annotationsToValidate
 }
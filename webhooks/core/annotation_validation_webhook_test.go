@@ -83,6 +83,11 @@ func TestAnnotationValidator_Handle(t *testing.T) {
 	windowsPodWithAnnotationRaw, err := json.Marshal(windowsPodWithAnnotation)
 	assert.NoError(t, err)
 
+	windowsPodWithIPv6Annotation := basePod.DeepCopy()
+	windowsPodWithIPv6Annotation.Annotations[config.ResourceNameIPv6Address] = "2600::1/64"
+	windowsPodWithIPv6AnnotationRaw, err := json.Marshal(windowsPodWithIPv6Annotation)
+	assert.NoError(t, err)
+
 	podWithAnnotation := basePod.DeepCopy()
 	podWithAnnotation.Annotations[config.ResourceNamePodENI] = "annotation-value"
 	podWithAnnotationRaw, err := json.Marshal(podWithAnnotation)
@@ -108,11 +113,67 @@ func TestAnnotationValidator_Handle(t *testing.T) {
 	assert.NoError(t, err)
 
 	test := []struct {
-		name           string
-		req            []admission.Request // Club tests with similar response & diff request in 1 test
-		want           admission.Response
-		mockInvocation func(mock MockAnnotationWebHook)
+		name            string
+		req             []admission.Request // Club tests with similar response & diff request in 1 test
+		want            admission.Response
+		mockInvocation  func(mock MockAnnotationWebHook)
+		enableLinuxIPv4 bool
+		enableWinIPv6   bool
 	}{
+		{
+			name: "[linux] deny IPv4 annotation on create when linux IPv4 enabled",
+			req: []admission.Request{
+				{
+					AdmissionRequest: admissionv1.AdmissionRequest{
+						Operation: admissionv1.Create,
+						Object: runtime.RawExtension{
+							Raw:    windowsPodWithAnnotationRaw,
+							Object: windowsPodWithAnnotation,
+						},
+					},
+				},
+			},
+			want: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed: false,
+					Result: &metav1.Status{
+						Code: http.StatusForbidden,
+					},
+				},
+			},
+			enableLinuxIPv4: true,
+		},
+		{
+			name: "[windows] deny IPv6 annotation update by unauthorized user when windows IPv6 enabled",
+			req: []admission.Request{
+				{
+					AdmissionRequest: admissionv1.AdmissionRequest{
+						Operation: admissionv1.Update,
+						UserInfo:  v1.UserInfo{Username: "unauthorized-user"},
+						Object: runtime.RawExtension{
+							Raw:    windowsPodWithIPv6AnnotationRaw,
+							Object: windowsPodWithIPv6Annotation,
+						},
+						OldObject: runtime.RawExtension{
+							Raw:    podWithoutAnnotationRaw,
+							Object: podWithoutAnnotation,
+						},
+					},
+				},
+			},
+			want: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed: false,
+					Result: &metav1.Status{
+						Code: http.StatusForbidden,
+					},
+				},
+			},
+			mockInvocation: func(mock MockAnnotationWebHook) {
+				mock.MockCondition.EXPECT().IsWindowsIPAMEnabled().Return(true)
+			},
+			enableWinIPv6: true,
+		},
 		{
 			name: "[windows] allow all request when feature disabled ",
 			req: []admission.Request{
@@ -549,6 +610,9 @@ func TestAnnotationValidator_Handle(t *testing.T) {
 					decoder:   decoder,
 					Log:       zap.New(),
 					Condition: mock.MockCondition,
+
+					EnableLinuxIPv4:   tt.enableLinuxIPv4,
+					EnableWindowsIPv6: tt.enableWinIPv6,
 				}
 
 				if tt.mockInvocation != nil {
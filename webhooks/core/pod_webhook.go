@@ -16,7 +16,9 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/go-logr/logr"
@@ -43,6 +45,14 @@ type PodMutationWebHook struct {
 	SGPAPI    utils.SecurityGroupForPodsAPI
 	Log       logr.Logger
 	Condition condition.Conditions
+	// EnablePodENIReadinessGate injects a readiness gate to pods that are allocated pod-eni so the pod is
+	// marked Ready only after the branch ENI is associated with the trunk and the pod is annotated
+	EnablePodENIReadinessGate bool
+	// LinuxIPv4Namespaces are the namespaces in which the Linux pods not matching any SGP are allocated
+	// secondary IPv4 addresses by the controller. Empty if the controller doesn't manage Linux IPv4 addresses
+	LinuxIPv4Namespaces []string
+	// EnableWindowsIPv6 injects IPv6 address limit instead of IPv4 address limit to the Windows pods
+	EnableWindowsIPv6 bool
 }
 
 type PodType string
@@ -136,8 +146,8 @@ func (i *PodMutationWebHook) HandleFargatePod(req admission.Request, pod *corev1
 	return response
 }
 
-// HandleWindowsPod mutates the Windows Pod by injecting a secondary IPv4 Address
-// Limit to the Pod when the Windows IPAM feature is enabled via ConfigMap
+// HandleWindowsPod mutates the Windows Pod by injecting a secondary IPv4 Address or an IPv6
+// Address Limit to the Pod when the Windows IPAM feature is enabled via ConfigMap
 func (i *PodMutationWebHook) HandleWindowsPod(req admission.Request, pod *corev1.Pod,
 	log logr.Logger) (response admission.Response) {
 
@@ -145,18 +155,55 @@ func (i *PodMutationWebHook) HandleWindowsPod(req admission.Request, pod *corev1
 		return admission.Allowed("")
 	}
 
+	if i.EnableWindowsIPv6 {
+		return i.injectIPAddress(req, pod, log, config.ResourceNameIPv6Address, config.IPv6AddressCountAnnotation)
+	}
+	return i.injectIPv4Address(req, pod, log)
+}
+
+// injectIPv4Address injects the secondary IPv4 address limit to the first container of the Pod, the number
+// of addresses can be set by the user using the IPv4 address count annotation
+func (i *PodMutationWebHook) injectIPv4Address(req admission.Request, pod *corev1.Pod,
+	log logr.Logger) (response admission.Response) {
+	return i.injectIPAddress(req, pod, log, config.ResourceNameIPAddress, config.IPv4AddressCountAnnotation)
+}
+
+// injectIPAddress injects the IP address resource limit to the first container of the Pod, the number
+// of addresses can be set by the user using the count annotation
+func (i *PodMutationWebHook) injectIPAddress(req admission.Request, pod *corev1.Pod, log logr.Logger,
+	resourceName corev1.ResourceName, countAnnotation string) (response admission.Response) {
+
+	// Pods explicitly requesting the resource are allocated the requested number of resources
+	for _, container := range pod.Spec.Containers {
+		_, hasRequest := container.Resources.Requests[resourceName]
+		_, hasLimit := container.Resources.Limits[resourceName]
+		if hasRequest || hasLimit {
+			return admission.Allowed("Pod already requests the resource")
+		}
+	}
+
+	resourceCount := DefaultResourceLimit
+	if count, ok := pod.Annotations[countAnnotation]; ok {
+		if parsedCount, err := strconv.Atoi(count); err != nil || parsedCount < 1 {
+			return admission.Denied(fmt.Sprintf("invalid value %s for annotation %s, must be a positive integer",
+				count, countAnnotation))
+		}
+		resourceCount = count
+	}
+
 	i.Log.Info("injecting resource to the first container of the pod",
-		"resource name", config.ResourceNameIPAddress, "resource count", DefaultResourceLimit)
+		"resource name", resourceName, "resource count", resourceCount)
 	pod.Spec.Containers[0].
-		Resources.Limits[config.ResourceNameIPAddress] = resource.MustParse(DefaultResourceLimit)
+		Resources.Limits[resourceName] = resource.MustParse(resourceCount)
 	pod.Spec.Containers[0].
-		Resources.Requests[config.ResourceNameIPAddress] = resource.MustParse(DefaultResourceLimit)
+		Resources.Requests[resourceName] = resource.MustParse(resourceCount)
 
 	return i.GetPatchResponse(req, pod, log)
 }
 
 // HandleLinuxPod mutates the Linux Pod by injecting pod-eni limit if the Linux Pod
-// matches any SGP
+// matches any SGP, otherwise injects secondary IPv4 Address limit if the Linux Pod
+// is in one of the namespaces selected for the controller managed IPv4 addresses
 func (i *PodMutationWebHook) HandleLinuxPod(req admission.Request, pod *corev1.Pod,
 	log logr.Logger) (response admission.Response) {
 
@@ -167,6 +214,9 @@ func (i *PodMutationWebHook) HandleLinuxPod(req admission.Request, pod *corev1.P
 		return admission.Denied("Failed to get Matching SGP for Pods, rejecting event")
 	}
 	if len(sgList) == 0 {
+		if i.isLinuxIPv4Namespace(pod.Namespace) {
+			return i.injectIPv4Address(req, pod, log)
+		}
 		return admission.Allowed("Pod didn't match any SGP")
 	}
 
@@ -178,9 +228,23 @@ func (i *PodMutationWebHook) HandleLinuxPod(req admission.Request, pod *corev1.P
 	pod.Spec.Containers[0].Resources.
 		Requests[config.ResourceNamePodENI] = resource.MustParse(DefaultResourceLimit)
 
+	if i.EnablePodENIReadinessGate {
+		injectReadinessGate(pod, config.PodENIReadinessGate)
+	}
+
 	return i.GetPatchResponse(req, pod, log)
 }
 
+// isLinuxIPv4Namespace returns true if the Linux pods in the namespace are allocated IPv4 addresses
+func (i *PodMutationWebHook) isLinuxIPv4Namespace(namespace string) bool {
+	for _, ns := range i.LinuxIPv4Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
 // InitializeEmptyFields inits the empty fields in the request
 func (i *PodMutationWebHook) InitializeEmptyFields(req admission.Request, pod *corev1.Pod) {
 	if pod.Spec.Containers[0].Resources.Limits == nil {
@@ -213,6 +277,17 @@ func (i *PodMutationWebHook) GetPatchResponse(req admission.Request, pod *corev1
 	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPod)
 }
 
+// injectReadinessGate adds the readiness gate with the condition type to the pod if not already present
+func injectReadinessGate(pod *corev1.Pod, conditionType string) {
+	for _, gate := range pod.Spec.ReadinessGates {
+		if string(gate.ConditionType) == conditionType {
+			return
+		}
+	}
+	pod.Spec.ReadinessGates = append(pod.Spec.ReadinessGates,
+		corev1.PodReadinessGate{ConditionType: corev1.PodConditionType(conditionType)})
+}
+
 func hasWindowsNodeSelector(pod *corev1.Pod) bool {
 	osLabel := pod.Spec.NodeSelector[config.NodeLabelOS]
 
@@ -42,6 +42,7 @@ var (
 	firstContainerPatchRequestURI = "/spec/containers/0/resources/requests"
 	firstContainerPatchLimitURI   = "/spec/containers/0/resources/limits"
 	ipResourceJsonPointer         = "/" + jsonPointer(config.ResourceNameIPAddress)
+	ipv6ResourceJsonPointer       = "/" + jsonPointer(config.ResourceNameIPv6Address)
 	podENIResourceJsonPointer     = "/" + jsonPointer(config.ResourceNamePodENI)
 )
 
@@ -118,6 +119,30 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 	windowsNoLimitsRaw, err := json.Marshal(windowsNoLimits)
 	assert.NoError(t, err)
 
+	// Windows Pod requesting multiple IPv4 addresses
+	windowsMultipleIPsPod := windowsPod.DeepCopy()
+	windowsMultipleIPsPod.Annotations[config.IPv4AddressCountAnnotation] = "3"
+	windowsMultipleIPsPodRaw, err := json.Marshal(windowsMultipleIPsPod)
+	assert.NoError(t, err)
+
+	// Windows Pod requesting multiple IPv6 addresses
+	windowsMultipleIPv6Pod := windowsPod.DeepCopy()
+	windowsMultipleIPv6Pod.Annotations[config.IPv6AddressCountAnnotation] = "2"
+	windowsMultipleIPv6PodRaw, err := json.Marshal(windowsMultipleIPv6Pod)
+	assert.NoError(t, err)
+
+	// Windows Pod with invalid IPv4 address count
+	windowsInvalidCountPod := windowsPod.DeepCopy()
+	windowsInvalidCountPod.Annotations[config.IPv4AddressCountAnnotation] = "0"
+	windowsInvalidCountPodRaw, err := json.Marshal(windowsInvalidCountPod)
+	assert.NoError(t, err)
+
+	// Windows Pod already requesting IPv4 addresses
+	windowsWithRequestPod := windowsPod.DeepCopy()
+	windowsWithRequestPod.Spec.Containers[0].Resources.Limits[config.ResourceNameIPAddress] = resource.MustParse("2")
+	windowsWithRequestPodRaw, err := json.Marshal(windowsWithRequestPod)
+	assert.NoError(t, err)
+
 	// Fargate Pod
 	fargatePod := basePod.DeepCopy()
 	fargatePod.Labels[FargatePodIdentifierLabelKey] = "fargate-profile"
@@ -142,11 +167,54 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 	assert.NoError(t, err)
 
 	test := []struct {
-		name           string
-		mockInvocation func(mock Mock)
-		req            admission.Request
-		want           admission.Response
+		name                string
+		mockInvocation      func(mock Mock)
+		req                 admission.Request
+		want                admission.Response
+		enableReadinessGate bool
+		linuxIPv4Namespaces []string
+		enableWindowsIPv6   bool
 	}{
+		{
+			name: "[Linux] Pod matches SG with readiness gate enabled",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    sgpPodWithoutLimitsRaw,
+						Object: sgpPodWithoutLimits,
+					},
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(sgpPod)).Return(sgList, nil)
+			},
+			enableReadinessGate: true,
+			want: admission.Response{
+				Patches: []jsonpatch.JsonPatchOperation{
+					{
+						Operation: "add",
+						Path:      firstContainerPatchLimitURI,
+						Value:     map[string]interface{}{config.ResourceNamePodENI: "1"},
+					},
+					{
+						Operation: "add",
+						Path:      firstContainerPatchRequestURI,
+						Value:     map[string]interface{}{config.ResourceNamePodENI: "1"},
+					},
+					{
+						Operation: "add",
+						Path:      "/spec/readinessGates",
+						Value: []interface{}{
+							map[string]interface{}{"conditionType": config.PodENIReadinessGate},
+						},
+					},
+				},
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed:   true,
+					PatchType: &jsonPatchType,
+				},
+			},
+		},
 		{
 			name: "[Linux] Pod matches SG no existing resource limits",
 			req: admission.Request{
@@ -233,6 +301,39 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "[Linux] Pod doesn't match SG in IPv4 namespace",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    sgpPodRaw,
+						Object: sgpPod,
+					},
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(sgpPod)).Return([]string{}, nil)
+			},
+			linuxIPv4Namespaces: []string{namespace},
+			want: admission.Response{
+				Patches: []jsonpatch.JsonPatchOperation{
+					{
+						Operation: "add",
+						Path:      firstContainerPatchLimitURI + ipResourceJsonPointer,
+						Value:     "1",
+					},
+					{
+						Operation: "add",
+						Path:      firstContainerPatchRequestURI + ipResourceJsonPointer,
+						Value:     "1",
+					},
+				},
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed:   true,
+					PatchType: &jsonPatchType,
+				},
+			},
+		},
 		{
 			name: "[Linux] SGP returns error",
 			req: admission.Request{
@@ -424,6 +525,142 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 				mock.ConditionMock.EXPECT().IsWindowsIPAMEnabled().Return(true)
 			},
 		},
+		{
+			name: "[Windows] with IPv6 enabled, should inject IPv6 address",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    windowsPodRaw,
+						Object: windowsPod,
+					},
+				},
+			},
+			enableWindowsIPv6: true,
+			want: admission.Response{
+				Patches: []jsonpatch.JsonPatchOperation{
+					{
+						Operation: "add",
+						Path:      firstContainerPatchLimitURI + ipv6ResourceJsonPointer,
+						Value:     "1",
+					},
+					{
+						Operation: "add",
+						Path:      firstContainerPatchRequestURI + ipv6ResourceJsonPointer,
+						Value:     "1",
+					},
+				},
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed:   true,
+					PatchType: &jsonPatchType,
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.ConditionMock.EXPECT().IsWindowsIPAMEnabled().Return(true)
+			},
+		},
+		{
+			name: "[Windows] with IPv6 enabled and IPv6 address count annotation, should inject the count",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    windowsMultipleIPv6PodRaw,
+						Object: windowsMultipleIPv6Pod,
+					},
+				},
+			},
+			enableWindowsIPv6: true,
+			want: admission.Response{
+				Patches: []jsonpatch.JsonPatchOperation{
+					{
+						Operation: "add",
+						Path:      firstContainerPatchLimitURI + ipv6ResourceJsonPointer,
+						Value:     "2",
+					},
+					{
+						Operation: "add",
+						Path:      firstContainerPatchRequestURI + ipv6ResourceJsonPointer,
+						Value:     "2",
+					},
+				},
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed:   true,
+					PatchType: &jsonPatchType,
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.ConditionMock.EXPECT().IsWindowsIPAMEnabled().Return(true)
+			},
+		},
+		{
+			name: "[Windows] with IPv4 address count annotation, should inject the count",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    windowsMultipleIPsPodRaw,
+						Object: windowsMultipleIPsPod,
+					},
+				},
+			},
+			want: admission.Response{
+				Patches: []jsonpatch.JsonPatchOperation{
+					{
+						Operation: "add",
+						Path:      firstContainerPatchLimitURI + ipResourceJsonPointer,
+						Value:     "3",
+					},
+					{
+						Operation: "add",
+						Path:      firstContainerPatchRequestURI + ipResourceJsonPointer,
+						Value:     "3",
+					},
+				},
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed:   true,
+					PatchType: &jsonPatchType,
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.ConditionMock.EXPECT().IsWindowsIPAMEnabled().Return(true)
+			},
+		},
+		{
+			name: "[Windows] with invalid IPv4 address count annotation, should be denied",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    windowsInvalidCountPodRaw,
+						Object: windowsInvalidCountPod,
+					},
+				},
+			},
+			want: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed: false,
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.ConditionMock.EXPECT().IsWindowsIPAMEnabled().Return(true)
+			},
+		},
+		{
+			name: "[Windows] with existing IPv4 address limit, should be allowed without patch",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    windowsWithRequestPodRaw,
+						Object: windowsWithRequestPod,
+					},
+				},
+			},
+			want: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed: true,
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.ConditionMock.EXPECT().IsWindowsIPAMEnabled().Return(true)
+			},
+		},
 		{
 			name: "[Windows] with beta label, should be allowed",
 			req: admission.Request{
@@ -524,6 +761,10 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 				Log:       zap.New(),
 				SGPAPI:    mock.SGPMock,
 				Condition: mock.ConditionMock,
+
+				EnablePodENIReadinessGate: tt.enableReadinessGate,
+				LinuxIPv4Namespaces:       tt.linuxIPv4Namespaces,
+				EnableWindowsIPv6:         tt.enableWindowsIPv6,
 			}
 
 			if tt.mockInvocation != nil {
@@ -544,3 +785,20 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 func jsonPointer(str string) string {
 	return strings.ReplaceAll(str, "/", "~1")
 }
+
+// TestInjectReadinessGate tests that the readiness gate is added only once to the pod
+func TestInjectReadinessGate(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			ReadinessGates: []corev1.PodReadinessGate{{ConditionType: "existing-gate"}},
+		},
+	}
+
+	injectReadinessGate(pod, config.PodENIReadinessGate)
+	injectReadinessGate(pod, config.PodENIReadinessGate)
+
+	assert.Equal(t, []corev1.PodReadinessGate{
+		{ConditionType: "existing-gate"},
+		{ConditionType: config.PodENIReadinessGate},
+	}, pod.Spec.ReadinessGates)
+}